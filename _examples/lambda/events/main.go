@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api/apigw"
+)
+
+func main() {
+	// Forward analytics events from client-side SDKs to LaunchDarkly
+	var h http.Handler = api.NewEventForwarder(os.Getenv("LAUNCHDARKLY_SDK_KEY"), nil)
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		h = api.CORS(h, api.CORSOptions{AllowedOrigins: strings.Split(origins, ",")})
+	}
+
+	lambda.Start(apigw.Handler(h))
+}
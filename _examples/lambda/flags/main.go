@@ -1,52 +1,179 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
 	ld "gopkg.in/launchdarkly/go-client.v4"
 
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api/apigw"
 	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/notify"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
 )
 
 func main() {
-	lambda.Start(handler)
-}
-
-func handler(req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	store, err := dynamodb.NewDynamoDBFeatureStore(os.Getenv("LAUNCHDARKLY_DYNAMODB_TABLE"), nil)
 	if err != nil {
-		return &events.APIGatewayProxyResponse{
-			StatusCode: http.StatusInternalServerError,
-			Body:       fmt.Sprintf("Failed to initialize DynamoDBFeatureStore: %s\n", err),
-		}, nil
+		log.Fatalf("Failed to initialize DynamoDBFeatureStore: %s", err)
+	}
+	defer store.Close()
+
+	// Serve flags from memory and only re-read them after a sync
+	if interval := os.Getenv("LAUNCHDARKLY_CACHE_REFRESH_INTERVAL"); interval != "" {
+		if store.CacheRefreshInterval, err = time.ParseDuration(interval); err != nil {
+			log.Fatalf("Invalid LAUNCHDARKLY_CACHE_REFRESH_INTERVAL %q: %s", interval, err)
+		}
+		// Check for changes on every read instead of only in the background
+		store.CacheCheckGeneration = os.Getenv("LAUNCHDARKLY_CACHE_CHECK_GENERATION") == "true"
+	}
+
+	// Serve no segments instead of failing if the table or IAM policy only
+	// provides flags, e.g. LAUNCHDARKLY_OPTIONAL_KINDS=segments
+	if kinds := os.Getenv("LAUNCHDARKLY_OPTIONAL_KINDS"); kinds != "" {
+		if store.OptionalKinds, err = dynamodb.ParseKinds(strings.Split(kinds, ",")...); err != nil {
+			log.Fatalf("Invalid LAUNCHDARKLY_OPTIONAL_KINDS %q: %s", kinds, err)
+		}
+	}
+
+	// Skip segment lookups altogether if segments aren't used; segment rules
+	// then never match
+	if os.Getenv("LAUNCHDARKLY_FLAGS_ONLY") == "true" {
+		store.ReadKinds = []ld.VersionedDataKind{ld.Features}
+	}
+
+	// Report the store as uninitialized if the table holds fewer flags than
+	// expected, e.g. LAUNCHDARKLY_MIN_FLAGS=10, so that evaluations return
+	// an error along with the default value
+	if n := os.Getenv("LAUNCHDARKLY_MIN_FLAGS"); n != "" {
+		if store.MinFlags, err = strconv.Atoi(n); err != nil {
+			log.Fatalf("Invalid LAUNCHDARKLY_MIN_FLAGS %q: %s", n, err)
+		}
+	}
+
+	// List the items rejected by syncs at /status
+	store.QuarantineTable = os.Getenv("LAUNCHDARKLY_DYNAMODB_QUARANTINE_TABLE")
+
+	// Ask the sync to repopulate the table if it lacks flags, e.g. after a
+	// faulty sync wiped it
+	if topic := os.Getenv("LAUNCHDARKLY_RESYNC_TOPIC_ARN"); topic != "" {
+		sess, err := session.NewSession()
+		if err != nil {
+			log.Fatalf("Failed to create AWS session: %s", err)
+		}
+		publisher := notify.NewSNS(sess, topic)
+		store.OnTooFewFlags = func(count int) {
+			req := sync.ResyncRequest{Table: store.Table, Flags: count, MinFlags: store.MinFlags}
+			if err := sync.RequestResync(publisher, req); err != nil {
+				log.Printf("ERROR: Failed to request resync: %s", err)
+			}
+		}
 	}
 
 	config := ld.DefaultConfig
 	config.FeatureStore = store
 	config.UseLdd = true
 
-	ldClient, err := ld.MakeCustomClient(os.Getenv("LAUNCHDARKLY_SDK_KEY"), config, 5*time.Second)
+	// In daemon mode, the client doesn't wait for data, so wait for the sync
+	// Lambda to have written flags to the table
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := store.WaitForInitialization(ctx); err != nil {
+		log.Printf("WARN: No flags in DynamoDB yet: %s", err)
+	}
+	cancel()
+
+	// The client is reused across invocations of the same Lambda container
+	ldClient, err := ld.MakeCustomClient(os.Getenv("LAUNCHDARKLY_SDK_KEY"), config, 0)
 	if err != nil {
-		return &events.APIGatewayProxyResponse{
-			StatusCode: http.StatusInternalServerError,
-			Body:       fmt.Sprintf("Failed to initialize LaunchDarkly client: %s\n", err),
-		}, nil
+		log.Fatalf("Failed to initialize LaunchDarkly client: %s", err)
 	}
 	defer ldClient.Close()
 
-	// Get and return all flags for the Lambda function
-	ldUser := ld.NewUser(os.Getenv("AWS_LAMBDA_FUNCTION_NAME"))
-	flags := ldClient.AllFlags(ldUser)
-	jsonFlags, _ := json.Marshal(flags)
+	// Return all flags for the requested user, honoring If-None-Match so
+	// that polling clients don't re-download unchanged flags.
+	flags := api.NewFlagsHandler(ldClient, store, nil)
+	flags.Salt = os.Getenv("ANONYMOUS_USER_SALT")
+	flags.CacheControl = os.Getenv("CACHE_CONTROL")
+	flags.SurrogateControl = os.Getenv("SURROGATE_CONTROL")
+	flags.CDNMode = os.Getenv("CDN_MODE") == "true"
 
-	return &events.APIGatewayProxyResponse{
-		StatusCode: http.StatusOK,
-		Body:       string(jsonFlags),
-	}, nil
+	// Report the store's health, the sync lock, and quarantined items at
+	// /status. As each request reads from the table, it's subject to the
+	// same authentication and rate limit as the flags.
+	mux := http.NewServeMux()
+	mux.Handle("/status", api.NewStatusHandler(store, nil))
+	mux.Handle("/", flags)
+	var h http.Handler = mux
+
+	// Only serve clients with a valid API key, as flags expose the flag
+	// configuration
+	if table := os.Getenv("API_KEYS_TABLE"); table != "" {
+		h = api.Auth(h, api.AuthOptions{Keys: &api.DynamoDBKeyStore{Client: store.Client, Table: table}})
+	}
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		h = api.CORS(h, api.CORSOptions{AllowedOrigins: strings.Split(origins, ",")})
+	}
+
+	// Flag payloads of big environments are large but compress well
+	if os.Getenv("COMPRESS_RESPONSES") == "true" {
+		h = api.Compress(h, api.CompressOptions{})
+	}
+
+	// Limit requests per API key or source IP so that a single client can't
+	// exhaust the table's read capacity
+	if rate := os.Getenv("RATE_LIMIT"); rate != "" {
+		limiter, err := newLimiter(rate, os.Getenv("RATE_LIMIT_BURST"), os.Getenv("RATE_LIMIT_TABLE"), store)
+		if err != nil {
+			log.Fatalf("Invalid rate limit: %s", err)
+		}
+		h = api.RateLimit(h, api.RateLimitOptions{Limiter: limiter, KeyHeader: "Authorization"})
+	}
+
+	// Warmup pings, e.g. from a schedule, only load the data into memory
+	// without evaluating flags
+	warmup := func(ctx context.Context) error {
+		for _, kind := range ld.VersionedDataKinds {
+			if _, err := store.All(kind); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Stream responses through a function URL, which doesn't limit them to
+	// 6 MB like API Gateway does (see the flagsStream function)
+	if os.Getenv("STREAM_RESPONSES") == "true" {
+		lambda.Start(apigw.WithRawWarmup(apigw.StreamingHandler(h), warmup))
+		return
+	}
+
+	lambda.Start(apigw.WithWarmup(apigw.Handler(h), warmup))
+}
+
+func newLimiter(rate, burst, table string, store *dynamodb.DynamoDBFeatureStore) (api.Limiter, error) {
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT must be a positive number: %q", rate)
+	}
+	b := int(math.Ceil(r))
+	if burst != "" {
+		if b, err = strconv.Atoi(burst); err != nil || b <= 0 {
+			return nil, fmt.Errorf("RATE_LIMIT_BURST must be a positive integer: %q", burst)
+		}
+	}
+
+	if table == "" {
+		return api.NewMemoryLimiter(r, b), nil
+	}
+	return &api.DynamoDBLimiter{Client: store.Client, Table: table, Rate: r, Burst: b}, nil
 }
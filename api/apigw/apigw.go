@@ -0,0 +1,121 @@
+/*
+Package apigw adapts HTTP handlers to AWS Lambda functions invoked through API
+Gateway's proxy integration.
+
+	h := api.NewEventForwarder(os.Getenv("LAUNCHDARKLY_SDK_KEY"), nil)
+	lambda.Start(apigw.Handler(h))
+
+Responses larger than Lambda allows can be streamed through a function URL
+instead (see StreamingHandler).
+*/
+package apigw
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// MaxResponseSize is the largest response body Lambda returns from a
+// synchronous invocation. The limit applies to the whole response, so bodies
+// close to it may still be rejected.
+//
+// Larger responses can be streamed through a function URL with
+// StreamingHandler.
+const MaxResponseSize = 6 * 1024 * 1024
+
+// HandlerFunc is the signature of Lambda functions invoked by API Gateway.
+type HandlerFunc func(context.Context, *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error)
+
+// Handler returns a Lambda function that translates API Gateway proxy requests
+// into HTTP requests served by h.
+func Handler(h http.Handler) HandlerFunc {
+	return func(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+		r, err := NewRequest(ctx, req)
+		if err != nil {
+			return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest}, nil
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		resp := NewResponse(w.Result().StatusCode, w.Header(), w.Body.Bytes())
+		if len(resp.Body) > MaxResponseSize {
+			// Lambda would fail the invocation with an error that doesn't
+			// say which request caused it
+			log.Printf("ERROR: Response to %s %s has %d bytes, more than Lambda allows", r.Method, r.URL.Path, len(resp.Body))
+			return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadGateway}, nil
+		}
+		return resp, nil
+	}
+}
+
+// NewRequest converts an API Gateway proxy request into an HTTP request.
+func NewRequest(ctx context.Context, req *events.APIGatewayProxyRequest) (*http.Request, error) {
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	u := url.URL{Path: req.Path}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	q := url.Values{}
+	for k, v := range req.QueryStringParameters {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+
+	method := req.HTTPMethod
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	r, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.Headers {
+		r.Header.Set(k, v)
+	}
+	r.Host = r.Header.Get("Host")
+	r.RemoteAddr = req.RequestContext.Identity.SourceIP
+	if r.UserAgent() == "" && req.RequestContext.Identity.UserAgent != "" {
+		r.Header.Set("User-Agent", req.RequestContext.Identity.UserAgent)
+	}
+
+	return r.WithContext(ctx), nil
+}
+
+// NewResponse builds an API Gateway proxy response. Bodies that aren't valid
+// UTF-8 text are base64-encoded.
+func NewResponse(status int, header http.Header, body []byte) *events.APIGatewayProxyResponse {
+	resp := &events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    make(map[string]string, len(header)),
+	}
+	for k, v := range header {
+		resp.Headers[k] = strings.Join(v, ",")
+	}
+	if isBinary(header, body) {
+		resp.Body = base64.StdEncoding.EncodeToString(body)
+		resp.IsBase64Encoded = true
+	} else {
+		resp.Body = string(body)
+	}
+	return resp
+}
+
+func isBinary(header http.Header, body []byte) bool {
+	return header.Get("Content-Encoding") != "" || !utf8.Valid(body)
+}
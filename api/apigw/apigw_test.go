@@ -0,0 +1,79 @@
+package apigw_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api/apigw"
+)
+
+func TestHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/events" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("user"); got != "bob" {
+			t.Errorf("got query param %q, want %q", got, "bob")
+		}
+		if r.RemoteAddr != "203.0.113.1" {
+			t.Errorf("got remote addr %q", r.RemoteAddr)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "hello" {
+			t.Errorf("got body %q", body)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	resp, err := apigw.Handler(h)(context.Background(), &events.APIGatewayProxyRequest{
+		HTTPMethod:            "POST",
+		Path:                  "/events",
+		QueryStringParameters: map[string]string{"user": "bob"},
+		Body:                  base64.StdEncoding.EncodeToString([]byte("hello")),
+		IsBase64Encoded:       true,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: "203.0.113.1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("got status %d", resp.StatusCode)
+	}
+	if resp.Body != "created" || resp.IsBase64Encoded {
+		t.Errorf("got body %q (base64=%v)", resp.Body, resp.IsBase64Encoded)
+	}
+	if resp.Headers["Content-Type"] != "text/plain" {
+		t.Errorf("got headers %v", resp.Headers)
+	}
+}
+
+func TestNewResponseBinary(t *testing.T) {
+	resp := apigw.NewResponse(http.StatusOK, http.Header{}, []byte{0xff, 0xfe})
+	if !resp.IsBase64Encoded {
+		t.Error("expected binary body to be base64-encoded")
+	}
+}
+
+func TestHandlerRejectsLargeResponses(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), apigw.MaxResponseSize+1))
+	})
+
+	resp, err := apigw.Handler(h)(context.Background(), &events.APIGatewayProxyRequest{Path: "/flags"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadGateway || resp.Body != "" {
+		t.Errorf("got status %d with %d bytes, want 502 without body", resp.StatusCode, len(resp.Body))
+	}
+}
@@ -0,0 +1,29 @@
+package apigw
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdaurl"
+)
+
+// StreamingHandler returns a Lambda function that serves requests from a
+// function URL with h and streams the responses, which, unlike those of
+// Handler, may be larger than MaxResponseSize, e.g. all flags of a big
+// environment.
+//
+// The function URL must be configured with the RESPONSE_STREAM invoke mode,
+// and the function must use the provided.al2 runtime, as the go1.x runtime
+// can't stream.
+func StreamingHandler(h http.Handler) RawHandlerFunc {
+	serve := lambdaurl.Wrap(h)
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		var req events.LambdaFunctionURLRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		return serve(ctx, &req)
+	}
+}
@@ -0,0 +1,49 @@
+package apigw_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api/apigw"
+)
+
+func TestStreamingHandler(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), apigw.MaxResponseSize+1)
+	h := apigw.StreamingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/flags" || r.URL.Query().Get("user") != "abc" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+
+	resp, err := h(context.Background(), json.RawMessage(`{
+		"rawPath": "/flags",
+		"rawQueryString": "user=abc",
+		"requestContext": {"domainName": "example.lambda-url.us-east-1.on.aws", "http": {"method": "GET"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, ok := resp.(*events.LambdaFunctionURLStreamingResponse)
+	if !ok {
+		t.Fatalf("got response %T", resp)
+	}
+	if stream.StatusCode != http.StatusOK || stream.Headers["Content-Type"] != "application/json" {
+		t.Errorf("got status %d and headers %v", stream.StatusCode, stream.Headers)
+	}
+	got, err := ioutil.ReadAll(stream.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(body) {
+		t.Errorf("got %d bytes, want %d", len(got), len(body))
+	}
+}
@@ -0,0 +1,63 @@
+package apigw
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RawHandlerFunc is the signature of Lambda functions that inspect the raw
+// event before deciding how to handle it.
+type RawHandlerFunc func(context.Context, json.RawMessage) (interface{}, error)
+
+// WarmupResponse is returned for warmup events.
+type WarmupResponse struct {
+	Warm bool `json:"warm"`
+}
+
+// WithWarmup returns a Lambda function that calls warmup for warmup events
+// (see IsWarmupEvent) and passes all other events to h. Use warmup to
+// initialize connections and caches, so that the first real request doesn't
+// hit a cold path.
+func WithWarmup(h HandlerFunc, warmup func(context.Context) error) RawHandlerFunc {
+	return WithRawWarmup(func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		var req events.APIGatewayProxyRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, err
+		}
+		return h(ctx, &req)
+	}, warmup)
+}
+
+// WithRawWarmup works like WithWarmup for Lambda functions that take raw
+// events, e.g. StreamingHandler.
+func WithRawWarmup(h RawHandlerFunc, warmup func(context.Context) error) RawHandlerFunc {
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		if IsWarmupEvent(payload) {
+			if err := warmup(ctx); err != nil {
+				return nil, err
+			}
+			return &WarmupResponse{Warm: true}, nil
+		}
+		return h(ctx, payload)
+	}
+}
+
+// IsWarmupEvent reports whether a Lambda event is a warmup ping rather than
+// an API Gateway request. These events are recognized:
+//
+//	{"warmer": true}                          // custom schedule input
+//	{"source": "serverless-plugin-warmup"}    // serverless-plugin-warmup
+//	{"source": "aws.events", ...}             // CloudWatch scheduled event
+func IsWarmupEvent(payload []byte) bool {
+	var event struct {
+		Warmer     bool   `json:"warmer"`
+		Source     string `json:"source"`
+		HTTPMethod string `json:"httpMethod"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil || event.HTTPMethod != "" {
+		return false
+	}
+	return event.Warmer || event.Source == "serverless-plugin-warmup" || event.Source == "aws.events"
+}
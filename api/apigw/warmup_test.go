@@ -0,0 +1,57 @@
+package apigw_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api/apigw"
+)
+
+func TestIsWarmupEvent(t *testing.T) {
+	for payload, want := range map[string]bool{
+		`{"warmer": true}`:                                           true,
+		`{"source": "serverless-plugin-warmup"}`:                     true,
+		`{"source": "aws.events", "detail-type": "Scheduled Event"}`: true,
+		`{"httpMethod": "GET", "path": "/", "source": "aws.events"}`: false,
+		`{"httpMethod": "GET", "path": "/"}`:                         false,
+		`"not an object"`:                                            false,
+	} {
+		if got := apigw.IsWarmupEvent([]byte(payload)); got != want {
+			t.Errorf("%s: got %v, want %v", payload, got, want)
+		}
+	}
+}
+
+func TestWithWarmup(t *testing.T) {
+	warmed := 0
+	h := apigw.WithWarmup(apigw.Handler(http.NotFoundHandler()), func(context.Context) error {
+		warmed++
+		return nil
+	})
+
+	resp, err := h(context.Background(), json.RawMessage(`{"warmer": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warmed != 1 {
+		t.Errorf("expected warmup to be called once, got %d", warmed)
+	}
+	if r, ok := resp.(*apigw.WarmupResponse); !ok || !r.Warm {
+		t.Errorf("unexpected warmup response: %#v", resp)
+	}
+
+	resp, err = h(context.Background(), json.RawMessage(`{"httpMethod": "GET", "path": "/"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warmed != 1 {
+		t.Error("expected warmup not to be called for API requests")
+	}
+	if r, ok := resp.(*events.APIGatewayProxyResponse); !ok || r.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+}
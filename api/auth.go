@@ -0,0 +1,231 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// APIKey describes a client's API key. A client may have several keys at a
+// time, e.g. the old and new key during a rotation, where the old one expires
+// once all instances of the client use the new one.
+type APIKey struct {
+	// Name of the client the key belongs to
+	Client string `json:"client"`
+
+	// Time after which the key is rejected, or zero if it doesn't expire
+	Expires time.Time `json:"expires,omitempty"`
+
+	// Whether the key was revoked
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+func (k *APIKey) valid(now time.Time) bool {
+	return k != nil && !k.Disabled && (k.Expires.IsZero() || now.Before(k.Expires))
+}
+
+// KeyStore looks up API keys by their hash (see HashAPIKey). It returns nil
+// if there is no such key.
+type KeyStore interface {
+	Lookup(hash string) (*APIKey, error)
+}
+
+// HashAPIKey returns the hash under which an API key is stored. Only hashes
+// are stored so that leaked key data can't be used to call the endpoints.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// StaticKeyStore is a KeyStore with a fixed set of keys, indexed by hash. It
+// can be loaded from a JSON document, e.g. stored in Secrets Manager or the
+// Parameter Store.
+type StaticKeyStore map[string]APIKey
+
+// ParseKeyStore parses a JSON object mapping key hashes to APIKeys.
+func ParseKeyStore(data []byte) (StaticKeyStore, error) {
+	var keys StaticKeyStore
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Lookup returns the key with the given hash.
+func (s StaticKeyStore) Lookup(hash string) (*APIKey, error) {
+	if k, ok := s[hash]; ok {
+		return &k, nil
+	}
+	return nil, nil
+}
+
+// DynamoDBKeyStore is a KeyStore backed by a DynamoDB table with a string
+// partition key named "id" that holds the key hash. Items have the attributes
+// "client" (string), "expires" (Unix time, optional), and "disabled" (boolean,
+// optional).
+type DynamoDBKeyStore struct {
+	// Client used to access the table
+	Client dynamodbiface.DynamoDBAPI
+
+	// Table to read keys from
+	Table string
+}
+
+// Lookup reads the key with the given hash from the table.
+func (s *DynamoDBKeyStore) Lookup(hash string) (*APIKey, error) {
+	out, err := s.Client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(s.Table),
+		Key:       map[string]*dynamodb.AttributeValue{"id": {S: aws.String(hash)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	k := &APIKey{}
+	if av := out.Item["client"]; av != nil && av.S != nil {
+		k.Client = *av.S
+	}
+	if av := out.Item["expires"]; av != nil && av.N != nil {
+		sec, err := strconv.ParseInt(*av.N, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		k.Expires = time.Unix(sec, 0)
+	}
+	if av := out.Item["disabled"]; av != nil && av.BOOL != nil {
+		k.Disabled = *av.BOOL
+	}
+	return k, nil
+}
+
+// AuthOptions configures API key authentication.
+type AuthOptions struct {
+	// Store to look up keys in
+	Keys KeyStore
+
+	// Header carrying the API key, with or without "Bearer " prefix.
+	// Defaults to Authorization.
+	Header string
+
+	// How long to cache lookups, including misses, to save reads. Revoked
+	// keys may be accepted for up to this long.
+	CacheTTL time.Duration
+
+	// Maximum number of misses to cache. Further misses aren't cached until
+	// older ones expire, so that requests with random keys can't grow the
+	// cache without bound. Defaults to DefaultAuthMaxCachedMisses.
+	MaxCachedMisses int
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// DefaultAuthCacheTTL is used if AuthOptions.CacheTTL isn't set.
+const DefaultAuthCacheTTL = time.Minute
+
+// DefaultAuthMaxCachedMisses is used if AuthOptions.MaxCachedMisses isn't set.
+const DefaultAuthMaxCachedMisses = 1000
+
+type cachedKey struct {
+	key     *APIKey
+	fetched time.Time
+}
+
+// Auth wraps an HTTP handler so that only requests with a valid API key reach
+// it. All others are rejected with 401 Unauthorized. Unlike rate limiting,
+// authentication fails closed if the key store can't be read.
+func Auth(h http.Handler, opts AuthOptions) http.Handler {
+	if opts.Header == "" {
+		opts.Header = "Authorization"
+	}
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = DefaultAuthCacheTTL
+	}
+	if opts.MaxCachedMisses == 0 {
+		opts.MaxCachedMisses = DefaultAuthMaxCachedMisses
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.New(os.Stderr, "[LaunchDarkly Auth]", log.LstdFlags)
+	}
+
+	var mu sync.Mutex
+	cache := make(map[string]cachedKey)
+	misses := 0
+
+	lookup := func(hash string, now time.Time) (*APIKey, error) {
+		mu.Lock()
+		c, ok := cache[hash]
+		mu.Unlock()
+		if ok && now.Sub(c.fetched) < opts.CacheTTL {
+			return c.key, nil
+		}
+
+		k, err := opts.Keys.Lookup(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		for h, c := range cache {
+			if now.Sub(c.fetched) >= opts.CacheTTL {
+				delete(cache, h)
+				if c.key == nil {
+					misses--
+				}
+			}
+		}
+		if old, ok := cache[hash]; ok && old.key == nil {
+			misses--
+		}
+		if k != nil {
+			cache[hash] = cachedKey{key: k, fetched: now}
+		} else if misses < opts.MaxCachedMisses {
+			cache[hash] = cachedKey{fetched: now}
+			misses++
+		} else {
+			delete(cache, hash)
+		}
+		mu.Unlock()
+		return k, nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(opts.Header))
+		if len(key) > 7 && strings.EqualFold(key[:7], "Bearer ") {
+			key = strings.TrimSpace(key[7:])
+		}
+		if key == "" {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		now := time.Now()
+		k, err := lookup(HashAPIKey(key), now)
+		if err != nil {
+			opts.Logger.Printf("ERROR: Failed to look up API key: %s", err)
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		if !k.valid(now) {
+			opts.Logger.Printf("WARN: Rejected invalid API key from %s", clientIP(r))
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
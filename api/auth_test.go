@@ -0,0 +1,97 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+)
+
+func TestAuth(t *testing.T) {
+	keys := api.StaticKeyStore{
+		api.HashAPIKey("current"): {Client: "web"},
+		api.HashAPIKey("rotated"): {Client: "web", Expires: time.Now().Add(-time.Hour)},
+		api.HashAPIKey("revoked"): {Client: "ios", Disabled: true},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := api.Auth(ok, api.AuthOptions{Keys: keys})
+
+	for _, tt := range []struct {
+		header string
+		code   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"current", http.StatusOK},
+		{"Bearer current", http.StatusOK},
+		{"rotated", http.StatusUnauthorized},
+		{"revoked", http.StatusUnauthorized},
+		{"unknown", http.StatusUnauthorized},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("%q: got status %d, want %d", tt.header, w.Code, tt.code)
+		}
+	}
+}
+
+type countingKeyStore struct {
+	api.StaticKeyStore
+	lookups map[string]int
+}
+
+func (s *countingKeyStore) Lookup(hash string) (*api.APIKey, error) {
+	s.lookups[hash]++
+	return s.StaticKeyStore.Lookup(hash)
+}
+
+func TestAuthLimitsCachedMisses(t *testing.T) {
+	keys := &countingKeyStore{
+		StaticKeyStore: api.StaticKeyStore{api.HashAPIKey("current"): {Client: "web"}},
+		lookups:        make(map[string]int),
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := api.Auth(ok, api.AuthOptions{Keys: keys, MaxCachedMisses: 10})
+
+	call := func(key string) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", key)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	call("current")
+	for i := 0; i < 1000; i++ {
+		call(fmt.Sprintf("bad-%d", i))
+	}
+	call("current")
+	call("bad-0")
+	call("bad-999")
+
+	if n := keys.lookups[api.HashAPIKey("current")]; n != 1 {
+		t.Errorf("valid key looked up %d times, want 1", n)
+	}
+	if n := keys.lookups[api.HashAPIKey("bad-0")]; n != 1 {
+		t.Errorf("early miss looked up %d times, want 1", n)
+	}
+	if n := keys.lookups[api.HashAPIKey("bad-999")]; n != 2 {
+		t.Errorf("miss over the limit looked up %d times, want 2", n)
+	}
+}
+
+func TestParseKeyStore(t *testing.T) {
+	keys, err := api.ParseKeyStore([]byte(`{"` + api.HashAPIKey("k") + `": {"client": "web", "expires": "2030-01-01T00:00:00Z"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, _ := keys.Lookup(api.HashAPIKey("k"))
+	if k == nil || k.Client != "web" || k.Expires.Year() != 2030 {
+		t.Errorf("unexpected key: %+v", k)
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// DefaultCompressMinSize is the size below which responses aren't compressed,
+// as the savings don't outweigh the overhead.
+const DefaultCompressMinSize = 1024
+
+// CompressOptions configures response compression.
+type CompressOptions struct {
+	// Minimum size of response bodies to compress (default:
+	// DefaultCompressMinSize)
+	MinSize int
+
+	// gzip compression level (default: gzip.DefaultCompression)
+	Level int
+
+	// Brotli compression level from 0 to 11 (default:
+	// brotli.DefaultCompression)
+	BrotliLevel int
+}
+
+// Compress wraps an HTTP handler and compresses its responses with Brotli or
+// gzip, whichever the client prefers according to Accept-Encoding, or Brotli
+// if it accepts both equally. Flag payloads are highly repetitive JSON that
+// usually shrinks to a tenth of its size with gzip, and further with Brotli.
+//
+// Responses are buffered in full before they are compressed. Strong ETags of
+// compressed responses are turned into weak ones, which the handlers of this
+// package still match in If-None-Match.
+func Compress(h http.Handler, opts CompressOptions) http.Handler {
+	if opts.MinSize <= 0 {
+		opts.MinSize = DefaultCompressMinSize
+	}
+	if opts.Level == 0 {
+		opts.Level = gzip.DefaultCompression
+	}
+	if opts.BrotliLevel == 0 {
+		opts.BrotliLevel = brotli.DefaultCompression
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if r.Method == http.MethodHead || encoding == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(buf, r)
+
+		header := w.Header()
+		if buf.status != http.StatusOK || buf.body.Len() < opts.MinSize || header.Get("Content-Encoding") != "" {
+			w.WriteHeader(buf.status)
+			w.Write(buf.body.Bytes())
+			return
+		}
+
+		var compressed bytes.Buffer
+		var zw io.WriteCloser
+		if encoding == "br" {
+			zw = brotli.NewWriterLevel(&compressed, opts.BrotliLevel)
+		} else {
+			var err error
+			if zw, err = gzip.NewWriterLevel(&compressed, opts.Level); err != nil {
+				w.WriteHeader(buf.status)
+				w.Write(buf.body.Bytes())
+				return
+			}
+		}
+		zw.Write(buf.body.Bytes())
+		zw.Close()
+
+		header.Set("Content-Encoding", encoding)
+		header.Set("Content-Length", strconv.Itoa(compressed.Len()))
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.WriteHeader(buf.status)
+		w.Write(compressed.Bytes())
+	})
+}
+
+// bufferedResponse holds back the status and body of a response so that it
+// can be compressed as a whole.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// negotiateEncoding returns the encoding to compress a response with
+// according to an Accept-Encoding header, "br" or "gzip", or an empty string
+// if the client accepts neither. Explicit entries like "gzip;q=0" take
+// precedence over "*".
+func negotiateEncoding(header string) string {
+	brQ, gzipQ, anyQ := -1.0, -1.0, -1.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(fields[0])) {
+		case "br":
+			brQ = q
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if brQ < 0 {
+		brQ = anyQ
+	}
+	if gzipQ < 0 {
+		gzipQ = anyQ
+	}
+	switch {
+	case brQ > 0 && brQ >= gzipQ:
+		return "br"
+	case gzipQ > 0:
+		return "gzip"
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package api_test
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+)
+
+func TestCompress(t *testing.T) {
+	body := `{"flags":` + strings.Repeat(`{"value":true},`, 200) + `}`
+	h := api.Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"abc"`)
+		w.Write([]byte(body))
+	}), api.CompressOptions{})
+
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"gzip, deflate, br", "br"},
+		{"br;q=1.0, gzip;q=0.8", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"gzip", "gzip"},
+		{"br", "br"},
+		{"*", "br"},
+		{"br;q=0, *", "gzip"},
+		{"", ""},
+		{"deflate", ""},
+		{"gzip;q=0, br;q=0, *", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("%q: got Vary %q", tt.acceptEncoding, got)
+		}
+		if tt.encoding == "" {
+			if w.Header().Get("Content-Encoding") != "" || w.Body.String() != body {
+				t.Errorf("%q: expected uncompressed response", tt.acceptEncoding)
+			}
+			continue
+		}
+
+		if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Fatalf("%q: got Content-Encoding %q", tt.acceptEncoding, got)
+		}
+		if got := w.Header().Get("ETag"); got != `W/"abc"` {
+			t.Errorf("%q: got ETag %q, want weak ETag", tt.acceptEncoding, got)
+		}
+		if w.Body.Len() >= len(body)/5 {
+			t.Errorf("%q: compressed %d bytes to %d", tt.acceptEncoding, len(body), w.Body.Len())
+		}
+		var zr io.Reader = brotli.NewReader(w.Body)
+		if tt.encoding == "gzip" {
+			var err error
+			if zr, err = gzip.NewReader(w.Body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		decompressed, _ := ioutil.ReadAll(zr)
+		if string(decompressed) != body {
+			t.Errorf("%q: got body %q after decompression", tt.acceptEncoding, decompressed)
+		}
+	}
+}
+
+func TestCompressSkipsSmallAndErrorResponses(t *testing.T) {
+	h := api.Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, strings.Repeat("not found ", 200), http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}), api.CompressOptions{})
+
+	for _, path := range []string{"/", "/missing"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: expected uncompressed response", path)
+		}
+	}
+}
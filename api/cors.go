@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures cross-origin resource sharing for browser-based
+// SDKs that call the evaluation endpoints directly.
+type CORSOptions struct {
+	// Origins allowed to make cross-origin requests. Use "*" to allow any
+	// origin.
+	AllowedOrigins []string
+
+	// Request headers allowed in cross-origin requests. Defaults to
+	// Authorization, Content-Type, If-None-Match, and X-Device-Id.
+	AllowedHeaders []string
+
+	// Methods allowed in cross-origin requests. Defaults to GET, HEAD, and
+	// POST.
+	AllowedMethods []string
+
+	// Response headers exposed to browsers. Defaults to ETag and
+	// X-Next-Cursor.
+	ExposedHeaders []string
+
+	// How long browsers may cache preflight responses
+	MaxAge time.Duration
+
+	// Whether browsers may send cookies and other credentials. Credentials
+	// are only allowed for origins listed explicitly, never for "*".
+	AllowCredentials bool
+}
+
+var (
+	defaultCORSHeaders        = []string{"Authorization", "Content-Type", "If-None-Match", DeviceIDHeader}
+	defaultCORSMethods        = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	defaultCORSExposedHeaders = []string{"ETag", NextCursorHeader}
+)
+
+// CORS wraps an HTTP handler with CORS handling. Preflight requests are
+// answered directly without calling the wrapped handler.
+func CORS(h http.Handler, opts CORSOptions) http.Handler {
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = defaultCORSHeaders
+	}
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = defaultCORSMethods
+	}
+	if len(opts.ExposedHeaders) == 0 {
+		opts.ExposedHeaders = defaultCORSExposedHeaders
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !opts.originAllowed(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// Reflecting any origin with credentials would let every website
+		// make credentialed requests, which browsers refuse for "*"
+		if opts.originListed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if opts.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if preflight {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(opts.AllowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(opts.AllowedHeaders, ", "))
+			if opts.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (opts CORSOptions) originListed(origin string) bool {
+	for _, o := range opts.AllowedOrigins {
+		if strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (opts CORSOptions) originAllowed(origin string) bool {
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return opts.originListed(origin)
+}
@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+)
+
+func TestCORS(t *testing.T) {
+	called := 0
+	h := api.CORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+	}), api.CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com"},
+		MaxAge:         time.Hour,
+	})
+
+	// Preflight from an allowed origin
+	req := httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("got status %d for preflight", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("got Access-Control-Allow-Origin %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Authorization") {
+		t.Errorf("got Access-Control-Allow-Headers %q, want Authorization for API keys", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "3600" {
+		t.Errorf("got Access-Control-Max-Age %q", got)
+	}
+	if called != 0 {
+		t.Error("preflight request reached the handler")
+	}
+
+	// Actual request from an allowed origin
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "ETag, X-Next-Cursor" {
+		t.Errorf("got Access-Control-Expose-Headers %q", got)
+	}
+	if called != 1 {
+		t.Error("request didn't reach the handler")
+	}
+
+	// Preflight from a disallowed origin
+	req = httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got status %d for disallowed preflight", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("got Access-Control-Allow-Origin %q for disallowed origin", got)
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	h := api.CORS(http.NotFoundHandler(), api.CORSOptions{AllowedOrigins: []string{"*"}})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("got Access-Control-Allow-Origin %q", got)
+	}
+}
+
+func TestCORSCredentialsRequireListedOrigin(t *testing.T) {
+	h := api.CORS(http.NotFoundHandler(), api.CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com", "*"},
+		AllowCredentials: true,
+	})
+
+	for origin, want := range map[string]string{
+		"https://app.example.com": "https://app.example.com",
+		"https://evil.example":    "*",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("%s: got Access-Control-Allow-Origin %q, want %q", origin, got, want)
+		}
+		credentials := w.Header().Get("Access-Control-Allow-Credentials") == "true"
+		if listed := want != "*"; credentials != listed {
+			t.Errorf("%s: got credentials %t, want %t", origin, credentials, listed)
+		}
+	}
+}
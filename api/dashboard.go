@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// DashboardSource is implemented by *dynamodb.DynamoDBFeatureStore.
+type DashboardSource interface {
+	Lister
+	SyncTimer
+}
+
+// DefaultStaleAfter is the age of the data after which the dashboard flags it
+// as stale.
+const DefaultStaleAfter = 2 * time.Hour
+
+// DashboardHandler is an HTTP handler that renders an HTML page listing all
+// flags with their versions, along with the time of the last sync and whether
+// the data is stale. It is meant as an operational view for teams without
+// access to the LaunchDarkly dashboard and needs no JavaScript.
+//
+// Items don't record when they changed, so the page shows when the store was
+// last updated rather than a time per flag. The "prefix" and "tag" query
+// parameters filter flags as with ListHandler.
+type DashboardHandler struct {
+	// Store to list flags from
+	Store DashboardSource
+
+	// Title of the page, e.g. the name of the environment
+	Title string
+
+	// Age of the data after which it's shown as stale (default:
+	// DefaultStaleAfter), which should be longer than the sync interval
+	StaleAfter time.Duration
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// NewDashboardHandler creates a DashboardHandler for the given store.
+func NewDashboardHandler(store DashboardSource, logger ld.Logger) *DashboardHandler {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly DashboardHandler]", log.LstdFlags)
+	}
+	return &DashboardHandler{Store: store, Title: "Feature Flags", Logger: logger}
+}
+
+type dashboardData struct {
+	Title      string
+	Prefix     string
+	Tag        string
+	Flags      []FlagSummary
+	LastSynced time.Time
+	Age        time.Duration
+	Stale      bool
+	Error      string
+}
+
+// ServeHTTP renders the dashboard.
+func (h *DashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	staleAfter := h.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = DefaultStaleAfter
+	}
+	q := r.URL.Query()
+	data := dashboardData{Title: h.Title, Prefix: q.Get("prefix"), Tag: q.Get("tag")}
+
+	if synced, err := h.Store.LastSynced(); err != nil {
+		h.Logger.Printf("WARN: Failed to get last sync time: %s", err)
+	} else if !synced.IsZero() {
+		data.LastSynced = synced
+		data.Age = time.Since(synced).Truncate(time.Second)
+		data.Stale = data.Age > staleAfter
+	}
+
+	items, err := h.Store.List(ld.Features, dynamodb.ListOptions{Prefix: data.Prefix, Tag: data.Tag})
+	if err != nil {
+		h.Logger.Printf("ERROR: Failed to list flags: %s", err)
+		data.Error = "Failed to read flags from the store."
+	}
+	data.Flags = summarizeFlags(items, h.Logger)
+
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, data); err != nil {
+		h.Logger.Printf("ERROR: Failed to render dashboard: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if data.Error != "" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(buf.Bytes())
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 1em; border-bottom: 1px solid #ddd; text-align: left; }
+.stale, .error { color: #b00; font-weight: bold; }
+.off { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<p>
+{{if .LastSynced.IsZero}}<span class="stale">Never synced</span>
+{{else}}Last synced {{.LastSynced.UTC.Format "2006-01-02 15:04:05 MST"}} ({{.Age}} ago){{if .Stale}} &ndash; <span class="stale">stale</span>{{end}}
+{{end}}
+</p>
+<form method="get">
+<input name="prefix" placeholder="Key prefix" value="{{.Prefix}}">
+<input name="tag" placeholder="Tag" value="{{.Tag}}">
+<button type="submit">Filter</button>
+</form>
+<p>{{len .Flags}} flag(s)</p>
+<table>
+<tr><th>Key</th><th>Version</th><th>Targeting</th><th>Tags</th></tr>
+{{range .Flags}}<tr><td>{{.Key}}</td><td>{{.Version}}</td><td{{if not .On}} class="off"{{end}}>{{if .On}}on{{else}}off{{end}}</td><td>{{range $i, $tag := .Tags}}{{if $i}}, {{end}}{{$tag}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
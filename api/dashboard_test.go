@@ -0,0 +1,52 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+type fakeDashboardSource struct {
+	fakeLister
+	synced time.Time
+}
+
+func (s *fakeDashboardSource) LastSynced() (time.Time, error) {
+	return s.synced, nil
+}
+
+func TestDashboardHandler(t *testing.T) {
+	store := &fakeDashboardSource{synced: time.Now().Add(-time.Minute)}
+	h := api.NewDashboardHandler(store, nil)
+	h.Title = "Staging <flags>"
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/dashboard?prefix=new-", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("got status %d and content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if store.opts != (dynamodb.ListOptions{Prefix: "new-"}) {
+		t.Errorf("got list options %+v", store.opts)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Staging &lt;flags&gt;", "<td>new-api</td><td>7</td>", "<td>frontend</td>", "2 flag(s)", `value="new-"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected dashboard to contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `class="stale"`) {
+		t.Error("expected fresh data not to be stale")
+	}
+
+	store.synced = time.Now().Add(-3 * time.Hour)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/dashboard", nil))
+	if !strings.Contains(w.Body.String(), `class="stale">stale`) {
+		t.Error("expected old data to be stale")
+	}
+}
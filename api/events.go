@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+const (
+	defaultEventBatchSize  = 100
+	defaultEventMaxRetries = 1
+	maxEventPayloadSize    = 1 << 20
+)
+
+// EventForwarder is an HTTP handler that accepts analytics events from
+// client-side SDKs and forwards them to LaunchDarkly's events API. This way,
+// browsers and mobile apps that get their flags from our own endpoints still
+// show up in LaunchDarkly's flag insights.
+type EventForwarder struct {
+	// SDK key used to authenticate with the events API
+	SDKKey string
+
+	// Base URI of the events API
+	EventsURI string
+
+	// HTTP client used to post events
+	Client *http.Client
+
+	// Maximum number of events sent in a single request
+	BatchSize int
+
+	// Number of times a failed batch is retried
+	MaxRetries int
+
+	// Time to wait between retries
+	RetryDelay time.Duration
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// NewEventForwarder creates an EventForwarder that posts events to the default
+// LaunchDarkly events API.
+func NewEventForwarder(sdkKey string, logger ld.Logger) *EventForwarder {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly EventForwarder]", log.LstdFlags)
+	}
+
+	return &EventForwarder{
+		SDKKey:     sdkKey,
+		EventsURI:  ld.DefaultConfig.EventsUri,
+		Client:     &http.Client{Timeout: 5 * time.Second},
+		BatchSize:  defaultEventBatchSize,
+		MaxRetries: defaultEventMaxRetries,
+		RetryDelay: time.Second,
+		Logger:     logger,
+	}
+}
+
+// ServeHTTP accepts a JSON array of events and forwards them in batches.
+func (f *EventForwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxEventPayloadSize))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var events []json.RawMessage
+	if err := json.Unmarshal(body, &events); err != nil {
+		http.Error(w, "Request body must be a JSON array of events", http.StatusBadRequest)
+		return
+	}
+
+	// Only ask the client to retry if nothing was forwarded. Otherwise, a
+	// retry would send the events of earlier batches twice.
+	if n, err := f.Forward(events); err != nil && n == 0 {
+		f.Logger.Printf("ERROR: Failed to forward %d event(s): %s", len(events), err)
+		http.Error(w, "Failed to forward events", http.StatusBadGateway)
+		return
+	} else if err != nil {
+		f.Logger.Printf("WARN: Dropped %d of %d event(s) after error: %s", len(events)-n, len(events), err)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Forward posts the given events to the events API, splitting them into
+// batches of BatchSize and retrying failed batches. It stops at the first
+// batch that fails and returns the number of events forwarded before.
+func (f *EventForwarder) Forward(events []json.RawMessage) (int, error) {
+	batchSize := f.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEventBatchSize
+	}
+
+	forwarded := 0
+	for len(events) > 0 {
+		n := batchSize
+		if n > len(events) {
+			n = len(events)
+		}
+		batch := events[:n]
+		events = events[n:]
+
+		payload, err := json.Marshal(batch)
+		if err != nil {
+			return forwarded, err
+		}
+
+		if err := f.postWithRetries(payload); err != nil {
+			return forwarded, err
+		}
+		forwarded += n
+	}
+
+	return forwarded, nil
+}
+
+func (f *EventForwarder) postWithRetries(payload []byte) error {
+	var err error
+	for attempt := 0; attempt <= f.MaxRetries; attempt++ {
+		if attempt > 0 {
+			f.Logger.Printf("WARN: Retrying to post events after error: %s", err)
+			time.Sleep(f.RetryDelay)
+		}
+		var retryable bool
+		if retryable, err = f.post(payload); err == nil || !retryable {
+			return err
+		}
+	}
+	return err
+}
+
+// post sends a single batch of events. It reports whether a failed request
+// may be retried.
+func (f *EventForwarder) post(payload []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(f.EventsURI, "/")+"/bulk", bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", f.SDKKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoClient/"+ld.Version)
+	req.Header.Set("X-LaunchDarkly-Event-Schema", "3")
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode >= 400 {
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("events API returned status %d", resp.StatusCode)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,113 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+)
+
+func TestEventForwarder(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]json.RawMessage
+	failures := 1
+
+	ld := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bulk" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "sdk-key" {
+			t.Errorf("got Authorization %q", got)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch []json.RawMessage
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &batch); err != nil {
+			t.Error(err)
+		}
+		batches = append(batches, batch)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ld.Close()
+
+	f := api.NewEventForwarder("sdk-key", nil)
+	f.EventsURI = ld.URL
+	f.BatchSize = 2
+	f.RetryDelay = 0
+
+	body := `[{"kind":"feature","key":"a"},{"kind":"feature","key":"b"},{"kind":"custom","key":"c"}]`
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest("POST", "/events", strings.NewReader(body)))
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("got status %d", w.Code)
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Errorf("unexpected batches: %v", batches)
+	}
+}
+
+func TestEventForwarderBadRequest(t *testing.T) {
+	f := api.NewEventForwarder("sdk-key", nil)
+
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest("POST", "/events", strings.NewReader("{}")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for invalid payload", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d for GET", w.Code)
+	}
+}
+
+func TestEventForwarderPartialFailure(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+
+	ld := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if requests++; requests > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ld.Close()
+
+	f := api.NewEventForwarder("sdk-key", log.New(ioutil.Discard, "", 0))
+	f.EventsURI = ld.URL
+	f.BatchSize = 2
+	f.RetryDelay = 0
+
+	// The first batch is forwarded; retrying would send it twice
+	body := `[{"kind":"feature","key":"a"},{"kind":"feature","key":"b"},{"kind":"custom","key":"c"}]`
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest("POST", "/events", strings.NewReader(body)))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("got status %d after partial failure, want %d", w.Code, http.StatusAccepted)
+	}
+
+	// Nothing is forwarded; the client should retry
+	w = httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest("POST", "/events", strings.NewReader(body)))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("got status %d after total failure, want %d", w.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Evaluator evaluates all feature flags for a user. It is satisfied by
+// *ld.LDClient.
+type Evaluator interface {
+	AllFlags(user ld.User) map[string]interface{}
+}
+
+// PageEvaluator evaluates flags page by page. It is satisfied by
+// *evaluator.Evaluator.
+type PageEvaluator interface {
+	FlagsPage(user ld.User, cursor string, limit int) (map[string]interface{}, string, error)
+}
+
+// NextCursorHeader is the response header with the cursor of the next page of
+// flags. It is missing after the last page.
+const NextCursorHeader = "X-Next-Cursor"
+
+// MaxPageLimit is the largest number of flags returned per page.
+const MaxPageLimit = 1000
+
+// SyncTimer is implemented by feature stores that know when their data was
+// last updated, e.g. *dynamodb.DynamoDBFeatureStore.
+type SyncTimer interface {
+	LastSynced() (time.Time, error)
+}
+
+// FlagsHandler is an HTTP handler that returns the values of all feature flags
+// for a user as a JSON object, which can be used to bootstrap client-side
+// SDKs.
+//
+// The user is read from the "user" query parameter (base64url-encoded JSON),
+// or from the "key" query parameter. Without either, an anonymous user is
+// derived from the request with AnonymousUserKey.
+//
+// If the client implements PageEvaluator, the "limit" query parameter
+// returns only that many flags, along with a NextCursorHeader to pass as the
+// "cursor" query parameter to get the next page. Only the flags of the page
+// are read from the store.
+//
+// In CDN mode, flags are always evaluated for the same anonymous user and all
+// user-related request data is ignored. This makes responses identical for
+// everyone so that they can be cached by CloudFront and other CDNs.
+type FlagsHandler struct {
+	// Client used to evaluate flags
+	Client Evaluator
+
+	// Optional store used to compute ETag and Last-Modified headers, which
+	// allows clients and CDNs to skip downloading unchanged flags.
+	Store SyncTimer
+
+	// Salt used to derive anonymous user keys
+	Salt string
+
+	// Value of the Cache-Control header sent with flags, e.g.
+	// "public, max-age=60"
+	CacheControl string
+
+	// Value of the Surrogate-Control header, which CDNs honor instead of
+	// Cache-Control, e.g. "max-age=300"
+	SurrogateControl string
+
+	// Whether to evaluate flags for a shared anonymous user (see above)
+	CDNMode bool
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// NewFlagsHandler creates a FlagsHandler for the given client and store.
+func NewFlagsHandler(client Evaluator, store SyncTimer, logger ld.Logger) *FlagsHandler {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly FlagsHandler]", log.LstdFlags)
+	}
+
+	return &FlagsHandler{
+		Client: client,
+		Store:  store,
+		Logger: logger,
+	}
+}
+
+// ServeHTTP evaluates all flags for the user of the request.
+func (h *FlagsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := ld.NewAnonymousUser(CDNUserKey)
+	if !h.CDNMode {
+		var err error
+		if user, err = UserFromRequest(r, h.Salt); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	cursor, limit, err := pageFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pager, ok := h.Client.(PageEvaluator)
+	if limit > 0 && !ok {
+		http.Error(w, "pagination is not supported", http.StatusBadRequest)
+		return
+	}
+
+	h.setCacheHeaders(w)
+
+	if h.Store != nil {
+		synced, err := h.Store.LastSynced()
+		if err != nil {
+			h.Logger.Printf("WARN: Failed to get last sync time: %s", err)
+		} else if !synced.IsZero() {
+			etag := computeETag(synced, user, cursor, limit)
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", synced.UTC().Format(http.TimeFormat))
+			if notModified(r, etag, synced) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
+
+	var flags map[string]interface{}
+	if limit > 0 {
+		var next string
+		if flags, next, err = pager.FlagsPage(user, cursor, limit); err != nil {
+			h.Logger.Printf("ERROR: Failed to evaluate page of flags: %s", err)
+			flags = nil
+		} else if next != "" {
+			w.Header().Set(NextCursorHeader, next)
+		}
+	} else {
+		flags = h.Client.AllFlags(user)
+	}
+	if flags == nil {
+		// The client isn't initialized, so don't let anyone cache the result
+		w.Header().Del("ETag")
+		w.Header().Del("Last-Modified")
+		w.Header().Del("Surrogate-Control")
+		w.Header().Set("Cache-Control", "no-store")
+		flags = map[string]interface{}{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(flags); err != nil {
+		h.Logger.Printf("ERROR: Failed to encode flags: %s", err)
+	}
+}
+
+// CDNUserKey is the key of the anonymous user flags are evaluated for in CDN
+// mode.
+const CDNUserKey = "cdn-anonymous-user"
+
+func (h *FlagsHandler) setCacheHeaders(w http.ResponseWriter) {
+	if h.CacheControl != "" {
+		w.Header().Set("Cache-Control", h.CacheControl)
+	}
+	if h.SurrogateControl != "" {
+		w.Header().Set("Surrogate-Control", h.SurrogateControl)
+	}
+}
+
+// UserFromRequest returns the user to evaluate flags for. See FlagsHandler
+// for the supported query parameters.
+func UserFromRequest(r *http.Request, salt string) (ld.User, error) {
+	q := r.URL.Query()
+
+	if encoded := q.Get("user"); encoded != "" {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil {
+			return ld.User{}, fmt.Errorf("user must be base64url-encoded: %s", err)
+		}
+		var user ld.User
+		if err := json.Unmarshal(data, &user); err != nil {
+			return ld.User{}, fmt.Errorf("user must be a JSON object: %s", err)
+		}
+		if user.Key == nil || *user.Key == "" {
+			return ld.User{}, fmt.Errorf("user must have a key")
+		}
+		return user, nil
+	}
+
+	if key := q.Get("key"); key != "" {
+		return ld.NewUser(key), nil
+	}
+
+	return AnonymousUser(r, salt), nil
+}
+
+// pageFromRequest returns the cursor and limit of a paginated request, or a
+// zero limit to return all flags.
+func pageFromRequest(r *http.Request) (string, int, error) {
+	q := r.URL.Query()
+	cursor := q.Get("cursor")
+	if q.Get("limit") == "" {
+		if cursor != "" {
+			return "", 0, fmt.Errorf("cursor requires limit")
+		}
+		return "", 0, nil
+	}
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 || limit > MaxPageLimit {
+		return "", 0, fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+	}
+	return cursor, limit, nil
+}
+
+// computeETag derives an entity tag from the last sync time, the user, and
+// the requested page, as the flag values only change if any of them changes.
+func computeETag(synced time.Time, user ld.User, cursor string, limit int) string {
+	userJSON, _ := json.Marshal(user)
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(synced.UnixNano(), 10)))
+	h.Write(userJSON)
+	if limit > 0 {
+		fmt.Fprintf(h, "\x00%s\x00%d", cursor, limit)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// notModified evaluates the conditional request headers. If-None-Match takes
+// precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !modified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
@@ -0,0 +1,179 @@
+package api_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+)
+
+type fakeEvaluator struct {
+	users []ld.User
+}
+
+func (e *fakeEvaluator) AllFlags(user ld.User) map[string]interface{} {
+	e.users = append(e.users, user)
+	return map[string]interface{}{"flag": *user.Key}
+}
+
+type fakeSyncTimer time.Time
+
+func (t fakeSyncTimer) LastSynced() (time.Time, error) {
+	return time.Time(t), nil
+}
+
+func TestFlagsHandler(t *testing.T) {
+	client := &fakeEvaluator{}
+	h := api.NewFlagsHandler(client, fakeSyncTimer(time.Now()), nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?key=bob", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d", w.Code)
+	}
+	var flags map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &flags); err != nil {
+		t.Fatal(err)
+	}
+	if flags["flag"] != "bob" {
+		t.Errorf("unexpected flags: %v", flags)
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+	if w.Header().Get("Last-Modified") == "" {
+		t.Error("expected Last-Modified header")
+	}
+
+	// Same user and unchanged data
+	req := httptest.NewRequest("GET", "/?key=bob", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("got status %d, want 304", w.Code)
+	}
+	if len(client.users) != 1 {
+		t.Errorf("flags were evaluated %d times, want 1", len(client.users))
+	}
+
+	// Different user
+	req = httptest.NewRequest("GET", "/?key=alice", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("got status %d, want 200", w.Code)
+	}
+}
+
+func TestUserFromRequest(t *testing.T) {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(`{"key":"bob","country":"DE"}`))
+	user, err := api.UserFromRequest(httptest.NewRequest("GET", "/?user="+encoded, nil), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *user.Key != "bob" || *user.Country != "DE" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	if _, err := api.UserFromRequest(httptest.NewRequest("GET", "/?user=e30", nil), ""); err == nil {
+		t.Error("expected error for user without key")
+	}
+
+	user, err = api.UserFromRequest(httptest.NewRequest("GET", "/", nil), "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Anonymous == nil || !*user.Anonymous {
+		t.Error("expected anonymous user")
+	}
+}
+
+func TestFlagsHandlerCDNMode(t *testing.T) {
+	client := &fakeEvaluator{}
+	h := api.NewFlagsHandler(client, nil, nil)
+	h.CDNMode = true
+	h.CacheControl = "public, max-age=60"
+	h.SurrogateControl = "max-age=300"
+
+	for _, target := range []string{"/?key=bob", "/?key=alice", "/"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d", w.Code)
+		}
+		if got := w.Header().Get("Cache-Control"); got != h.CacheControl {
+			t.Errorf("got Cache-Control %q", got)
+		}
+		if got := w.Header().Get("Surrogate-Control"); got != h.SurrogateControl {
+			t.Errorf("got Surrogate-Control %q", got)
+		}
+	}
+
+	for _, user := range client.users {
+		if *user.Key != api.CDNUserKey {
+			t.Errorf("flags evaluated for user %q in CDN mode", *user.Key)
+		}
+	}
+}
+
+type fakePageEvaluator struct {
+	fakeEvaluator
+}
+
+func (e *fakePageEvaluator) FlagsPage(user ld.User, cursor string, limit int) (map[string]interface{}, string, error) {
+	if cursor == "" {
+		return map[string]interface{}{"a": true}, "next", nil
+	}
+	return map[string]interface{}{"b": true}, "", nil
+}
+
+func TestFlagsHandlerPagination(t *testing.T) {
+	h := api.NewFlagsHandler(&fakePageEvaluator{}, fakeSyncTimer(time.Now()), nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?key=bob&limit=1", nil))
+	if w.Code != http.StatusOK || w.Header().Get(api.NextCursorHeader) != "next" {
+		t.Fatalf("got status %d and next cursor %q", w.Code, w.Header().Get(api.NextCursorHeader))
+	}
+	firstETag := w.Header().Get("ETag")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?key=bob&limit=1&cursor=next", nil))
+	var flags map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &flags); err != nil {
+		t.Fatal(err)
+	}
+	if flags["b"] != true || w.Header().Get(api.NextCursorHeader) != "" {
+		t.Errorf("got flags %v and next cursor %q on last page", flags, w.Header().Get(api.NextCursorHeader))
+	}
+	if w.Header().Get("ETag") == firstETag {
+		t.Error("expected pages to have different ETags")
+	}
+
+	for _, query := range []string{"limit=0", "limit=1001", "cursor=next"} {
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/?key=bob&"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400", query, w.Code)
+		}
+	}
+
+	// Clients that can't page reject paginated requests
+	w = httptest.NewRecorder()
+	api.NewFlagsHandler(&fakeEvaluator{}, nil, nil).ServeHTTP(w, httptest.NewRequest("GET", "/?key=bob&limit=1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// Lister is implemented by *dynamodb.DynamoDBFeatureStore.
+type Lister interface {
+	List(kind ld.VersionedDataKind, opts dynamodb.ListOptions) ([]ld.VersionedData, error)
+}
+
+// FlagSummary describes a flag in the response of ListHandler.
+type FlagSummary struct {
+	Key     string   `json:"key"`
+	Version int      `json:"version"`
+	On      bool     `json:"on"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// ListHandler is an HTTP handler that lists the flags in the store, e.g. for
+// internal dashboards. Unlike FlagsHandler, it doesn't evaluate flags but
+// describes their configuration.
+//
+// The query parameters "prefix" and "tag" select flags whose key starts
+// with the prefix or that have the tag; both are evaluated by DynamoDB (see
+// dynamodb.ListOptions). The "sort" parameter orders flags by "key"
+// (default) or "version", or in descending order with "-key" or "-version".
+type ListHandler struct {
+	// Store to list flags from
+	Store Lister
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// NewListHandler creates a ListHandler for the given store.
+func NewListHandler(store Lister, logger ld.Logger) *ListHandler {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly ListHandler]", log.LstdFlags)
+	}
+	return &ListHandler{Store: store, Logger: logger}
+}
+
+// ServeHTTP lists the flags as a JSON array of FlagSummary objects.
+func (h *ListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	less, ok := flagOrders[q.Get("sort")]
+	if !ok {
+		http.Error(w, "sort must be one of key, -key, version, or -version", http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.Store.List(ld.Features, dynamodb.ListOptions{Prefix: q.Get("prefix"), Tag: q.Get("tag")})
+	if err != nil {
+		h.Logger.Printf("ERROR: Failed to list flags: %s", err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	flags := summarizeFlags(items, h.Logger)
+	sort.SliceStable(flags, func(i, j int) bool { return less(flags[i], flags[j]) })
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(flags); err != nil {
+		h.Logger.Printf("ERROR: Failed to encode flags: %s", err)
+	}
+}
+
+// summarizeFlags describes flags as FlagSummary objects. They are decoded via
+// JSON to support both SDK structs and raw items, which may have fields such
+// as tags that the SDK doesn't know about.
+func summarizeFlags(items []ld.VersionedData, logger ld.Logger) []FlagSummary {
+	flags := make([]FlagSummary, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			logger.Printf("WARN: Skipping flag that can't be encoded (key=%s): %s", item.GetKey(), err)
+			continue
+		}
+		var flag FlagSummary
+		if err := json.Unmarshal(data, &flag); err != nil {
+			logger.Printf("WARN: Skipping flag that can't be decoded (key=%s): %s", item.GetKey(), err)
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+// flagOrders are the orders supported by the "sort" query parameter. Flags
+// with the same version are ordered by key.
+var flagOrders = map[string]func(a, b FlagSummary) bool{
+	"":         func(a, b FlagSummary) bool { return a.Key < b.Key },
+	"key":      func(a, b FlagSummary) bool { return a.Key < b.Key },
+	"-key":     func(a, b FlagSummary) bool { return a.Key > b.Key },
+	"version":  func(a, b FlagSummary) bool { return a.Version < b.Version },
+	"-version": func(a, b FlagSummary) bool { return a.Version > b.Version },
+}
@@ -0,0 +1,61 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+type fakeLister struct {
+	opts dynamodb.ListOptions
+}
+
+func (l *fakeLister) List(kind ld.VersionedDataKind, opts dynamodb.ListOptions) ([]ld.VersionedData, error) {
+	l.opts = opts
+	raw := &dynamodb.RawItem{}
+	if err := json.Unmarshal([]byte(`{"key":"new-ui","version":1,"on":true,"tags":["frontend"]}`), raw); err != nil {
+		return nil, err
+	}
+	return []ld.VersionedData{
+		&ld.FeatureFlag{Key: "new-api", Version: 7},
+		raw,
+	}, nil
+}
+
+func TestListHandler(t *testing.T) {
+	store := &fakeLister{}
+	h := api.NewListHandler(store, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/flags?prefix=new-&tag=frontend&sort=-version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d", w.Code)
+	}
+	if store.opts.Prefix != "new-" || store.opts.Tag != "frontend" {
+		t.Errorf("got list options %+v", store.opts)
+	}
+
+	var flags []api.FlagSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &flags); err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 2 || flags[0].Key != "new-api" || flags[1].Key != "new-ui" {
+		t.Fatalf("got flags %+v, want new-api before new-ui", flags)
+	}
+	if !flags[1].On || strings.Join(flags[1].Tags, ",") != "frontend" {
+		t.Errorf("got flag %+v, want tags of raw item", flags[1])
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/flags?sort=name", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for invalid sort, want 400", w.Code)
+	}
+}
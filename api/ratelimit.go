@@ -0,0 +1,224 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Limiter decides whether a client identified by key may make another
+// request. If not, it returns how long the client should wait.
+type Limiter interface {
+	Take(key string) (ok bool, retryAfter time.Duration, err error)
+}
+
+// RateLimitOptions configures rate limiting of the evaluation endpoints.
+type RateLimitOptions struct {
+	// Limiter that keeps track of the token buckets
+	Limiter Limiter
+
+	// Header carrying the client's API key, e.g. "Authorization". Requests
+	// with this header are limited per key, all others per source IP.
+	KeyHeader string
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// RateLimit wraps an HTTP handler with rate limiting. Requests over the limit
+// are rejected with 429 Too Many Requests. If the limiter fails, e.g. because
+// its table is throttled, requests are let through.
+func RateLimit(h http.Handler, opts RateLimitOptions) http.Handler {
+	if opts.Logger == nil {
+		opts.Logger = log.New(os.Stderr, "[LaunchDarkly RateLimit]", log.LstdFlags)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, retryAfter, err := opts.Limiter.Take(opts.key(r))
+		if err != nil {
+			opts.Logger.Printf("WARN: Failed to check rate limit: %s", err)
+		} else if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// key returns the bucket key of a request. API keys are hashed so that they
+// aren't stored in plain text.
+func (opts RateLimitOptions) key(r *http.Request) string {
+	if opts.KeyHeader != "" {
+		if k := r.Header.Get(opts.KeyHeader); k != "" {
+			sum := sha256.Sum256([]byte(k))
+			return "key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + clientIP(r)
+}
+
+// bucket is the state of a token bucket.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take adds the tokens accrued since the last update and takes one if
+// possible.
+func (b *bucket) take(now time.Time, rate float64, burst int) (bool, time.Duration) {
+	if b.updated.IsZero() {
+		b.tokens = float64(burst)
+	} else if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+elapsed*rate)
+	}
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// MemoryLimiter is a Limiter that keeps token buckets in memory. With
+// multiple instances, e.g. Lambda containers, each one limits separately.
+type MemoryLimiter struct {
+	// Requests per second allowed on average
+	Rate float64
+
+	// Requests allowed at once
+	Burst int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryLimiter creates a MemoryLimiter.
+func NewMemoryLimiter(rate float64, burst int) *MemoryLimiter {
+	return &MemoryLimiter{Rate: rate, Burst: burst}
+}
+
+// Take takes a token from the key's bucket.
+func (l *MemoryLimiter) Take(key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+	}
+	now := time.Now()
+	l.expire(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{}
+		l.buckets[key] = b
+	}
+	ok, retryAfter := b.take(now, l.Rate, l.Burst)
+	return ok, retryAfter, nil
+}
+
+// expire drops full buckets, which behave like new ones, to bound memory use.
+func (l *MemoryLimiter) expire(now time.Time) {
+	full := time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// DynamoDBLimiter is a Limiter that keeps token buckets in a DynamoDB table,
+// so that the limit is shared by all instances. The table must have a string
+// partition key named "id". Expired buckets are removed if TTL is enabled on
+// the "ttl" attribute.
+type DynamoDBLimiter struct {
+	// Client used to access the table
+	Client dynamodbiface.DynamoDBAPI
+
+	// Table to store the buckets in
+	Table string
+
+	// Requests per second allowed on average
+	Rate float64
+
+	// Requests allowed at once
+	Burst int
+}
+
+// Take takes a token from the key's bucket. Concurrent updates of the same
+// bucket are detected with a conditional write and retried.
+func (l *DynamoDBLimiter) Take(key string) (bool, time.Duration, error) {
+	const attempts = 3
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		var ok bool
+		var retryAfter time.Duration
+		ok, retryAfter, err = l.take(key)
+		if ae, isAWS := err.(awserr.Error); isAWS && ae.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			continue
+		}
+		return ok, retryAfter, err
+	}
+	return false, 0, err
+}
+
+func (l *DynamoDBLimiter) take(key string) (bool, time.Duration, error) {
+	out, err := l.Client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(l.Table),
+		Key:            map[string]*dynamodb.AttributeValue{"id": {S: aws.String(key)}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return false, 0, err
+	}
+
+	var b bucket
+	condition := "attribute_not_exists(id)"
+	values := map[string]*dynamodb.AttributeValue{}
+	if av := out.Item["updated"]; av != nil && av.N != nil {
+		updated, _ := strconv.ParseInt(*av.N, 10, 64)
+		b.updated = time.Unix(0, updated)
+		if av := out.Item["tokens"]; av != nil && av.N != nil {
+			b.tokens, _ = strconv.ParseFloat(*av.N, 64)
+		}
+		condition = "updated = :updated"
+		values[":updated"] = av
+	}
+
+	now := time.Now()
+	ok, retryAfter := b.take(now, l.Rate, l.Burst)
+	full := time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(l.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"id":      {S: aws.String(key)},
+			"tokens":  {N: aws.String(strconv.FormatFloat(b.tokens, 'f', -1, 64))},
+			"updated": {N: aws.String(strconv.FormatInt(now.UnixNano(), 10))},
+			"ttl":     {N: aws.String(strconv.FormatInt(now.Add(full).Unix()+1, 10))},
+		},
+		ConditionExpression: aws.String(condition),
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+	if _, err := l.Client.PutItem(input); err != nil {
+		return false, 0, err
+	}
+	return ok, retryAfter, nil
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+)
+
+func TestRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := api.RateLimit(ok, api.RateLimitOptions{
+		Limiter:   api.NewMemoryLimiter(0.001, 2),
+		KeyHeader: "Authorization",
+	})
+
+	do := func(ip, key string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = ip + ":1234"
+		if key != "" {
+			r.Header.Set("Authorization", key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for i, want := range []int{200, 200, 429} {
+		if got := do("1.2.3.4", ""); got != want {
+			t.Errorf("request %d: got status %d, want %d", i, got, want)
+		}
+	}
+	if got := do("5.6.7.8", ""); got != 200 {
+		t.Errorf("other IP: got status %d", got)
+	}
+	if got := do("1.2.3.4", "secret"); got != 200 {
+		t.Errorf("API key: got status %d", got)
+	}
+}
+
+// fakeLimiterTable stores items in memory and honors the conditions used by
+// DynamoDBLimiter.
+type fakeLimiterTable struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (f *fakeLimiterTable) GetItem(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: f.items[*in.Key["id"].S]}, nil
+}
+
+func (f *fakeLimiterTable) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	id := *in.Item["id"].S
+	old, exists := f.items[id]
+	if prev := in.ExpressionAttributeValues[":updated"]; (prev == nil && exists) || (prev != nil && (!exists || *old["updated"].N != *prev.N)) {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "condition failed", nil)
+	}
+	f.items[id] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestDynamoDBLimiter(t *testing.T) {
+	l := &api.DynamoDBLimiter{
+		Client: &fakeLimiterTable{items: map[string]map[string]*dynamodb.AttributeValue{}},
+		Table:  "limits",
+		Rate:   0.001,
+		Burst:  1,
+	}
+
+	if ok, _, err := l.Take("ip:1.2.3.4"); !ok || err != nil {
+		t.Fatalf("first request: ok=%v err=%v", ok, err)
+	}
+	ok, retryAfter, err := l.Take("ip:1.2.3.4")
+	if ok || err != nil || retryAfter <= 0 {
+		t.Errorf("second request: ok=%v retryAfter=%s err=%v", ok, retryAfter, err)
+	}
+}
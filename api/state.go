@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/evaluator"
+)
+
+// StateEvaluator evaluates all flags for a user including their metadata. It
+// is satisfied by *evaluator.Evaluator.
+type StateEvaluator interface {
+	AllFlagsState(user ld.User, options ...evaluator.FlagsStateOption) *evaluator.FlagsState
+}
+
+// FlagsStateHandler is an HTTP handler that returns the state of all feature
+// flags for a user in the format of the SDKs' AllFlagsState, which client-side
+// SDKs can be bootstrapped with.
+//
+// The user is read like in FlagsHandler. The boolean query parameters
+// "clientSideOnly", "withReasons", and "detailsOnlyForTrackedFlags" enable the
+// corresponding options.
+type FlagsStateHandler struct {
+	// Evaluator used to evaluate flags
+	Evaluator StateEvaluator
+
+	// Salt used to derive anonymous user keys
+	Salt string
+
+	// Options applied to every request in addition to those in the query
+	Options []evaluator.FlagsStateOption
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// NewFlagsStateHandler creates a FlagsStateHandler for the given evaluator.
+func NewFlagsStateHandler(e StateEvaluator, logger ld.Logger) *FlagsStateHandler {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly FlagsStateHandler]", log.LstdFlags)
+	}
+
+	return &FlagsStateHandler{
+		Evaluator: e,
+		Logger:    logger,
+	}
+}
+
+// ServeHTTP evaluates all flags for the user of the request.
+func (h *FlagsStateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := UserFromRequest(r, h.Salt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options := append([]evaluator.FlagsStateOption(nil), h.Options...)
+	params := map[string]evaluator.FlagsStateOption{
+		"clientSideOnly":             evaluator.ClientSideOnly,
+		"withReasons":                evaluator.WithReasons,
+		"detailsOnlyForTrackedFlags": evaluator.DetailsOnlyForTrackedFlags,
+	}
+	for name, option := range params {
+		if v := r.URL.Query().Get(name); v != "" {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				http.Error(w, name+" must be a boolean", http.StatusBadRequest)
+				return
+			}
+			if enabled {
+				options = append(options, option)
+			}
+		}
+	}
+
+	state := h.Evaluator.AllFlagsState(user, options...)
+	if !state.Valid {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		h.Logger.Printf("ERROR: Failed to encode flags state: %s", err)
+	}
+}
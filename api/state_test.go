@@ -0,0 +1,54 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/evaluator"
+)
+
+type fakeStateEvaluator struct {
+	options []evaluator.FlagsStateOption
+}
+
+func (e *fakeStateEvaluator) AllFlagsState(user ld.User, options ...evaluator.FlagsStateOption) *evaluator.FlagsState {
+	e.options = options
+	return &evaluator.FlagsState{
+		Values:   map[string]interface{}{"flag": *user.Key},
+		Metadata: map[string]evaluator.FlagMetadata{"flag": {}},
+		Valid:    true,
+	}
+}
+
+func TestFlagsStateHandler(t *testing.T) {
+	e := &fakeStateEvaluator{}
+	h := api.NewFlagsStateHandler(e, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?key=bob&withReasons=true&clientSideOnly=false", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d", w.Code)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["flag"] != "bob" || body["$valid"] != true {
+		t.Errorf("unexpected body: %v", body)
+	}
+	if len(e.options) != 1 || e.options[0] != evaluator.WithReasons {
+		t.Errorf("unexpected options: %v", e.options)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?key=bob&withReasons=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, want 400", w.Code)
+	}
+}
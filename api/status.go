@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// StatusSource is implemented by *dynamodb.DynamoDBFeatureStore.
+type StatusSource interface {
+	Status() dynamodb.DataStoreStatus
+	LastSynced() (time.Time, error)
+	SyncLock(ctx context.Context) (*dynamodb.SyncLock, error)
+}
+
+// QuarantineSource is implemented by *dynamodb.DynamoDBFeatureStore. If the
+// store of a StatusHandler implements it, the status lists the items in the
+// quarantine table, without their payloads.
+type QuarantineSource interface {
+	QuarantineSummary() ([]dynamodb.QuarantinedItem, error)
+}
+
+// StatusHandler is an HTTP handler that reports the health of the store,
+// the time of the last sync, the sync lock of an Init in progress, and
+// quarantined items.
+type StatusHandler struct {
+	// Store to report on
+	Store StatusSource
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// NewStatusHandler creates a StatusHandler for the given store.
+func NewStatusHandler(store StatusSource, logger ld.Logger) *StatusHandler {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly StatusHandler]", log.LstdFlags)
+	}
+	return &StatusHandler{Store: store, Logger: logger}
+}
+
+type statusResponse struct {
+	Available  bool               `json:"available"`
+	Since      *time.Time         `json:"since,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	LastSynced *time.Time         `json:"lastSynced,omitempty"`
+	Lock       *dynamodb.SyncLock `json:"lock,omitempty"`
+	LockState  string             `json:"lockState,omitempty"`
+
+	// Quarantined items without their payload, which may be large
+	Quarantined []dynamodb.QuarantinedItem `json:"quarantined,omitempty"`
+}
+
+// ServeHTTP reports the status as JSON. It responds with 503 Service
+// Unavailable if the store can't access DynamoDB.
+func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var resp statusResponse
+	if synced, err := h.Store.LastSynced(); err != nil {
+		h.Logger.Printf("WARN: Failed to get last sync time: %s", err)
+	} else if !synced.IsZero() {
+		resp.LastSynced = &synced
+	}
+	if lock, err := h.Store.SyncLock(r.Context()); err != nil {
+		h.Logger.Printf("WARN: Failed to get sync lock: %s", err)
+	} else if lock != nil {
+		resp.Lock = lock
+		resp.LockState = "held"
+		if lock.Expired(time.Now()) {
+			resp.LockState = "expired"
+		}
+	}
+
+	if q, ok := h.Store.(QuarantineSource); ok {
+		if items, err := q.QuarantineSummary(); err != nil {
+			h.Logger.Printf("WARN: Failed to get quarantined items: %s", err)
+		} else {
+			resp.Quarantined = items
+		}
+	}
+
+	// Read the status last so that it reflects the requests above
+	status := h.Store.Status()
+	resp.Available = status.Available
+	if !status.Since.IsZero() {
+		resp.Since = &status.Since
+	}
+	if status.Err != nil {
+		resp.Error = status.Err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !resp.Available {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.Logger.Printf("ERROR: Failed to encode status: %s", err)
+	}
+}
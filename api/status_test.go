@@ -0,0 +1,83 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+type fakeStatusSource struct {
+	status dynamodb.DataStoreStatus
+	lock   *dynamodb.SyncLock
+}
+
+func (s *fakeStatusSource) Status() dynamodb.DataStoreStatus { return s.status }
+func (s *fakeStatusSource) LastSynced() (time.Time, error)   { return time.Unix(1000, 0), nil }
+func (s *fakeStatusSource) SyncLock(ctx context.Context) (*dynamodb.SyncLock, error) {
+	return s.lock, nil
+}
+
+func TestStatusHandler(t *testing.T) {
+	source := &fakeStatusSource{
+		status: dynamodb.DataStoreStatus{Available: true},
+		lock:   &dynamodb.SyncLock{Holder: "lambda", ExpiresAt: time.Now().Add(-time.Minute)},
+	}
+	h := api.NewStatusHandler(source, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d", w.Code)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["lockState"] != "expired" || body["lock"].(map[string]interface{})["holder"] != "lambda" {
+		t.Errorf("unexpected body: %v", body)
+	}
+
+	source.status = dynamodb.DataStoreStatus{Available: false, Err: errors.New("throttled")}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want 503", w.Code)
+	}
+}
+
+type fakeQuarantineSource struct {
+	fakeStatusSource
+}
+
+func (s *fakeQuarantineSource) QuarantineSummary() ([]dynamodb.QuarantinedItem, error) {
+	return []dynamodb.QuarantinedItem{
+		{Namespace: "features", Key: "flag", Version: 2, Reason: "invalid"},
+	}, nil
+}
+
+func TestStatusHandlerQuarantine(t *testing.T) {
+	source := &fakeQuarantineSource{fakeStatusSource{status: dynamodb.DataStoreStatus{Available: true}}}
+	h := api.NewStatusHandler(source, nil)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d", w.Code)
+	}
+	var body struct {
+		Quarantined []map[string]interface{} `json:"quarantined"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Quarantined) != 1 || body.Quarantined[0]["key"] != "flag" || body.Quarantined[0]["reason"] != "invalid" || body.Quarantined[0]["item"] != nil {
+		t.Errorf("got quarantined items %v", body.Quarantined)
+	}
+}
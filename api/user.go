@@ -0,0 +1,67 @@
+/*
+Package api provides HTTP handlers and helpers for serving feature flags from
+a DynamoDB-backed feature store to clients that don't embed a LaunchDarkly
+SDK, e.g. browsers or mobile apps.
+*/
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// DeviceIDHeader is the request header clients can use to provide a stable
+// device ID instead of relying on IP address and User-Agent.
+const DeviceIDHeader = "X-Device-Id"
+
+// AnonymousUserKey derives a stable user key for unauthenticated requests so
+// that percentage rollouts stay sticky for the same client.
+//
+// If the client provides a device ID (via the X-Device-Id header or the
+// deviceId query parameter), the key is derived from that ID alone. Otherwise,
+// the client IP and User-Agent are used. Either way, the input is hashed with
+// the given salt so that keys can't be traced back to IP addresses.
+func AnonymousUserKey(r *http.Request, salt string) string {
+	if id := deviceID(r); id != "" {
+		return hmacSHA256("device:"+id, salt)
+	}
+	return hmacSHA256("client:"+clientIP(r)+"\n"+r.UserAgent(), salt)
+}
+
+// AnonymousUser returns an anonymous LaunchDarkly user whose key is derived
+// with AnonymousUserKey.
+func AnonymousUser(r *http.Request, salt string) ld.User {
+	return ld.NewAnonymousUser(AnonymousUserKey(r, salt))
+}
+
+func deviceID(r *http.Request) string {
+	if id := strings.TrimSpace(r.Header.Get(DeviceIDHeader)); id != "" {
+		return id
+	}
+	return strings.TrimSpace(r.URL.Query().Get("deviceId"))
+}
+
+// clientIP returns the IP address of the client, preferring the first entry of
+// X-Forwarded-For as set by API Gateway and CloudFront.
+func clientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		return strings.TrimSpace(strings.Split(xff, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func hmacSHA256(message string, secret string) string {
+	sig := hmac.New(sha256.New, []byte(secret))
+	sig.Write([]byte(message))
+	return hex.EncodeToString(sig.Sum(nil))
+}
@@ -0,0 +1,54 @@
+package api_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api"
+)
+
+func TestAnonymousUserKey(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.1, 10.0.0.1")
+	req.Header.Set("User-Agent", "test-agent")
+
+	key := api.AnonymousUserKey(req, "salt")
+	if key == "" {
+		t.Fatal("expected non-empty key")
+	}
+	if again := api.AnonymousUserKey(req, "salt"); again != key {
+		t.Errorf("key is not stable: %q != %q", again, key)
+	}
+	if other := api.AnonymousUserKey(req, "pepper"); other == key {
+		t.Error("expected different key for different salt")
+	}
+
+	req.Header.Set("User-Agent", "other-agent")
+	if other := api.AnonymousUserKey(req, "salt"); other == key {
+		t.Error("expected different key for different User-Agent")
+	}
+
+	// A device ID takes precedence over IP and User-Agent
+	req.Header.Set(api.DeviceIDHeader, "device-1")
+	withDevice := api.AnonymousUserKey(req, "salt")
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	if again := api.AnonymousUserKey(req, "salt"); again != withDevice {
+		t.Errorf("device key changed with IP: %q != %q", again, withDevice)
+	}
+
+	fromQuery := httptest.NewRequest("GET", "/?deviceId=device-1", nil)
+	if key := api.AnonymousUserKey(fromQuery, "salt"); key != withDevice {
+		t.Errorf("got %q from query parameter, want %q", key, withDevice)
+	}
+}
+
+func TestAnonymousUser(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	user := api.AnonymousUser(req, "salt")
+	if user.Key == nil || *user.Key != api.AnonymousUserKey(req, "salt") {
+		t.Errorf("unexpected user key: %v", user.Key)
+	}
+	if user.Anonymous == nil || !*user.Anonymous {
+		t.Error("expected anonymous user")
+	}
+}
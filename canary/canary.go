@@ -0,0 +1,107 @@
+// Package canary compares flag evaluations of a client reading from DynamoDB
+// with those of a client connected to LaunchDarkly, to build confidence in
+// running clients in LDD mode.
+package canary
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Evaluator evaluates flags, e.g. an *ld.LDClient.
+type Evaluator interface {
+	Evaluate(key string, user ld.User, defaultVal interface{}) (interface{}, *int, error)
+}
+
+// Verify that the LaunchDarkly client satisfies the Evaluator interface
+var _ Evaluator = (*ld.LDClient)(nil)
+
+// Divergence describes a flag that evaluated differently for a user.
+type Divergence struct {
+	Flag      string      `json:"flag"`
+	User      string      `json:"user"`
+	Store     interface{} `json:"store"`
+	Live      interface{} `json:"live"`
+	StoreErr  string      `json:"store_error,omitempty"`
+	LiveErr   string      `json:"live_error,omitempty"`
+	Variation [2]*int     `json:"variation"`
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("flag=%s user=%s store=%v live=%v", d.Flag, d.User, d.Store, d.Live)
+}
+
+// Result is the outcome of a comparison.
+type Result struct {
+	Evaluations int          `json:"evaluations"`
+	Divergences []Divergence `json:"divergences"`
+}
+
+// Compare evaluates each flag for each user with both evaluators and returns
+// all evaluations whose value, variation, or error differ.
+func Compare(store, live Evaluator, flags []string, users []ld.User) *Result {
+	result := &Result{Divergences: []Divergence{}}
+	for _, flag := range flags {
+		for _, user := range users {
+			result.Evaluations++
+
+			storeVal, storeVar, storeErr := store.Evaluate(flag, user, nil)
+			liveVal, liveVar, liveErr := live.Evaluate(flag, user, nil)
+			if reflect.DeepEqual(storeVal, liveVal) && equalVariation(storeVar, liveVar) &&
+				(storeErr == nil) == (liveErr == nil) {
+				continue
+			}
+
+			d := Divergence{
+				Flag:      flag,
+				User:      *user.Key,
+				Store:     storeVal,
+				Live:      liveVal,
+				Variation: [2]*int{storeVar, liveVar},
+			}
+			if storeErr != nil {
+				d.StoreErr = storeErr.Error()
+			}
+			if liveErr != nil {
+				d.LiveErr = liveErr.Error()
+			}
+			result.Divergences = append(result.Divergences, d)
+		}
+	}
+	return result
+}
+
+func equalVariation(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// SyntheticUsers returns n users with deterministic keys, spread over rollout
+// buckets by their key.
+func SyntheticUsers(n int) []ld.User {
+	users := make([]ld.User, n)
+	for i := range users {
+		users[i] = ld.NewUser(fmt.Sprintf("canary-user-%d", i))
+	}
+	return users
+}
+
+// SampleFlags returns up to n randomly chosen flag keys in sorted order, or
+// all keys if n is not positive.
+func SampleFlags(keys []string, n int, rnd *rand.Rand) []string {
+	sample := append([]string(nil), keys...)
+	if n > 0 && n < len(sample) {
+		rnd.Shuffle(len(sample), func(i, j int) {
+			sample[i], sample[j] = sample[j], sample[i]
+		})
+		sample = sample[:n]
+	}
+	sort.Strings(sample)
+	return sample
+}
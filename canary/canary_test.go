@@ -0,0 +1,58 @@
+package canary_test
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/canary"
+)
+
+type fakeEvaluator map[string]interface{}
+
+func (f fakeEvaluator) Evaluate(key string, user ld.User, defaultVal interface{}) (interface{}, *int, error) {
+	val, ok := f[key]
+	if !ok {
+		return defaultVal, nil, errors.New("unknown flag")
+	}
+	variation := 0
+	if val == true {
+		variation = 1
+	}
+	return val, &variation, nil
+}
+
+func TestCompare(t *testing.T) {
+	store := fakeEvaluator{"same": true, "changed": false}
+	live := fakeEvaluator{"same": true, "changed": true, "new": "x"}
+
+	result := canary.Compare(store, live, []string{"same", "changed", "new"}, canary.SyntheticUsers(2))
+	if result.Evaluations != 6 {
+		t.Errorf("got %d evaluations, want 6", result.Evaluations)
+	}
+
+	var flags []string
+	for _, d := range result.Divergences {
+		flags = append(flags, d.Flag)
+	}
+	if want := []string{"changed", "changed", "new", "new"}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("got divergent flags %v, want %v", flags, want)
+	}
+	if d := result.Divergences[2]; d.StoreErr == "" || d.LiveErr != "" {
+		t.Errorf("got errors %q/%q, want store error only", d.StoreErr, d.LiveErr)
+	}
+}
+
+func TestSampleFlags(t *testing.T) {
+	keys := []string{"d", "c", "b", "a"}
+
+	if got := canary.SampleFlags(keys, 0, rand.New(rand.NewSource(1))); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("got %v, want all keys", got)
+	}
+	if got := canary.SampleFlags(keys, 2, rand.New(rand.NewSource(1))); len(got) != 2 {
+		t.Errorf("got %v, want 2 keys", got)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Package canonical provides a canonical JSON form of flags, segments, and other
+data, so that the output of exports and reports is the same for the same
+content, no matter which store it was read from:
+
+	data, err := filestore.Export(store)
+	...
+	err = canonical.Write(os.Stdout, data)
+
+Object keys are sorted, and null values and empty lists and objects are
+omitted, as DynamoDB can't distinguish them from missing ones.
+*/
+package canonical
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// JSON returns the canonical JSON encoding of v without whitespace. Numbers
+// are normalized to their shortest form, e.g. 1.50 becomes 1.5.
+func JSON(v interface{}) ([]byte, error) {
+	return encode(v, "")
+}
+
+// Write writes the canonical JSON encoding of v indented by two spaces and
+// followed by a newline, which makes it suitable for files under version
+// control.
+func Write(w io.Writer, v interface{}) error {
+	data, err := encode(v, "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func encode(v interface{}, indent string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	// Maps are marshaled with sorted keys
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	if err := enc.Encode(dropEmpty(generic)); err != nil {
+		return nil, err
+	}
+	if indent == "" {
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	}
+	return buf.Bytes(), nil
+}
+
+func dropEmpty(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if e = dropEmpty(e); isEmpty(e) {
+				delete(v, k)
+			} else {
+				v[k] = e
+			}
+		}
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = dropEmpty(e)
+		}
+		return v
+	}
+	return v
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// Keys returns the keys of items in sorted order, the order in which
+// commands and reports should list them.
+func Keys(items map[string]ld.VersionedData) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
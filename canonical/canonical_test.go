@@ -0,0 +1,76 @@
+package canonical_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/canonical"
+)
+
+func TestJSON(t *testing.T) {
+	for _, tc := range []struct {
+		in   interface{}
+		want string
+	}{
+		{json.RawMessage(`{"b": 1, "a": {"d": [], "c": "<x>"}}`), `{"a":{"c":"<x>"},"b":1}`},
+		{json.RawMessage(`{"a": null, "b": {}, "c": [{}, 1.50]}`), `{"c":[{},1.5]}`},
+		{&ld.Segment{Key: "segment", Version: 2, Salt: "x"}, `{"deleted":false,"key":"segment","salt":"x","version":2}`},
+	} {
+		got, err := canonical.JSON(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("got %s, want %s", got, tc.want)
+		}
+	}
+}
+
+func TestJSONIsIndependentOfSource(t *testing.T) {
+	flag := &ld.FeatureFlag{Key: "flag", Version: 1, Variations: []interface{}{true, false}}
+	// As stored by a source that keeps empty lists and nulls
+	var fields map[string]interface{}
+	data, _ := json.Marshal(flag)
+	json.Unmarshal(data, &fields)
+	fields["targets"] = []interface{}{}
+	fields["rules"] = nil
+	raw, _ := json.Marshal(fields)
+
+	a, err := canonical.JSON(flag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := canonical.JSON(json.RawMessage(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("got %s and %s, want the same", a, b)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := canonical.Write(&buf, map[string]interface{}{"b": 1, "a": []int{1}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": [\n    1\n  ],\n  \"b\": 1\n}\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := canonical.Keys(map[string]ld.VersionedData{
+		"b": &ld.FeatureFlag{Key: "b"},
+		"a": &ld.FeatureFlag{Key: "a"},
+		"c": &ld.FeatureFlag{Key: "c"},
+	})
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/canary"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/canonical"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["canary"] = command{
+		usage: "Compare flag evaluations from the table with LaunchDarkly",
+		run:   runCanary,
+	}
+}
+
+func runCanary(args []string) error {
+	fs, table := newFlagSet("canary")
+	sdkKey := fs.String("sdk-key", os.Getenv("LAUNCHDARKLY_SDK_KEY"), "LaunchDarkly SDK key")
+	numFlags := fs.Int("flags", 0, "number of flags to sample (0 for all)")
+	numUsers := fs.Int("users", 100, "number of synthetic users")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if *sdkKey == "" {
+		return errors.New("-sdk-key is required")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	// Neither client sends analytics events for the synthetic users
+	config := ld.DefaultConfig
+	config.SendEvents = false
+
+	storeConfig := config
+	storeConfig.FeatureStore = store
+	storeConfig.UseLdd = true
+	storeClient, err := ld.MakeCustomClient(*sdkKey, storeConfig, 0)
+	if err != nil {
+		return err
+	}
+	defer storeClient.Close()
+
+	liveClient, err := ld.MakeCustomClient(*sdkKey, config, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to LaunchDarkly: %s", err)
+	}
+	defer liveClient.Close()
+
+	items, err := store.All(ld.Features)
+	if err != nil {
+		return err
+	}
+	flags := canary.SampleFlags(canonical.Keys(items), *numFlags, rand.New(rand.NewSource(time.Now().UnixNano())))
+	result := canary.Compare(storeClient, liveClient, flags, canary.SyntheticUsers(*numUsers))
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range result.Divergences {
+			fmt.Println(d)
+		}
+		fmt.Printf("%d of %d evaluation(s) diverged\n", len(result.Divergences), result.Evaluations)
+	}
+
+	if n := len(result.Divergences); n > 0 {
+		return fmt.Errorf("%d divergence(s) found", n)
+	}
+	return nil
+}
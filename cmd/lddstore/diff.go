@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/diff"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["diff"] = command{
+		usage: "Compare the flags and segments of two tables",
+		run:   runDiff,
+	}
+}
+
+func runDiff(args []string) error {
+	fs, _ := newFlagSet("diff")
+	versions := fs.Bool("versions", false, "also report items whose versions differ")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: lddstore diff [flags] table-a table-b")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("exactly two tables are required")
+	}
+
+	a, err := dynamodb.NewDynamoDBFeatureStore(fs.Arg(0), nil)
+	if err != nil {
+		return err
+	}
+	b, err := dynamodb.NewDynamoDBFeatureStore(fs.Arg(1), nil)
+	if err != nil {
+		return err
+	}
+
+	report, err := diff.Stores(a, b, *versions)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("A: %s\nB: %s\n", fs.Arg(0), fs.Arg(1))
+		for _, d := range report.Differences {
+			fmt.Println(d)
+		}
+	}
+
+	if n := len(report.Differences); n > 0 {
+		return fmt.Errorf("%d difference(s) found", n)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["dump"] = command{
+		usage: "Print all items of the table as JSON, including deleted ones",
+		run:   runDump,
+	}
+}
+
+func runDump(args []string) error {
+	fs, table := newFlagSet("dump")
+	kindName := fs.String("kind", "features", "data kind to dump (features or segments)")
+	raw := fs.Bool("raw", false, "print DynamoDB attribute maps instead of items")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	kinds, err := dynamodb.ParseKinds(*kindName)
+	if err != nil {
+		return err
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	var items interface{}
+	if *raw {
+		items, err = store.AllRaw(kinds[0])
+	} else {
+		var all map[string]ld.VersionedData
+		all, err = store.AllIncludingDeleted(kinds[0])
+		items = all
+	}
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
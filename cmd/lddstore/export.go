@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/canonical"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/filestore"
+)
+
+func init() {
+	commands["export"] = command{
+		usage: "Export flags and segments to a file for LaunchDarkly's file data source",
+		run:   runExport,
+	}
+}
+
+func runExport(args []string) error {
+	fs, table := newFlagSet("export")
+	output := fs.String("o", "", "file to write to (default: stdout)")
+	canon := fs.Bool("canonical", false, "write canonical JSON, which omits empty values, for stable diffs between runs and environments")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	data, err := filestore.Export(store)
+	if err != nil {
+		return err
+	}
+
+	write := data.Write
+	if *canon {
+		write = func(w io.Writer) error { return canonical.Write(w, data) }
+	}
+
+	if *output == "" {
+		return write(os.Stdout)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
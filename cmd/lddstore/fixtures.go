@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/fixtures"
+)
+
+func init() {
+	commands["fixtures"] = command{
+		usage: "Generate flags and segments for load tests, to be imported",
+		run:   runFixtures,
+	}
+}
+
+func runFixtures(args []string) error {
+	fs := flag.NewFlagSet("lddstore fixtures", flag.ExitOnError)
+	var opts fixtures.Options
+	fs.Int64Var(&opts.Seed, "seed", 1, "seed of the random generator; the same seed yields the same data")
+	fs.IntVar(&opts.Flags, "flags", 100, "number of flags")
+	fs.IntVar(&opts.Segments, "segments", 10, "number of segments")
+	fs.IntVar(&opts.MaxRules, "max-rules", 3, "maximum number of rules per flag and segment")
+	fs.IntVar(&opts.BigSegments, "big-segments", 1, "number of big segments")
+	fs.IntVar(&opts.BigSegmentSize, "big-segment-size", 10000, "number of users per big segment")
+	output := fs.String("o", "", "file to write to (default: stdout)")
+	fs.Parse(args)
+
+	data := fixtures.Generate(opts).FileData()
+	if *output == "" {
+		return data.Write(os.Stdout)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := data.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
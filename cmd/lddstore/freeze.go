@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["freeze"] = command{
+		usage: "Keep syncs from changing items, e.g. during an incident",
+		run:   runFreeze,
+	}
+}
+
+func runFreeze(args []string) error {
+	fs, table := newFlagSet("freeze")
+	kindName := fs.String("kind", "features", "data kind of the items (features or segments)")
+	unfreeze := fs.Bool("unfreeze", false, "remove the items from the freeze list")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: lddstore freeze [flags] [key...]")
+		fmt.Fprintln(fs.Output(), "Lists the frozen items if no keys are given.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	kinds, err := dynamodb.ParseKinds(*kindName)
+	if err != nil {
+		return err
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+	store.CheckFrozen = true
+	ctx := context.Background()
+
+	switch {
+	case fs.NArg() == 0:
+		frozen, err := store.FrozenKeys(ctx)
+		if err != nil {
+			return err
+		}
+		var namespaces []string
+		for ns := range frozen {
+			namespaces = append(namespaces, ns)
+		}
+		sort.Strings(namespaces)
+		for _, ns := range namespaces {
+			for _, key := range frozen[ns] {
+				fmt.Printf("%s/%s\n", ns, key)
+			}
+		}
+		return nil
+	case *unfreeze:
+		return store.Unfreeze(ctx, kinds[0], fs.Args()...)
+	default:
+		return store.Freeze(ctx, kinds[0], fs.Args()...)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/filestore"
+)
+
+func init() {
+	commands["import"] = command{
+		usage: "Replace the table's data with flags and segments from files",
+		run:   runImport,
+	}
+}
+
+func runImport(args []string) error {
+	fs, table := newFlagSet("import")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: lddstore import [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if fs.NArg() == 0 {
+		return errors.New("at least one file is required")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	return filestore.Import(store, fs.Args()...)
+}
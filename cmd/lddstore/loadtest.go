@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/canonical"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["loadtest"] = command{
+		usage: "Simulate concurrent consumers reading the table and report latencies",
+		run:   runLoadtest,
+	}
+}
+
+// runLoadtest lets several consumers share one store, like the goroutines of
+// a busy service, each calling Get or All at a fixed rate. Consumed capacity
+// is read from the responses, which is why the requests ask for it.
+func runLoadtest(args []string) error {
+	fs, table := newFlagSet("loadtest")
+	endpoint := fs.String("endpoint", "", "DynamoDB endpoint, e.g. http://localhost:8000 for DynamoDB Local")
+	consumers := fs.Int("consumers", 10, "number of concurrent consumers")
+	rate := fs.Float64("rate", 10, "operations per second per consumer")
+	duration := fs.Duration("duration", 30*time.Second, "duration of the test")
+	allPercent := fs.Int("all", 10, "percentage of operations that read all flags instead of one")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if *consumers <= 0 || *rate <= 0 {
+		return errors.New("-consumers and -rate must be positive")
+	}
+
+	cfg := aws.NewConfig()
+	if *endpoint != "" {
+		cfg.Endpoint = endpoint
+	}
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return err
+	}
+	client := dynamodb.NewClient(sess)
+	capacity := &capacityCounter{}
+	client.Handlers.Build.PushFront(capacity.request)
+	client.Handlers.Complete.PushBack(capacity.record)
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+	store.Client = client
+
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		return err
+	}
+	if len(flags) == 0 {
+		return fmt.Errorf("no flags in %s, import some first, e.g. with \"lddstore fixtures\"", *table)
+	}
+	keys := canonical.Keys(flags)
+	capacity.reset()
+
+	fmt.Printf("Running %d consumers at %.1f ops/s each for %s against %s (%d flags)\n",
+		*consumers, *rate, *duration, *table, len(keys))
+
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+		case <-time.After(*duration):
+		}
+		close(stop)
+	}()
+
+	latencies := &latencyRecorder{byOp: map[string][]time.Duration{}}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < *consumers; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+				op, t0 := "Get", time.Now()
+				var err error
+				if rnd.Intn(100) < *allPercent {
+					op = "All"
+					_, err = store.All(ld.Features)
+				} else {
+					_, err = store.Get(ld.Features, keys[rnd.Intn(len(keys))])
+				}
+				latencies.record(op, time.Since(t0), err)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	fmt.Printf("\n%-4s %8s %8s %10s %10s %10s %10s\n", "op", "count", "errors", "p50", "p90", "p99", "max")
+	for _, op := range []string{"Get", "All"} {
+		d := latencies.byOp[op]
+		if len(d) == 0 {
+			continue
+		}
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+		fmt.Printf("%-4s %8d %8d %10s %10s %10s %10s\n", op, len(d), latencies.errors[op],
+			percentile(d, 0.5), percentile(d, 0.9), percentile(d, 0.99), d[len(d)-1])
+	}
+
+	stats := store.Stats()
+	units := capacity.total()
+	fmt.Printf("\nConsumed %.1f read capacity units (%.1f per second) in %s\n", units, units/elapsed.Seconds(), elapsed.Round(time.Millisecond))
+	fmt.Printf("Requests: %d gets, %d queries, %d throttled\n", stats.Gets, stats.Queries, stats.Throttles)
+	if stats.LastError != nil {
+		fmt.Printf("Last error: %s\n", stats.LastError)
+	}
+	return nil
+}
+
+// latencyRecorder collects the latencies of operations by type.
+type latencyRecorder struct {
+	mu     sync.Mutex
+	byOp   map[string][]time.Duration
+	errors map[string]int
+}
+
+func (r *latencyRecorder) record(op string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byOp[op] = append(r.byOp[op], d)
+	if err != nil {
+		if r.errors == nil {
+			r.errors = map[string]int{}
+		}
+		r.errors[op]++
+	}
+}
+
+// percentile returns the q-th percentile of sorted durations.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	return sorted[int(float64(len(sorted)-1)*q)]
+}
+
+// capacityCounter sums the capacity consumed by reads.
+type capacityCounter struct {
+	mu    sync.Mutex
+	units float64
+}
+
+// request asks DynamoDB to return the consumed capacity. It must run before
+// the request is serialized.
+func (c *capacityCounter) request(r *request.Request) {
+	switch in := r.Params.(type) {
+	case *ddb.GetItemInput:
+		in.ReturnConsumedCapacity = aws.String(ddb.ReturnConsumedCapacityTotal)
+	case *ddb.QueryInput:
+		in.ReturnConsumedCapacity = aws.String(ddb.ReturnConsumedCapacityTotal)
+	}
+}
+
+func (c *capacityCounter) record(r *request.Request) {
+	var consumed *ddb.ConsumedCapacity
+	switch out := r.Data.(type) {
+	case *ddb.GetItemOutput:
+		consumed = out.ConsumedCapacity
+	case *ddb.QueryOutput:
+		consumed = out.ConsumedCapacity
+	}
+	if consumed == nil {
+		return
+	}
+	c.mu.Lock()
+	c.units += aws.Float64Value(consumed.CapacityUnits)
+	c.mu.Unlock()
+}
+
+func (c *capacityCounter) total() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.units
+}
+
+func (c *capacityCounter) reset() {
+	c.mu.Lock()
+	c.units = 0
+	c.mu.Unlock()
+}
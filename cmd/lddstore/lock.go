@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["lock"] = command{
+		usage: "Show the sync lock of the Init in progress, or clear a stale one",
+		run:   runLock,
+	}
+}
+
+func runLock(args []string) error {
+	fs, table := newFlagSet("lock")
+	forceUnlock := fs.Bool("force-unlock", false, "clear the lock, e.g. after a crashed sync")
+	asJSON := fs.Bool("json", false, "print the lock as JSON")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	lock, err := store.SyncLock(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *forceUnlock {
+		if lock == nil {
+			fmt.Println("Not locked")
+			return nil
+		}
+		if err := store.ForceUnlock(ctx); err != nil {
+			return err
+		}
+		fmt.Printf("Cleared lock held by %s since %s\n", lock.Holder, lock.AcquiredAt.Format(time.RFC3339))
+		return nil
+	}
+
+	if *asJSON {
+		return json.NewEncoder(os.Stdout).Encode(lock)
+	}
+	if lock == nil {
+		fmt.Println("Not locked")
+		return nil
+	}
+	state := "held"
+	if lock.Expired(time.Now()) {
+		state = "expired"
+	}
+	fmt.Printf("holder:     %s\n", lock.Holder)
+	fmt.Printf("generation: %d\n", lock.Generation)
+	fmt.Printf("acquired:   %s\n", lock.AcquiredAt.Format(time.RFC3339))
+	fmt.Printf("expires:    %s (%s)\n", lock.ExpiresAt.Format(time.RFC3339), state)
+	return nil
+}
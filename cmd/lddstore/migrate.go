@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["migrate"] = command{
+		usage: "Escape item fields named like attributes used by the store",
+		run:   runMigrate,
+	}
+}
+
+func runMigrate(args []string) error {
+	fs, table := newFlagSet("migrate")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	migrated, err := store.MigrateReservedAttributes(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Migrated %d items\n", migrated)
+	return nil
+}
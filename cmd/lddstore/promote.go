@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/diff"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["promote"] = command{
+		usage: "Copy all flags and segments from one environment's table to another's",
+		run:   runPromote,
+	}
+}
+
+// runPromote replaces the data of the target environment's table with that
+// of the source environment's table (see DynamoDBFeatureStore.Promote).
+func runPromote(args []string) error {
+	fs, _ := newFlagSet("promote")
+	prefix := fs.String("prefix", tablePrefix(), "prefix of table names, followed by the environment")
+	yes := fs.Bool("yes", false, "confirm that the target table may be overwritten")
+	asJSON := fs.Bool("json", false, "print the diff report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: lddstore promote [flags] source-env target-env")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Items are copied in transactions of up to 10 items. If the target")
+		fmt.Fprintln(fs.Output(), "changes during the promotion, it stops after the last complete")
+		fmt.Fprintln(fs.Output(), "transaction and can be run again.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("exactly two environments are required")
+	}
+	sourceTable, targetTable := *prefix+fs.Arg(0), *prefix+fs.Arg(1)
+
+	source, err := dynamodb.NewDynamoDBFeatureStore(sourceTable, nil)
+	if err != nil {
+		return err
+	}
+	target, err := dynamodb.NewDynamoDBFeatureStore(targetTable, nil)
+	if err != nil {
+		return err
+	}
+
+	// Show what the promotion changes, from the target's point of view
+	report, err := diff.Stores(target, source, false)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("A: %s (target)\nB: %s (source)\n", targetTable, sourceTable)
+		for _, d := range report.Differences {
+			fmt.Println(d)
+		}
+		fmt.Printf("%d difference(s) found\n", len(report.Differences))
+	}
+
+	if len(report.Differences) == 0 {
+		return nil
+	}
+	if !*yes {
+		return errors.New("refusing to overwrite target table without -yes")
+	}
+
+	r, err := target.Promote(source)
+	for _, kind := range ld.VersionedDataKinds {
+		fmt.Fprintf(os.Stderr, "Promoted %d %s, deleted %d\n", r.Written[kind], kind.GetNamespace(), r.Deleted[kind])
+	}
+	return err
+}
+
+// tablePrefix returns the prefix of table names configured for the
+// serverless deployment, or the default one.
+func tablePrefix() string {
+	if prefix := os.Getenv("LAUNCHDARKLY_DYNAMODB_TABLE_PREFIX"); prefix != "" {
+		return prefix
+	}
+	return "launchdarkly-"
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["prune"] = command{
+		usage: "Mark items as deleted, e.g. flags removed from LaunchDarkly",
+		run:   runPrune,
+	}
+}
+
+func runPrune(args []string) error {
+	fs, table := newFlagSet("prune")
+	kindName := fs.String("kind", "features", "data kind of the items (features or segments)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: lddstore prune [flags] key...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("at least one key is required")
+	}
+	kinds, err := dynamodb.ParseKinds(*kindName)
+	if err != nil {
+		return err
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	items, err := store.AllIncludingDeleted(kinds[0])
+	if err != nil {
+		return err
+	}
+
+	// Tombstones must have a higher version than the items they replace
+	keys := make(map[string]int, fs.NArg())
+	for _, key := range fs.Args() {
+		item, ok := items[key]
+		if !ok {
+			return fmt.Errorf("%s %q not found", kinds[0].GetNamespace(), key)
+		}
+		if item.IsDeleted() {
+			continue
+		}
+		keys[key] = item.GetVersion() + 1
+	}
+
+	return store.DeleteAll(kinds[0], keys)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["quarantine"] = command{
+		usage: "List or remove items rejected by syncs",
+		run:   runQuarantine,
+	}
+}
+
+func runQuarantine(args []string) error {
+	fs, table := newFlagSet("quarantine")
+	quarantineTable := fs.String("quarantine-table", "", "name of the quarantine table (default: <table>-quarantine)")
+	kindName := fs.String("kind", "features", "data kind of the items to remove (features or segments)")
+	remove := fs.Bool("remove", false, "remove the items with the given keys")
+	asJSON := fs.Bool("json", false, "print the items as JSON, including their payload")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: lddstore quarantine [flags] [key...]")
+		fmt.Fprintln(fs.Output(), "Lists the quarantined items unless -remove is given.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if *quarantineTable == "" {
+		*quarantineTable = *table + "-quarantine"
+	}
+	kinds, err := dynamodb.ParseKinds(*kindName)
+	if err != nil {
+		return err
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+	store.QuarantineTable = *quarantineTable
+
+	if *remove {
+		if fs.NArg() == 0 {
+			fs.Usage()
+			return errors.New("no items to remove")
+		}
+		for _, key := range fs.Args() {
+			if err := store.RemoveQuarantined(kinds[0], key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	items, err := store.Quarantined()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	}
+	for _, item := range items {
+		fmt.Printf("%s/%s version %d, quarantined at %s: %s\n", item.Namespace, item.Key, item.Version,
+			item.QuarantinedAt.Format(time.RFC3339), item.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+)
+
+func init() {
+	commands["repair"] = command{
+		usage: "Overwrite individual items with fresh copies from LaunchDarkly",
+		run:   runRepair,
+	}
+}
+
+func runRepair(args []string) error {
+	fs, table := newFlagSet("repair")
+	sdkKey := fs.String("sdk-key", os.Getenv("LAUNCHDARKLY_SDK_KEY"), "LaunchDarkly SDK key")
+	kindName := fs.String("kind", "features", "data kind of the items (features or segments)")
+	corrupt := fs.Bool("corrupt", false, "repair all items that can't be unmarshaled or whose checksum doesn't match")
+	force := fs.Bool("force", false, "overwrite items even if their stored version is higher or unreadable")
+	raw := fs.Bool("raw", false, "store the JSON received from LaunchDarkly as is")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: lddstore repair [flags] [key...]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if *sdkKey == "" {
+		return errors.New("-sdk-key is required")
+	}
+	kinds, err := dynamodb.ParseKinds(*kindName)
+	if err != nil {
+		return err
+	}
+	kind := kinds[0]
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	keys := fs.Args()
+	if *corrupt {
+		// Also repair items modified outside of the store
+		store.Checksums = dynamodb.ChecksumError
+		items, err := store.CorruptItems(kind)
+		if err != nil {
+			return err
+		}
+		for key := range items {
+			keys = append(keys, key)
+		}
+		// Corrupt items may not have a readable version to compare to
+		*force = true
+	}
+	if len(keys) == 0 {
+		fs.Usage()
+		return errors.New("no items to repair")
+	}
+	sort.Strings(keys)
+
+	syncer := &sync.Syncer{SDKKey: *sdkKey, Raw: *raw}
+	failed := 0
+	for _, key := range keys {
+		item, err := syncer.FetchItem(context.Background(), kind, key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: failed to fetch from LaunchDarkly: %s\n", key, err)
+			failed++
+			continue
+		}
+		written, err := store.Repair(kind, item, *force)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "%s: failed to write: %s\n", key, err)
+			failed++
+		case !written:
+			fmt.Printf("%s: skipped, stored version is newer than %d\n", key, item.GetVersion())
+		default:
+			fmt.Printf("%s: repaired with version %d\n", key, item.GetVersion())
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to repair %d of %d item(s)", failed, len(keys))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// maxRequestSize is the limit of a BatchWriteItem request. Init splits
+// writes into batches, but a kind larger than this is a sign that syncs are
+// getting expensive.
+const maxRequestSize = 16 * 1024 * 1024
+
+func init() {
+	commands["sizes"] = command{
+		usage: "Report the size of all items and warn about items near DynamoDB limits",
+		run:   runSizes,
+	}
+}
+
+type itemSize struct {
+	Kind    string  `json:"kind"`
+	Key     string  `json:"key"`
+	Size    int     `json:"size"`
+	Percent float64 `json:"percent"`
+	Warning bool    `json:"warning,omitempty"`
+}
+
+func runSizes(args []string) error {
+	fs, table := newFlagSet("sizes")
+	warn := fs.Float64("warn", 80, "warn about items using more than this percentage of the 400 KB item limit")
+	asJSON := fs.Bool("json", false, "print sizes as JSON")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	var sizes []itemSize
+	totals := make(map[string]int)
+	warnings := 0
+	for _, kind := range ld.VersionedDataKinds {
+		items, err := store.AllRaw(kind)
+		if err != nil {
+			return err
+		}
+		for key, item := range items {
+			s := itemSize{Kind: kind.GetNamespace(), Key: key, Size: dynamodb.ItemSize(item)}
+			s.Percent = 100 * float64(s.Size) / dynamodb.MaxItemSize
+			if s.Percent >= *warn {
+				s.Warning = true
+				warnings++
+			}
+			sizes = append(sizes, s)
+			totals[s.Kind] += s.Size
+		}
+	}
+
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Size != sizes[j].Size {
+			return sizes[i].Size > sizes[j].Size
+		}
+		return sizes[i].Kind+"/"+sizes[i].Key < sizes[j].Kind+"/"+sizes[j].Key
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(sizes); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, s := range sizes {
+			mark := ""
+			if s.Warning {
+				mark = "WARNING: near item size limit"
+			}
+			fmt.Fprintf(w, "%s/%s\t%d\t%.1f%%\t%s\n", s.Kind, s.Key, s.Size, s.Percent, mark)
+		}
+		w.Flush()
+
+		for _, kind := range ld.VersionedDataKinds {
+			ns := kind.GetNamespace()
+			fmt.Printf("Total %s: %d bytes (%.1f%% of a 16 MB batch request)\n",
+				ns, totals[ns], 100*float64(totals[ns])/maxRequestSize)
+		}
+	}
+
+	if warnings > 0 {
+		return fmt.Errorf("%d item(s) near the item size limit", warnings)
+	}
+	return nil
+}
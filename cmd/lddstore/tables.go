@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["tables"] = command{
+		usage: "List and validate all tables with a common name prefix",
+		run:   runTables,
+	}
+}
+
+func runTables(args []string) error {
+	fs, _ := newFlagSet("tables")
+	prefix := fs.String("prefix", "launchdarkly-", "prefix of table names")
+	fs.Parse(args)
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return err
+	}
+
+	tables, err := dynamodb.DiscoverTables(dynamodb.NewClient(sess), *prefix)
+	for _, table := range tables {
+		fmt.Println(table)
+	}
+	return err
+}
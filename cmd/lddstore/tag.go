@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["tag"] = command{
+		usage: "Apply resource tags to the table, or check that they exist",
+		run:   runTag,
+	}
+}
+
+// tagFlags collects repeated -tag key=value flags.
+type tagFlags map[string]string
+
+func (t tagFlags) String() string {
+	var pairs []string
+	for k, v := range t {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (t tagFlags) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("invalid tag %q, want key=value", s)
+	}
+	t[parts[0]] = parts[1]
+	return nil
+}
+
+func runTag(args []string) error {
+	fs, table := newFlagSet("tag")
+	tags := tagFlags{}
+	fs.Var(tags, "tag", "tag as key=value (repeatable)")
+	check := fs.Bool("check", false, "only check that the tags exist with the given values")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if len(tags) == 0 {
+		return errors.New("at least one -tag is required")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+	store.Tags = tags
+
+	if *check {
+		return store.ValidateTags(context.Background())
+	}
+	return store.TagTable(context.Background())
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["truncate"] = command{
+		usage: "Delete all data by recreating the table",
+		run:   runTruncate,
+	}
+}
+
+func runTruncate(args []string) error {
+	fs, table := newFlagSet("truncate")
+	yes := fs.Bool("yes", false, "confirm that the table may be deleted and recreated")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if !*yes {
+		return errors.New("refusing to recreate table without -yes")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+	return store.RecreateTable(context.Background())
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/flagusage"
+)
+
+func init() {
+	commands["usage"] = command{
+		usage: "Report stale and orphaned flags based on the flags used by services",
+		run:   runUsage,
+	}
+}
+
+func runUsage(args []string) error {
+	fs, table := newFlagSet("usage")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: lddstore usage [flags] references.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("exactly one references file is required")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	refs, err := flagusage.LoadReferences(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %s", fs.Arg(0), err)
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	report, err := flagusage.Compare(store, refs)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	for _, key := range report.Stale {
+		fmt.Printf("stale: %s (not used by any service)\n", key)
+	}
+	for _, o := range report.Orphaned {
+		if len(o.Services) == 0 {
+			fmt.Printf("orphaned: %s (used but not in %s)\n", o.Key, *table)
+			continue
+		}
+		fmt.Printf("orphaned: %s (used by %s but not in %s)\n", o.Key, strings.Join(o.Services, ", "), *table)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func init() {
+	commands["watch"] = command{
+		usage: "Print flag and segment changes as they reach the table",
+		run:   runWatch,
+	}
+}
+
+// runWatch polls the table for changes. The vendored AWS SDK doesn't include
+// a DynamoDB Streams client, so it checks the sync metadata item at each
+// interval and only reads all items when that has changed.
+func runWatch(args []string) error {
+	fs, table := newFlagSet("watch")
+	interval := fs.Duration("interval", 2*time.Second, "polling interval")
+	fs.Parse(args)
+
+	if *table == "" {
+		return errors.New("-table is required")
+	}
+
+	store, err := dynamodb.NewDynamoDBFeatureStore(*table, nil)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := watchSnapshot(store)
+	if err != nil {
+		return err
+	}
+	lastSynced, err := store.LastSynced()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Watching %s (last synced %s), press Ctrl-C to stop\n", *table, formatTime(lastSynced))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+
+		synced, err := store.LastSynced()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s failed to check for changes: %s\n", time.Now().Format(time.RFC3339), err)
+			continue
+		}
+		if synced.Equal(lastSynced) {
+			continue
+		}
+		lastSynced = synced
+
+		current, err := watchSnapshot(store)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s failed to read changes: %s\n", time.Now().Format(time.RFC3339), err)
+			continue
+		}
+		for _, change := range watchChanges(snapshot, current) {
+			fmt.Printf("%s %s\n", formatTime(synced), change)
+		}
+		snapshot = current
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// watchSnapshot returns all items of the table, including tombstones, keyed
+// by namespace and key.
+func watchSnapshot(store *dynamodb.DynamoDBFeatureStore) (map[string]map[string]ld.VersionedData, error) {
+	snapshot := make(map[string]map[string]ld.VersionedData)
+	for _, kind := range ld.VersionedDataKinds {
+		items, err := store.AllIncludingDeleted(kind)
+		if err != nil {
+			return nil, err
+		}
+		snapshot[kind.GetNamespace()] = items
+	}
+	return snapshot, nil
+}
+
+// watchChanges describes the differences between two snapshots in a
+// human-readable way, sorted by namespace and key.
+func watchChanges(before, after map[string]map[string]ld.VersionedData) []string {
+	var changes []string
+	for ns, items := range after {
+		for key, item := range items {
+			old, existed := before[ns][key]
+			switch {
+			case !existed || old.IsDeleted() && !item.IsDeleted():
+				changes = append(changes, fmt.Sprintf("%s %s: created (version %d)%s", ns, key, item.GetVersion(), flagState(nil, item)))
+			case item.IsDeleted() && !old.IsDeleted():
+				changes = append(changes, fmt.Sprintf("%s %s: deleted (version %d)", ns, key, item.GetVersion()))
+			case item.GetVersion() != old.GetVersion():
+				changes = append(changes, fmt.Sprintf("%s %s: updated (version %d -> %d)%s", ns, key, old.GetVersion(), item.GetVersion(), flagState(old, item)))
+			}
+		}
+		for key := range before[ns] {
+			if _, ok := items[key]; !ok {
+				changes = append(changes, fmt.Sprintf("%s %s: removed from table", ns, key))
+			}
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// flagState describes whether a flag was turned on or off.
+func flagState(old, item ld.VersionedData) string {
+	flag, ok := item.(*ld.FeatureFlag)
+	if !ok {
+		return ""
+	}
+	if oldFlag, ok := old.(*ld.FeatureFlag); ok && oldFlag.On == flag.On {
+		return ""
+	}
+	if flag.On {
+		return ", targeting on"
+	}
+	return ", targeting off"
+}
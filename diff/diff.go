@@ -0,0 +1,149 @@
+/*
+Package diff compares the flags and segments of two feature stores, e.g. the
+DynamoDB tables of two environments, to audit environment parity.
+*/
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/canonical"
+)
+
+// ChangeType describes how an item differs between two stores.
+type ChangeType string
+
+// Types of differences
+const (
+	OnlyInA        ChangeType = "only-in-a"
+	OnlyInB        ChangeType = "only-in-b"
+	ConfigDiffers  ChangeType = "config-differs"
+	VersionDiffers ChangeType = "version-differs"
+)
+
+// Difference describes an item that differs between two stores.
+type Difference struct {
+	Kind     string     `json:"kind"`
+	Key      string     `json:"key"`
+	Type     ChangeType `json:"type"`
+	VersionA int        `json:"versionA,omitempty"`
+	VersionB int        `json:"versionB,omitempty"`
+}
+
+func (d Difference) String() string {
+	switch d.Type {
+	case OnlyInA:
+		return fmt.Sprintf("%s %s: only in A (version %d)", d.Kind, d.Key, d.VersionA)
+	case OnlyInB:
+		return fmt.Sprintf("%s %s: only in B (version %d)", d.Kind, d.Key, d.VersionB)
+	case ConfigDiffers:
+		return fmt.Sprintf("%s %s: configuration differs (version %d vs %d)", d.Kind, d.Key, d.VersionA, d.VersionB)
+	default:
+		return fmt.Sprintf("%s %s: version differs (%d vs %d)", d.Kind, d.Key, d.VersionA, d.VersionB)
+	}
+}
+
+// Report lists all differences between two stores.
+type Report struct {
+	Differences []Difference `json:"differences"`
+}
+
+// Stores compares all flags and segments of two stores. Items whose
+// configuration is equal are reported only if their versions differ and
+// includeVersions is true, as versions are independent between environments.
+func Stores(a, b ld.FeatureStore, includeVersions bool) (*Report, error) {
+	report := &Report{}
+
+	for _, kind := range ld.VersionedDataKinds {
+		itemsA, err := a.All(kind)
+		if err != nil {
+			return nil, err
+		}
+		itemsB, err := b.All(kind)
+		if err != nil {
+			return nil, err
+		}
+		diffs, err := Items(kind, itemsA, itemsB, includeVersions)
+		if err != nil {
+			return nil, err
+		}
+		report.Differences = append(report.Differences, diffs...)
+	}
+
+	// Sorted by kind, then key, so that reports of the same stores are
+	// identical and can be compared between runs
+	sort.SliceStable(report.Differences, func(i, j int) bool {
+		return report.Differences[i].Kind < report.Differences[j].Kind
+	})
+
+	return report, nil
+}
+
+// Items compares two sets of items of the same kind. Differences are sorted by
+// key.
+func Items(kind ld.VersionedDataKind, a, b map[string]ld.VersionedData, includeVersions bool) ([]Difference, error) {
+	var diffs []Difference
+
+	for key, itemA := range a {
+		itemB, ok := b[key]
+		if !ok {
+			diffs = append(diffs, Difference{Kind: kind.GetNamespace(), Key: key, Type: OnlyInA, VersionA: itemA.GetVersion()})
+			continue
+		}
+
+		equal, err := sameConfig(itemA, itemB)
+		if err != nil {
+			return nil, err
+		}
+		d := Difference{Kind: kind.GetNamespace(), Key: key, VersionA: itemA.GetVersion(), VersionB: itemB.GetVersion()}
+		if !equal {
+			d.Type = ConfigDiffers
+			diffs = append(diffs, d)
+		} else if includeVersions && d.VersionA != d.VersionB {
+			d.Type = VersionDiffers
+			diffs = append(diffs, d)
+		}
+	}
+
+	for key, itemB := range b {
+		if _, ok := a[key]; !ok {
+			diffs = append(diffs, Difference{Kind: kind.GetNamespace(), Key: key, Type: OnlyInB, VersionB: itemB.GetVersion()})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+
+	return diffs, nil
+}
+
+// sameConfig compares the canonical JSON of two items ignoring their
+// versions, so that null values and empty lists are considered the same.
+func sameConfig(a, b ld.VersionedData) (bool, error) {
+	ja, err := configJSON(a)
+	if err != nil {
+		return false, err
+	}
+	jb, err := configJSON(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ja, jb), nil
+}
+
+func configJSON(item ld.VersionedData) ([]byte, error) {
+	data, err := canonical.JSON(item)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "version")
+	return json.Marshal(m)
+}
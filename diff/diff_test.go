@@ -0,0 +1,99 @@
+package diff_test
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/diff"
+)
+
+func newStore(t *testing.T, flags ...*ld.FeatureFlag) ld.FeatureStore {
+	store := ld.NewInMemoryFeatureStore(log.New(ioutil.Discard, "", 0))
+	items := make(map[string]ld.VersionedData)
+	for _, f := range flags {
+		items[f.Key] = f
+	}
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: items}); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStores(t *testing.T) {
+	a := newStore(t,
+		&ld.FeatureFlag{Key: "same", Version: 1, On: true},
+		&ld.FeatureFlag{Key: "changed", Version: 2, On: true},
+		&ld.FeatureFlag{Key: "bumped", Version: 3},
+		&ld.FeatureFlag{Key: "only-a", Version: 4},
+	)
+	b := newStore(t,
+		&ld.FeatureFlag{Key: "same", Version: 1, On: true},
+		&ld.FeatureFlag{Key: "changed", Version: 2, On: false},
+		&ld.FeatureFlag{Key: "bumped", Version: 7},
+		&ld.FeatureFlag{Key: "only-b", Version: 5},
+	)
+
+	report, err := diff.Stores(a, b, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []diff.Difference{
+		{Kind: "features", Key: "bumped", Type: diff.VersionDiffers, VersionA: 3, VersionB: 7},
+		{Kind: "features", Key: "changed", Type: diff.ConfigDiffers, VersionA: 2, VersionB: 2},
+		{Kind: "features", Key: "only-a", Type: diff.OnlyInA, VersionA: 4},
+		{Kind: "features", Key: "only-b", Type: diff.OnlyInB, VersionB: 5},
+	}
+	if !reflect.DeepEqual(report.Differences, want) {
+		t.Errorf("got differences\n%v\nwant\n%v", report.Differences, want)
+	}
+
+	report, err = diff.Stores(a, b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Differences) != 3 {
+		t.Errorf("got %d differences without versions, want 3", len(report.Differences))
+	}
+}
+
+func TestStoresIgnoresEmptyValues(t *testing.T) {
+	a := newStore(t, &ld.FeatureFlag{Key: "flag", Version: 1, Targets: []ld.Target{}})
+	b := newStore(t, &ld.FeatureFlag{Key: "flag", Version: 1})
+
+	report, err := diff.Stores(a, b, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Differences) != 0 {
+		t.Errorf("got differences %v, want none", report.Differences)
+	}
+}
+
+func TestStoresOrdersByKindAndKey(t *testing.T) {
+	a := ld.NewInMemoryFeatureStore(log.New(ioutil.Discard, "", 0))
+	a.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"b": &ld.FeatureFlag{Key: "b", Version: 1}, "a": &ld.FeatureFlag{Key: "a", Version: 1}},
+		ld.Segments: {"c": &ld.Segment{Key: "c", Version: 1}, "a": &ld.Segment{Key: "a", Version: 1}},
+	})
+	b := newStore(t)
+
+	for i := 0; i < 5; i++ {
+		report, err := diff.Stores(a, b, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range report.Differences {
+			got = append(got, d.Kind+"/"+d.Key)
+		}
+		want := []string{"features/a", "features/b", "segments/a", "segments/c"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
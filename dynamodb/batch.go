@@ -0,0 +1,198 @@
+package dynamodb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// Limits of BatchWriteItem
+	maxBatchItems = 25
+	maxBatchBytes = 16 * 1024 * 1024
+
+	// Give up if a batch couldn't be written after this many attempts
+	maxBatchAttempts = 10
+
+	batchBackoffBase = 50 * time.Millisecond
+	batchBackoffMax  = 5 * time.Second
+)
+
+// batchWriteRequests executes a list of write requests (PutItem or DeleteItem)
+// in batches.
+//
+// Batches are limited to 25 items, which is the maximum BatchWriteItem can
+// handle, and to an estimated request size of 16 MB. When DynamoDB throttles
+// writes or leaves items unprocessed, the batch size is halved and the
+// remaining items are retried with exponential backoff. After successful
+// writes, the batch size grows again.
+//
+// If WriteRateLimit is set, batches are spaced out to not exceed that rate,
+// which is shared by all concurrent calls.
+//
+// If check is set, it's called before each batch, and its error, if any,
+// stops the remaining batches from being written.
+func (store *DynamoDBFeatureStore) batchWriteRequests(requests []*dynamodb.WriteRequest, check func() error) error {
+	limiter := store.writeLimiter()
+
+	batchSize := maxBatchItems
+	attempts := 0
+
+	for len(requests) > 0 {
+		n := nextBatchSize(requests, batchSize, maxBatchBytes)
+		batch := requests[:n]
+		requests = requests[n:]
+
+		limiter.waitN(n)
+
+		if check != nil {
+			if err := check(); err != nil {
+				return err
+			}
+		}
+
+		var unprocessed []*dynamodb.WriteRequest
+
+		out, err := store.Client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]*dynamodb.WriteRequest{store.Table: batch},
+		})
+		store.observe(writeRequest, err)
+		if err != nil {
+			if !isThrottlingError(err) {
+				return err
+			}
+			unprocessed = batch
+		} else {
+			unprocessed = out.UnprocessedItems[store.Table]
+		}
+
+		if len(unprocessed) == 0 {
+			attempts = 0
+			if batchSize < maxBatchItems {
+				batchSize++
+			}
+			continue
+		}
+
+		attempts++
+		if attempts >= maxBatchAttempts {
+			// DynamoDB leaves items unprocessed when it runs out of
+			// capacity, so the error is reported as throttling either way
+			if err == nil {
+				err = awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException,
+					"items left unprocessed", nil)
+			}
+			return fmt.Errorf("giving up after %d attempts with %d unprocessed item(s): %w",
+				attempts, len(unprocessed)+len(requests), err)
+		}
+
+		if batchSize > 1 {
+			batchSize /= 2
+		}
+		delay := batchBackoff(attempts)
+		store.Logger.Printf("WARN: %d item(s) unprocessed due to throttling, retrying in %s with batch size %d",
+			len(unprocessed), delay, batchSize)
+		time.Sleep(delay)
+
+		requests = append(append([]*dynamodb.WriteRequest{}, unprocessed...), requests...)
+	}
+
+	return nil
+}
+
+// nextBatchSize returns how many of the given requests fit into the next
+// batch. It always returns at least one unless there are no requests.
+func nextBatchSize(requests []*dynamodb.WriteRequest, maxItems, maxBytes int) int {
+	n, size := 0, 0
+	for n < len(requests) && n < maxItems {
+		size += writeRequestSize(requests[n])
+		if n > 0 && size > maxBytes {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+func batchBackoff(attempt int) time.Duration {
+	delay := batchBackoffBase << uint(attempt-1)
+	if delay > batchBackoffMax || delay <= 0 {
+		delay = batchBackoffMax
+	}
+	return delay
+}
+
+func isThrottlingError(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case dynamodb.ErrCodeProvisionedThroughputExceededException, "ThrottlingException", "RequestLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
+
+// writeRequestSize estimates the size of a write request when encoded as
+// JSON, which is what counts towards the request size limit.
+func writeRequestSize(r *dynamodb.WriteRequest) int {
+	switch {
+	case r.PutRequest != nil:
+		return 20 + attributeMapSize(r.PutRequest.Item)
+	case r.DeleteRequest != nil:
+		return 20 + attributeMapSize(r.DeleteRequest.Key)
+	}
+	return 0
+}
+
+func attributeMapSize(m map[string]*dynamodb.AttributeValue) int {
+	size := 2
+	for name, av := range m {
+		size += len(name) + 4 + attributeValueSize(av)
+	}
+	return size
+}
+
+func attributeValueSize(av *dynamodb.AttributeValue) int {
+	if av == nil {
+		return 0
+	}
+
+	const overhead = 8 // e.g. {"S":""}
+	size := overhead
+
+	switch {
+	case av.S != nil:
+		size += len(aws.StringValue(av.S))
+	case av.N != nil:
+		size += len(aws.StringValue(av.N))
+	case av.B != nil:
+		size += (len(av.B) + 2) / 3 * 4 // base64
+	case av.BOOL != nil:
+		size += 5
+	case av.NULL != nil:
+		size += 5
+	case av.M != nil:
+		size += attributeMapSize(av.M)
+	case av.L != nil:
+		for _, v := range av.L {
+			size += attributeValueSize(v) + 1
+		}
+	case av.SS != nil:
+		for _, s := range av.SS {
+			size += len(aws.StringValue(s)) + 3
+		}
+	case av.NS != nil:
+		for _, s := range av.NS {
+			size += len(aws.StringValue(s)) + 3
+		}
+	case av.BS != nil:
+		for _, b := range av.BS {
+			size += (len(b)+2)/3*4 + 3
+		}
+	}
+
+	return size
+}
@@ -0,0 +1,50 @@
+package dynamodb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func putRequest(key string, payloadSize int) *dynamodb.WriteRequest {
+	return &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: map[string]*dynamodb.AttributeValue{
+		tablePartitionKey: {S: aws.String("features")},
+		tableSortKey:      {S: aws.String(key)},
+		"payload":         {S: aws.String(strings.Repeat("x", payloadSize))},
+	}}}
+}
+
+func TestNextBatchSize(t *testing.T) {
+	var small, large []*dynamodb.WriteRequest
+	for i := 0; i < 30; i++ {
+		small = append(small, putRequest("small", 100))
+		large = append(large, putRequest("large", 400*1024))
+	}
+
+	if n := nextBatchSize(small, maxBatchItems, maxBatchBytes); n != 25 {
+		t.Errorf("got batch size %d for small items, want 25", n)
+	}
+	if n := nextBatchSize(small, 3, maxBatchBytes); n != 3 {
+		t.Errorf("got batch size %d with limit 3, want 3", n)
+	}
+	if n := nextBatchSize(large, maxBatchItems, 1024*1024); n != 2 {
+		t.Errorf("got batch size %d for large items, want 2", n)
+	}
+	if n := nextBatchSize(large, maxBatchItems, 1); n != 1 {
+		t.Errorf("got batch size %d for oversized item, want 1", n)
+	}
+	if n := nextBatchSize(nil, maxBatchItems, maxBatchBytes); n != 0 {
+		t.Errorf("got batch size %d for no requests, want 0", n)
+	}
+}
+
+func TestBatchBackoff(t *testing.T) {
+	if d := batchBackoff(1); d != batchBackoffBase {
+		t.Errorf("got backoff %s for first attempt", d)
+	}
+	if d := batchBackoff(100); d != batchBackoffMax {
+		t.Errorf("got backoff %s for many attempts", d)
+	}
+}
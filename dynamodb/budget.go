@@ -0,0 +1,39 @@
+package dynamodb
+
+import "fmt"
+
+// ReadBudgetError is returned by All and related reads if reading the items of
+// a kind exceeded MaxItemsPerKind or MaxPagesPerKind. The read is stopped as
+// soon as the budget is exceeded, so no more capacity is consumed.
+type ReadBudgetError struct {
+	Table     string
+	Namespace string
+
+	// What was read until the budget was exceeded
+	Items int
+	Pages int
+
+	// The budget that was exceeded, e.g. "MaxItemsPerKind=1000"
+	Limit string
+}
+
+func (e *ReadBudgetError) Error() string {
+	return fmt.Sprintf("reading %q items from table %q exceeded %s after %d item(s) in %d page(s); "+
+		"the table may be shared with other data: use a dedicated table, limit the synced kinds (Kinds), "+
+		"or raise the budget", e.Namespace, e.Table, e.Limit, e.Items, e.Pages)
+}
+
+// checkReadBudget returns a ReadBudgetError if items or pages exceed the
+// store's budget.
+func (store *DynamoDBFeatureStore) checkReadBudget(table, namespace string, items, pages int) error {
+	limit := ""
+	switch {
+	case store.MaxItemsPerKind > 0 && items > store.MaxItemsPerKind:
+		limit = fmt.Sprintf("MaxItemsPerKind=%d", store.MaxItemsPerKind)
+	case store.MaxPagesPerKind > 0 && pages > store.MaxPagesPerKind:
+		limit = fmt.Sprintf("MaxPagesPerKind=%d", store.MaxPagesPerKind)
+	default:
+		return nil
+	}
+	return &ReadBudgetError{Table: table, Namespace: namespace, Items: items, Pages: pages, Limit: limit}
+}
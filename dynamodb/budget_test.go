@@ -0,0 +1,44 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestReadBudget(t *testing.T) {
+	store, client := newTestStore(t)
+	flags := make(map[string]ld.VersionedData)
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("flag-%d", i)
+		flags[key] = &ld.FeatureFlag{Key: key, Version: 1}
+	}
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: flags}); err != nil {
+		t.Fatal(err)
+	}
+	client.pageSize = 3
+
+	store.MaxItemsPerKind = 10
+	if all, err := store.All(ld.Features); err != nil || len(all) != 10 {
+		t.Fatalf("got %d flags (err=%v) within budget, want 10", len(all), err)
+	}
+
+	store.MaxItemsPerKind = 5
+	_, err := store.All(ld.Features)
+	berr, ok := err.(*dynamodb.ReadBudgetError)
+	if !ok {
+		t.Fatalf("got %v, want *ReadBudgetError", err)
+	}
+	if berr.Items != 6 || berr.Pages != 2 || berr.Namespace != "features" {
+		t.Errorf("unexpected error: %+v", berr)
+	}
+
+	store.MaxItemsPerKind = 0
+	store.MaxPagesPerKind = 3
+	if _, err := store.All(ld.Features); err == nil {
+		t.Error("expected page budget to be exceeded with 4 pages")
+	}
+}
@@ -0,0 +1,219 @@
+package dynamodb
+
+import (
+	"sync"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// itemCache caches the results of All and Get. A nil cache caches nothing.
+type itemCache struct {
+	mu    sync.RWMutex
+	all   map[string]map[string]ld.VersionedData
+	items map[string]map[string]ld.VersionedData
+
+	// Generation at which each entry of all was read, if known
+	generations map[string]int64
+
+	// Earliest expiry time of the overrides read into the cache, if any.
+	// Once it has passed, the cache is invalidated.
+	expires time.Time
+
+	// Incremented by invalidate. Items are only cached if no invalidation
+	// happened since they were read (see epoch).
+	currentEpoch uint64
+}
+
+func newItemCache() *itemCache {
+	c := &itemCache{}
+	c.invalidate()
+	return c
+}
+
+// itemCache returns the store's cache, or nil if caching is disabled. The
+// first call starts polling for changes in the background.
+func (store *DynamoDBFeatureStore) itemCache() *itemCache {
+	if store.CacheRefreshInterval <= 0 {
+		return nil
+	}
+	store.cacheOnce.Do(func() {
+		store.cache = newItemCache()
+		store.goBackground(store.pollForChanges)
+	})
+	return store.cache
+}
+
+// pollForChanges invalidates the cache whenever the last sync time changes.
+func (store *DynamoDBFeatureStore) pollForChanges(done <-chan struct{}) {
+	last, err := store.LastSynced()
+	if err != nil {
+		store.Logger.Printf("WARN: Failed to get last sync time: %s", err)
+	}
+
+	ticker := time.NewTicker(store.CacheRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			synced, err := store.LastSynced()
+			if err != nil {
+				store.Logger.Printf("WARN: Failed to get last sync time: %s", err)
+				continue
+			}
+			if !synced.Equal(last) {
+				store.Logger.Printf("DEBUG: Data changed at %s, invalidating cache", synced)
+				store.cache.invalidate()
+				last = synced
+			}
+		}
+	}
+}
+
+func (c *itemCache) getAll(kind ld.VersionedDataKind) (map[string]ld.VersionedData, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.dropExpired()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	items, ok := c.all[kind.GetNamespace()]
+	if !ok {
+		return nil, false
+	}
+	// Callers may modify the returned map
+	copied := make(map[string]ld.VersionedData, len(items))
+	for k, v := range items {
+		copied[k] = v
+	}
+	return copied, true
+}
+
+// epoch returns the current epoch of the cache. Get it before reading items
+// from DynamoDB and pass it when caching them, so that items read before an
+// invalidation don't replace newer data.
+func (c *itemCache) epoch() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.currentEpoch
+}
+
+func (c *itemCache) putAll(kind ld.VersionedDataKind, items map[string]ld.VersionedData, epoch uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putAllLocked(kind, items, epoch)
+}
+
+func (c *itemCache) putAllLocked(kind ld.VersionedDataKind, items map[string]ld.VersionedData, epoch uint64) bool {
+	if epoch != c.currentEpoch {
+		return false
+	}
+	copied := make(map[string]ld.VersionedData, len(items))
+	for k, v := range items {
+		copied[k] = v
+	}
+	c.all[kind.GetNamespace()] = copied
+	return true
+}
+
+// getAllAt works like getAll but only returns items cached at the given
+// generation.
+func (c *itemCache) getAllAt(kind ld.VersionedDataKind, generation int64) (map[string]ld.VersionedData, bool) {
+	c.mu.RLock()
+	cached, ok := c.generations[kind.GetNamespace()]
+	c.mu.RUnlock()
+	if !ok || cached != generation {
+		return nil, false
+	}
+	return c.getAll(kind)
+}
+
+// putAllAt works like putAll and records the generation of the items.
+func (c *itemCache) putAllAt(kind ld.VersionedDataKind, items map[string]ld.VersionedData, generation int64, epoch uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.putAllLocked(kind, items, epoch) {
+		c.generations[kind.GetNamespace()] = generation
+	}
+}
+
+// get returns a cached item, which may be nil if the item doesn't exist.
+func (c *itemCache) get(kind ld.VersionedDataKind, key string) (ld.VersionedData, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.dropExpired()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if all, ok := c.all[kind.GetNamespace()]; ok {
+		return all[key], true
+	}
+	item, ok := c.items[kind.GetNamespace()][key]
+	return item, ok
+}
+
+func (c *itemCache) put(kind ld.VersionedDataKind, key string, item ld.VersionedData, epoch uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if epoch != c.currentEpoch {
+		return
+	}
+	items, ok := c.items[kind.GetNamespace()]
+	if !ok {
+		items = make(map[string]ld.VersionedData)
+		c.items[kind.GetNamespace()] = items
+	}
+	items[key] = item
+}
+
+// expireAt makes sure the cache is invalidated no later than t. It must be
+// called before caching items that are only valid until t.
+func (c *itemCache) expireAt(t time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.expires.IsZero() || t.Before(c.expires) {
+		c.expires = t
+	}
+}
+
+// dropExpired invalidates the cache if its expiry time has passed.
+func (c *itemCache) dropExpired() {
+	c.mu.RLock()
+	expired := !c.expires.IsZero() && !time.Now().Before(c.expires)
+	c.mu.RUnlock()
+	if expired {
+		c.invalidate()
+	}
+}
+
+func (c *itemCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.all = make(map[string]map[string]ld.VersionedData)
+	c.items = make(map[string]map[string]ld.VersionedData)
+	c.generations = make(map[string]int64)
+	c.currentEpoch++
+	// Keep a pending expiry time, which may belong to items that are being
+	// read concurrently and are about to be cached
+	if !c.expires.After(time.Now()) {
+		c.expires = time.Time{}
+	}
+}
@@ -0,0 +1,156 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestCacheRefresh(t *testing.T) {
+	writer, client := newTestStore(t)
+	if err := writer.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := &dynamodb.DynamoDBFeatureStore{
+		Client:               client,
+		Table:                writer.Table,
+		Logger:               writer.Logger,
+		CacheRefreshInterval: 10 * time.Millisecond,
+	}
+	defer reader.Close()
+
+	if _, err := reader.All(ld.Features); err != nil {
+		t.Fatal(err)
+	}
+	queries := client.count("Query")
+	for i := 0; i < 3; i++ {
+		item, err := reader.Get(ld.Features, "flag")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.GetVersion() != 1 {
+			t.Errorf("got version %d, want 1", item.GetVersion())
+		}
+		reader.All(ld.Features)
+	}
+	if n := client.count("Query"); n != queries {
+		t.Errorf("expected reads to be served from cache, got %d more queries", n-queries)
+	}
+
+	// Another process updates the table
+	time.Sleep(5 * time.Millisecond) // make sure the sync time changes
+	if err := writer.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		item, err := reader.Get(ld.Features, "flag")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.GetVersion() == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cache wasn't invalidated after update")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCacheCheckGeneration(t *testing.T) {
+	writer, client := newTestStore(t)
+	if err := writer.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := &dynamodb.DynamoDBFeatureStore{
+		Client:               client,
+		Table:                writer.Table,
+		Logger:               writer.Logger,
+		CacheRefreshInterval: time.Hour, // never poll in this test
+		CacheCheckGeneration: true,
+	}
+	defer reader.Close()
+
+	if _, err := reader.All(ld.Features); err != nil {
+		t.Fatal(err)
+	}
+	queries := client.count("Query")
+	if _, err := reader.All(ld.Features); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.count("Query"); n != queries {
+		t.Errorf("expected unchanged generation to be served from cache, got %d more queries", n-queries)
+	}
+
+	if err := writer.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+	flags, err := reader.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := flags["flag"].GetVersion(); v != 2 {
+		t.Errorf("got version %d after generation changed, want 2", v)
+	}
+}
+
+// readingClient runs a function after the first GetItem request for the
+// given key, e.g. a write.
+type readingClient struct {
+	*fakeDynamoDB
+	key   string
+	after func()
+}
+
+func (c *readingClient) GetItem(in *ddb.GetItemInput) (*ddb.GetItemOutput, error) {
+	out, err := c.fakeDynamoDB.GetItem(in)
+	if aws.StringValue(in.Key["key"].S) == c.key && c.after != nil {
+		after := c.after
+		c.after = nil
+		after()
+	}
+	return out, err
+}
+
+func TestCacheIgnoresReadsBeforeInvalidation(t *testing.T) {
+	store, client := newTestStore(t)
+	reading := &readingClient{fakeDynamoDB: client, key: "flag"}
+	store.Client = reading
+	store.CacheRefreshInterval = time.Hour // never poll in this test
+	defer store.Close()
+
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flag is updated after Get read it, but before Get caches it
+	reading.after = func() {
+		if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if item, err := store.Get(ld.Features, "flag"); err != nil || item.GetVersion() != 1 {
+		t.Fatalf("got %v, %v, want version 1", item, err)
+	}
+
+	item, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := item.GetVersion(); v != 2 {
+		t.Errorf("got version %d after update, want 2", v)
+	}
+}
@@ -0,0 +1,186 @@
+package dynamodb
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// checksumAttribute holds a hash of all other attributes of an item. Like
+// other attributes of the store, it starts with "$" so that it can't collide
+// with fields of the item, which are escaped.
+const checksumAttribute = "$checksum"
+
+// ChecksumPolicy selects what happens when the checksum of an item read from
+// the table doesn't match its attributes, e.g. because the item was edited
+// in the console or only partially written by a tool other than the store.
+type ChecksumPolicy string
+
+const (
+	// ChecksumIgnore doesn't verify checksums. This is the default.
+	ChecksumIgnore ChecksumPolicy = "ignore"
+
+	// ChecksumWarn logs items whose checksum doesn't match but uses them
+	// anyway.
+	ChecksumWarn ChecksumPolicy = "warn"
+
+	// ChecksumError fails to read items whose checksum doesn't match with a
+	// *ChecksumMismatchError, so that they are treated as corrupt (see
+	// SkipCorruptItems and CorruptItems).
+	ChecksumError ChecksumPolicy = "error"
+)
+
+// ParseChecksumPolicy returns the checksum policy with the given name,
+// "ignore", "warn", or "error".
+func ParseChecksumPolicy(name string) (ChecksumPolicy, error) {
+	switch policy := ChecksumPolicy(name); policy {
+	case ChecksumIgnore, ChecksumWarn, ChecksumError:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown checksum policy %q", name)
+}
+
+// ChecksumMismatchError is returned when reading an item whose checksum doesn't
+// match its attributes if Checksums is ChecksumError.
+type ChecksumMismatchError struct {
+	Namespace string
+	Key       string
+	Stored    string
+	Computed  string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %q item (key=%s): stored %s, computed %s; "+
+		"the item was modified outside of the store",
+		e.Namespace, e.Key, e.Stored, e.Computed)
+}
+
+// setChecksum adds the checksum attribute to an item about to be written.
+func setChecksum(av map[string]*dynamodb.AttributeValue) {
+	av[checksumAttribute] = &dynamodb.AttributeValue{S: aws.String(itemChecksum(av))}
+}
+
+// verifyChecksum checks the checksum of an item read from the table according
+// to the store's policy. Items written before checksums were added don't
+// have one and pass.
+func (store *DynamoDBFeatureStore) verifyChecksum(kind ld.VersionedDataKind, av map[string]*dynamodb.AttributeValue) error {
+	if store.Checksums == "" || store.Checksums == ChecksumIgnore {
+		return nil
+	}
+	stored, ok := av[checksumAttribute]
+	if !ok || stored.S == nil {
+		return nil
+	}
+	computed := itemChecksum(av)
+	if *stored.S == computed {
+		return nil
+	}
+
+	err := &ChecksumMismatchError{
+		Namespace: kind.GetNamespace(),
+		Stored:    *stored.S,
+		Computed:  computed,
+	}
+	if key, ok := av[tableSortKey]; ok && key.S != nil {
+		err.Key = *key.S
+	}
+	if store.Checksums == ChecksumError {
+		return err
+	}
+	store.Logger.Printf("WARN: Using item despite %s", err)
+	return nil
+}
+
+// itemChecksum returns a hash of all attributes of an item except for the
+// checksum itself. Numbers and sets are hashed in canonical form, as
+// DynamoDB may return them differently than they were written.
+func itemChecksum(av map[string]*dynamodb.AttributeValue) string {
+	h := sha256.New()
+	hashAttributes(h, av)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func hashAttributes(w io.Writer, m map[string]*dynamodb.AttributeValue) {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		if name != checksumAttribute {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "M%d:", len(names))
+	for _, name := range names {
+		hashString(w, "", name)
+		hashAttribute(w, m[name])
+	}
+}
+
+// hashAttribute writes an attribute value to w with its type and length, so
+// that different values never produce the same output.
+func hashAttribute(w io.Writer, av *dynamodb.AttributeValue) {
+	switch {
+	case av == nil:
+		io.WriteString(w, "0")
+	case av.S != nil:
+		hashString(w, "S", *av.S)
+	case av.N != nil:
+		hashString(w, "N", canonicalNumber(*av.N))
+	case av.B != nil:
+		hashString(w, "B", string(av.B))
+	case av.BOOL != nil:
+		fmt.Fprintf(w, "T%t", *av.BOOL)
+	case av.NULL != nil:
+		io.WriteString(w, "0")
+	case av.M != nil:
+		hashAttributes(w, av.M)
+	case av.L != nil:
+		fmt.Fprintf(w, "L%d:", len(av.L))
+		for _, v := range av.L {
+			hashAttribute(w, v)
+		}
+	case av.SS != nil:
+		hashSet(w, "SS", av.SS, func(s string) string { return s })
+	case av.NS != nil:
+		hashSet(w, "NS", av.NS, canonicalNumber)
+	case av.BS != nil:
+		set := make([]*string, len(av.BS))
+		for i, b := range av.BS {
+			set[i] = aws.String(string(b))
+		}
+		hashSet(w, "BS", set, func(s string) string { return s })
+	default:
+		io.WriteString(w, "?")
+	}
+}
+
+func hashSet(w io.Writer, typ string, set []*string, canonical func(string) string) {
+	values := make([]string, len(set))
+	for i, s := range set {
+		values[i] = canonical(aws.StringValue(s))
+	}
+	sort.Strings(values)
+	fmt.Fprintf(w, "%s%d:", typ, len(values))
+	for _, s := range values {
+		hashString(w, "", s)
+	}
+}
+
+func hashString(w io.Writer, typ, s string) {
+	fmt.Fprintf(w, "%s%d:%s", typ, len(s), s)
+}
+
+// canonicalNumber returns the exact value of a number attribute as a
+// fraction, e.g. "3/2" for "1.50" and "1.5E0".
+func canonicalNumber(n string) string {
+	r, ok := new(big.Rat).SetString(n)
+	if !ok {
+		return n
+	}
+	return r.RatString()
+}
@@ -0,0 +1,44 @@
+package dynamodb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestItemChecksum(t *testing.T) {
+	item := func(n string, set ...string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"key":    {S: aws.String("flag")},
+			"weight": {N: aws.String(n)},
+			"tags":   {SS: aws.StringSlice(set)},
+		}
+	}
+
+	// DynamoDB may return numbers and sets in a different form
+	sum := itemChecksum(item("1.50", "a", "b"))
+	if got := itemChecksum(item("1.5", "b", "a")); got != sum {
+		t.Errorf("got different checksums %s and %s for equal items", got, sum)
+	}
+	if got := itemChecksum(item("1.51", "a", "b")); got == sum {
+		t.Error("got same checksum for different numbers")
+	}
+	if got := itemChecksum(item("1.5", "ab")); got == sum {
+		t.Error("got same checksum for different sets")
+	}
+
+	// The checksum itself isn't part of it
+	av := item("1.5", "a", "b")
+	setChecksum(av)
+	if got := itemChecksum(av); got != sum {
+		t.Errorf("got checksum %s, want %s", got, sum)
+	}
+
+	// Neither are nested strings confused with attribute names
+	a := map[string]*dynamodb.AttributeValue{"a": {S: aws.String("b")}, "c": {S: aws.String("")}}
+	b := map[string]*dynamodb.AttributeValue{"a": {S: aws.String("b1:c")}}
+	if itemChecksum(a) == itemChecksum(b) {
+		t.Error("got same checksum for different items")
+	}
+}
@@ -0,0 +1,108 @@
+package dynamodb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestChecksums(t *testing.T) {
+	store, client := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"good":     &ld.FeatureFlag{Key: "good", Version: 1},
+			"modified": &ld.FeatureFlag{Key: "modified", Version: 1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	client.table("test-table")["features\x00modified"]["on"] = &ddb.AttributeValue{BOOL: aws.Bool(true)}
+
+	for _, policy := range []dynamodb.ChecksumPolicy{"", dynamodb.ChecksumIgnore, dynamodb.ChecksumWarn} {
+		store.Checksums = policy
+		flag, err := store.Get(ld.Features, "modified")
+		if err != nil {
+			t.Fatalf("%s: %s", policy, err)
+		}
+		if !flag.(*ld.FeatureFlag).On {
+			t.Errorf("%s: got flag %#v", policy, flag)
+		}
+	}
+
+	store.Checksums = dynamodb.ChecksumError
+	if _, err := store.Get(ld.Features, "good"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := store.Get(ld.Features, "modified")
+	if cerr, ok := err.(*dynamodb.ChecksumMismatchError); !ok || cerr.Key != "modified" || cerr.Namespace != "features" {
+		t.Fatalf("got error %#v, want checksum mismatch", err)
+	}
+
+	corrupt, err := store.CorruptItems(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := corrupt["modified"]; !ok || len(corrupt) != 1 {
+		t.Errorf("got corrupt items %v, want [modified]", corrupt)
+	}
+}
+
+func TestChecksumsOfItemsWithoutChecksum(t *testing.T) {
+	store, client := newTestStore(t)
+	store.Checksums = dynamodb.ChecksumError
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// Items written before checksums were added can still be read
+	item := client.table("test-table")["features\x00flag"]
+	delete(item, "$checksum")
+	item["on"] = &ddb.AttributeValue{BOOL: aws.Bool(true)}
+
+	flag, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flag.(*ld.FeatureFlag).On {
+		t.Errorf("got flag %#v", flag)
+	}
+}
+
+func TestChecksumsOfEscapedItems(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.Checksums = dynamodb.ChecksumError
+	var item dynamodb.RawItem
+	if err := json.Unmarshal([]byte(`{"key":"flag","version":1,"expiresAt":1,"$checksum":"x"}`), &item); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upsert(ld.Features, &item); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get(ld.Features, "flag"); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := store.AllRaw(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := dynamodb.ItemFields(raw["flag"])
+	if len(fields) != 4 || fields["$checksum"] == nil || fields["$checksum"].S == nil || *fields["$checksum"].S != "x" || fields["checksum"] != nil {
+		t.Errorf("got fields %v", fields)
+	}
+}
+
+func TestParseChecksumPolicy(t *testing.T) {
+	for _, name := range []string{"ignore", "warn", "error"} {
+		if policy, err := dynamodb.ParseChecksumPolicy(name); err != nil || string(policy) != name {
+			t.Errorf("%s: got policy %q, error %v", name, policy, err)
+		}
+	}
+	if _, err := dynamodb.ParseChecksumPolicy("strict"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
@@ -0,0 +1,24 @@
+package dynamodb
+
+import (
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// ClientSideFlags returns the keys of all flags that are available to
+// client-side SDKs. The vendored SDK doesn't know the clientSide property,
+// so it's read from the raw items, which only have it if they were stored
+// with RawItem (see the raw sync mode).
+func (store *DynamoDBFeatureStore) ClientSideFlags() (map[string]bool, error) {
+	raw, err := store.AllRaw(ld.Features)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for key, item := range raw {
+		if av, ok := item["clientSide"]; ok && av.BOOL != nil && *av.BOOL {
+			keys[key] = true
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,34 @@
+package dynamodb
+
+// Close stops all background goroutines of the store, e.g. cache refreshers,
+// and waits for them to exit. It's safe to call Close multiple times. The
+// store can still be used for reads and writes afterwards, but nothing
+// happens in the background anymore.
+func (store *DynamoDBFeatureStore) Close() error {
+	store.closeOnce.Do(func() {
+		close(store.doneChan())
+	})
+	store.background.Wait()
+	return nil
+}
+
+// doneChan returns the channel that is closed when the store is closed.
+func (store *DynamoDBFeatureStore) doneChan() chan struct{} {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.done == nil {
+		store.done = make(chan struct{})
+	}
+	return store.done
+}
+
+// goBackground runs fn in a goroutine that Close waits for. fn must return
+// once the given channel is closed.
+func (store *DynamoDBFeatureStore) goBackground(fn func(done <-chan struct{})) {
+	done := store.doneChan()
+	store.background.Add(1)
+	go func() {
+		defer store.background.Done()
+		fn(done)
+	}()
+}
@@ -0,0 +1,29 @@
+package dynamodb
+
+import (
+	"testing"
+)
+
+func TestClose(t *testing.T) {
+	store := &DynamoDBFeatureStore{}
+
+	stopped := make(chan struct{})
+	store.goBackground(func(done <-chan struct{}) {
+		<-done
+		close(stopped)
+	})
+
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Close returned before background goroutine stopped")
+	}
+
+	// Closing again is a no-op
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// Run with -race to detect unsynchronized access to the store's state.
+func TestConcurrentUse(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.InitRetries = 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("flag-%d", i)
+			for v := 1; v <= 5; v++ {
+				if i%4 == 0 {
+					// Overlapping Inits abort all but the latest one
+					report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+						ld.Features: {key: &ld.FeatureFlag{Key: key, Version: v}},
+					})
+					for _, err := range report.Failed {
+						if err != dynamodb.ErrConcurrentInit {
+							t.Error(err)
+						}
+					}
+				}
+				if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: key, Version: v}); err != nil {
+					t.Error(err)
+				}
+				if _, err := store.Get(ld.Features, key); err != nil {
+					t.Error(err)
+				}
+				if _, err := store.All(ld.Features); err != nil {
+					t.Error(err)
+				}
+				store.Initialized()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if !store.Initialized() {
+		t.Error("expected store to be initialized")
+	}
+}
@@ -0,0 +1,30 @@
+package dynamodb
+
+import (
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// CorruptItems returns the keys of all items of the given kind that can't be
+// unmarshaled, along with the reason. Repair them by writing a valid item
+// with a higher version, e.g. with the lddstore repair command.
+func (store *DynamoDBFeatureStore) CorruptItems(kind ld.VersionedDataKind) (map[string]error, error) {
+	raw, err := store.AllRaw(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	corrupt := make(map[string]error)
+	for key, av := range raw {
+		if _, err := store.unmarshalItem(kind, av); err != nil {
+			corrupt[key] = err
+		}
+	}
+	return corrupt, nil
+}
+
+func (store *DynamoDBFeatureStore) skipCorruptItem(kind ld.VersionedDataKind, key string, err error) {
+	store.Logger.Printf("ERROR: Skipping corrupt %q item (key=%s): %s", kind.GetNamespace(), key, err)
+	if store.OnCorruptItem != nil {
+		store.OnCorruptItem(kind, key, err)
+	}
+}
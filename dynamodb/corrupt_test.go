@@ -0,0 +1,51 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestSkipCorruptItems(t *testing.T) {
+	store, client := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"good": &ld.FeatureFlag{Key: "good", Version: 1},
+			"bad":  &ld.FeatureFlag{Key: "bad", Version: 1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	client.table("test-table")["features\x00bad"]["version"] = &ddb.AttributeValue{S: aws.String("not a number")}
+
+	if _, err := store.All(ld.Features); err == nil {
+		t.Fatal("expected All to fail on corrupt item by default")
+	}
+
+	var skipped []string
+	store.SkipCorruptItems = true
+	store.OnCorruptItem = func(kind ld.VersionedDataKind, key string, err error) {
+		skipped = append(skipped, key)
+	}
+
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := flags["good"]; !ok || len(flags) != 1 {
+		t.Errorf("got flags %v, want only the good one", flags)
+	}
+	if len(skipped) != 1 || skipped[0] != "bad" {
+		t.Errorf("got skipped items %v, want [bad]", skipped)
+	}
+
+	corrupt, err := store.CorruptItems(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := corrupt["bad"]; !ok || len(corrupt) != 1 {
+		t.Errorf("got corrupt items %v, want [bad]", corrupt)
+	}
+}
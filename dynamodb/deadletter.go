@@ -0,0 +1,210 @@
+package dynamodb
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+const deadLetterKey = "deadLetter"
+
+// DeadLetter lists items that failed to be written, so that they can be
+// synced on their own instead of by repeating full syncs that keep failing
+// (see sync.Syncer.Recover). It is stored as a metadata item in the table.
+//
+// Only the keys passed to the failed Init are listed. Stale items that Init
+// would have deleted are removed by the next successful full sync.
+type DeadLetter struct {
+	// Keys of the failed items by namespace, e.g. {"segments":["beta"]}
+	Keys map[string][]string `json:"keys"`
+
+	// Last error that caused items to be added
+	Error string `json:"error"`
+
+	// Number of times items were added without all of them being recovered
+	Failures int `json:"failures"`
+
+	// Time of the last change
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Empty reports whether the dead letter lists no keys.
+func (dl *DeadLetter) Empty() bool {
+	return dl == nil || len(dl.Keys) == 0
+}
+
+// DeadLetter returns the items that failed to be written, or nil if there are
+// none.
+func (store *DynamoDBFeatureStore) DeadLetter(ctx context.Context) (*DeadLetter, error) {
+	result, err := store.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            rawKey(metadataNamespace, deadLetterKey),
+	})
+	store.observe(getRequest, err)
+	if err != nil || len(result.Item) == 0 {
+		return nil, err
+	}
+
+	dl := &DeadLetter{
+		Keys:      make(map[string][]string),
+		UpdatedAt: fromMillis(result.Item["updatedAt"]),
+	}
+	if av := result.Item["keys"]; av != nil {
+		for namespace, keys := range av.M {
+			dl.Keys[namespace] = aws.StringValueSlice(keys.SS)
+		}
+	}
+	if av := result.Item["error"]; av != nil && av.S != nil {
+		dl.Error = *av.S
+	}
+	if av := result.Item["failures"]; av != nil && av.N != nil {
+		dl.Failures, _ = strconv.Atoi(*av.N)
+	}
+	return dl, nil
+}
+
+// AddToDeadLetter adds the keys of items that failed to be written, along
+// with the error that caused it.
+func (store *DynamoDBFeatureStore) AddToDeadLetter(ctx context.Context, keys map[string][]string, cause error) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return store.modifyDeadLetter(ctx, func(dl *DeadLetter) {
+		for namespace, k := range keys {
+			dl.Keys[namespace] = mergeKeys(dl.Keys[namespace], k)
+		}
+		if cause != nil {
+			dl.Error = cause.Error()
+		}
+		dl.Failures++
+	})
+}
+
+// RemoveFromDeadLetter removes the keys of items that were written
+// successfully. The dead letter is deleted once it lists no more keys.
+func (store *DynamoDBFeatureStore) RemoveFromDeadLetter(ctx context.Context, keys map[string][]string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return store.modifyDeadLetter(ctx, func(dl *DeadLetter) {
+		for namespace, k := range keys {
+			dl.Keys[namespace] = removeKeys(dl.Keys[namespace], k)
+		}
+	})
+}
+
+// updateDeadLetter records the outcome of InitWithReport: all items of
+// succeeded kinds are removed from the dead letter, and all items of failed
+// kinds are added to it. Kinds that failed because another Init took over
+// are left to that Init.
+func (store *DynamoDBFeatureStore) updateDeadLetter(report *InitReport, allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	var cause error
+	failed := make(map[string][]string)
+	for kind, err := range report.Failed {
+		if err == ErrConcurrentInit {
+			continue
+		}
+		for key := range allData[kind] {
+			failed[kind.GetNamespace()] = append(failed[kind.GetNamespace()], key)
+		}
+		cause = err
+	}
+
+	return store.modifyDeadLetter(context.Background(), func(dl *DeadLetter) {
+		for _, kind := range report.Succeeded {
+			delete(dl.Keys, kind.GetNamespace())
+		}
+		for namespace, keys := range failed {
+			dl.Keys[namespace] = mergeKeys(dl.Keys[namespace], keys)
+		}
+		if len(failed) > 0 {
+			dl.Error = cause.Error()
+			dl.Failures++
+		}
+	})
+}
+
+// modifyDeadLetter applies a change to the stored dead letter. The dead letter
+// is only changed by syncs, which rarely overlap, so this is a plain
+// read-modify-write.
+func (store *DynamoDBFeatureStore) modifyDeadLetter(ctx context.Context, change func(*DeadLetter)) error {
+	dl, err := store.DeadLetter(ctx)
+	if err != nil {
+		return err
+	}
+	existed := dl != nil
+	if !existed {
+		dl = &DeadLetter{Keys: make(map[string][]string)}
+	}
+	change(dl)
+	for namespace, keys := range dl.Keys {
+		if len(keys) == 0 {
+			delete(dl.Keys, namespace)
+		}
+	}
+
+	if dl.Empty() {
+		if !existed {
+			return nil
+		}
+		_, err = store.Client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(store.Table),
+			Key:       rawKey(metadataNamespace, deadLetterKey),
+		})
+		store.observe(writeRequest, err)
+		return err
+	}
+
+	keys := make(map[string]*dynamodb.AttributeValue, len(dl.Keys))
+	for namespace, k := range dl.Keys {
+		keys[namespace] = &dynamodb.AttributeValue{SS: aws.StringSlice(k)}
+	}
+	item := rawKey(metadataNamespace, deadLetterKey)
+	item["keys"] = &dynamodb.AttributeValue{M: keys}
+	item["failures"] = &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(dl.Failures))}
+	item["updatedAt"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(unixMillis(time.Now()), 10))}
+	if dl.Error != "" {
+		item["error"] = &dynamodb.AttributeValue{S: aws.String(dl.Error)}
+	}
+	_, err = store.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item:      item,
+	})
+	store.observe(writeRequest, err)
+	return err
+}
+
+// mergeKeys returns the sorted union of two lists of keys.
+func mergeKeys(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var merged []string
+	for _, key := range append(append([]string{}, a...), b...) {
+		if !seen[key] {
+			seen[key] = true
+			merged = append(merged, key)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// removeKeys returns the keys of a that aren't in b.
+func removeKeys(a, b []string) []string {
+	remove := make(map[string]bool, len(b))
+	for _, key := range b {
+		remove[key] = true
+	}
+	var kept []string
+	for _, key := range a {
+		if !remove[key] {
+			kept = append(kept, key)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,64 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestDeadLetters(t *testing.T) {
+	store, client := newTestStore(t)
+	store.InitRetries = 0
+	store.DeadLetters = true
+	ctx := context.Background()
+
+	client.batchWriteErr = func(requests []*ddb.WriteRequest) error {
+		for _, r := range requests {
+			if r.PutRequest != nil && aws.StringValue(r.PutRequest.Item["namespace"].S) == ld.Segments.GetNamespace() {
+				return errors.New("boom")
+			}
+		}
+		return nil
+	}
+	allData := map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+		ld.Segments: {
+			"a": &ld.Segment{Key: "a", Version: 1},
+			"b": &ld.Segment{Key: "b", Version: 1},
+		},
+	}
+	if err := store.Init(allData); err == nil {
+		t.Fatal("expected error")
+	}
+
+	dl, err := store.DeadLetter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.Empty() || !reflect.DeepEqual(dl.Keys, map[string][]string{"segments": {"a", "b"}}) {
+		t.Fatalf("got dead letter %+v, want segments a and b", dl)
+	}
+	if dl.Failures != 1 || dl.Error == "" || dl.UpdatedAt.IsZero() {
+		t.Errorf("got dead letter %+v, want one failure", dl)
+	}
+
+	if err := store.RemoveFromDeadLetter(ctx, map[string][]string{"segments": {"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if dl, _ := store.DeadLetter(ctx); !reflect.DeepEqual(dl.Keys, map[string][]string{"segments": {"b"}}) {
+		t.Errorf("got dead letter %+v, want segment b", dl)
+	}
+
+	client.batchWriteErr = nil
+	if err := store.Init(allData); err != nil {
+		t.Fatal(err)
+	}
+	if dl, err := store.DeadLetter(ctx); err != nil || !dl.Empty() {
+		t.Errorf("got dead letter %+v and error %v, want none", dl, err)
+	}
+}
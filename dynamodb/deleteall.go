@@ -0,0 +1,224 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// maxTransactItems is the maximum number of items DynamoDB accepts in a
+// single TransactWriteItems request.
+const maxTransactItems = 10
+
+// DeleteAll marks multiple items of a kind as deleted. keys maps the key of
+// each item to the version of its tombstone; like Delete, an item is only
+// replaced if the stored version is lower, which preserves version ordering
+// with concurrent updates.
+//
+// The tombstones are written in transactions of up to 10 items, so that
+// either all or none of the items of a transaction are deleted. Items whose
+// stored version isn't lower are left out of the transaction. All
+// transactions are attempted even if some fail, and the returned error lists
+// every key that wasn't deleted.
+func (store *DynamoDBFeatureStore) DeleteAll(kind ld.VersionedDataKind, keys map[string]int) error {
+	_, err := store.deleteAll(kind, keys)
+	return err
+}
+
+// deleteAll works like DeleteAll but also returns the number of tombstones
+// written.
+func (store *DynamoDBFeatureStore) deleteAll(kind ld.VersionedDataKind, keys map[string]int) (int, error) {
+	if !store.storesKind(kind) {
+		return 0, nil
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var failed []string
+	var errs []error
+	written := 0
+	for len(sorted) > 0 {
+		n := len(sorted)
+		if n > maxTransactItems {
+			n = maxTransactItems
+		}
+		batch := make(map[string]int, n)
+		for _, key := range sorted[:n] {
+			batch[key] = keys[key]
+		}
+		sorted = sorted[n:]
+
+		w, err := store.transactDelete(kind, batch)
+		if err != nil {
+			for key := range batch {
+				failed = append(failed, key)
+			}
+			store.Logger.Printf("ERROR: Failed to delete %d %q item(s) in transaction: %s", len(batch), kind.GetNamespace(), err)
+			errs = append(errs, err)
+			continue
+		}
+		written += w
+	}
+
+	if written > 0 {
+		store.itemCache().invalidate()
+		if err := store.touchLastSynced(); err != nil {
+			store.Logger.Printf("WARN: Failed to update sync metadata: %s", err)
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return written, fmt.Errorf("failed to delete %d of %d %q item(s) (%s): %w",
+			len(failed), len(keys), kind.GetNamespace(), strings.Join(failed, ", "), errors.Join(errs...))
+	}
+	return written, nil
+}
+
+// transactDelete writes the tombstones of up to maxTransactItems items in a
+// single transaction. If the transaction is cancelled because the stored
+// version of some items isn't lower, it's retried without them. It returns
+// the number of tombstones written.
+//
+// Transactions are spaced out by the same limiter as batch writes (see
+// WriteRateLimit). Each item counts twice, as transactional writes consume
+// twice the write capacity.
+func (store *DynamoDBFeatureStore) transactDelete(kind ld.VersionedDataKind, keys map[string]int) (int, error) {
+	limiter := store.writeLimiter()
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for len(sorted) > 0 {
+		items := make([]*dynamodb.TransactWriteItem, 0, len(sorted))
+		for _, key := range sorted {
+			av, err := store.marshalItem(kind, kind.MakeDeletedItem(key, keys[key]))
+			if err != nil {
+				return 0, fmt.Errorf("failed to marshal item (key=%s): %s", key, err)
+			}
+			items = append(items, &dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+				TableName: aws.String(store.Table),
+				Item:      av,
+				ConditionExpression: aws.String(
+					"attribute_not_exists(#namespace) or " +
+						"attribute_not_exists(#key) or " +
+						":version > #version",
+				),
+				ExpressionAttributeNames: map[string]*string{
+					"#namespace": aws.String(tablePartitionKey),
+					"#key":       aws.String(tableSortKey),
+					"#version":   aws.String("version"),
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":version": &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(keys[key]))},
+				},
+			}})
+		}
+
+		limiter.waitN(2 * len(items))
+
+		_, err := store.Client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+			TransactItems: items,
+		})
+		store.observe(writeRequest, err)
+		if err == nil {
+			return len(sorted), nil
+		}
+
+		reasons, ok := cancellationReasons(err)
+		if !ok || len(reasons) != len(sorted) {
+			return 0, err
+		}
+		var retry []string
+		for i, reason := range reasons {
+			switch reason {
+			case "None":
+				retry = append(retry, sorted[i])
+			case "ConditionalCheckFailed":
+				store.Logger.Printf("DEBUG: Not deleting item due to condition (key=%s version=%d)",
+					sorted[i], keys[sorted[i]])
+			default:
+				return 0, err
+			}
+		}
+		if len(retry) == len(sorted) {
+			return 0, err
+		}
+		sorted = retry
+	}
+	return 0, nil
+}
+
+// cancellationReasons returns the reason for each item of a cancelled
+// transaction, e.g. "ConditionalCheckFailed" or "None".
+func cancellationReasons(err error) ([]string, bool) {
+	if rerr, ok := err.(*RequestError); ok {
+		err = rerr.Err
+	}
+	cerr, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok || len(cerr.CancellationReasons) == 0 {
+		return nil, false
+	}
+	reasons := make([]string, len(cerr.CancellationReasons))
+	for i, reason := range cerr.CancellationReasons {
+		reasons[i] = aws.StringValue(reason.Code)
+	}
+	return reasons, true
+}
+
+// DeleteLatest marks an item as deleted without knowing its version, e.g.
+// when LaunchDarkly reports that a flag was deleted or archived. The
+// tombstone gets the version following the stored one, so a later Init or
+// Upsert with a higher version from LaunchDarkly still takes precedence. It
+// returns false if the item doesn't exist or is already deleted.
+func (store *DynamoDBFeatureStore) DeleteLatest(kind ld.VersionedDataKind, key string) (bool, error) {
+	if !store.storesKind(kind) {
+		return false, nil
+	}
+	if frozen, err := store.isFrozen(kind, key); frozen || err != nil {
+		return false, err
+	}
+
+	result, err := store.Client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            ItemKey(kind, key),
+	})
+	store.observe(getRequest, err)
+	if err != nil {
+		return false, err
+	}
+	if len(result.Item) == 0 {
+		return false, nil
+	}
+	item, err := store.unmarshalItem(kind, result.Item)
+	if err != nil {
+		return false, err
+	}
+	if item.IsDeleted() {
+		return false, nil
+	}
+
+	ok, err := store.putWithVersioning(kind, kind.MakeDeletedItem(key, item.GetVersion()+1))
+	if err != nil || !ok {
+		return false, err
+	}
+
+	store.itemCache().invalidate()
+	if err := store.touchLastSynced(); err != nil {
+		store.Logger.Printf("WARN: Failed to update sync metadata: %s", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,158 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestDeleteAll(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"a": &ld.FeatureFlag{Key: "a", Version: 1},
+			"b": &ld.FeatureFlag{Key: "b", Version: 5},
+			"c": &ld.FeatureFlag{Key: "c", Version: 1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// b has a newer version than its tombstone, so it must be kept
+	if err := store.DeleteAll(ld.Features, map[string]int{"a": 2, "b": 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := flags["a"]; ok {
+		t.Error("expected a to be deleted")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, ok := flags[key]; !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+
+	all, err := store.AllIncludingDeleted(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := all["a"]; item == nil || !item.IsDeleted() || item.GetVersion() != 2 {
+		t.Errorf("expected tombstone for a with version 2, got %v", item)
+	}
+}
+
+func TestDeleteLatest(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 7}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := store.DeleteLatest(ld.Features, "flag")
+	if err != nil || !deleted {
+		t.Fatalf("got deleted=%v err=%v, want true", deleted, err)
+	}
+	items, err := store.AllIncludingDeleted(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := items["flag"]; !item.IsDeleted() || item.GetVersion() != 8 {
+		t.Errorf("got %+v, want tombstone with version 8", item)
+	}
+
+	if deleted, _ := store.DeleteLatest(ld.Features, "flag"); deleted {
+		t.Error("expected already deleted flag to be skipped")
+	}
+	if deleted, _ := store.DeleteLatest(ld.Features, "missing"); deleted {
+		t.Error("expected missing flag to be skipped")
+	}
+}
+
+func TestDeleteAllInTransactions(t *testing.T) {
+	store, client := newTestStore(t)
+	flags := make(map[string]ld.VersionedData)
+	keys := make(map[string]int)
+	for i := 0; i < 25; i++ {
+		key := fmt.Sprintf("flag-%02d", i)
+		flags[key] = &ld.FeatureFlag{Key: key, Version: 1}
+		keys[key] = 2
+	}
+	// A newer version in the middle of a transaction must not stop the
+	// other items of the transaction from being deleted
+	flags["flag-05"] = &ld.FeatureFlag{Key: "flag-05", Version: 9}
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: flags}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteAll(ld.Features, keys); err != nil {
+		t.Fatal(err)
+	}
+	// 3 transactions, one of which is retried without flag-05
+	if n := client.count("TransactWriteItems"); n != 4 {
+		t.Errorf("got %d transactions, want 4", n)
+	}
+	all, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all["flag-05"] == nil {
+		t.Errorf("got %v, want only flag-05", all)
+	}
+}
+
+func TestInitReplacesStaleItemsWithTombstones(t *testing.T) {
+	store, _ := newTestStore(t)
+	for _, flags := range []map[string]ld.VersionedData{
+		{"a": &ld.FeatureFlag{Key: "a", Version: 1}, "b": &ld.FeatureFlag{Key: "b", Version: 4}},
+		{"a": &ld.FeatureFlag{Key: "a", Version: 1}},
+	} {
+		if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: flags}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := store.AllIncludingDeleted(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := all["b"]; item == nil || !item.IsDeleted() || item.GetVersion() != 5 {
+		t.Errorf("expected tombstone for b with version 5, got %v", item)
+	}
+	if item := all["a"]; item == nil || item.IsDeleted() {
+		t.Errorf("expected a to be kept, got %v", item)
+	}
+}
+
+func TestInitRateLimitsDeletes(t *testing.T) {
+	store, _ := newTestStore(t)
+	flags := make(map[string]ld.VersionedData)
+	for i := 0; i < 40; i++ {
+		key := fmt.Sprintf("flag-%02d", i)
+		flags[key] = &ld.FeatureFlag{Key: key, Version: 1}
+	}
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: flags}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 40 tombstones in 4 transactions, each counting 20 items; the first
+	// one is free, the following three take 20ms each
+	store.WriteRateLimit = 1000
+	start := time.Now()
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: {}})
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n := report.Deleted[ld.Features]; n != 40 {
+		t.Fatalf("got %d deleted items, want 40", n)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("deletes finished too quickly: %s", elapsed)
+	}
+}
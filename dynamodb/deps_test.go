@@ -0,0 +1,102 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"strings"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// Dependencies that only the Lambda functions may pull in, as they increase
+// the binary size and cold start time of library consumers.
+var lambdaOnlyDeps = []string{
+	"github.com/aws/aws-lambda-go",
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api/apigw",
+}
+
+func TestMinimalDependencies(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	var walk func(path, dir string) error
+	walk = func(path, dir string) error {
+		pkg, err := build.Import(path, dir, 0)
+		if err != nil {
+			return err
+		}
+		if seen[pkg.ImportPath] || pkg.Goroot {
+			return nil
+		}
+		seen[pkg.ImportPath] = true
+		for _, dep := range lambdaOnlyDeps {
+			if strings.HasSuffix(pkg.ImportPath, dep) || strings.Contains(pkg.ImportPath, dep+"/") {
+				return fmt.Errorf("imports %s", pkg.ImportPath)
+			}
+		}
+		for _, imp := range pkg.Imports {
+			if imp == "C" {
+				continue
+			}
+			if err := walk(imp, pkg.Dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The library packages next to the store must stay free of them too
+	for _, path := range []string{".", "../sync", "../webhook", "../handler", "../api", "../middleware", "../evaluator"} {
+		if err := walk(path, wd); err != nil {
+			t.Errorf("%s: %s", path, err)
+		}
+	}
+}
+
+// BenchmarkColdStart measures what a Lambda function does on cold start:
+// create a store and read all flags once.
+func BenchmarkColdStart(b *testing.B) {
+	client := newFakeDynamoDB()
+	seed := &dynamodb.DynamoDBFeatureStore{Client: client, Table: "test-table", Logger: discardLogger{}}
+	flags := make(map[string]ld.VersionedData)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("flag-%d", i)
+		flags[key] = &ld.FeatureFlag{Key: key, Version: 1, Variations: []interface{}{true, false}}
+	}
+	if err := seed.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: flags, ld.Segments: {}}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store := &dynamodb.DynamoDBFeatureStore{Client: client, Table: "test-table", Logger: discardLogger{}}
+		if _, err := store.All(ld.Features); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkNewDynamoDBFeatureStore measures creating the AWS session and
+// client, which dominates cold starts when credentials come from the
+// environment.
+func BenchmarkNewDynamoDBFeatureStore(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := dynamodb.NewDynamoDBFeatureStore("test-table", discardLogger{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Println(...interface{})        {}
+func (discardLogger) Printf(string, ...interface{}) {}
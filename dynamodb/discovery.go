@@ -0,0 +1,96 @@
+package dynamodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// DiscoverTables returns the names of all tables whose names start with
+// prefix, e.g. "launchdarkly-" to find the tables of all environments. Each
+// table's schema is validated with ValidateTable; if any table is
+// misconfigured, the returned error lists all of them.
+func DiscoverTables(client dynamodbiface.DynamoDBAPI, prefix string) ([]string, error) {
+	var tables []string
+
+	err := client.ListTablesPages(&dynamodb.ListTablesInput{}, func(out *dynamodb.ListTablesOutput, lastPage bool) bool {
+		for _, name := range out.TableNames {
+			if strings.HasPrefix(aws.StringValue(name), prefix) {
+				tables = append(tables, aws.StringValue(name))
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(tables)
+	return tables, ValidateTables(client, tables...)
+}
+
+// ValidateTables validates the schemas of the given tables and returns a
+// single error listing all misconfigured tables.
+func ValidateTables(client dynamodbiface.DynamoDBAPI, tables ...string) error {
+	var msgs []string
+	for _, table := range tables {
+		if err := ValidateTable(client, table); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("%d misconfigured table(s): %s", len(msgs), strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// ValidateTable checks that a table exists and has the key schema expected by
+// the store: a string partition key named "namespace" and a string sort key
+// named "key".
+func ValidateTable(client dynamodbiface.DynamoDBAPI, table string) error {
+	out, err := client.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return fmt.Errorf("table %q: %s", table, err)
+	}
+
+	types := make(map[string]string)
+	for _, def := range out.Table.AttributeDefinitions {
+		types[aws.StringValue(def.AttributeName)] = aws.StringValue(def.AttributeType)
+	}
+	keys := make(map[string]string)
+	for _, elem := range out.Table.KeySchema {
+		keys[aws.StringValue(elem.KeyType)] = aws.StringValue(elem.AttributeName)
+	}
+
+	var problems []string
+	for keyType, name := range map[string]string{
+		dynamodb.KeyTypeHash:  tablePartitionKey,
+		dynamodb.KeyTypeRange: tableSortKey,
+	} {
+		if keys[keyType] != name {
+			problems = append(problems, fmt.Sprintf("%s key must be %q, not %q", strings.ToLower(keyType), name, keys[keyType]))
+		} else if types[name] != dynamodb.ScalarAttributeTypeS {
+			problems = append(problems, fmt.Sprintf("attribute %q must be of type S, not %q", name, types[name]))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("table %q: %s", table, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Validate checks the schemas of the store's table and, if configured, its
+// overrides table. Call it at startup to fail early on misconfiguration
+// instead of on first access.
+func (store *DynamoDBFeatureStore) Validate() error {
+	tables := []string{store.Table}
+	if store.OverridesTable != "" {
+		tables = append(tables, store.OverridesTable)
+	}
+	return ValidateTables(store.Client, tables...)
+}
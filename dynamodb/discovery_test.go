@@ -0,0 +1,60 @@
+package dynamodb_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestDiscoverTables(t *testing.T) {
+	client := newFakeDynamoDB()
+	for _, table := range []string{"launchdarkly-staging", "launchdarkly-production", "other"} {
+		client.table(table)
+	}
+
+	tables, err := dynamodb.DiscoverTables(client, "launchdarkly-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"launchdarkly-production", "launchdarkly-staging"}; !reflect.DeepEqual(tables, want) {
+		t.Errorf("got %v, want %v", tables, want)
+	}
+
+	client.keySchemas = map[string][]*ddb.KeySchemaElement{
+		"launchdarkly-staging": {
+			{AttributeName: aws.String("id"), KeyType: aws.String(ddb.KeyTypeHash)},
+		},
+		"launchdarkly-production": {
+			{AttributeName: aws.String("namespace"), KeyType: aws.String(ddb.KeyTypeHash)},
+		},
+	}
+	_, err = dynamodb.DiscoverTables(client, "launchdarkly-")
+	if err == nil {
+		t.Fatal("expected error for misconfigured tables")
+	}
+	for _, s := range []string{"2 misconfigured table(s)", `"launchdarkly-staging": hash key must be "namespace", not "id"`, `"launchdarkly-production": range key must be "key"`} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected error to contain %q, got: %s", s, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Validate(); err == nil {
+		t.Error("expected error for missing table")
+	}
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: {}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -26,15 +26,16 @@ Here's how to use the feature store with the LaunchDarkly client:
 package dynamodb
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"math"
 	"os"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
@@ -45,12 +46,21 @@ const (
 	// Schema of the DynamoDB table
 	tablePartitionKey = "namespace"
 	tableSortKey      = "key"
+
+	// Items describing the state of the store itself live in their own
+	// namespace so that they never show up as flags or segments.
+	metadataNamespace = "$metadata"
+	lastSyncedKey     = "lastSynced"
 )
 
 // Verify that the store satisfies the FeatureStore interface
 var _ ld.FeatureStore = (*DynamoDBFeatureStore)(nil)
 
 // DynamoDBFeatureStore provides a DynamoDB-backed feature store for LaunchDarkly.
+//
+// A DynamoDBFeatureStore is safe for concurrent use by multiple goroutines, as
+// required by the LaunchDarkly client. Its exported fields must not be
+// modified once the store is in use.
 type DynamoDBFeatureStore struct {
 	// Client to access DynamoDB
 	Client dynamodbiface.DynamoDBAPI
@@ -58,10 +68,169 @@ type DynamoDBFeatureStore struct {
 	// Name of the DynamoDB table
 	Table string
 
+	// Name of an optional DynamoDB table with overrides (see SetOverride)
+	OverridesTable string
+
+	// Maximum number of items written per second by Init, or zero for no
+	// limit. Use this to keep a full sync from consuming all of the table's
+	// provisioned write capacity. Items written in transactions, such as
+	// the tombstones of stale items, count twice.
+	WriteRateLimit float64
+
+	// Number of times Init retries a data kind that failed to be written
+	InitRetries int
+
+	// If set, InitWithReport lists the items of kinds that failed even after
+	// InitRetries in a dead letter, and removes them once their kind is
+	// initialized successfully (see DeadLetter)
+	DeadLetters bool
+
+	// Maximum number of data kinds Init writes concurrently, or zero to write
+	// all kinds at once. Set to 1 to write one kind after another.
+	InitConcurrency int
+
+	// Options for marshaling items to DynamoDB attributes. By default, empty
+	// strings are stored as NULL, as DynamoDB used to reject empty strings,
+	// which turns flag variations that are empty strings into null. Since
+	// DynamoDB accepts empty strings in non-key attributes, pass
+	// func(e *dynamodbattribute.Encoder) { e.NullEmptyString = false } to
+	// store them as they are.
+	EncoderOptions []func(*dynamodbattribute.Encoder)
+
+	// Options for unmarshaling items from DynamoDB attributes
+	DecoderOptions []func(*dynamodbattribute.Decoder)
+
+	// If set, Init re-reads all items it has written and reports those whose
+	// content differs from the input data in InitReport.Divergent
+	// (RawItems are compared as decoded by the SDK, so fields unknown to it
+	// are reported as differences)
+	VerifyInit bool
+
+	// If set, All skips items that can't be unmarshaled, e.g. because they
+	// were corrupted by manual edits, instead of failing altogether. Use
+	// CorruptItems to find them.
+	SkipCorruptItems bool
+
+	// If set, Init and Upsert don't write flags that fail ValidateFlag for
+	// ValidationUsers, so that consumers keep evaluating the stored version
+	// instead. Upsert returns a *ValidationError for them, and Init lists
+	// them in InitReport.Rejected.
+	ValidateFlags bool
+
+	// Users to evaluate flags for when validating them (optional, default:
+	// DefaultValidationUsers)
+	ValidationUsers []ld.User
+
+	// Name of an optional DynamoDB table, with the same schema as the main
+	// table, that invalid flags and items that failed to decode (see
+	// RejectedItem) are written to instead of being rejected, along with
+	// the payload and reason (see Quarantined)
+	QuarantineTable string
+
+	// Called for each item skipped due to SkipCorruptItems (optional)
+	OnCorruptItem func(kind ld.VersionedDataKind, key string, err error)
+
+	// What to do when an item's checksum, which is written along with each
+	// item, doesn't match its content, e.g. because it was edited in the
+	// console (optional, default: ChecksumIgnore)
+	Checksums ChecksumPolicy
+
+	// Resource tags, e.g. cost center or owner, applied by TagTable and
+	// RecreateTable and expected by ValidateTags
+	Tags map[string]string
+
+	// Data kinds to store, e.g. only ld.Features for consumers that don't
+	// use segments. Items of other kinds are ignored by Init, Upsert, and
+	// Delete. If empty, all kinds are stored.
+	Kinds []ld.VersionedDataKind
+
+	// Items that Init, Upsert, and Delete leave untouched, given as
+	// "namespace/key" or just the key of a flag, e.g. "features/my-flag" or
+	// "my-flag" (see Freeze)
+	Frozen []string
+
+	// If set, items frozen with Freeze are left untouched as well. This
+	// costs one read per call of Init, Upsert, or Delete.
+	CheckFrozen bool
+
+	// Data kinds that consumers may do without, e.g. ld.Segments if only
+	// flags are synced and the IAM policy only grants access to the
+	// "features" partition. If their items can't be read because the table
+	// doesn't exist or access is denied, All returns no items and Get
+	// returns nil, with a warning, instead of failing.
+	OptionalKinds []ld.VersionedDataKind
+
+	// Data kinds read from the table, e.g. only ld.Features for consumers
+	// that don't use segments (see NewFlagOnlyFeatureStore). All returns no
+	// items and Get returns nil for other kinds without accessing DynamoDB,
+	// so segment rules never match. If empty, all kinds are read.
+	ReadKinds []ld.VersionedDataKind
+
+	// If positive, Upsert and Delete fail with a *VersionSkewError if the
+	// item's version is more than this much lower than the stored version,
+	// instead of ignoring the item as usual. This makes a misconfigured
+	// secondary syncer writing stale data visible.
+	MaxVersionSkew int
+
+	// Called for each write rejected due to MaxVersionSkew, e.g. to emit a
+	// metric (optional)
+	OnVersionSkew func(kind ld.VersionedDataKind, key string, version, stored int)
+
+	// If set, reads are served from an in-memory cache that is invalidated
+	// whenever the data in DynamoDB changes. The store checks for changes
+	// at this interval by reading a single metadata item. This gives
+	// long-lived processes using daemon mode (UseLdd) near-real-time updates
+	// without scanning the table all the time.
+	CacheRefreshInterval time.Duration
+
+	// If set along with CacheRefreshInterval, All reads the change
+	// generation (see Generation) before serving cached results and only
+	// queries the table if it changed. This costs one read per call, but
+	// All never returns data older than the last completed sync.
+	CacheCheckGeneration bool
+
+	// Maximum number of items and result pages (of up to 1 MB each) read
+	// for a single kind, e.g. by All. If exceeded, the read fails with a
+	// *ReadBudgetError instead of consuming more read capacity, which
+	// protects small functions from reading a huge shared table by mistake.
+	// Zero means no limit.
+	MaxItemsPerKind int
+	MaxPagesPerKind int
+
+	// If positive, Initialized only returns true if the table holds at
+	// least this many flags, so that consumers of a table wiped by a faulty
+	// Init or synced from an empty environment don't silently evaluate
+	// defaults while reporting healthy. The flags are counted at most once
+	// per MinFlagsCheckInterval.
+	MinFlags int
+
+	// Called when Initialized finds fewer than MinFlags flags after there
+	// were enough, or on the first check, e.g. to ask the sync to repopulate
+	// the table with sync.RequestResync (optional)
+	OnTooFewFlags func(count int)
+
 	// Logger to write all log messages to
 	Logger ld.Logger
 
+	mu          sync.RWMutex
 	initialized bool
+
+	cache     *itemCache
+	cacheOnce sync.Once
+
+	limiter     *rateLimiter
+	limiterOnce sync.Once
+
+	status statusTracker
+	stats  statsTracker
+	flags  flagCounter
+
+	skippedReadOnce sync.Once
+
+	// Used to stop background goroutines
+	done       chan struct{}
+	closeOnce  sync.Once
+	background sync.WaitGroup
 }
 
 // NewDynamoDBFeatureStore creates a new DynamoDB feature store ready to be used
@@ -70,6 +239,8 @@ type DynamoDBFeatureStore struct {
 // This function uses https://docs.aws.amazon.com/sdk-for-go/api/aws/session/#NewSession
 // to configure access to DynamoDB, which means that environment variables like
 // AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_REGION work as expected.
+// Connections are shared with other stores and kept open for reuse (see
+// TransportOptions).
 //
 // For more control, compose your own DynamoDBFeatureStore with a custom DynamoDB client.
 func NewDynamoDBFeatureStore(table string, logger ld.Logger) (*DynamoDBFeatureStore, error) {
@@ -77,113 +248,204 @@ func NewDynamoDBFeatureStore(table string, logger ld.Logger) (*DynamoDBFeatureSt
 		logger = log.New(os.Stderr, "[LaunchDarkly DynamoDBFeatureStore]", log.LstdFlags)
 	}
 
-	sess, err := session.NewSession()
+	sess, err := newSession()
 	if err != nil {
 		return nil, err
 	}
-	client := dynamodb.New(sess)
+	client := NewClient(sess)
 
 	return &DynamoDBFeatureStore{
 		Client:      client,
 		Table:       table,
 		Logger:      logger,
+		InitRetries: 2,
 		initialized: false,
 	}, nil
 }
 
-// Init initializes the store by writing the given data to DynamoDB. It will
-// delete all existing data from the table.
-func (store *DynamoDBFeatureStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
-	// FIXME: deleting all items before storing new ones is racy, or isn't it?
-	if err := store.truncateTable(); err != nil {
-		store.Logger.Printf("ERROR: Failed to truncate table: %s", err)
-		return err
+// Initialized returns true if the store has been initialized. If MinFlags is
+// set, it returns true if the table holds enough flags instead, regardless
+// of whether this store initialized it.
+func (store *DynamoDBFeatureStore) Initialized() bool {
+	if store.MinFlags > 0 {
+		return store.hasMinFlags()
 	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return store.initialized
+}
 
-	var requests []*dynamodb.WriteRequest
+func (store *DynamoDBFeatureStore) setInitialized() {
+	store.mu.Lock()
+	store.initialized = true
+	store.mu.Unlock()
+}
 
-	for kind, items := range allData {
-		for k, v := range items {
-			av, err := marshalItem(kind, v)
-			if err != nil {
-				store.Logger.Printf("ERROR: Failed to marshal item (key=%s): %s", k, err)
-				return err
-			}
-			requests = append(requests, &dynamodb.WriteRequest{
-				PutRequest: &dynamodb.PutRequest{Item: av},
-			})
-		}
+// All returns all items currently stored in DynamoDB that are of the given
+// data kind. (It won't return items marked as deleted.)
+func (store *DynamoDBFeatureStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	if !store.readsKind(kind, "") {
+		return map[string]ld.VersionedData{}, nil
+	}
+	c := store.itemCache()
+	if c != nil && store.CacheCheckGeneration {
+		return store.allCheckingGeneration(c, kind)
+	}
+	if items, ok := c.getAll(kind); ok {
+		return items, nil
 	}
 
-	if err := store.batchWriteRequests(requests); err != nil {
-		store.Logger.Printf("ERROR: Failed to write %d item(s) in batches: %s", len(requests), err)
-		return err
+	epoch := c.epoch()
+	items, err := store.all(kind)
+	if err != nil {
+		return nil, err
 	}
+	c.putAll(kind, items, epoch)
 
-	store.Logger.Printf("INFO: Initialized table %q with %d item(s)", store.Table, len(requests))
+	return items, nil
+}
 
-	store.initialized = true
+// allCheckingGeneration serves All from the cache if the generation hasn't
+// changed since the items were cached. If the generation can't be read, the
+// cached items are returned as they would be without the check.
+func (store *DynamoDBFeatureStore) allCheckingGeneration(c *itemCache, kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	generation, err := store.Generation(context.Background())
+	if err != nil {
+		store.Logger.Printf("WARN: Failed to get generation: %s", err)
+		if items, ok := c.getAll(kind); ok {
+			return items, nil
+		}
+	} else if items, ok := c.getAllAt(kind, generation); ok {
+		return items, nil
+	}
 
-	return nil
+	epoch := c.epoch()
+	items, err := store.all(kind)
+	if err != nil {
+		return nil, err
+	}
+	if generation > 0 {
+		c.putAllAt(kind, items, generation, epoch)
+	}
+	return items, nil
 }
 
-// Initialized returns true if the store has been initialized.
-func (store *DynamoDBFeatureStore) Initialized() bool {
-	return store.initialized
-}
+func (store *DynamoDBFeatureStore) all(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	results, err := store.AllIncludingDeleted(kind)
+	if err != nil {
+		return nil, err
+	}
 
-// All returns all items currently stored in DynamoDB that are of the given
-// data kind. (It won't return items marked as deleted.)
-func (store *DynamoDBFeatureStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
-	var items []map[string]*dynamodb.AttributeValue
+	for key, item := range results {
+		if item.IsDeleted() {
+			delete(results, key)
+		}
+	}
 
-	err := store.Client.QueryPages(&dynamodb.QueryInput{
-		TableName:      aws.String(store.Table),
+	if store.OverridesTable != "" {
+		overrides, err := store.allOverrides(kind)
+		if err != nil {
+			store.Logger.Printf("WARN: Ignoring overrides due to error: %s", err)
+		}
+		for key, item := range overrides {
+			if item.IsDeleted() {
+				delete(results, key)
+			} else {
+				results[key] = item
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// queryItems returns all items of the given namespace stored in a table.
+func (store *DynamoDBFeatureStore) queryItems(table, namespace string) ([]map[string]*dynamodb.AttributeValue, error) {
+	return store.query(&dynamodb.QueryInput{
+		TableName:      aws.String(table),
 		ConsistentRead: aws.Bool(true),
 		KeyConditions: map[string]*dynamodb.Condition{
 			tablePartitionKey: {
 				ComparisonOperator: aws.String("EQ"),
 				AttributeValueList: []*dynamodb.AttributeValue{
-					{S: aws.String(kind.GetNamespace())},
+					{S: aws.String(namespace)},
 				},
 			},
 		},
-	}, func(out *dynamodb.QueryOutput, lastPage bool) bool {
+	}, namespace)
+}
+
+// query returns all items matching a query of the given namespace, reading
+// as many pages as the read budget allows.
+func (store *DynamoDBFeatureStore) query(input *dynamodb.QueryInput, namespace string) ([]map[string]*dynamodb.AttributeValue, error) {
+	var items []map[string]*dynamodb.AttributeValue
+	var budgetErr error
+	pages := 0
+	table := aws.StringValue(input.TableName)
+
+	err := store.Client.QueryPages(input, func(out *dynamodb.QueryOutput, lastPage bool) bool {
 		items = append(items, out.Items...)
+		pages++
+		if budgetErr = store.checkReadBudget(table, namespace, len(items), pages); budgetErr != nil {
+			return false
+		}
 		return !lastPage
 	})
+	if store.missingOptional(namespace, err) {
+		return nil, nil
+	}
+	store.observe(queryRequest, err)
+	if err == nil && budgetErr != nil {
+		return nil, budgetErr
+	}
+
+	return items, err
+}
+
+// Get returns a specific item with the given key. It returns nil if the item
+// does not exist or if it's marked as deleted.
+func (store *DynamoDBFeatureStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	if !store.readsKind(kind, key) {
+		return nil, nil
+	}
+	c := store.itemCache()
+	if item, ok := c.get(kind, key); ok {
+		return item, nil
+	}
+
+	epoch := c.epoch()
+	item, err := store.get(kind, key)
 	if err != nil {
-		store.Logger.Printf("ERROR: Failed to get all %q items: %s", kind.GetNamespace(), err)
 		return nil, err
 	}
+	c.put(kind, key, item, epoch)
 
-	results := make(map[string]ld.VersionedData)
+	return item, nil
+}
 
-	for _, i := range items {
-		item, err := unmarshalItem(kind, i)
+func (store *DynamoDBFeatureStore) get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	if store.OverridesTable != "" {
+		item, err := store.getOverride(kind, key)
 		if err != nil {
-			store.Logger.Printf("ERROR: Failed to unmarshal item: %s", err)
-			return nil, err
-		}
-		if !item.IsDeleted() {
-			results[item.GetKey()] = item
+			store.Logger.Printf("WARN: Ignoring override due to error (key=%s): %s", key, err)
+		} else if item != nil {
+			store.Logger.Printf("DEBUG: Using override (key=%s)", key)
+			if item.IsDeleted() {
+				return nil, nil
+			}
+			return item, nil
 		}
 	}
 
-	return results, nil
-}
-
-// Get returns a specific item with the given key. It returns nil if the item
-// does not exist or if it's marked as deleted.
-func (store *DynamoDBFeatureStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
 	result, err := store.Client.GetItem(&dynamodb.GetItemInput{
 		TableName:      aws.String(store.Table),
 		ConsistentRead: aws.Bool(true),
-		Key: map[string]*dynamodb.AttributeValue{
-			tablePartitionKey: {S: aws.String(kind.GetNamespace())},
-			tableSortKey:      {S: aws.String(key)},
-		},
+		Key:            ItemKey(kind, key),
 	})
+	if store.missingOptional(kind.GetNamespace(), err) {
+		return nil, nil
+	}
+	store.observe(getRequest, err)
 	if err != nil {
 		store.Logger.Printf("ERROR: Failed to get item (key=%s): %s", key, err)
 		return nil, err
@@ -194,7 +456,7 @@ func (store *DynamoDBFeatureStore) Get(kind ld.VersionedDataKind, key string) (l
 		return nil, nil
 	}
 
-	item, err := unmarshalItem(kind, result.Item)
+	item, err := store.unmarshalItem(kind, result.Item)
 	if err != nil {
 		store.Logger.Printf("ERROR: Failed to unmarshal item (key=%s): %s", key, err)
 		return nil, err
@@ -212,22 +474,97 @@ func (store *DynamoDBFeatureStore) Get(kind ld.VersionedDataKind, key string) (l
 // already exist, or updates an existing item if the given item has a higher
 // version.
 func (store *DynamoDBFeatureStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	if !store.storesKind(kind) {
+		return nil
+	}
+	if frozen, err := store.isFrozen(kind, item.GetKey()); frozen || err != nil {
+		return err
+	}
+	if verr := store.validateItem(kind, item); verr != nil {
+		return store.rejectInvalid(kind, item, verr)
+	}
 	return store.updateWithVersioning(kind, item)
 }
 
 // Delete marks an item as deleted. (It won't actually remove the item from
 // DynamoDB.)
 func (store *DynamoDBFeatureStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	if !store.storesKind(kind) {
+		return nil
+	}
+	if frozen, err := store.isFrozen(kind, key); frozen || err != nil {
+		return err
+	}
 	deletedItem := kind.MakeDeletedItem(key, version)
 	return store.updateWithVersioning(kind, deletedItem)
 }
 
+// storesKind reports whether items of the given kind are stored according
+// to the Kinds setting.
+func (store *DynamoDBFeatureStore) storesKind(kind ld.VersionedDataKind) bool {
+	if len(store.Kinds) == 0 {
+		return true
+	}
+	for _, k := range store.Kinds {
+		if k.GetNamespace() == kind.GetNamespace() {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKinds returns the data kinds with the given namespaces, e.g.
+// "features" or "segments", to be used as the Kinds setting.
+func ParseKinds(namespaces ...string) ([]ld.VersionedDataKind, error) {
+	var kinds []ld.VersionedDataKind
+	for _, ns := range namespaces {
+		found := false
+		for _, kind := range ld.VersionedDataKinds {
+			if kind.GetNamespace() == ns {
+				kinds = append(kinds, kind)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown data kind: %q", ns)
+		}
+	}
+	return kinds, nil
+}
+
 func (store *DynamoDBFeatureStore) updateWithVersioning(kind ld.VersionedDataKind, item ld.VersionedData) error {
-	av, err := marshalItem(kind, item)
+	written, err := store.putWithVersioning(kind, item)
 	if err != nil {
-		store.Logger.Printf("ERROR: Failed to marshal item (key=%s): %s", item.GetKey(), err)
 		return err
 	}
+	if !written {
+		return store.checkVersionSkew(kind, item)
+	}
+
+	store.itemCache().invalidate()
+
+	if err := store.touchLastSynced(); err != nil {
+		store.Logger.Printf("WARN: Failed to update sync metadata: %s", err)
+	}
+
+	return nil
+}
+
+// putWithVersioning writes an item unless the stored item has the same or a
+// higher version. It reports whether the item was written.
+func (store *DynamoDBFeatureStore) putWithVersioning(kind ld.VersionedDataKind, item ld.VersionedData) (bool, error) {
+	return store.putIfVersion(kind, item, ">")
+}
+
+// putIfVersion writes an item if its version compares to the stored item's
+// version with the given operator, e.g. ">", or if there is no stored item.
+func (store *DynamoDBFeatureStore) putIfVersion(kind ld.VersionedDataKind, item ld.VersionedData, op string) (bool, error) {
+	av, err := store.marshalItem(kind, item)
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to marshal item (key=%s): %s", item.GetKey(), err)
+		return false, err
+	}
 
 	_, err = store.Client.PutItem(&dynamodb.PutItemInput{
 		TableName: aws.String(store.Table),
@@ -235,7 +572,7 @@ func (store *DynamoDBFeatureStore) updateWithVersioning(kind ld.VersionedDataKin
 		ConditionExpression: aws.String(
 			"attribute_not_exists(#namespace) or " +
 				"attribute_not_exists(#key) or " +
-				":version > #version",
+				":version " + op + " #version",
 		),
 		ExpressionAttributeNames: map[string]*string{
 			"#namespace": aws.String(tablePartitionKey),
@@ -246,91 +583,115 @@ func (store *DynamoDBFeatureStore) updateWithVersioning(kind ld.VersionedDataKin
 			":version": &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(item.GetVersion()))},
 		},
 	})
+	store.observe(writeRequest, err)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
 			store.Logger.Printf("DEBUG: Not updating item due to condition (key=%s version=%d)",
 				item.GetKey(), item.GetVersion())
-			return nil
+			return false, nil
 		}
 		store.Logger.Printf("ERROR: Failed to put item (key=%s): %s", item.GetKey(), err)
-		return err
+		return false, err
 	}
 
-	return nil
+	return true, nil
 }
 
-// truncateTable deletes all items from the table.
-func (store *DynamoDBFeatureStore) truncateTable() error {
-	var items []map[string]*dynamodb.AttributeValue
-
-	err := store.Client.ScanPages(&dynamodb.ScanInput{
-		TableName:            aws.String(store.Table),
-		ConsistentRead:       aws.Bool(true),
-		ProjectionExpression: aws.String("#namespace, #key"),
-		ExpressionAttributeNames: map[string]*string{
-			"#namespace": aws.String(tablePartitionKey),
-			"#key":       aws.String(tableSortKey),
-		},
-	}, func(out *dynamodb.ScanOutput, lastPage bool) bool {
-		items = append(items, out.Items...)
-		return !lastPage
+// LastSynced returns the time the data in DynamoDB was last updated by Init,
+// Upsert, or Delete, or the time an override last expired if that is later.
+// It returns the zero time if the table has never been initialized.
+func (store *DynamoDBFeatureStore) LastSynced() (time.Time, error) {
+	result, err := store.Client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            rawKey(metadataNamespace, lastSyncedKey),
 	})
+	store.observe(getRequest, err)
 	if err != nil {
-		store.Logger.Printf("ERROR: Failed to get all items: %s", err)
-		return err
+		store.Logger.Printf("ERROR: Failed to get sync metadata: %s", err)
+		return time.Time{}, err
 	}
 
-	var requests []*dynamodb.WriteRequest
-
-	for _, item := range items {
-		requests = append(requests, &dynamodb.WriteRequest{
-			DeleteRequest: &dynamodb.DeleteRequest{Key: item},
-		})
+	synced, err := syncTimestamp(result.Item)
+	if err != nil {
+		return time.Time{}, err
 	}
+	if expired := lastOverrideExpiry(result.Item, time.Now()); expired.After(synced) {
+		synced = expired
+	}
+	return synced, nil
+}
 
-	if err := store.batchWriteRequests(requests); err != nil {
-		store.Logger.Printf("ERROR: Failed to delete %d item(s) in batches: %s", len(items), err)
-		return err
+// syncTimestamp returns the time of the last update stored in the sync
+// metadata, or the zero time if there is none.
+func syncTimestamp(metadata map[string]*dynamodb.AttributeValue) (time.Time, error) {
+	av, ok := metadata["timestamp"]
+	if !ok || av.N == nil {
+		return time.Time{}, nil
+	}
+	ms, err := strconv.ParseInt(*av.N, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid sync timestamp %q: %s", *av.N, err)
 	}
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
+}
 
-	return nil
+// touchLastSynced records the current time as the time of the last update
+// and bumps the change generation (see Generation).
+func (store *DynamoDBFeatureStore) touchLastSynced() error {
+	ms := time.Now().UnixNano() / int64(time.Millisecond)
+	_, err := store.Client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:        aws.String(store.Table),
+		Key:              rawKey(metadataNamespace, lastSyncedKey),
+		UpdateExpression: aws.String("SET #timestamp = :timestamp ADD #generation :one"),
+		ExpressionAttributeNames: map[string]*string{
+			"#timestamp":  aws.String("timestamp"),
+			"#generation": aws.String(generationAttribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":timestamp": {N: aws.String(strconv.FormatInt(ms, 10))},
+			":one":       {N: aws.String("1")},
+		},
+	})
+	store.observe(writeRequest, err)
+	return err
 }
 
-// batchWriteRequests executes a list of write requests (PutItem or DeleteItem)
-// in batches of 25, which is the maximum BatchWriteItem can handle.
-func (store *DynamoDBFeatureStore) batchWriteRequests(requests []*dynamodb.WriteRequest) error {
-	for len(requests) > 0 {
-		batchSize := int(math.Min(float64(len(requests)), 25))
-		batch := requests[:batchSize]
-		requests = requests[batchSize:]
-
-		_, err := store.Client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]*dynamodb.WriteRequest{store.Table: batch},
-		})
+func (store *DynamoDBFeatureStore) marshalItem(kind ld.VersionedDataKind, item ld.VersionedData) (map[string]*dynamodb.AttributeValue, error) {
+	var v interface{} = item
+	if raw, ok := item.(*RawItem); ok {
+		fields, err := raw.fields()
 		if err != nil {
-			return err
+			return nil, err
 		}
+		v = fields
 	}
-	return nil
-}
-
-func marshalItem(kind ld.VersionedDataKind, item ld.VersionedData) (map[string]*dynamodb.AttributeValue, error) {
-	av, err := dynamodbattribute.MarshalMap(item)
+	encoded, err := dynamodbattribute.NewEncoder(store.EncoderOptions...).Encode(v)
 	if err != nil {
 		return nil, err
 	}
+	if encoded == nil || encoded.M == nil {
+		return nil, fmt.Errorf("Unexpected attribute value from marshal: %v", encoded)
+	}
+	av := encoded.M
+	escapeAttributes(av)
 
 	// Adding the namespace as a partition key allows us to store everything
 	// (feature flags, segments, etc.) in a single DynamoDB table. The
 	// namespace attribute will be ignored when unmarshalling.
 	av[tablePartitionKey] = &dynamodb.AttributeValue{S: aws.String(kind.GetNamespace())}
+	setChecksum(av)
 
 	return av, nil
 }
 
-func unmarshalItem(kind ld.VersionedDataKind, item map[string]*dynamodb.AttributeValue) (ld.VersionedData, error) {
+func (store *DynamoDBFeatureStore) unmarshalItem(kind ld.VersionedDataKind, item map[string]*dynamodb.AttributeValue) (ld.VersionedData, error) {
+	if err := store.verifyChecksum(kind, item); err != nil {
+		return nil, err
+	}
 	data := kind.GetDefaultItem()
-	if err := dynamodbattribute.UnmarshalMap(item, &data); err != nil {
+	decoder := dynamodbattribute.NewDecoder(store.DecoderOptions...)
+	if err := decoder.Decode(&dynamodb.AttributeValue{M: unescapeAttributes(item)}, &data); err != nil {
 		return nil, err
 	}
 	if item, ok := data.(ld.VersionedData); ok {
@@ -1,8 +1,13 @@
 package dynamodb_test
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
 	"os"
 	"testing"
+	"time"
 
 	ld "gopkg.in/launchdarkly/go-client.v4"
 	ldtest "gopkg.in/launchdarkly/go-client.v4/shared_test"
@@ -26,3 +31,106 @@ func TestDynamoDBFeatureStore(t *testing.T) {
 		return store
 	})
 }
+
+func newTestStore(t *testing.T) (*dynamodb.DynamoDBFeatureStore, *fakeDynamoDB) {
+	client := newFakeDynamoDB()
+	return &dynamodb.DynamoDBFeatureStore{
+		Client: client,
+		Table:  "test-table",
+		Logger: log.New(ioutil.Discard, "", 0),
+	}, client
+}
+
+func TestDynamoDBFeatureStoreWithFakeClient(t *testing.T) {
+	ldtest.RunFeatureStoreTests(t, func() ld.FeatureStore {
+		store, _ := newTestStore(t)
+		return store
+	})
+}
+
+func TestLastSynced(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	synced, err := store.LastSynced()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !synced.IsZero() {
+		t.Errorf("expected zero time before Init, got %s", synced)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	synced, err = store.LastSynced()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced.Before(before) {
+		t.Errorf("expected recent sync time, got %s", synced)
+	}
+
+	// Metadata must not leak into flag data
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 1 {
+		t.Errorf("got %d flags, want 1", len(flags))
+	}
+}
+
+func TestGeneration(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	if gen, err := store.Generation(ctx); err != nil || gen != 0 {
+		t.Fatalf("got generation %d (err=%v) before Init, want 0", gen, err)
+	}
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	afterInit, err := store.Generation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if afterInit <= 0 {
+		t.Errorf("got generation %d after Init, want > 0", afterInit)
+	}
+
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if gen, _ := store.Generation(ctx); gen <= afterInit {
+		t.Errorf("got generation %d after Upsert, want > %d", gen, afterInit)
+	}
+}
+
+func TestInitRetriesUnprocessedItems(t *testing.T) {
+	store, client := newTestStore(t)
+	client.unprocessed = 30
+
+	flags := make(map[string]ld.VersionedData)
+	for i := 0; i < 60; i++ {
+		key := fmt.Sprintf("flag-%d", i)
+		flags[key] = &ld.FeatureFlag{Key: key, Version: 1}
+	}
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: flags}); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(flags) {
+		t.Errorf("got %d flags after Init, want %d", len(all), len(flags))
+	}
+}
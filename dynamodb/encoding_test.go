@@ -0,0 +1,59 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestEmptyStringVariations(t *testing.T) {
+	for _, tt := range []struct {
+		nullEmptyString bool
+		want            interface{}
+	}{
+		{true, nil},
+		{false, ""},
+	} {
+		store, _ := newTestStore(t)
+		nullEmptyString := tt.nullEmptyString
+		store.EncoderOptions = []func(*dynamodbattribute.Encoder){
+			func(e *dynamodbattribute.Encoder) { e.NullEmptyString = nullEmptyString },
+		}
+
+		flag := &ld.FeatureFlag{Key: "flag", Version: 1, Variations: []interface{}{"", "on"}}
+		if err := store.Upsert(ld.Features, flag); err != nil {
+			t.Fatal(err)
+		}
+		item, err := store.Get(ld.Features, "flag")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		variations := item.(*ld.FeatureFlag).Variations
+		if len(variations) != 2 || variations[0] != tt.want || variations[1] != "on" {
+			t.Errorf("NullEmptyString=%v: got variations %#v", tt.nullEmptyString, variations)
+		}
+	}
+}
+
+func TestVerifyInitReportsDivergentItems(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.VerifyInit = true
+
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"empty": &ld.FeatureFlag{Key: "empty", Version: 1, Variations: []interface{}{"", "on"}},
+			"plain": &ld.FeatureFlag{Key: "plain", Version: 1, Variations: []interface{}{true, false}},
+		},
+	})
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Empty strings are stored as NULL by default
+	divergent := report.Divergent[ld.Features]
+	if len(divergent) != 1 || divergent[0] != "empty" {
+		t.Errorf("got divergent items %v, want [empty]", divergent)
+	}
+}
@@ -0,0 +1,614 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// fakeDynamoDB is an in-memory implementation of the subset of the DynamoDB
+// API used by the feature store. Tables are created on first write.
+type fakeDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+
+	mu     sync.Mutex
+	tables map[string]map[string]map[string]*dynamodb.AttributeValue
+	calls  map[string]int
+
+	// Number of write requests or keys to leave unprocessed in the next batch
+	unprocessed int
+
+	// Key schemas returned by DescribeTable; tables not listed here get the
+	// schema expected by the store
+	keySchemas map[string][]*dynamodb.KeySchemaElement
+
+	// Tags by table ARN
+	tags map[string][]*dynamodb.Tag
+
+	// If set, Query returns results in pages of this many items
+	pageSize int
+
+	// If set, GetItem and Query fail with this error
+	readErr error
+
+	// Optional hook to make BatchWriteItem fail
+	batchWriteErr func(requests []*dynamodb.WriteRequest) error
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{
+		tables: make(map[string]map[string]map[string]*dynamodb.AttributeValue),
+		calls:  make(map[string]int),
+	}
+}
+
+func itemID(item map[string]*dynamodb.AttributeValue) string {
+	return aws.StringValue(item["namespace"].S) + "\x00" + aws.StringValue(item["key"].S)
+}
+
+func (f *fakeDynamoDB) table(name string) map[string]map[string]*dynamodb.AttributeValue {
+	t, ok := f.tables[name]
+	if !ok {
+		t = make(map[string]map[string]*dynamodb.AttributeValue)
+		f.tables[name] = t
+	}
+	return t
+}
+
+// sortedItems returns the items of a table ordered by partition and sort key.
+func (f *fakeDynamoDB) sortedItems(name string) []map[string]*dynamodb.AttributeValue {
+	t := f.table(name)
+	ids := make([]string, 0, len(t))
+	for id := range t {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	items := make([]map[string]*dynamodb.AttributeValue, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, t[id])
+	}
+	return items
+}
+
+func (f *fakeDynamoDB) count(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[op]
+}
+
+func (f *fakeDynamoDB) GetItem(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["GetItem"]++
+	if f.readErr != nil {
+		return nil, f.readErr
+	}
+
+	return &dynamodb.GetItemOutput{Item: f.table(*in.TableName)[itemID(in.Key)]}, nil
+}
+
+func (f *fakeDynamoDB) GetItemWithContext(ctx aws.Context, in *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	return f.GetItem(in)
+}
+
+// updateAction matches the SET, ADD, and DELETE actions of update
+// expressions, e.g. "SET #a = :a ADD #b :b".
+var updateAction = regexp.MustCompile(`(SET|ADD|DELETE) (#\w+)(?: =)? (:\w+)`)
+
+func (f *fakeDynamoDB) UpdateItem(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["UpdateItem"]++
+
+	t := f.table(*in.TableName)
+	id := itemID(in.Key)
+	item := make(map[string]*dynamodb.AttributeValue)
+	for k, v := range t[id] {
+		item[k] = v
+	}
+	for k, v := range in.Key {
+		item[k] = v
+	}
+	for _, m := range updateAction.FindAllStringSubmatch(*in.UpdateExpression, -1) {
+		name := *in.ExpressionAttributeNames[m[2]]
+		value := in.ExpressionAttributeValues[m[3]]
+		switch {
+		case m[1] == "ADD" && value.NS != nil && item[name] != nil:
+			value = &dynamodb.AttributeValue{NS: append(numberSet(item[name].NS, value.NS), value.NS...)}
+		case m[1] == "ADD" && item[name] != nil:
+			a, _ := strconv.ParseInt(*item[name].N, 10, 64)
+			b, _ := strconv.ParseInt(*value.N, 10, 64)
+			value = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(a+b, 10))}
+		case m[1] == "DELETE":
+			if item[name] == nil {
+				continue
+			}
+			value = &dynamodb.AttributeValue{NS: numberSet(item[name].NS, value.NS)}
+		}
+		item[name] = value
+	}
+	t[id] = item
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+// numberSet returns the numbers in set that aren't in remove.
+func numberSet(set, remove []*string) []*string {
+	var result []*string
+	for _, n := range set {
+		found := false
+		for _, r := range remove {
+			found = found || aws.StringValue(n) == aws.StringValue(r)
+		}
+		if !found {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func (f *fakeDynamoDB) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["PutItem"]++
+
+	t := f.table(*in.TableName)
+	id := itemID(in.Item)
+	if in.ConditionExpression != nil {
+		ok := evalCondition(*in.ConditionExpression, t[id], in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+		if !ok {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "condition failed", nil)
+		}
+	}
+	t[id] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	return f.PutItem(in)
+}
+
+// TransactWriteItems writes all items or none, like DynamoDB. Cancelled
+// transactions report a reason per item.
+func (f *fakeDynamoDB) TransactWriteItems(in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["TransactWriteItems"]++
+
+	reasons := make([]*dynamodb.CancellationReason, len(in.TransactItems))
+	cancelled := false
+	for i, item := range in.TransactItems {
+		reasons[i] = &dynamodb.CancellationReason{Code: aws.String("None")}
+		put := item.Put
+		if put.ConditionExpression == nil {
+			continue
+		}
+		if !evalCondition(*put.ConditionExpression, f.table(*put.TableName)[itemID(put.Item)], put.ExpressionAttributeNames, put.ExpressionAttributeValues) {
+			reasons[i].Code = aws.String("ConditionalCheckFailed")
+			cancelled = true
+		}
+	}
+	if cancelled {
+		return nil, &dynamodb.TransactionCanceledException{
+			Message_:            aws.String("Transaction cancelled, please refer cancellation reasons for specific reasons"),
+			CancellationReasons: reasons,
+		}
+	}
+	for _, item := range in.TransactItems {
+		f.table(*item.Put.TableName)[itemID(item.Put.Item)] = item.Put.Item
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["DeleteItem"]++
+
+	delete(f.table(*in.TableName), itemID(in.Key))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItemWithContext(ctx aws.Context, in *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	return f.DeleteItem(in)
+}
+
+func (f *fakeDynamoDB) QueryPages(in *dynamodb.QueryInput, fn func(*dynamodb.QueryOutput, bool) bool) error {
+	f.mu.Lock()
+	f.calls["Query"]++
+	if f.readErr != nil {
+		f.mu.Unlock()
+		return f.readErr
+	}
+	namespace := aws.StringValue(in.KeyConditions["namespace"].AttributeValueList[0].S)
+	prefix := ""
+	if c, ok := in.KeyConditions["key"]; ok && aws.StringValue(c.ComparisonOperator) == "BEGINS_WITH" {
+		prefix = aws.StringValue(c.AttributeValueList[0].S)
+	}
+	var items []map[string]*dynamodb.AttributeValue
+	for _, item := range f.sortedItems(*in.TableName) {
+		if aws.StringValue(item["namespace"].S) != namespace || !strings.HasPrefix(aws.StringValue(item["key"].S), prefix) {
+			continue
+		}
+		if in.FilterExpression != nil && !evalContains(*in.FilterExpression, item, in.ExpressionAttributeNames, in.ExpressionAttributeValues) {
+			continue
+		}
+		if in.ProjectionExpression != nil {
+			item = project(*in.ProjectionExpression, item, in.ExpressionAttributeNames)
+		}
+		items = append(items, item)
+	}
+	pageSize := f.pageSize
+	f.mu.Unlock()
+
+	if pageSize <= 0 {
+		fn(&dynamodb.QueryOutput{Items: items}, true)
+		return nil
+	}
+	for start := 0; ; start += pageSize {
+		end := start + pageSize
+		if end >= len(items) {
+			fn(&dynamodb.QueryOutput{Items: items[start:]}, true)
+			return nil
+		}
+		if !fn(&dynamodb.QueryOutput{Items: items[start:end]}, false) {
+			return nil
+		}
+	}
+}
+
+func (f *fakeDynamoDB) Query(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["Query"]++
+	if f.readErr != nil {
+		return nil, f.readErr
+	}
+
+	namespace := aws.StringValue(in.KeyConditions["namespace"].AttributeValueList[0].S)
+	start := ""
+	if in.ExclusiveStartKey != nil {
+		start = aws.StringValue(in.ExclusiveStartKey["key"].S)
+	}
+	out := &dynamodb.QueryOutput{}
+	for _, item := range f.sortedItems(*in.TableName) {
+		if aws.StringValue(item["namespace"].S) != namespace || aws.StringValue(item["key"].S) <= start {
+			continue
+		}
+		if in.Limit != nil && int64(len(out.Items)) == *in.Limit {
+			last := out.Items[len(out.Items)-1]
+			out.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{"namespace": last["namespace"], "key": last["key"]}
+			break
+		}
+		out.Items = append(out.Items, item)
+	}
+	return out, nil
+}
+
+func (f *fakeDynamoDB) QueryPagesWithContext(ctx aws.Context, in *dynamodb.QueryInput, fn func(*dynamodb.QueryOutput, bool) bool, opts ...request.Option) error {
+	return f.QueryPages(in, fn)
+}
+
+func (f *fakeDynamoDB) ScanPages(in *dynamodb.ScanInput, fn func(*dynamodb.ScanOutput, bool) bool) error {
+	f.mu.Lock()
+	f.calls["Scan"]++
+	items := f.sortedItems(*in.TableName)
+	f.mu.Unlock()
+
+	fn(&dynamodb.ScanOutput{Items: items}, true)
+	return nil
+}
+
+func (f *fakeDynamoDB) ListTablesPages(in *dynamodb.ListTablesInput, fn func(*dynamodb.ListTablesOutput, bool) bool) error {
+	f.mu.Lock()
+	f.calls["ListTables"]++
+	var names []string
+	for name := range f.tables {
+		names = append(names, name)
+	}
+	f.mu.Unlock()
+
+	sort.Strings(names)
+	fn(&dynamodb.ListTablesOutput{TableNames: aws.StringSlice(names)}, true)
+	return nil
+}
+
+func (f *fakeDynamoDB) DescribeTable(in *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["DescribeTable"]++
+
+	if _, ok := f.tables[*in.TableName]; !ok {
+		return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "table not found", nil)
+	}
+	schema, ok := f.keySchemas[*in.TableName]
+	if !ok {
+		schema = []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("namespace"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+			{AttributeName: aws.String("key"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+		}
+	}
+	var defs []*dynamodb.AttributeDefinition
+	for _, elem := range schema {
+		defs = append(defs, &dynamodb.AttributeDefinition{AttributeName: elem.AttributeName, AttributeType: aws.String("S")})
+	}
+	return &dynamodb.DescribeTableOutput{Table: &dynamodb.TableDescription{
+		TableName:            in.TableName,
+		TableArn:             aws.String(tableARN(*in.TableName)),
+		KeySchema:            schema,
+		AttributeDefinitions: defs,
+		ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  aws.Int64(5),
+			WriteCapacityUnits: aws.Int64(5),
+		},
+	}}, nil
+}
+
+func (f *fakeDynamoDB) DescribeTableWithContext(ctx aws.Context, in *dynamodb.DescribeTableInput, opts ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+	return f.DescribeTable(in)
+}
+
+func tableARN(name string) string {
+	return "arn:aws:dynamodb:local:000000000000:table/" + name
+}
+
+func (f *fakeDynamoDB) CreateTableWithContext(ctx aws.Context, in *dynamodb.CreateTableInput, opts ...request.Option) (*dynamodb.CreateTableOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["CreateTable"]++
+
+	if _, ok := f.tables[*in.TableName]; ok {
+		return nil, awserr.New(dynamodb.ErrCodeResourceInUseException, "table exists", nil)
+	}
+	f.table(*in.TableName)
+	return &dynamodb.CreateTableOutput{TableDescription: &dynamodb.TableDescription{
+		TableName: in.TableName,
+		TableArn:  aws.String(tableARN(*in.TableName)),
+	}}, nil
+}
+
+func (f *fakeDynamoDB) DeleteTableWithContext(ctx aws.Context, in *dynamodb.DeleteTableInput, opts ...request.Option) (*dynamodb.DeleteTableOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["DeleteTable"]++
+
+	if _, ok := f.tables[*in.TableName]; !ok {
+		return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "table not found", nil)
+	}
+	delete(f.tables, *in.TableName)
+	delete(f.tags, tableARN(*in.TableName))
+	return &dynamodb.DeleteTableOutput{}, nil
+}
+
+// Tables are created and deleted synchronously, so there's nothing to wait for
+func (f *fakeDynamoDB) WaitUntilTableExistsWithContext(ctx aws.Context, in *dynamodb.DescribeTableInput, opts ...request.WaiterOption) error {
+	return nil
+}
+
+func (f *fakeDynamoDB) WaitUntilTableNotExistsWithContext(ctx aws.Context, in *dynamodb.DescribeTableInput, opts ...request.WaiterOption) error {
+	return nil
+}
+
+func (f *fakeDynamoDB) ListTagsOfResourceWithContext(ctx aws.Context, in *dynamodb.ListTagsOfResourceInput, opts ...request.Option) (*dynamodb.ListTagsOfResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &dynamodb.ListTagsOfResourceOutput{Tags: f.tags[*in.ResourceArn]}, nil
+}
+
+func (f *fakeDynamoDB) TagResourceWithContext(ctx aws.Context, in *dynamodb.TagResourceInput, opts ...request.Option) (*dynamodb.TagResourceOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tags == nil {
+		f.tags = make(map[string][]*dynamodb.Tag)
+	}
+	for _, tag := range in.Tags {
+		replaced := false
+		for i, t := range f.tags[*in.ResourceArn] {
+			if *t.Key == *tag.Key {
+				f.tags[*in.ResourceArn][i] = tag
+				replaced = true
+			}
+		}
+		if !replaced {
+			f.tags[*in.ResourceArn] = append(f.tags[*in.ResourceArn], tag)
+		}
+	}
+	return &dynamodb.TagResourceOutput{}, nil
+}
+
+func (f *fakeDynamoDB) BatchGetItemWithContext(ctx aws.Context, in *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["BatchGetItem"]++
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]*dynamodb.AttributeValue{},
+		UnprocessedKeys: map[string]*dynamodb.KeysAndAttributes{},
+	}
+	for name, ka := range in.RequestItems {
+		if len(ka.Keys) > 100 {
+			return nil, fmt.Errorf("too many keys in batch: %d", len(ka.Keys))
+		}
+		seen := make(map[string]bool)
+		for _, key := range ka.Keys {
+			if seen[itemID(key)] {
+				return nil, awserr.New("ValidationException", "Provided list of item keys contains duplicates", nil)
+			}
+			seen[itemID(key)] = true
+		}
+		keys := ka.Keys
+		if f.unprocessed > 0 {
+			n := f.unprocessed
+			if n > len(keys) {
+				n = len(keys)
+			}
+			f.unprocessed -= n
+			out.UnprocessedKeys[name] = &dynamodb.KeysAndAttributes{Keys: keys[len(keys)-n:]}
+			keys = keys[:len(keys)-n]
+		}
+		t := f.table(name)
+		for _, key := range keys {
+			if item, ok := t[itemID(key)]; ok {
+				out.Responses[name] = append(out.Responses[name], item)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDynamoDB) BatchWriteItem(in *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["BatchWriteItem"]++
+
+	out := &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]*dynamodb.WriteRequest{}}
+	for name, requests := range in.RequestItems {
+		if len(requests) > 25 {
+			return nil, fmt.Errorf("too many requests in batch: %d", len(requests))
+		}
+		if f.batchWriteErr != nil {
+			if err := f.batchWriteErr(requests); err != nil {
+				return nil, err
+			}
+		}
+		if f.unprocessed > 0 {
+			n := f.unprocessed
+			if n > len(requests) {
+				n = len(requests)
+			}
+			f.unprocessed -= n
+			out.UnprocessedItems[name] = requests[len(requests)-n:]
+			requests = requests[:len(requests)-n]
+		}
+		t := f.table(name)
+		for _, r := range requests {
+			if r.PutRequest != nil {
+				t[itemID(r.PutRequest.Item)] = r.PutRequest.Item
+			}
+			if r.DeleteRequest != nil {
+				delete(t, itemID(r.DeleteRequest.Key))
+			}
+		}
+	}
+	return out, nil
+}
+
+var (
+	conditionFuncRE = regexp.MustCompile(`^(attribute_not_exists|attribute_exists)\((\S+)\)$`)
+	conditionCmpRE  = regexp.MustCompile(`^(\S+)\s*(<>|<=|>=|=|<|>)\s*(\S+)$`)
+)
+
+// evalCondition evaluates simple condition expressions consisting of
+// attribute_exists/attribute_not_exists functions and comparisons, joined by
+// "and" and "or" (without parentheses).
+// project returns the attributes of an item listed in a projection
+// expression of the form "#a, #b".
+func project(expr string, item map[string]*dynamodb.AttributeValue, names map[string]*string) map[string]*dynamodb.AttributeValue {
+	projected := make(map[string]*dynamodb.AttributeValue)
+	for _, name := range strings.Split(expr, ",") {
+		name = strings.TrimSpace(name)
+		if n, ok := names[name]; ok {
+			name = aws.StringValue(n)
+		}
+		if av, ok := item[name]; ok {
+			projected[name] = av
+		}
+	}
+	return projected
+}
+
+// evalContains evaluates a filter expression of the form
+// "contains(#attr, :value)" against a list or string set attribute.
+func evalContains(expr string, item map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue) bool {
+	m := regexp.MustCompile(`^contains\((#\w+), (:\w+)\)$`).FindStringSubmatch(expr)
+	if m == nil {
+		panic("unsupported filter expression: " + expr)
+	}
+	attr, want := item[aws.StringValue(names[m[1]])], aws.StringValue(values[m[2]].S)
+	if attr == nil {
+		return false
+	}
+	for _, s := range attr.SS {
+		if aws.StringValue(s) == want {
+			return true
+		}
+	}
+	for _, v := range attr.L {
+		if aws.StringValue(v.S) == want {
+			return true
+		}
+	}
+	return false
+}
+
+func evalCondition(expr string, item map[string]*dynamodb.AttributeValue, names map[string]*string, values map[string]*dynamodb.AttributeValue) bool {
+	resolve := func(operand string) *dynamodb.AttributeValue {
+		if strings.HasPrefix(operand, ":") {
+			return values[operand]
+		}
+		if strings.HasPrefix(operand, "#") {
+			operand = aws.StringValue(names[operand])
+		}
+		return item[operand]
+	}
+
+	for _, disjunct := range strings.Split(expr, " or ") {
+		matched := true
+		for _, term := range strings.Split(disjunct, " and ") {
+			term = strings.TrimSpace(term)
+			if m := conditionFuncRE.FindStringSubmatch(term); m != nil {
+				exists := resolve(m[2]) != nil
+				matched = matched && (exists == (m[1] == "attribute_exists"))
+			} else if m := conditionCmpRE.FindStringSubmatch(term); m != nil {
+				matched = matched && compare(resolve(m[1]), m[2], resolve(m[3]))
+			} else {
+				panic("unsupported condition: " + term)
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(a *dynamodb.AttributeValue, op string, b *dynamodb.AttributeValue) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	var cmp int
+	if a.N != nil && b.N != nil {
+		x, _ := strconv.ParseFloat(*a.N, 64)
+		y, _ := strconv.ParseFloat(*b.N, 64)
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(aws.StringValue(a.S), aws.StringValue(b.S))
+	}
+	switch op {
+	case "=":
+		return cmp == 0
+	case "<>":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
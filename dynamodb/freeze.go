@@ -0,0 +1,131 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+const frozenKey = "frozen"
+
+// Freeze pins the stored state of the given items: Init, Upsert, and Delete
+// leave them untouched until they are unfrozen, e.g. to keep a flag as it is
+// during an incident even if someone changes it in LaunchDarkly. The freeze
+// list is a metadata item in the table, and only honored by stores with
+// CheckFrozen set.
+func (store *DynamoDBFeatureStore) Freeze(ctx context.Context, kind ld.VersionedDataKind, keys ...string) error {
+	return store.modifyFrozen(ctx, func(frozen map[string][]string) {
+		ns := kind.GetNamespace()
+		frozen[ns] = mergeKeys(frozen[ns], keys)
+	})
+}
+
+// Unfreeze removes items from the freeze list, so that the next sync
+// updates them again.
+func (store *DynamoDBFeatureStore) Unfreeze(ctx context.Context, kind ld.VersionedDataKind, keys ...string) error {
+	return store.modifyFrozen(ctx, func(frozen map[string][]string) {
+		ns := kind.GetNamespace()
+		frozen[ns] = removeKeys(frozen[ns], keys)
+	})
+}
+
+// FrozenKeys returns the keys of frozen items by namespace, e.g.
+// {"features":["my-flag"]}, including those of the Frozen setting.
+func (store *DynamoDBFeatureStore) FrozenKeys(ctx context.Context) (map[string][]string, error) {
+	frozen := make(map[string][]string)
+	for _, id := range store.Frozen {
+		ns, key := ld.Features.GetNamespace(), id
+		if i := strings.Index(id, "/"); i >= 0 {
+			ns, key = id[:i], id[i+1:]
+		}
+		frozen[ns] = mergeKeys(frozen[ns], []string{key})
+	}
+	if !store.CheckFrozen {
+		return frozen, nil
+	}
+
+	stored, err := store.storedFrozenKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for ns, keys := range stored {
+		frozen[ns] = mergeKeys(frozen[ns], keys)
+	}
+	return frozen, nil
+}
+
+func (store *DynamoDBFeatureStore) storedFrozenKeys(ctx context.Context) (map[string][]string, error) {
+	result, err := store.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            rawKey(metadataNamespace, frozenKey),
+	})
+	store.observe(getRequest, err)
+	if err != nil {
+		return nil, err
+	}
+
+	frozen := make(map[string][]string)
+	if av := result.Item["keys"]; av != nil {
+		for ns, keys := range av.M {
+			frozen[ns] = aws.StringValueSlice(keys.SS)
+		}
+	}
+	return frozen, nil
+}
+
+// modifyFrozen applies a change to the stored freeze list. Freezes are
+// manual operations, so this is a plain read-modify-write.
+func (store *DynamoDBFeatureStore) modifyFrozen(ctx context.Context, change func(map[string][]string)) error {
+	frozen, err := store.storedFrozenKeys(ctx)
+	if err != nil {
+		return err
+	}
+	change(frozen)
+
+	keys := make(map[string]*dynamodb.AttributeValue, len(frozen))
+	for ns, k := range frozen {
+		// String sets must not be empty
+		if len(k) > 0 {
+			keys[ns] = &dynamodb.AttributeValue{SS: aws.StringSlice(k)}
+		}
+	}
+	if len(keys) == 0 {
+		_, err = store.Client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(store.Table),
+			Key:       rawKey(metadataNamespace, frozenKey),
+		})
+		store.observe(writeRequest, err)
+		return err
+	}
+
+	item := rawKey(metadataNamespace, frozenKey)
+	item["keys"] = &dynamodb.AttributeValue{M: keys}
+	_, err = store.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item:      item,
+	})
+	store.observe(writeRequest, err)
+	return err
+}
+
+// isFrozen reports whether an item must be left untouched by writes.
+func (store *DynamoDBFeatureStore) isFrozen(kind ld.VersionedDataKind, key string) (bool, error) {
+	if len(store.Frozen) == 0 && !store.CheckFrozen {
+		return false, nil
+	}
+	frozen, err := store.FrozenKeys(context.Background())
+	if err != nil {
+		return false, err
+	}
+	for _, k := range frozen[kind.GetNamespace()] {
+		if k == key {
+			store.Logger.Printf("WARN: Not updating frozen %s %q", kind.GetNamespace(), key)
+			return true, nil
+		}
+	}
+	return false, nil
+}
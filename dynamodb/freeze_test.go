@@ -0,0 +1,86 @@
+package dynamodb_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestFreeze(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.CheckFrozen = true
+	ctx := context.Background()
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"frozen": &ld.FeatureFlag{Key: "frozen", Version: 1},
+			"other":  &ld.FeatureFlag{Key: "other", Version: 1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Freeze(ctx, ld.Features, "frozen"); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := store.FrozenKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"features": {"frozen"}}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got frozen keys %v, want %v", keys, want)
+	}
+
+	// Neither updates nor deletions of frozen items are stored
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"other": &ld.FeatureFlag{Key: "other", Version: 2}},
+	})
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Frozen[ld.Features], []string{"frozen"}) {
+		t.Errorf("got frozen items %v in report", report.Frozen)
+	}
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "frozen", Version: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if item, _ := store.Get(ld.Features, "frozen"); item == nil || item.GetVersion() != 1 {
+		t.Errorf("frozen flag changed: %+v", item)
+	}
+	if item, _ := store.Get(ld.Features, "other"); item == nil || item.GetVersion() != 2 {
+		t.Errorf("other flag not updated: %+v", item)
+	}
+
+	if err := store.Unfreeze(ctx, ld.Features, "frozen"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "frozen", Version: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if item, _ := store.Get(ld.Features, "frozen"); item == nil || item.GetVersion() != 3 {
+		t.Errorf("unfrozen flag not updated: %+v", item)
+	}
+}
+
+func TestFrozenSetting(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Upsert(ld.Segments, &ld.Segment{Key: "beta", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	store.Frozen = []string{"my-flag", "segments/beta"}
+
+	keys, err := store.FrozenKeys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"features": {"my-flag"}, "segments": {"beta"}}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got frozen keys %v, want %v", keys, want)
+	}
+	if err := store.Delete(ld.Segments, "beta", 2); err != nil {
+		t.Fatal(err)
+	}
+	if item, _ := store.Get(ld.Segments, "beta"); item == nil {
+		t.Error("frozen segment was deleted")
+	}
+}
@@ -0,0 +1,114 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ErrConcurrentInit is reported for the kinds that an Init didn't write
+// because another Init started in the meantime.
+var ErrConcurrentInit = errors.New("aborted because another Init started")
+
+// Each Init increments a generation counter in the metadata namespace. This
+// way, two overlapping Inits, e.g. of two sync Lambdas triggered at the same
+// time, detect each other: the older one aborts before writing the next batch
+// of items or deleting items that the newer one may have written.
+const (
+	generationKey       = "generation"
+	generationAttribute = "generation"
+)
+
+// readGeneration returns the current generation, or zero if there was no Init
+// yet.
+func (store *DynamoDBFeatureStore) readGeneration() (int64, error) {
+	result, err := store.Client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            rawKey(metadataNamespace, generationKey),
+	})
+	store.observe(getRequest, err)
+	if err != nil {
+		return 0, err
+	}
+	av, ok := result.Item[generationAttribute]
+	if !ok || av.N == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(*av.N, 10, 64)
+}
+
+// startGeneration claims the next generation for an Init and records the
+// sync lock (see SyncLock). It fails with ErrConcurrentInit if another Init
+// claimed it first.
+func (store *DynamoDBFeatureStore) startGeneration() (int64, error) {
+	current, err := store.readGeneration()
+	if err != nil {
+		return 0, err
+	}
+	next := current + 1
+
+	item := lockAttributes(time.Now())
+	item[tablePartitionKey] = &dynamodb.AttributeValue{S: aws.String(metadataNamespace)}
+	item[tableSortKey] = &dynamodb.AttributeValue{S: aws.String(generationKey)}
+	item[generationAttribute] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(next, 10))}
+
+	_, err = store.Client.PutItem(&dynamodb.PutItemInput{
+		TableName:           aws.String(store.Table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#generation) or #generation = :current"),
+		ExpressionAttributeNames: map[string]*string{
+			"#generation": aws.String(generationAttribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":current": {N: aws.String(strconv.FormatInt(current, 10))},
+		},
+	})
+	store.observe(writeRequest, err)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return 0, ErrConcurrentInit
+	}
+	return next, err
+}
+
+// checkGeneration fails with ErrConcurrentInit if another Init has started
+// since the given generation was claimed.
+func (store *DynamoDBFeatureStore) checkGeneration(generation int64) error {
+	current, err := store.readGeneration()
+	if err != nil {
+		return err
+	}
+	if current != generation {
+		return ErrConcurrentInit
+	}
+	return nil
+}
+
+// Generation returns a counter that is incremented with every change of the
+// data, i.e. every Init, Upsert, and Delete, or zero if there was none yet.
+// Reading it costs a single read unit, so consumers can poll it cheaply to
+// decide whether to refresh their caches.
+//
+// Unlike the generation claimed by Init, which only guards against
+// overlapping Inits, it's stored with the last sync time.
+func (store *DynamoDBFeatureStore) Generation(ctx context.Context) (int64, error) {
+	result, err := store.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            rawKey(metadataNamespace, lastSyncedKey),
+	})
+	store.observe(getRequest, err)
+	if err != nil {
+		return 0, err
+	}
+	av, ok := result.Item[generationAttribute]
+	if !ok || av.N == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(*av.N, 10, 64)
+}
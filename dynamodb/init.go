@@ -0,0 +1,486 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// InitReport describes the outcome of InitWithReport for each data kind.
+type InitReport struct {
+	// Kinds whose data was replaced completely
+	Succeeded []ld.VersionedDataKind
+
+	// Kinds whose data couldn't be replaced, and why
+	Failed map[ld.VersionedDataKind]error
+
+	// Number of items written per kind
+	Written map[ld.VersionedDataKind]int
+
+	// Number of stale items deleted per kind
+	Deleted map[ld.VersionedDataKind]int
+
+	// Keys of items whose stored content differs from the data passed to
+	// Init, per kind (only set if VerifyInit is enabled)
+	Divergent map[ld.VersionedDataKind][]string
+
+	// Keys of frozen items that were left untouched, per kind (see Freeze)
+	Frozen map[ld.VersionedDataKind][]string
+
+	// Keys of invalid flags (only if ValidateFlags is enabled) and of
+	// RejectedItems that weren't written, per kind, leaving their stored
+	// versions untouched
+	Rejected map[ld.VersionedDataKind][]string
+
+	// Time spent per kind, broken down by phase
+	Durations map[ld.VersionedDataKind]InitDurations
+
+	// Guards the maps while kinds are initialized concurrently
+	mu sync.Mutex
+}
+
+// InitDurations breaks down the time Init spent on a data kind. Phases of
+// failed attempts that were retried are included.
+type InitDurations struct {
+	// Reading the keys of existing items
+	Scan time.Duration
+
+	// Converting items to DynamoDB attribute values
+	Marshal time.Duration
+
+	// Writing items in batches
+	Write time.Duration
+
+	// Deleting stale items in batches
+	Delete time.Duration
+
+	// Re-reading items (only if VerifyInit is enabled)
+	Verify time.Duration
+
+	// All of the above, plus backoff between retries
+	Total time.Duration
+}
+
+func (d *InitDurations) add(other InitDurations) {
+	d.Scan += other.Scan
+	d.Marshal += other.Marshal
+	d.Write += other.Write
+	d.Delete += other.Delete
+	d.Verify += other.Verify
+	d.Total += other.Total
+}
+
+// addDurations adds the durations of an attempt to those of the kind.
+func (r *InitReport) addDurations(kind ld.VersionedDataKind, d InitDurations) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := r.Durations[kind]
+	total.add(d)
+	r.Durations[kind] = total
+}
+
+// Err returns an *InitError summarizing all failed kinds, or nil if all
+// kinds succeeded.
+func (r *InitReport) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return &InitError{Failed: r.Failed}
+}
+
+// InitError is returned by Init if some data kinds couldn't be initialized.
+// It keeps the error of each kind, so that callers can tell transient
+// failures like throttling from permanent ones.
+type InitError struct {
+	// Kinds that couldn't be initialized, and why
+	Failed map[ld.VersionedDataKind]error
+}
+
+func (e *InitError) Error() string {
+	var msgs []string
+	for kind, err := range e.Failed {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", kind.GetNamespace(), err))
+	}
+	sort.Strings(msgs)
+	return fmt.Sprintf("failed to initialize %d kind(s): %s", len(e.Failed), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failed kinds, ordered by namespace.
+func (e *InitError) Unwrap() []error {
+	kinds := make([]ld.VersionedDataKind, 0, len(e.Failed))
+	for kind := range e.Failed {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].GetNamespace() < kinds[j].GetNamespace()
+	})
+	errs := make([]error, len(kinds))
+	for i, kind := range kinds {
+		errs[i] = e.Failed[kind]
+	}
+	return errs
+}
+
+// FailedData returns the subset of allData belonging to failed kinds, which
+// can be passed to InitWithReport again to retry only those kinds.
+func (r *InitReport) FailedData(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) map[ld.VersionedDataKind]map[string]ld.VersionedData {
+	failed := make(map[ld.VersionedDataKind]map[string]ld.VersionedData, len(r.Failed))
+	for kind := range r.Failed {
+		failed[kind] = allData[kind]
+	}
+	return failed
+}
+
+// Init initializes the store by writing the given data to DynamoDB. Items of
+// the given kinds that aren't part of the data are deleted from the table.
+//
+// See InitWithReport for details on how failures are handled.
+func (store *DynamoDBFeatureStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	report := store.InitWithReport(allData)
+	return report.Err()
+}
+
+// InitWithReport works like Init but returns a report of which data kinds
+// were initialized successfully.
+//
+// Each kind is replaced independently, and up to InitConcurrency kinds at
+// the same time: new items are written first, then stale items are deleted.
+// This way, a kind is never left empty, even if writing it fails halfway.
+// Failed kinds are retried up to InitRetries times. The store is marked as
+// initialized only if all kinds succeeded; to resume after a failure, pass
+// report.FailedData(allData) to this method again.
+//
+// If another Init starts while this one is running, this one stops and
+// reports the remaining kinds as failed with ErrConcurrentInit.
+//
+// Kinds that aren't part of allData or excluded by the Kinds setting are left
+// untouched.
+func (store *DynamoDBFeatureStore) InitWithReport(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) *InitReport {
+	report := &InitReport{
+		Failed:    make(map[ld.VersionedDataKind]error),
+		Written:   make(map[ld.VersionedDataKind]int),
+		Deleted:   make(map[ld.VersionedDataKind]int),
+		Divergent: make(map[ld.VersionedDataKind][]string),
+		Frozen:    make(map[ld.VersionedDataKind][]string),
+		Rejected:  make(map[ld.VersionedDataKind][]string),
+		Durations: make(map[ld.VersionedDataKind]InitDurations),
+	}
+
+	allData = store.rejectInvalidItems(allData, report)
+
+	frozen, err := store.FrozenKeys(context.Background())
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to read frozen items: %s", err)
+		for kind := range allData {
+			if store.storesKind(kind) {
+				report.Failed[kind] = err
+			}
+		}
+		return report
+	}
+
+	generation, err := store.startGeneration()
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to start initialization: %s", err)
+		for kind := range allData {
+			if store.storesKind(kind) {
+				report.Failed[kind] = err
+			}
+		}
+		return report
+	}
+
+	concurrency := store.InitConcurrency
+	if concurrency <= 0 {
+		concurrency = len(allData)
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for kind, items := range allData {
+		if !store.storesKind(kind) {
+			store.Logger.Printf("DEBUG: Skipping initialization of %q items", kind.GetNamespace())
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(kind ld.VersionedDataKind, items map[string]ld.VersionedData) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			store.initKindWithRetries(kind, items, frozen[kind.GetNamespace()], generation, report)
+		}(kind, items)
+	}
+	wg.Wait()
+
+	if err := store.releaseGeneration(generation); err != nil {
+		store.Logger.Printf("WARN: Failed to release sync lock: %s", err)
+	}
+
+	// Report kinds in a stable order regardless of which finished first
+	sort.Slice(report.Succeeded, func(i, j int) bool {
+		return report.Succeeded[i].GetNamespace() < report.Succeeded[j].GetNamespace()
+	})
+
+	store.itemCache().invalidate()
+
+	if len(report.Succeeded) > 0 {
+		if err := store.touchLastSynced(); err != nil {
+			store.Logger.Printf("ERROR: Failed to update sync metadata: %s", err)
+			for _, kind := range report.Succeeded {
+				report.Failed[kind] = err
+			}
+			report.Succeeded = nil
+		}
+	}
+
+	if store.DeadLetters {
+		if err := store.updateDeadLetter(report, allData); err != nil {
+			store.Logger.Printf("WARN: Failed to update dead letter: %s", err)
+		}
+	}
+
+	if len(report.Failed) > 0 {
+		return report
+	}
+
+	total := 0
+	for _, n := range report.Written {
+		total += n
+	}
+	store.Logger.Printf("INFO: Initialized table %q with %d item(s)", store.Table, total)
+
+	store.setInitialized()
+
+	return report
+}
+
+// initKindWithRetries initializes a kind, retrying failures up to
+// InitRetries times, and records the outcome in the report.
+func (store *DynamoDBFeatureStore) initKindWithRetries(kind ld.VersionedDataKind, items map[string]ld.VersionedData, frozen []string, generation int64, report *InitReport) {
+	start := time.Now()
+	defer func() {
+		report.addDurations(kind, InitDurations{Total: time.Since(start)})
+	}()
+
+	var err error
+	for attempt := 0; attempt <= store.InitRetries; attempt++ {
+		if err == ErrConcurrentInit {
+			break
+		}
+		if attempt > 0 {
+			delay := batchBackoff(attempt)
+			store.Logger.Printf("WARN: Retrying initialization of %q items in %s after error: %s",
+				kind.GetNamespace(), delay, err)
+			time.Sleep(delay)
+		}
+		if err = store.initKind(kind, items, frozen, generation, report); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to initialize %q items: %s", kind.GetNamespace(), err)
+		report.mu.Lock()
+		report.Failed[kind] = err
+		report.mu.Unlock()
+		return
+	}
+	report.mu.Lock()
+	report.Succeeded = append(report.Succeeded, kind)
+	report.mu.Unlock()
+
+	if store.VerifyInit {
+		verifyStart := time.Now()
+		if err := store.verifyKind(kind, items, report); err != nil {
+			store.Logger.Printf("WARN: Failed to verify %q items: %s", kind.GetNamespace(), err)
+		}
+		report.addDurations(kind, InitDurations{Verify: time.Since(verifyStart)})
+	}
+}
+
+// initKind replaces all items of a kind with the given ones, except for
+// frozen and rejected ones. It aborts if another Init has started since the
+// given generation was claimed.
+func (store *DynamoDBFeatureStore) initKind(kind ld.VersionedDataKind, items map[string]ld.VersionedData, frozen []string, generation int64, report *InitReport) error {
+	if err := store.checkGeneration(generation); err != nil {
+		return err
+	}
+
+	isFrozen := make(map[string]bool, len(frozen))
+	for _, key := range frozen {
+		isFrozen[key] = true
+	}
+	if len(frozen) > 0 {
+		store.Logger.Printf("WARN: Not updating %d frozen %q item(s): %v", len(frozen), kind.GetNamespace(), frozen)
+		report.mu.Lock()
+		report.Frozen[kind] = frozen
+		report.mu.Unlock()
+	}
+
+	// Rejected items aren't deleted either, so the stored versions are kept
+	report.mu.Lock()
+	for _, key := range report.Rejected[kind] {
+		isFrozen[key] = true
+	}
+	report.mu.Unlock()
+
+	var durations InitDurations
+	defer func() { report.addDurations(kind, durations) }()
+
+	start := time.Now()
+	existing, err := store.queryVersions(kind.GetNamespace())
+	durations.Scan = time.Since(start)
+	if err != nil {
+		return fmt.Errorf("failed to get existing keys: %w", err)
+	}
+
+	start = time.Now()
+	var puts []*dynamodb.WriteRequest
+	for k, v := range items {
+		if isFrozen[k] {
+			continue
+		}
+		av, err := store.marshalItem(kind, v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal item (key=%s): %w", k, err)
+		}
+		puts = append(puts, &dynamodb.WriteRequest{
+			PutRequest: &dynamodb.PutRequest{Item: av},
+		})
+	}
+	durations.Marshal = time.Since(start)
+
+	// Overwriting items that a newer Init has written would lose data, so
+	// the generation is checked before each batch
+	start = time.Now()
+	err = store.batchWriteRequests(puts, func() error {
+		return store.checkGeneration(generation)
+	})
+	durations.Write = time.Since(start)
+	if err == ErrConcurrentInit {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %d item(s) in batches: %w", len(puts), err)
+	}
+	report.mu.Lock()
+	report.Written[kind] = len(puts)
+	report.mu.Unlock()
+
+	// Stale items are replaced with tombstones, rather than deleted, so that
+	// an item updated since the query is kept
+	deletes := make(map[string]int)
+	for key, v := range existing {
+		if _, ok := items[key]; ok || isFrozen[key] || v.deleted {
+			continue
+		}
+		deletes[key] = v.version + 1
+	}
+
+	// Deleting items that a newer Init has written would lose data
+	if err := store.checkGeneration(generation); err != nil {
+		return err
+	}
+
+	start = time.Now()
+	deleted, err := store.deleteAll(kind, deletes)
+	durations.Delete = time.Since(start)
+	if err != nil {
+		return fmt.Errorf("failed to delete %d stale item(s): %w", len(deletes), err)
+	}
+	report.mu.Lock()
+	report.Deleted[kind] = deleted
+	report.mu.Unlock()
+
+	return nil
+}
+
+// storedVersion is the version of an item in the table.
+type storedVersion struct {
+	version int
+	deleted bool
+}
+
+// queryVersions returns the versions of all items of the given namespace.
+func (store *DynamoDBFeatureStore) queryVersions(namespace string) (map[string]storedVersion, error) {
+	versions := make(map[string]storedVersion)
+
+	err := store.Client.QueryPages(&dynamodb.QueryInput{
+		TableName:            aws.String(store.Table),
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("#key, #version, #deleted"),
+		ExpressionAttributeNames: map[string]*string{
+			"#key":     aws.String(tableSortKey),
+			"#version": aws.String("version"),
+			"#deleted": aws.String("deleted"),
+		},
+		KeyConditions: map[string]*dynamodb.Condition{
+			tablePartitionKey: {
+				ComparisonOperator: aws.String("EQ"),
+				AttributeValueList: []*dynamodb.AttributeValue{
+					{S: aws.String(namespace)},
+				},
+			},
+		},
+	}, func(out *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range out.Items {
+			av, ok := item[tableSortKey]
+			if !ok || av.S == nil {
+				continue
+			}
+			var v storedVersion
+			if n := item["version"]; n != nil && n.N != nil {
+				v.version, _ = strconv.Atoi(*n.N)
+			}
+			if d := item["deleted"]; d != nil {
+				v.deleted = aws.BoolValue(d.BOOL)
+			}
+			versions[*av.S] = v
+		}
+		return !lastPage
+	})
+	store.observe(queryRequest, err)
+
+	return versions, err
+}
+
+// queryKeys returns the keys of all items of the given namespace.
+func (store *DynamoDBFeatureStore) queryKeys(namespace string) ([]string, error) {
+	var keys []string
+
+	err := store.Client.QueryPages(&dynamodb.QueryInput{
+		TableName:            aws.String(store.Table),
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("#key"),
+		ExpressionAttributeNames: map[string]*string{
+			"#key": aws.String(tableSortKey),
+		},
+		KeyConditions: map[string]*dynamodb.Condition{
+			tablePartitionKey: {
+				ComparisonOperator: aws.String("EQ"),
+				AttributeValueList: []*dynamodb.AttributeValue{
+					{S: aws.String(namespace)},
+				},
+			},
+		},
+	}, func(out *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range out.Items {
+			if av, ok := item[tableSortKey]; ok && av.S != nil {
+				keys = append(keys, *av.S)
+			}
+		}
+		return !lastPage
+	})
+	store.observe(queryRequest, err)
+
+	return keys, err
+}
@@ -0,0 +1,309 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/fixtures"
+)
+
+func TestInitDeletesStaleItems(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"a": &ld.FeatureFlag{Key: "a", Version: 1},
+			"b": &ld.FeatureFlag{Key: "b", Version: 1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"a": &ld.FeatureFlag{Key: "a", Version: 2},
+		},
+	})
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if report.Written[ld.Features] != 1 || report.Deleted[ld.Features] != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 1 || flags["a"].GetVersion() != 2 {
+		t.Errorf("unexpected flags after Init: %v", flags)
+	}
+}
+
+func TestInitPartialFailure(t *testing.T) {
+	store, client := newTestStore(t)
+	store.InitRetries = 0
+
+	client.batchWriteErr = func(requests []*ddb.WriteRequest) error {
+		for _, r := range requests {
+			if r.PutRequest != nil && aws.StringValue(r.PutRequest.Item["namespace"].S) == ld.Segments.GetNamespace() {
+				return errors.New("boom")
+			}
+		}
+		return nil
+	}
+
+	allData := map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+		ld.Segments: {"segment": &ld.Segment{Key: "segment", Version: 1}},
+	}
+
+	report := store.InitWithReport(allData)
+	if report.Err() == nil {
+		t.Fatal("expected error")
+	}
+	if len(report.Succeeded) != 1 || report.Succeeded[0] != ld.Features {
+		t.Errorf("unexpected succeeded kinds: %v", report.Succeeded)
+	}
+	if _, ok := report.Failed[ld.Segments]; !ok {
+		t.Errorf("expected segments to fail: %v", report.Failed)
+	}
+	if store.Initialized() {
+		t.Error("store must not be initialized after partial failure")
+	}
+	if flag, _ := store.Get(ld.Features, "flag"); flag == nil {
+		t.Error("expected flags to be written despite failure of segments")
+	}
+
+	// Resume with only the failed kinds
+	client.batchWriteErr = nil
+	retryData := report.FailedData(allData)
+	if len(retryData) != 1 {
+		t.Fatalf("got %d kinds to retry, want 1", len(retryData))
+	}
+	if err := store.InitWithReport(retryData).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !store.Initialized() {
+		t.Error("expected store to be initialized after retry")
+	}
+}
+
+func TestInitReportsDurations(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.VerifyInit = true
+
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	})
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := report.Durations[ld.Features]
+	if !ok || d.Total <= 0 || d.Write <= 0 || d.Verify <= 0 || d.Total < d.Scan+d.Marshal+d.Write+d.Delete+d.Verify {
+		t.Errorf("got durations %+v", d)
+	}
+	if _, ok := report.Durations[ld.Segments]; ok {
+		t.Error("got durations for kind that wasn't initialized")
+	}
+}
+
+func TestInitRetries(t *testing.T) {
+	store, client := newTestStore(t)
+	store.InitRetries = 1
+
+	failures := 1
+	client.batchWriteErr = func([]*ddb.WriteRequest) error {
+		if failures > 0 {
+			failures--
+			return errors.New("boom")
+		}
+		return nil
+	}
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitSkipsExcludedKinds(t *testing.T) {
+	store, client := newTestStore(t)
+	store.Kinds = []ld.VersionedDataKind{ld.Features}
+
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+		ld.Segments: {"segment": &ld.Segment{Key: "segment", Version: 1}},
+	})
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Succeeded) != 1 || report.Succeeded[0] != ld.Features {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if !store.Initialized() {
+		t.Error("expected store to be initialized")
+	}
+
+	if err := store.Upsert(ld.Segments, &ld.Segment{Key: "other", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range client.sortedItems("test-table") {
+		if aws.StringValue(item["namespace"].S) == ld.Segments.GetNamespace() {
+			t.Errorf("unexpected segment in table: %v", item)
+		}
+	}
+}
+
+func TestParseKinds(t *testing.T) {
+	kinds, err := dynamodb.ParseKinds("features", "segments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kinds) != 2 || kinds[0] != ld.Features || kinds[1] != ld.Segments {
+		t.Errorf("unexpected kinds: %v", kinds)
+	}
+	if _, err := dynamodb.ParseKinds("flags"); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func TestInitAbortsOnConcurrentInit(t *testing.T) {
+	store, client := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"stale": &ld.FeatureFlag{Key: "stale", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another Init claims a new generation while this one writes its items
+	writes := client.count("BatchWriteItem")
+	client.batchWriteErr = func(requests []*ddb.WriteRequest) error {
+		generation := map[string]*ddb.AttributeValue{
+			"namespace":  {S: aws.String("$metadata")},
+			"key":        {S: aws.String("generation")},
+			"generation": {N: aws.String("99")},
+		}
+		client.table("test-table")[itemID(generation)] = generation
+		return nil
+	}
+
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"new": &ld.FeatureFlag{Key: "new", Version: 1}},
+	})
+	if err := report.Failed[ld.Features]; err != dynamodb.ErrConcurrentInit {
+		t.Fatalf("got error %v, want ErrConcurrentInit", err)
+	}
+	if n := client.count("BatchWriteItem") - writes; n != 1 {
+		t.Errorf("expected no retries after concurrent Init, got %d batch writes", n)
+	}
+
+	// The stale item must not have been deleted by the aborted Init
+	client.batchWriteErr = nil
+	if item, err := store.Get(ld.Features, "stale"); err != nil || item == nil {
+		t.Errorf("expected stale item to be kept, got %v, %v", item, err)
+	}
+}
+
+// interleavingClient runs a function after the first BatchWriteItem request,
+// e.g. another Init.
+type interleavingClient struct {
+	*fakeDynamoDB
+	once  sync.Once
+	after func()
+}
+
+func (c *interleavingClient) BatchWriteItem(in *ddb.BatchWriteItemInput) (*ddb.BatchWriteItemOutput, error) {
+	out, err := c.fakeDynamoDB.BatchWriteItem(in)
+	c.once.Do(c.after)
+	return out, err
+}
+
+func TestInterleavingInits(t *testing.T) {
+	older, client := newTestStore(t)
+	newer, _ := newTestStore(t)
+	newer.Client = client
+
+	flags := func(version int) map[ld.VersionedDataKind]map[string]ld.VersionedData {
+		items := make(map[string]ld.VersionedData)
+		for i := 0; i < 60; i++ {
+			key := fmt.Sprintf("flag-%02d", i)
+			items[key] = &ld.FeatureFlag{Key: key, Version: version}
+		}
+		return map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: items}
+	}
+
+	// The newer Init runs to completion after the older one wrote its first
+	// batch, which must stop the older one from writing the other batches
+	var newerErr error
+	older.Client = &interleavingClient{fakeDynamoDB: client, after: func() {
+		newerErr = newer.Init(flags(2))
+	}}
+	report := older.InitWithReport(flags(1))
+	if newerErr != nil {
+		t.Fatal(newerErr)
+	}
+	if err := report.Failed[ld.Features]; err != dynamodb.ErrConcurrentInit {
+		t.Fatalf("got error %v, want ErrConcurrentInit", err)
+	}
+
+	all, err := newer.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, item := range all {
+		if item.GetVersion() != 2 {
+			t.Errorf("%s: got version %d written by older Init", key, item.GetVersion())
+		}
+	}
+	if len(all) != 60 {
+		t.Errorf("got %d flags, want 60", len(all))
+	}
+}
+
+func TestInitConcurrency(t *testing.T) {
+	for _, concurrency := range []int{0, 1} {
+		store, _ := newTestStore(t)
+		store.InitConcurrency = concurrency
+
+		report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+			ld.Segments: {"s": &ld.Segment{Key: "s", Version: 1}},
+			ld.Features: {
+				"a": &ld.FeatureFlag{Key: "a", Version: 1},
+				"b": &ld.FeatureFlag{Key: "b", Version: 1},
+			},
+		})
+		if err := report.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Succeeded) != 2 || report.Succeeded[0] != ld.Features || report.Succeeded[1] != ld.Segments {
+			t.Errorf("concurrency %d: got succeeded kinds %v", concurrency, report.Succeeded)
+		}
+		if report.Written[ld.Features] != 2 || report.Written[ld.Segments] != 1 {
+			t.Errorf("concurrency %d: got written items %v", concurrency, report.Written)
+		}
+	}
+}
+
+// BenchmarkInit measures a full sync of a realistic environment into an empty
+// table.
+func BenchmarkInit(b *testing.B) {
+	allData := fixtures.Generate(fixtures.Options{Seed: 1, Flags: 500, Segments: 20}).AllData()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store := &dynamodb.DynamoDBFeatureStore{Client: newFakeDynamoDB(), Table: "test-table", Logger: discardLogger{}}
+		if err := store.Init(allData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
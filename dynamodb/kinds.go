@@ -0,0 +1,49 @@
+package dynamodb
+
+import (
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// NewFlagOnlyFeatureStore creates a store for consumers that only need flags.
+// It neither reads nor writes segments, so its IAM policy only needs access
+// to the "features" partition, e.g. with a dynamodb:LeadingKeys condition.
+// Flags with segment rules are still evaluated, but the segment rules never
+// match, as if the segments were empty.
+func NewFlagOnlyFeatureStore(table string, logger ld.Logger) (*DynamoDBFeatureStore, error) {
+	return newKindStore(table, logger, ld.Features)
+}
+
+// NewSegmentOnlyFeatureStore creates a store that neither reads nor writes
+// flags, e.g. for a process that syncs or inspects big segments separately
+// from the flags.
+func NewSegmentOnlyFeatureStore(table string, logger ld.Logger) (*DynamoDBFeatureStore, error) {
+	return newKindStore(table, logger, ld.Segments)
+}
+
+func newKindStore(table string, logger ld.Logger, kind ld.VersionedDataKind) (*DynamoDBFeatureStore, error) {
+	store, err := NewDynamoDBFeatureStore(table, logger)
+	if err != nil {
+		return nil, err
+	}
+	store.Kinds = []ld.VersionedDataKind{kind}
+	store.ReadKinds = []ld.VersionedDataKind{kind}
+	return store, nil
+}
+
+// readsKind reports whether items of the given kind are read according to
+// the ReadKinds setting. The first skipped read is logged, as it usually
+// means that a flag has a segment rule which can't match.
+func (store *DynamoDBFeatureStore) readsKind(kind ld.VersionedDataKind, key string) bool {
+	if len(store.ReadKinds) == 0 {
+		return true
+	}
+	for _, k := range store.ReadKinds {
+		if k.GetNamespace() == kind.GetNamespace() {
+			return true
+		}
+	}
+	store.skippedReadOnce.Do(func() {
+		store.Logger.Printf("WARN: Not reading %q items (key=%s), treating them as missing", kind.GetNamespace(), key)
+	})
+	return false
+}
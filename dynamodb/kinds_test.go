@@ -0,0 +1,76 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestReadKinds(t *testing.T) {
+	store, client := newTestStore(t)
+	store.ReadKinds = []ld.VersionedDataKind{ld.Features}
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+		ld.Segments: {"segment": &ld.Segment{Key: "segment", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	queries, gets := client.count("Query"), client.count("GetItem")
+
+	segments, err := store.All(ld.Segments)
+	if err != nil || len(segments) != 0 {
+		t.Errorf("got segments %v and error %v, want none", segments, err)
+	}
+	if segment, err := store.Get(ld.Segments, "segment"); err != nil || segment != nil {
+		t.Errorf("got segment %v and error %v, want none", segment, err)
+	}
+	if client.count("Query") != queries || client.count("GetItem") != gets {
+		t.Error("expected segments not to be read from DynamoDB")
+	}
+
+	if flag, err := store.Get(ld.Features, "flag"); err != nil || flag == nil {
+		t.Errorf("got flag %v and error %v", flag, err)
+	}
+}
+
+func TestFlagOnlyEvaluation(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	on, off := 0, 1
+	flag := &ld.FeatureFlag{
+		Key:         "flag",
+		Version:     1,
+		On:          true,
+		Variations:  []interface{}{true, false},
+		Fallthrough: ld.VariationOrRollout{Variation: &off},
+		Rules: []ld.Rule{{
+			VariationOrRollout: ld.VariationOrRollout{Variation: &on},
+			Clauses:            []ld.Clause{{Op: ld.OperatorSegmentMatch, Values: []interface{}{"beta"}}},
+		}},
+	}
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": flag},
+		ld.Segments: {"beta": &ld.Segment{Key: "beta", Version: 1, Included: []string{"user"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	store.ReadKinds = []ld.VersionedDataKind{ld.Features}
+
+	config := ld.DefaultConfig
+	config.FeatureStore = store
+	config.UseLdd = true
+	config.SendEvents = false
+	client, err := ld.MakeCustomClient("sdk-key", config, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// The segment includes the user, but isn't read, so the user falls
+	// through
+	value, err := client.BoolVariation("flag", ld.NewUser("user"), true)
+	if err != nil || value {
+		t.Errorf("got %v, %v, want fallthrough value false", value, err)
+	}
+}
@@ -0,0 +1,39 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Storage layout, exported for tools that access the table directly, e.g.
+// Step Functions or programs written in other languages. All data kinds live
+// in the same table. Each item is keyed by the namespace of its kind, e.g.
+// "features" or "segments", and its key. The attributes of an item are those
+// of its JSON representation. Since each kind has its own partition, reading
+// all items of a kind is a Query, never a Scan of the whole table.
+const (
+	// Name of the partition key attribute, which holds the namespace
+	PartitionKeyAttribute = tablePartitionKey
+
+	// Name of the sort key attribute, which holds the item key
+	SortKeyAttribute = tableSortKey
+)
+
+// TableName returns the name of the table that stores items of the given
+// kind.
+func (store *DynamoDBFeatureStore) TableName(kind ld.VersionedDataKind) string {
+	return store.Table
+}
+
+// ItemKey returns the primary key of an item.
+func ItemKey(kind ld.VersionedDataKind, key string) map[string]*dynamodb.AttributeValue {
+	return rawKey(kind.GetNamespace(), key)
+}
+
+func rawKey(namespace, key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		tablePartitionKey: {S: aws.String(namespace)},
+		tableSortKey:      {S: aws.String(key)},
+	}
+}
@@ -0,0 +1,55 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestItemKey(t *testing.T) {
+	store, client := newTestStore(t)
+	if err := store.Upsert(ld.Segments, &ld.Segment{Key: "beta", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	key := dynamodb.ItemKey(ld.Segments, "beta")
+	if ns := aws.StringValue(key[dynamodb.PartitionKeyAttribute].S); ns != "segments" {
+		t.Errorf("got partition key %q, want segments", ns)
+	}
+	if k := aws.StringValue(key[dynamodb.SortKeyAttribute].S); k != "beta" {
+		t.Errorf("got sort key %q, want beta", k)
+	}
+
+	// The key must address the item written by the store
+	if _, ok := client.table(store.TableName(ld.Segments))[itemID(key)]; !ok {
+		t.Error("item not found by exported key")
+	}
+}
+
+func TestAllQueriesNamespacePartition(t *testing.T) {
+	store, client := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+		ld.Segments: {"beta": &ld.Segment{Key: "beta", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := client.count("Query")
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 1 {
+		t.Errorf("got %d flags, want 1", len(flags))
+	}
+	if n := client.count("Query") - queries; n != 1 {
+		t.Errorf("got %d queries, want 1", n)
+	}
+	if n := client.count("Scan"); n != 0 {
+		t.Errorf("got %d scans, want none", n)
+	}
+}
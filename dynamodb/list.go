@@ -0,0 +1,75 @@
+package dynamodb
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// ListOptions selects the items returned by List. Both filters are evaluated
+// by DynamoDB, so unmatched items aren't transferred.
+type ListOptions struct {
+	// Only items whose key starts with this prefix. As the key is the sort
+	// key, unmatched items aren't even read.
+	Prefix string
+
+	// Only items with this value in their "tags" attribute. Items only have
+	// tags if they are stored as RawItems and LaunchDarkly sent them.
+	Tag string
+}
+
+// List returns the items of the given kind that match the options, ordered
+// by key. Deleted items are left out. Like All, it is subject to the read
+// budget, but it bypasses the cache and overrides.
+func (store *DynamoDBFeatureStore) List(kind ld.VersionedDataKind, opts ListOptions) ([]ld.VersionedData, error) {
+	input := &dynamodb.QueryInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		KeyConditions: map[string]*dynamodb.Condition{
+			tablePartitionKey: {
+				ComparisonOperator: aws.String("EQ"),
+				AttributeValueList: []*dynamodb.AttributeValue{
+					{S: aws.String(kind.GetNamespace())},
+				},
+			},
+		},
+	}
+	if opts.Prefix != "" {
+		input.KeyConditions[tableSortKey] = &dynamodb.Condition{
+			ComparisonOperator: aws.String("BEGINS_WITH"),
+			AttributeValueList: []*dynamodb.AttributeValue{
+				{S: aws.String(opts.Prefix)},
+			},
+		}
+	}
+	if opts.Tag != "" {
+		input.FilterExpression = aws.String("contains(#tags, :tag)")
+		input.ExpressionAttributeNames = map[string]*string{"#tags": aws.String("tags")}
+		input.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+			":tag": {S: aws.String(opts.Tag)},
+		}
+	}
+
+	raw, err := store.query(input, kind.GetNamespace())
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to list %q items: %s", kind.GetNamespace(), err)
+		return nil, err
+	}
+
+	items := make([]ld.VersionedData, 0, len(raw))
+	for _, av := range raw {
+		item, err := store.unmarshalItem(kind, av)
+		if err != nil {
+			return nil, err
+		}
+		if !item.IsDeleted() {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].GetKey() < items[j].GetKey()
+	})
+	return items, nil
+}
@@ -0,0 +1,60 @@
+package dynamodb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestList(t *testing.T) {
+	store, _ := newTestStore(t)
+	flags := map[string]ld.VersionedData{}
+	for key, data := range map[string]string{
+		"new-api":  `{"key":"new-api","version":1,"tags":["backend"]}`,
+		"new-ui":   `{"key":"new-ui","version":1,"tags":["frontend"]}`,
+		"old-ui":   `{"key":"old-ui","version":1,"tags":["frontend"]}`,
+		"new-beta": `{"key":"new-beta","version":2,"deleted":true}`,
+	} {
+		item := &dynamodb.RawItem{}
+		if err := json.Unmarshal([]byte(data), item); err != nil {
+			t.Fatal(err)
+		}
+		flags[key] = item
+	}
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: flags}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts dynamodb.ListOptions
+		want []string
+	}{
+		{dynamodb.ListOptions{}, []string{"new-api", "new-ui", "old-ui"}},
+		{dynamodb.ListOptions{Prefix: "new-"}, []string{"new-api", "new-ui"}},
+		{dynamodb.ListOptions{Tag: "frontend"}, []string{"new-ui", "old-ui"}},
+		{dynamodb.ListOptions{Prefix: "new-", Tag: "frontend"}, []string{"new-ui"}},
+	}
+	for _, tt := range tests {
+		items, err := store.List(ld.Features, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, item := range items {
+			keys = append(keys, item.GetKey())
+		}
+		if len(keys) != len(tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.opts, keys, tt.want)
+			continue
+		}
+		for i := range keys {
+			if keys[i] != tt.want[i] {
+				t.Errorf("%+v: got %v, want %v", tt.opts, keys, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// SyncLockTTL is how long an Init is considered in progress after it
+// started, which is the maximum run time of a Lambda function. A crashed
+// Init's lock expires after this time.
+const SyncLockTTL = 15 * time.Minute
+
+// SyncLock describes the Init currently in progress, as recorded with the
+// generation it claimed.
+//
+// The lock doesn't block other Inits: a newer Init takes over and the older
+// one aborts with ErrConcurrentInit. It shows which process is writing to
+// the table, e.g. to diagnose Lambdas that crashed or time out during a sync.
+type SyncLock struct {
+	// Process that started the Init, e.g. a Lambda log stream
+	Holder string `json:"holder"`
+
+	// Generation claimed by the Init
+	Generation int64 `json:"generation"`
+
+	// When the Init started
+	AcquiredAt time.Time `json:"acquiredAt"`
+
+	// When the lock is no longer considered held
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Expired reports whether the lock expired at the given time.
+func (l *SyncLock) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// lockHolder identifies this process in the sync lock.
+func lockHolder() string {
+	if stream := os.Getenv("AWS_LAMBDA_LOG_STREAM_NAME"); stream != "" {
+		return stream
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// Attributes of the lock, in Unix milliseconds. They are named after their
+// unit so that they can't be mistaken for the epoch seconds of an "expiresAt"
+// TTL, which would delete the generation with the lock.
+const (
+	lockAcquiredAttribute = "acquiredAtMillis"
+	lockExpiresAttribute  = "expiresAtMillis"
+)
+
+// lockAttributes returns the attributes recording a lock acquired now.
+func lockAttributes(now time.Time) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"holder":              {S: aws.String(lockHolder())},
+		lockAcquiredAttribute: {N: aws.String(strconv.FormatInt(unixMillis(now), 10))},
+		lockExpiresAttribute:  {N: aws.String(strconv.FormatInt(unixMillis(now.Add(SyncLockTTL)), 10))},
+	}
+}
+
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromMillis(av *dynamodb.AttributeValue) time.Time {
+	if av == nil || av.N == nil {
+		return time.Time{}
+	}
+	ms, _ := strconv.ParseInt(*av.N, 10, 64)
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// SyncLock returns the lock of the Init in progress, or nil if there is none.
+// Expired locks are returned as well so that crashed Inits can be detected.
+func (store *DynamoDBFeatureStore) SyncLock(ctx context.Context) (*SyncLock, error) {
+	result, err := store.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            rawKey(metadataNamespace, generationKey),
+	})
+	store.observe(getRequest, err)
+	if err != nil {
+		return nil, err
+	}
+
+	holder, ok := result.Item["holder"]
+	if !ok || holder.S == nil {
+		return nil, nil
+	}
+	lock := &SyncLock{
+		Holder:     *holder.S,
+		AcquiredAt: fromMillis(result.Item[lockAcquiredAttribute]),
+		ExpiresAt:  fromMillis(result.Item[lockExpiresAttribute]),
+	}
+	if av := result.Item[generationAttribute]; av != nil && av.N != nil {
+		lock.Generation, _ = strconv.ParseInt(*av.N, 10, 64)
+	}
+	return lock, nil
+}
+
+// ForceUnlock clears the lock left behind by an Init that didn't finish. An
+// Init still running isn't stopped by this, but its lock is no longer shown.
+func (store *DynamoDBFeatureStore) ForceUnlock(ctx context.Context) error {
+	generation, err := store.readGeneration()
+	if err != nil {
+		return err
+	}
+	return store.releaseGeneration(generation)
+}
+
+// releaseGeneration clears the lock of the given generation, unless another
+// Init has claimed a newer one in the meantime.
+func (store *DynamoDBFeatureStore) releaseGeneration(generation int64) error {
+	_, err := store.Client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			tablePartitionKey:   {S: aws.String(metadataNamespace)},
+			tableSortKey:        {S: aws.String(generationKey)},
+			generationAttribute: {N: aws.String(strconv.FormatInt(generation, 10))},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(#generation) or #generation = :generation"),
+		ExpressionAttributeNames: map[string]*string{
+			"#generation": aws.String(generationAttribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":generation": {N: aws.String(strconv.FormatInt(generation, 10))},
+		},
+	})
+	store.observe(writeRequest, err)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return nil
+	}
+	return err
+}
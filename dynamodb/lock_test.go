@@ -0,0 +1,54 @@
+package dynamodb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestSyncLock(t *testing.T) {
+	store, client := newTestStore(t)
+	ctx := context.Background()
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if lock, err := store.SyncLock(ctx); err != nil || lock != nil {
+		t.Fatalf("got lock %+v (err=%v) after Init, want none", lock, err)
+	}
+
+	// Simulate an Init that crashed after claiming a generation
+	acquired := time.Now().Add(-time.Hour)
+	client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("test-table"),
+		Item: map[string]*dynamodb.AttributeValue{
+			"namespace":        {S: aws.String("$metadata")},
+			"key":              {S: aws.String("generation")},
+			"generation":       {N: aws.String("2")},
+			"holder":           {S: aws.String("crashed-lambda")},
+			"acquiredAtMillis": {N: aws.String("1000")},
+			"expiresAtMillis":  {N: aws.String("2000")},
+		},
+	})
+	lock, err := store.SyncLock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock == nil || lock.Holder != "crashed-lambda" || lock.Generation != 2 || !lock.Expired(acquired) ||
+		!lock.ExpiresAt.Equal(time.Unix(2, 0)) {
+		t.Fatalf("unexpected lock: %+v", lock)
+	}
+
+	if err := store.ForceUnlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if lock, _ := store.SyncLock(ctx); lock != nil {
+		t.Errorf("got lock %+v after ForceUnlock, want none", lock)
+	}
+}
@@ -0,0 +1,58 @@
+package dynamodb
+
+import (
+	"sync"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// MinFlagsCheckInterval is how long Initialized relies on the last count of
+// flags if MinFlags is set. Flags are counted by reading them with All, which
+// is served from the cache if CacheRefreshInterval is set.
+const MinFlagsCheckInterval = 30 * time.Second
+
+// flagCounter remembers the last count of flags in the table.
+type flagCounter struct {
+	mu        sync.Mutex
+	count     int
+	counted   bool
+	checkedAt time.Time
+}
+
+// hasMinFlags reports whether the table holds at least MinFlags flags. If
+// the flags can't be read, the last count is used, so that a transient error
+// doesn't make consumers fall back to default values.
+func (store *DynamoDBFeatureStore) hasMinFlags() bool {
+	c := &store.flags
+	c.mu.Lock()
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < MinFlagsCheckInterval {
+		defer c.mu.Unlock()
+		return c.counted && c.count >= store.MinFlags
+	}
+	c.checkedAt = time.Now()
+
+	items, err := store.All(ld.Features)
+	if err != nil {
+		defer c.mu.Unlock()
+		store.Logger.Printf("WARN: Failed to count flags: %s", err)
+		return c.counted && c.count >= store.MinFlags
+	}
+
+	wasEnough := !c.counted || c.count >= store.MinFlags
+	c.count, c.counted = len(items), true
+	count := c.count
+	c.mu.Unlock()
+
+	enough := count >= store.MinFlags
+	if !enough && wasEnough {
+		store.Logger.Printf("ERROR: Table %q holds only %d flag(s), expected at least %d; reporting store as uninitialized",
+			store.Table, count, store.MinFlags)
+		if store.OnTooFewFlags != nil {
+			store.OnTooFewFlags(count)
+		}
+	} else if enough && !wasEnough {
+		store.Logger.Printf("INFO: Table %q holds %d flag(s) again", store.Table, count)
+	}
+	return enough
+}
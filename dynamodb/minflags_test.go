@@ -0,0 +1,43 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestMinFlags(t *testing.T) {
+	store, client := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	store.MinFlags = 2
+	var counts []int
+	store.OnTooFewFlags = func(count int) { counts = append(counts, count) }
+	if store.Initialized() {
+		t.Error("store with fewer flags than MinFlags reported as initialized")
+	}
+	if len(counts) != 1 || counts[0] != 1 {
+		t.Errorf("got OnTooFewFlags calls with %v, want [1]", counts)
+	}
+
+	// Another consumer of the table sees the flags once there are enough
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "other", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	consumer, _ := newTestStore(t)
+	consumer.Client = client
+	consumer.MinFlags = 2
+	if !consumer.Initialized() {
+		t.Error("store with enough flags reported as uninitialized")
+	}
+
+	// The count is reused within the check interval
+	queries := client.count("Query")
+	consumer.Initialized()
+	if n := client.count("Query"); n != queries {
+		t.Errorf("got %d more queries, want none", n-queries)
+	}
+}
@@ -0,0 +1,30 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// missingOptional reports whether a read of the given namespace failed only
+// because the namespace belongs to one of the OptionalKinds and its items
+// can't be accessed, either because the table doesn't exist or because the
+// IAM policy doesn't allow it.
+func (store *DynamoDBFeatureStore) missingOptional(namespace string, err error) bool {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	switch aerr.Code() {
+	case dynamodb.ErrCodeResourceNotFoundException, "AccessDeniedException":
+	default:
+		return false
+	}
+
+	for _, kind := range store.OptionalKinds {
+		if kind.GetNamespace() == namespace {
+			store.Logger.Printf("WARN: Treating optional %q items as empty: %s", namespace, err)
+			return true
+		}
+	}
+	return false
+}
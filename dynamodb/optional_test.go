@@ -0,0 +1,35 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestOptionalKinds(t *testing.T) {
+	store, client := newTestStore(t)
+	store.OptionalKinds = []ld.VersionedDataKind{ld.Segments}
+	client.readErr = awserr.New("AccessDeniedException", "not authorized", nil)
+
+	segments, err := store.All(ld.Segments)
+	if err != nil || len(segments) != 0 {
+		t.Errorf("got segments %v and error %v, want none", segments, err)
+	}
+	if segment, err := store.Get(ld.Segments, "segment"); err != nil || segment != nil {
+		t.Errorf("got segment %v and error %v, want none", segment, err)
+	}
+	if !store.Status().Available {
+		t.Error("expected store to stay available")
+	}
+
+	if _, err := store.All(ld.Features); err == nil {
+		t.Error("expected error for features")
+	}
+
+	client.readErr = awserr.New(ddb.ErrCodeProvisionedThroughputExceededException, "throttled", nil)
+	if _, err := store.All(ld.Segments); err == nil {
+		t.Error("expected throttling of optional kind to fail")
+	}
+}
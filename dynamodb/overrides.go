@@ -0,0 +1,230 @@
+package dynamodb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Overrides allow engineers to force the state of a flag during incidents
+// without touching LaunchDarkly. They're stored in a separate table with the
+// same schema as the main table and take precedence over synced data at read
+// time. Each override can have an expiration time, stored as Unix timestamp in
+// the expiresAt attribute. Expired overrides are ignored; enable DynamoDB's
+// Time to Live on that attribute to have them removed automatically.
+const overrideExpiryAttribute = "expiresAt"
+
+// The expiry times of overrides are also recorded in the sync metadata, so
+// that LastSynced advances, and caches and ETags change, once an override
+// expires.
+const overrideExpiriesAttribute = "overrideExpiries"
+
+// SetOverride stores an item in the overrides table that will be returned
+// instead of the synced item with the same key. A ttl of zero means the
+// override never expires. Overriding an item with a deleted one hides the
+// synced item.
+func (store *DynamoDBFeatureStore) SetOverride(kind ld.VersionedDataKind, item ld.VersionedData, ttl time.Duration) error {
+	av, err := store.marshalItem(kind, item)
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to marshal override (key=%s): %s", item.GetKey(), err)
+		return err
+	}
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).Unix()
+		av[overrideExpiryAttribute] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(expiresAt, 10))}
+		// The checksum covers all attributes, including the expiry
+		setChecksum(av)
+	}
+
+	_, err = store.Client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(store.OverridesTable),
+		Item:      av,
+	})
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to put override (key=%s): %s", item.GetKey(), err)
+		return err
+	}
+
+	store.Logger.Printf("INFO: Set override (key=%s ttl=%s)", item.GetKey(), ttl)
+
+	if expiresAt > 0 {
+		if err := store.recordOverrideExpiry(expiresAt); err != nil {
+			store.Logger.Printf("WARN: Failed to record override expiry: %s", err)
+		}
+	}
+	store.overridesChanged()
+
+	return nil
+}
+
+// RemoveOverride deletes an override from the overrides table.
+func (store *DynamoDBFeatureStore) RemoveOverride(kind ld.VersionedDataKind, key string) error {
+	_, err := store.Client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(store.OverridesTable),
+		Key:       ItemKey(kind, key),
+	})
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to delete override (key=%s): %s", key, err)
+		return err
+	}
+
+	store.overridesChanged()
+
+	return nil
+}
+
+// overridesChanged notifies all consumers of the table that cached data is
+// stale.
+func (store *DynamoDBFeatureStore) overridesChanged() {
+	store.itemCache().invalidate()
+	if err := store.touchLastSynced(); err != nil {
+		store.Logger.Printf("WARN: Failed to update sync metadata: %s", err)
+	}
+}
+
+// getOverride returns the active override for the given key, or nil if there
+// is none. The returned item may be marked as deleted.
+func (store *DynamoDBFeatureStore) getOverride(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	result, err := store.Client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(store.OverridesTable),
+		ConsistentRead: aws.Bool(true),
+		Key:            ItemKey(kind, key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Item) == 0 || overrideExpired(result.Item, time.Now()) {
+		return nil, nil
+	}
+	if t, ok := overrideExpiry(result.Item); ok {
+		store.itemCache().expireAt(t)
+	}
+	return store.unmarshalItem(kind, result.Item)
+}
+
+// allOverrides returns all active overrides of the given kind, including those
+// marked as deleted.
+func (store *DynamoDBFeatureStore) allOverrides(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	items, err := store.queryItems(store.OverridesTable, kind.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make(map[string]ld.VersionedData)
+	for _, i := range items {
+		if overrideExpired(i, now) {
+			continue
+		}
+		if t, ok := overrideExpiry(i); ok {
+			store.itemCache().expireAt(t)
+		}
+		item, err := store.unmarshalItem(kind, i)
+		if err != nil {
+			return nil, err
+		}
+		results[item.GetKey()] = item
+	}
+
+	return results, nil
+}
+
+func overrideExpired(item map[string]*dynamodb.AttributeValue, now time.Time) bool {
+	t, ok := overrideExpiry(item)
+	return ok && !now.Before(t)
+}
+
+// overrideExpiry returns the time at which an override expires, if any.
+func overrideExpiry(item map[string]*dynamodb.AttributeValue) (time.Time, bool) {
+	av, ok := item[overrideExpiryAttribute]
+	if !ok || av.N == nil {
+		return time.Time{}, false
+	}
+	expiresAt, err := strconv.ParseInt(*av.N, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(expiresAt, 0), true
+}
+
+// recordOverrideExpiry adds the given expiry time to the sync metadata.
+// Expiry times before the last sync no longer affect LastSynced and are
+// removed.
+func (store *DynamoDBFeatureStore) recordOverrideExpiry(expiresAt int64) error {
+	result, err := store.Client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            rawKey(metadataNamespace, lastSyncedKey),
+	})
+	store.observe(getRequest, err)
+	if err != nil {
+		return err
+	}
+	synced, err := syncTimestamp(result.Item)
+	if err != nil {
+		return err
+	}
+	var stale []*string
+	if av, ok := result.Item[overrideExpiriesAttribute]; ok {
+		for _, n := range av.NS {
+			if e, err := strconv.ParseInt(aws.StringValue(n), 10, 64); err != nil || !time.Unix(e, 0).After(synced) {
+				stale = append(stale, n)
+			}
+		}
+	}
+
+	_, err = store.Client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:        aws.String(store.Table),
+		Key:              rawKey(metadataNamespace, lastSyncedKey),
+		UpdateExpression: aws.String("ADD #expiries :expiresAt"),
+		ExpressionAttributeNames: map[string]*string{
+			"#expiries": aws.String(overrideExpiriesAttribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":expiresAt": {NS: []*string{aws.String(strconv.FormatInt(expiresAt, 10))}},
+		},
+	})
+	store.observe(writeRequest, err)
+	if err != nil || len(stale) == 0 {
+		return err
+	}
+
+	// A single update expression can't both add to and delete from a set
+	_, err = store.Client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:        aws.String(store.Table),
+		Key:              rawKey(metadataNamespace, lastSyncedKey),
+		UpdateExpression: aws.String("DELETE #expiries :stale"),
+		ExpressionAttributeNames: map[string]*string{
+			"#expiries": aws.String(overrideExpiriesAttribute),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":stale": {NS: stale},
+		},
+	})
+	store.observe(writeRequest, err)
+	return err
+}
+
+// lastOverrideExpiry returns the latest override expiry time recorded in the
+// sync metadata that has passed, or the zero time if there is none.
+func lastOverrideExpiry(metadata map[string]*dynamodb.AttributeValue, now time.Time) time.Time {
+	var last time.Time
+	av, ok := metadata[overrideExpiriesAttribute]
+	if !ok {
+		return last
+	}
+	for _, n := range av.NS {
+		e, err := strconv.ParseInt(aws.StringValue(n), 10, 64)
+		if err != nil {
+			continue
+		}
+		if t := time.Unix(e, 0); !t.After(now) && t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
@@ -0,0 +1,147 @@
+package dynamodb_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestOverrides(t *testing.T) {
+	store, client := newTestStore(t)
+	store.OverridesTable = "test-overrides"
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"on":     &ld.FeatureFlag{Key: "on", Version: 5, On: true},
+			"hidden": &ld.FeatureFlag{Key: "hidden", Version: 5, On: true},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SetOverride(ld.Features, &ld.FeatureFlag{Key: "on", Version: 1, On: false}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetOverride(ld.Features, ld.Features.MakeDeletedItem("hidden", 1), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := store.Get(ld.Features, "on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.(*ld.FeatureFlag).On {
+		t.Error("expected override to turn flag off")
+	}
+
+	if item, _ := store.Get(ld.Features, "hidden"); item != nil {
+		t.Errorf("expected deleted override to hide flag, got %v", item)
+	}
+
+	all, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all["on"].(*ld.FeatureFlag).On {
+		t.Errorf("unexpected flags with overrides: %v", all)
+	}
+
+	// Expire the override manually
+	client.mu.Lock()
+	for _, item := range client.tables["test-overrides"] {
+		if aws.StringValue(item["key"].S) == "on" {
+			item["expiresAt"] = &ddb.AttributeValue{N: aws.String(strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))}
+		}
+	}
+	client.mu.Unlock()
+
+	item, _ = store.Get(ld.Features, "on")
+	if !item.(*ld.FeatureFlag).On {
+		t.Error("expected expired override to be ignored")
+	}
+
+	if err := store.RemoveOverride(ld.Features, "hidden"); err != nil {
+		t.Fatal(err)
+	}
+	if item, _ := store.Get(ld.Features, "hidden"); item == nil {
+		t.Error("expected flag to be visible after removing override")
+	}
+}
+
+func TestOverridesWithChecksums(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.OverridesTable = "test-overrides"
+	store.Checksums = dynamodb.ChecksumError
+
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 5, On: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetOverride(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1, On: false}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.GetVersion() != 1 || item.(*ld.FeatureFlag).On {
+		t.Errorf("got %#v, want the override", item)
+	}
+	all, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all["flag"].GetVersion() != 1 {
+		t.Errorf("got %#v, want the override", all["flag"])
+	}
+}
+
+func TestExpiredOverridesInvalidateCaches(t *testing.T) {
+	writer, client := newTestStore(t)
+	writer.OverridesTable = "test-overrides"
+	if err := writer.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 5, On: true}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.SetOverride(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1, On: false}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := &dynamodb.DynamoDBFeatureStore{
+		Client:               client,
+		Table:                writer.Table,
+		OverridesTable:       writer.OverridesTable,
+		Logger:               writer.Logger,
+		CacheRefreshInterval: time.Hour, // never poll in this test
+		CacheCheckGeneration: true,
+	}
+	defer reader.Close()
+
+	synced, err := reader.LastSynced()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all, err := reader.All(ld.Features); err != nil || all["flag"].(*ld.FeatureFlag).On {
+		t.Fatalf("got %v (err=%v), want the override", all, err)
+	}
+
+	time.Sleep(2 * time.Second) // expiry has a resolution of one second
+
+	if all, err := reader.All(ld.Features); err != nil || !all["flag"].(*ld.FeatureFlag).On {
+		t.Errorf("got %v (err=%v), want the cached override to expire", all, err)
+	}
+	expired, err := reader.LastSynced()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expired.After(synced) {
+		t.Errorf("got last sync time %s after expiry, want it to advance from %s", expired, synced)
+	}
+}
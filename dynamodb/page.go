@@ -0,0 +1,71 @@
+package dynamodb
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// ErrInvalidCursor is returned by Page if the cursor wasn't returned by a
+// previous call.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page returns up to limit items of the given kind, ordered by key, and the
+// cursor to pass to get the next page, which is empty after the last page.
+// Pass an empty cursor to get the first page. Unlike All, it reads only the
+// requested page from DynamoDB.
+//
+// Deleted items count towards the limit but aren't returned, so a page may
+// have fewer items even if more follow. Like AllIncludingDeleted, Page
+// bypasses the cache and overrides.
+func (store *DynamoDBFeatureStore) Page(kind ld.VersionedDataKind, cursor string, limit int) ([]ld.VersionedData, string, error) {
+	input := &dynamodb.QueryInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		KeyConditions: map[string]*dynamodb.Condition{
+			tablePartitionKey: {
+				ComparisonOperator: aws.String("EQ"),
+				AttributeValueList: []*dynamodb.AttributeValue{
+					{S: aws.String(kind.GetNamespace())},
+				},
+			},
+		},
+	}
+	if limit > 0 {
+		input.Limit = aws.Int64(int64(limit))
+	}
+	if cursor != "" {
+		key, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || len(key) == 0 {
+			return nil, "", ErrInvalidCursor
+		}
+		input.ExclusiveStartKey = ItemKey(kind, string(key))
+	}
+
+	out, err := store.Client.Query(input)
+	store.observe(queryRequest, err)
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to get page of %q items: %s", kind.GetNamespace(), err)
+		return nil, "", err
+	}
+
+	items := make([]ld.VersionedData, 0, len(out.Items))
+	for _, av := range out.Items {
+		item, err := store.unmarshalItem(kind, av)
+		if err != nil {
+			return nil, "", err
+		}
+		if !item.IsDeleted() {
+			items = append(items, item)
+		}
+	}
+
+	next := ""
+	if av, ok := out.LastEvaluatedKey[tableSortKey]; ok && av.S != nil {
+		next = base64.RawURLEncoding.EncodeToString([]byte(*av.S))
+	}
+	return items, next, nil
+}
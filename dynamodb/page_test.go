@@ -0,0 +1,51 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestPage(t *testing.T) {
+	store, client := newTestStore(t)
+	allData := map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"a": &ld.FeatureFlag{Key: "a", Version: 1},
+			"b": &ld.FeatureFlag{Key: "b", Version: 1},
+			"c": &ld.FeatureFlag{Key: "c", Version: 1},
+		},
+		ld.Segments: {"s": &ld.Segment{Key: "s", Version: 1}},
+	}
+	if err := store.Init(allData); err != nil {
+		t.Fatal(err)
+	}
+	client.calls = map[string]int{}
+
+	var keys []string
+	cursor := ""
+	for pages := 1; ; pages++ {
+		items, next, err := store.Page(ld.Features, cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range items {
+			keys = append(keys, item.GetKey())
+		}
+		if next == "" {
+			if pages != 2 || client.count("Query") != 2 {
+				t.Errorf("got %d page(s) with %d queries, want 2", pages, client.count("Query"))
+			}
+			break
+		}
+		cursor = next
+	}
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Errorf("got keys %v, want [a b c]", keys)
+	}
+
+	if _, _, err := store.Page(ld.Features, "not base64!", 2); err != dynamodb.ErrInvalidCursor {
+		t.Errorf("got error %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,139 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Maximum number of keys per BatchGetItem request
+const maxBatchGetKeys = 100
+
+// Prefetch loads the items with the given keys into the in-memory cache using
+// BatchGetItem, so that subsequent calls to Get don't have to fetch them one
+// by one. Keys that don't exist are cached as missing. Items that can't be
+// decoded are logged and left to Get. Call it at cold start with the flags
+// that are known to be evaluated.
+//
+// Prefetch requires caching to be enabled (see CacheRefreshInterval).
+func (store *DynamoDBFeatureStore) Prefetch(ctx context.Context, kind ld.VersionedDataKind, keys ...string) error {
+	c := store.itemCache()
+	if c == nil {
+		return errors.New("cannot prefetch items with caching disabled")
+	}
+
+	// BatchGetItem rejects requests with duplicate keys
+	keys = uniqueKeys(keys)
+
+	for start := 0; start < len(keys); start += maxBatchGetKeys {
+		end := start + maxBatchGetKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+		epoch := c.epoch()
+		items, failed, err := store.batchGet(ctx, kind, keys[start:end])
+		if err != nil {
+			store.Logger.Printf("ERROR: Failed to prefetch %q items: %s", kind.GetNamespace(), err)
+			return err
+		}
+		for _, key := range keys[start:end] {
+			if !failed[key] {
+				c.put(kind, key, items[key], epoch)
+			}
+		}
+	}
+
+	return nil
+}
+
+// batchGet returns the items with the given keys, taking overrides into
+// account. Missing and deleted items are left out. It also returns the keys of
+// items that couldn't be decoded.
+func (store *DynamoDBFeatureStore) batchGet(ctx context.Context, kind ld.VersionedDataKind, keys []string) (map[string]ld.VersionedData, map[string]bool, error) {
+	tables := []string{store.Table}
+	if store.OverridesTable != "" {
+		tables = append(tables, store.OverridesTable)
+	}
+
+	request := make(map[string]*dynamodb.KeysAndAttributes, len(tables))
+	for _, table := range tables {
+		var avs []map[string]*dynamodb.AttributeValue
+		for _, key := range keys {
+			avs = append(avs, ItemKey(kind, key))
+		}
+		request[table] = &dynamodb.KeysAndAttributes{Keys: avs, ConsistentRead: aws.Bool(true)}
+	}
+
+	results := make(map[string][]map[string]*dynamodb.AttributeValue, len(tables))
+	for attempt := 0; len(request) > 0; attempt++ {
+		if attempt >= maxBatchAttempts {
+			return nil, nil, fmt.Errorf("keys still unprocessed after %d attempts", attempt)
+		}
+		if attempt > 0 {
+			time.Sleep(batchBackoff(attempt))
+		}
+		out, err := store.Client.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+		store.observe(getRequest, err)
+		if err != nil {
+			return nil, nil, err
+		}
+		for table, items := range out.Responses {
+			results[table] = append(results[table], items...)
+		}
+		request = out.UnprocessedKeys
+	}
+
+	items := make(map[string]ld.VersionedData, len(keys))
+	failed := make(map[string]bool)
+	for _, av := range results[store.Table] {
+		item, err := store.unmarshalItem(kind, av)
+		if err != nil {
+			key := aws.StringValue(av[tableSortKey].S)
+			store.Logger.Printf("ERROR: Failed to unmarshal item (key=%s): %s", key, err)
+			failed[key] = true
+			continue
+		}
+		items[item.GetKey()] = item
+	}
+
+	now := time.Now()
+	for _, av := range results[store.OverridesTable] {
+		if overrideExpired(av, now) {
+			continue
+		}
+		if t, ok := overrideExpiry(av); ok {
+			store.itemCache().expireAt(t)
+		}
+		item, err := store.unmarshalItem(kind, av)
+		if err != nil {
+			store.Logger.Printf("WARN: Ignoring override due to error: %s", err)
+			continue
+		}
+		items[item.GetKey()] = item
+	}
+
+	for key, item := range items {
+		if item.IsDeleted() {
+			delete(items, key)
+		}
+	}
+
+	return items, failed, nil
+}
+
+func uniqueKeys(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
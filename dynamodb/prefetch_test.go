@@ -0,0 +1,124 @@
+package dynamodb_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestPrefetch(t *testing.T) {
+	store, client := newTestStore(t)
+	store.CacheRefreshInterval = time.Minute
+	defer store.Close()
+
+	flags := make(map[string]ld.VersionedData)
+	var keys []string
+	for i := 0; i < 150; i++ {
+		key := fmt.Sprintf("flag-%d", i)
+		flags[key] = &ld.FeatureFlag{Key: key, Version: 1}
+		keys = append(keys, key)
+	}
+	flags["deleted"] = &ld.FeatureFlag{Key: "deleted", Version: 1, Deleted: true}
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: flags}); err != nil {
+		t.Fatal(err)
+	}
+
+	client.unprocessed = 10
+	if err := store.Prefetch(context.Background(), ld.Features, append(keys, "deleted", "missing")...); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.count("BatchGetItem"); n < 2 {
+		t.Errorf("expected at least 2 BatchGetItem calls, got %d", n)
+	}
+
+	gets := client.count("GetItem")
+	for _, key := range keys {
+		item, err := store.Get(ld.Features, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item == nil || item.GetKey() != key {
+			t.Errorf("got %v for key %s", item, key)
+		}
+	}
+	for _, key := range []string{"deleted", "missing"} {
+		if item, err := store.Get(ld.Features, key); err != nil || item != nil {
+			t.Errorf("got %v, %v for key %s, want nil", item, err, key)
+		}
+	}
+	if n := client.count("GetItem"); n != gets {
+		t.Errorf("expected Get to be served from cache, got %d GetItem calls", n-gets)
+	}
+}
+
+func TestPrefetchWithOverrides(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.CacheRefreshInterval = time.Minute
+	store.OverridesTable = "test-overrides"
+	defer store.Close()
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetOverride(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1, On: true}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Prefetch(context.Background(), ld.Features, "flag"); err != nil {
+		t.Fatal(err)
+	}
+	item, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !item.(*ld.FeatureFlag).On {
+		t.Error("expected override to take precedence")
+	}
+}
+
+func TestPrefetchSkipsDuplicatesAndCorruptItems(t *testing.T) {
+	store, client := newTestStore(t)
+	store.CacheRefreshInterval = time.Minute
+	defer store.Close()
+
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"good":    &ld.FeatureFlag{Key: "good", Version: 1},
+			"corrupt": &ld.FeatureFlag{Key: "corrupt", Version: 1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	client.mu.Lock()
+	client.table("test-table")["features\x00corrupt"]["on"] = &ddb.AttributeValue{S: aws.String("yes")}
+	client.mu.Unlock()
+
+	if err := store.Prefetch(context.Background(), ld.Features, "good", "corrupt", "good"); err != nil {
+		t.Fatal(err)
+	}
+
+	gets := client.count("GetItem")
+	if item, err := store.Get(ld.Features, "good"); err != nil || item == nil {
+		t.Errorf("got %v, %v for good item", item, err)
+	}
+	if n := client.count("GetItem"); n != gets {
+		t.Errorf("expected good item to be served from cache, got %d GetItem calls", n-gets)
+	}
+	if _, err := store.Get(ld.Features, "corrupt"); err == nil {
+		t.Error("expected corrupt item to be left to Get and fail")
+	}
+}
+
+func TestPrefetchWithoutCache(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Prefetch(context.Background(), ld.Features, "flag"); err == nil {
+		t.Error("expected error with caching disabled")
+	}
+}
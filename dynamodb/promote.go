@@ -0,0 +1,157 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// PromoteReport describes the outcome of Promote for each data kind.
+type PromoteReport struct {
+	// Number of items copied from the source table
+	Written map[ld.VersionedDataKind]int
+
+	// Number of items that only existed in this table and were replaced
+	// with tombstones
+	Deleted map[ld.VersionedDataKind]int
+}
+
+// Promote replaces the flags and segments in this store's table with those
+// in the source store's table, e.g. to promote staging to production.
+// Unknown fields and checksums are copied as stored. Frozen items are left
+// untouched.
+//
+// Items are written in transactions of up to 10 items, so that either all or
+// none of the items of a transaction are changed, and each write is
+// conditioned on the version of the target item read when the promotion
+// started. If an item is changed in the meantime, the transaction fails and
+// Promote stops, leaving the remaining items untouched; run it again to
+// finish the promotion. Segments are copied before flags, and all items are
+// written before stale ones are deleted, so a flag never references a
+// missing segment.
+func (store *DynamoDBFeatureStore) Promote(source *DynamoDBFeatureStore) (*PromoteReport, error) {
+	report := &PromoteReport{
+		Written: make(map[ld.VersionedDataKind]int),
+		Deleted: make(map[ld.VersionedDataKind]int),
+	}
+
+	frozen, err := store.FrozenKeys(context.Background())
+	if err != nil {
+		return report, err
+	}
+
+	kinds := []ld.VersionedDataKind{ld.Segments, ld.Features}
+	puts := make(map[ld.VersionedDataKind][]*dynamodb.TransactWriteItem)
+	deletes := make(map[ld.VersionedDataKind][]*dynamodb.TransactWriteItem)
+	for _, kind := range kinds {
+		if !store.storesKind(kind) {
+			continue
+		}
+		items, err := source.AllRaw(kind)
+		if err != nil {
+			return report, err
+		}
+		existing, err := store.queryVersions(kind.GetNamespace())
+		if err != nil {
+			return report, err
+		}
+		isFrozen := make(map[string]bool)
+		for _, key := range frozen[kind.GetNamespace()] {
+			isFrozen[key] = true
+		}
+
+		for _, key := range sortedKeys(items) {
+			if isFrozen[key] {
+				continue
+			}
+			v, ok := existing[key]
+			puts[kind] = append(puts[kind], store.promotePut(items[key], v, ok))
+		}
+		for key, v := range existing {
+			if _, ok := items[key]; ok || isFrozen[key] || v.deleted {
+				continue
+			}
+			av, err := store.marshalItem(kind, kind.MakeDeletedItem(key, v.version+1))
+			if err != nil {
+				return report, fmt.Errorf("failed to marshal item (key=%s): %s", key, err)
+			}
+			deletes[kind] = append(deletes[kind], store.promotePut(av, v, true))
+		}
+	}
+
+	defer store.itemCache().invalidate()
+	for _, kind := range kinds {
+		if err := store.transactWrite(puts[kind]); err != nil {
+			return report, fmt.Errorf("failed to promote %q items: %s", kind.GetNamespace(), err)
+		}
+		report.Written[kind] = len(puts[kind])
+	}
+	for i := len(kinds) - 1; i >= 0; i-- {
+		kind := kinds[i]
+		if err := store.transactWrite(deletes[kind]); err != nil {
+			return report, fmt.Errorf("failed to delete stale %q items: %s", kind.GetNamespace(), err)
+		}
+		report.Deleted[kind] = len(deletes[kind])
+	}
+
+	if err := store.touchLastSynced(); err != nil {
+		store.Logger.Printf("WARN: Failed to update sync metadata: %s", err)
+	}
+	return report, nil
+}
+
+// promotePut returns a write of the given item to this store's table that
+// only succeeds if the stored version is still the given one, or if there is
+// no stored item if exists is false.
+func (store *DynamoDBFeatureStore) promotePut(item map[string]*dynamodb.AttributeValue, v storedVersion, exists bool) *dynamodb.TransactWriteItem {
+	put := &dynamodb.Put{
+		TableName: aws.String(store.Table),
+		Item:      item,
+		ExpressionAttributeNames: map[string]*string{
+			"#namespace": aws.String(tablePartitionKey),
+		},
+		ConditionExpression: aws.String("attribute_not_exists(#namespace)"),
+	}
+	if exists {
+		put.ConditionExpression = aws.String("#version = :version")
+		put.ExpressionAttributeNames = map[string]*string{"#version": aws.String("version")}
+		put.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+			":version": {N: aws.String(strconv.Itoa(v.version))},
+		}
+	}
+	return &dynamodb.TransactWriteItem{Put: put}
+}
+
+// transactWrite executes the given writes in transactions of up to
+// maxTransactItems items and stops at the first one that fails.
+func (store *DynamoDBFeatureStore) transactWrite(items []*dynamodb.TransactWriteItem) error {
+	for len(items) > 0 {
+		n := len(items)
+		if n > maxTransactItems {
+			n = maxTransactItems
+		}
+		_, err := store.Client.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+			TransactItems: items[:n],
+		})
+		store.observe(writeRequest, err)
+		if err != nil {
+			return err
+		}
+		items = items[n:]
+	}
+	return nil
+}
+
+func sortedKeys(items map[string]map[string]*dynamodb.AttributeValue) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
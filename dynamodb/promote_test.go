@@ -0,0 +1,135 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestPromote(t *testing.T) {
+	staging, client := newTestStore(t)
+	staging.Table = "staging"
+	production, _ := newTestStore(t)
+	production.Client = client
+	production.Table = "production"
+
+	if err := staging.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"new":    &ld.FeatureFlag{Key: "new", Version: 1, On: true},
+			"frozen": &ld.FeatureFlag{Key: "frozen", Version: 3},
+		},
+		ld.Segments: {"segment": &ld.Segment{Key: "segment", Version: 2}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := production.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"new":    &ld.FeatureFlag{Key: "new", Version: 8},
+			"stale":  &ld.FeatureFlag{Key: "stale", Version: 4},
+			"frozen": &ld.FeatureFlag{Key: "frozen", Version: 1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	production.Frozen = []string{"frozen"}
+	report, err := production.Promote(staging)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Written[ld.Features] != 1 || report.Written[ld.Segments] != 1 || report.Deleted[ld.Features] != 1 {
+		t.Errorf("got report %+v", report)
+	}
+
+	flags, err := production.AllIncludingDeleted(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The promoted version replaces the target's, even if it's lower
+	if f, ok := flags["new"].(*ld.FeatureFlag); !ok || f.Version != 1 || !f.On {
+		t.Errorf("got new flag %#v", flags["new"])
+	}
+	if item := flags["stale"]; item == nil || !item.IsDeleted() || item.GetVersion() != 5 {
+		t.Errorf("expected tombstone for stale flag, got %v", item)
+	}
+	if item := flags["frozen"]; item == nil || item.GetVersion() != 1 {
+		t.Errorf("expected frozen flag to be kept, got %v", item)
+	}
+	if segment, err := production.Get(ld.Segments, "segment"); err != nil || segment == nil {
+		t.Errorf("expected segment to be promoted, got %v, %v", segment, err)
+	}
+}
+
+// changingClient runs a function after the first TransactWriteItems request.
+type changingClient struct {
+	*fakeDynamoDB
+	once  sync.Once
+	after func()
+}
+
+func (c *changingClient) TransactWriteItems(in *ddb.TransactWriteItemsInput) (*ddb.TransactWriteItemsOutput, error) {
+	out, err := c.fakeDynamoDB.TransactWriteItems(in)
+	c.once.Do(c.after)
+	return out, err
+}
+
+func TestPromoteStopsOnConcurrentChange(t *testing.T) {
+	staging, client := newTestStore(t)
+	staging.Table = "staging"
+	production, _ := newTestStore(t)
+	production.Table = "production"
+	production.Client = client
+
+	flags := func(version int) map[ld.VersionedDataKind]map[string]ld.VersionedData {
+		items := make(map[string]ld.VersionedData)
+		for i := 0; i < 15; i++ {
+			key := fmt.Sprintf("flag-%02d", i)
+			items[key] = &ld.FeatureFlag{Key: key, Version: version}
+		}
+		return map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: items}
+	}
+	if err := staging.Init(flags(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := production.Init(flags(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A flag of the second transaction changes after the first one
+	production.Client = &changingClient{fakeDynamoDB: client, after: func() {
+		if _, err := client.UpdateItem(&ddb.UpdateItemInput{
+			TableName:                 aws.String("production"),
+			Key:                       map[string]*ddb.AttributeValue{"namespace": {S: aws.String("features")}, "key": {S: aws.String("flag-12")}},
+			UpdateExpression:          aws.String("SET #version = :version"),
+			ExpressionAttributeNames:  map[string]*string{"#version": aws.String("version")},
+			ExpressionAttributeValues: map[string]*ddb.AttributeValue{":version": {N: aws.String("6")}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}}
+	if _, err := production.Promote(staging); err == nil {
+		t.Fatal("expected error")
+	}
+
+	all, err := production.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, item := range all {
+		// The first transaction is complete, the second one left untouched
+		want := 1
+		if key >= "flag-10" {
+			want = 5
+			if key == "flag-12" {
+				want = 6
+			}
+		}
+		if item.GetVersion() != want {
+			t.Errorf("%s: got version %d, want %d", key, item.GetVersion(), want)
+		}
+	}
+}
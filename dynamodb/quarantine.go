@@ -0,0 +1,182 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// RejectedItem is an item received from LaunchDarkly that couldn't be
+// decoded. Init and Upsert never write it to the main table, keeping the
+// stored version, but to the QuarantineTable, if there is one. Otherwise,
+// Upsert fails and Init lists it in InitReport.Rejected. This way, a single
+// malformed item neither fails a sync nor disappears without a trace.
+type RejectedItem struct {
+	Key     string
+	Version int
+
+	// Payload as received
+	JSON []byte
+
+	// Why the item couldn't be decoded
+	Err error
+}
+
+// Verify that RejectedItem satisfies the VersionedData interface
+var _ ld.VersionedData = (*RejectedItem)(nil)
+
+// NewRejectedItem returns a RejectedItem for the given payload. The version
+// is read from the payload if possible.
+func NewRejectedItem(key string, data []byte, err error) *RejectedItem {
+	var fields struct {
+		Version int `json:"version"`
+	}
+	json.Unmarshal(data, &fields)
+	return &RejectedItem{Key: key, Version: fields.Version, JSON: data, Err: err}
+}
+
+// GetKey returns the key of the item.
+func (item *RejectedItem) GetKey() string { return item.Key }
+
+// GetVersion returns the version of the item, or zero if it's unknown.
+func (item *RejectedItem) GetVersion() int { return item.Version }
+
+// IsDeleted returns false, as rejected items are never tombstones.
+func (item *RejectedItem) IsDeleted() bool { return false }
+
+// QuarantinedItem is an item in the quarantine table (see QuarantineTable).
+type QuarantinedItem struct {
+	Namespace     string    `json:"namespace"`
+	Key           string    `json:"key"`
+	Version       int       `json:"version"`
+	Reason        string    `json:"reason"`
+	QuarantinedAt time.Time `json:"quarantinedAt"`
+
+	// Payload as received, which isn't necessarily valid JSON
+	Item string `json:"item,omitempty"`
+}
+
+// Quarantined returns all items in the quarantine table, ordered by namespace
+// and key, or none if there is no QuarantineTable.
+func (store *DynamoDBFeatureStore) Quarantined() ([]QuarantinedItem, error) {
+	return store.quarantined(true)
+}
+
+// QuarantineSummary works like Quarantined but leaves out the payloads, which
+// can be large, so that e.g. a status endpoint reads as little as possible.
+func (store *DynamoDBFeatureStore) QuarantineSummary() ([]QuarantinedItem, error) {
+	return store.quarantined(false)
+}
+
+func (store *DynamoDBFeatureStore) quarantined(withPayload bool) ([]QuarantinedItem, error) {
+	if store.QuarantineTable == "" {
+		return nil, nil
+	}
+
+	var quarantined []QuarantinedItem
+	for _, kind := range ld.VersionedDataKinds {
+		input := &dynamodb.QueryInput{
+			TableName:      aws.String(store.QuarantineTable),
+			ConsistentRead: aws.Bool(true),
+			KeyConditions: map[string]*dynamodb.Condition{
+				tablePartitionKey: {
+					ComparisonOperator: aws.String("EQ"),
+					AttributeValueList: []*dynamodb.AttributeValue{
+						{S: aws.String(kind.GetNamespace())},
+					},
+				},
+			},
+		}
+		if !withPayload {
+			input.ProjectionExpression = aws.String("#key, #version, #reason, #quarantinedAt")
+			input.ExpressionAttributeNames = map[string]*string{
+				"#key":           aws.String(tableSortKey),
+				"#version":       aws.String("version"),
+				"#reason":        aws.String("reason"),
+				"#quarantinedAt": aws.String("quarantinedAt"),
+			}
+		}
+		items, err := store.query(input, kind.GetNamespace())
+		if err != nil {
+			store.Logger.Printf("ERROR: Failed to get quarantined %q items: %s", kind.GetNamespace(), err)
+			return nil, err
+		}
+		for _, av := range items {
+			q := QuarantinedItem{
+				Namespace:     kind.GetNamespace(),
+				Key:           stringAttribute(av[tableSortKey]),
+				Reason:        stringAttribute(av["reason"]),
+				QuarantinedAt: fromMillis(av["quarantinedAt"]),
+				Item:          stringAttribute(av["item"]),
+			}
+			if v := av["version"]; v != nil && v.N != nil {
+				q.Version, _ = strconv.Atoi(*v.N)
+			}
+			quarantined = append(quarantined, q)
+		}
+	}
+	sort.Slice(quarantined, func(i, j int) bool {
+		if quarantined[i].Namespace != quarantined[j].Namespace {
+			return quarantined[i].Namespace < quarantined[j].Namespace
+		}
+		return quarantined[i].Key < quarantined[j].Key
+	})
+	return quarantined, nil
+}
+
+// RemoveQuarantined deletes an item from the quarantine table, e.g. once it
+// has been fixed in LaunchDarkly.
+func (store *DynamoDBFeatureStore) RemoveQuarantined(kind ld.VersionedDataKind, key string) error {
+	_, err := store.Client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(store.QuarantineTable),
+		Key:       ItemKey(kind, key),
+	})
+	store.observe(writeRequest, err)
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to delete quarantined item (key=%s): %s", key, err)
+	}
+	return err
+}
+
+// quarantine writes an item that wasn't written to the main table to the
+// quarantine table, which has the same schema, along with the reason. The
+// item is stored as JSON, as it may not be representable as attributes.
+func (store *DynamoDBFeatureStore) quarantine(kind ld.VersionedDataKind, item ld.VersionedData, reason error) error {
+	var data []byte
+	switch v := item.(type) {
+	case *RawItem:
+		// Keep the JSON as received, even if it's invalid
+		data = v.JSON
+	case *RejectedItem:
+		data = v.JSON
+	default:
+		var err error
+		if data, err = json.Marshal(item); err != nil {
+			return err
+		}
+	}
+	av := ItemKey(kind, item.GetKey())
+	av["version"] = &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(item.GetVersion()))}
+	av["item"] = &dynamodb.AttributeValue{S: aws.String(string(data))}
+	av["reason"] = &dynamodb.AttributeValue{S: aws.String(reason.Error())}
+	av["quarantinedAt"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(unixMillis(time.Now()), 10))}
+
+	_, err := store.Client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(store.QuarantineTable),
+		Item:      av,
+	})
+	store.observe(writeRequest, err)
+	return err
+}
+
+func stringAttribute(av *dynamodb.AttributeValue) string {
+	if av == nil {
+		return ""
+	}
+	return aws.StringValue(av.S)
+}
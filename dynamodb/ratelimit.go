@@ -0,0 +1,49 @@
+package dynamodb
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out writes so that no more than rate items are written
+// per second on average.
+type rateLimiter struct {
+	rate float64
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	return &rateLimiter{rate: rate}
+}
+
+// writeLimiter returns the store's limiter for batch writes, or nil if
+// WriteRateLimit isn't set.
+func (store *DynamoDBFeatureStore) writeLimiter() *rateLimiter {
+	if store.WriteRateLimit <= 0 {
+		return nil
+	}
+	store.limiterOnce.Do(func() {
+		store.limiter = newRateLimiter(store.WriteRateLimit)
+	})
+	return store.limiter
+}
+
+// waitN blocks until n more items may be written. A nil limiter never blocks.
+func (l *rateLimiter) waitN(n int) {
+	if l == nil || l.rate <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	l.mu.Unlock()
+
+	time.Sleep(delay)
+}
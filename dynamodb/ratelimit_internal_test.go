@@ -0,0 +1,30 @@
+package dynamodb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(1000)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		l.waitN(25)
+	}
+
+	// The first batch is free, the following three take 25ms each
+	if elapsed := time.Since(start); elapsed < 75*time.Millisecond {
+		t.Errorf("writes finished too quickly: %s", elapsed)
+	}
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	var l *rateLimiter
+
+	start := time.Now()
+	l.waitN(1000)
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("nil limiter blocked for %s", elapsed)
+	}
+}
@@ -0,0 +1,51 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// AllIncludingDeleted works like All but also returns items marked as deleted
+// (tombstones). It reads the table directly, bypassing the cache and
+// overrides, which makes it suitable for audit and maintenance tooling.
+// Corrupt items are skipped if SkipCorruptItems is set.
+func (store *DynamoDBFeatureStore) AllIncludingDeleted(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	raw, err := store.AllRaw(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]ld.VersionedData, len(raw))
+	for key, av := range raw {
+		item, err := store.unmarshalItem(kind, av)
+		if err != nil {
+			if store.SkipCorruptItems {
+				store.skipCorruptItem(kind, key, err)
+				continue
+			}
+			store.Logger.Printf("ERROR: Failed to unmarshal item (key=%s): %s", key, err)
+			return nil, err
+		}
+		results[key] = item
+	}
+	return results, nil
+}
+
+// AllRaw returns the attribute maps of all items of the given kind, including
+// tombstones, keyed by item key. Like AllIncludingDeleted, it bypasses the
+// cache and overrides.
+func (store *DynamoDBFeatureStore) AllRaw(kind ld.VersionedDataKind) (map[string]map[string]*dynamodb.AttributeValue, error) {
+	items, err := store.queryItems(store.Table, kind.GetNamespace())
+	if err != nil {
+		store.Logger.Printf("ERROR: Failed to get all %q items: %s", kind.GetNamespace(), err)
+		return nil, err
+	}
+
+	results := make(map[string]map[string]*dynamodb.AttributeValue, len(items))
+	for _, item := range items {
+		if av, ok := item[tableSortKey]; ok && av.S != nil {
+			results[*av.S] = item
+		}
+	}
+	return results, nil
+}
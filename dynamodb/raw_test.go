@@ -0,0 +1,50 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestAllIncludingDeleted(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"live":    &ld.FeatureFlag{Key: "live", Version: 1},
+			"deleted": &ld.FeatureFlag{Key: "deleted", Version: 1},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ld.Features, "deleted", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 {
+		t.Errorf("expected All to skip tombstones, got %v", all)
+	}
+
+	items, err := store.AllIncludingDeleted(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || !items["deleted"].IsDeleted() || items["live"].IsDeleted() {
+		t.Errorf("unexpected items: %v", items)
+	}
+
+	raw, err := store.AllRaw(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 2 || !aws.BoolValue(raw["deleted"]["deleted"].BOOL) {
+		t.Errorf("unexpected raw items: %v", raw)
+	}
+	if ns := aws.StringValue(raw["live"]["namespace"].S); ns != "features" {
+		t.Errorf("got namespace %q, want features", ns)
+	}
+}
@@ -0,0 +1,70 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// RawItem is an item as received from LaunchDarkly, kept as raw JSON. Storing
+// raw items instead of SDK structs preserves fields that the vendored SDK
+// doesn't know about, so that consumers using newer SDK versions can read
+// them.
+//
+// RawItem can be passed to Init and Upsert. Items read from the store are
+// always decoded into SDK structs.
+type RawItem struct {
+	Key     string
+	Version int
+	Deleted bool
+	JSON    json.RawMessage
+}
+
+// Verify that RawItem satisfies the VersionedData interface
+var _ ld.VersionedData = (*RawItem)(nil)
+
+// GetKey returns the key of the item.
+func (item *RawItem) GetKey() string { return item.Key }
+
+// GetVersion returns the version of the item.
+func (item *RawItem) GetVersion() int { return item.Version }
+
+// IsDeleted reports whether the item is a tombstone.
+func (item *RawItem) IsDeleted() bool { return item.Deleted }
+
+// UnmarshalJSON keeps the raw JSON and extracts the fields needed for
+// versioning.
+func (item *RawItem) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Key     string `json:"key"`
+		Version int    `json:"version"`
+		Deleted bool   `json:"deleted"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if fields.Key == "" {
+		return fmt.Errorf("item without key: %s", data)
+	}
+	item.Key = fields.Key
+	item.Version = fields.Version
+	item.Deleted = fields.Deleted
+	item.JSON = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON returns the raw JSON of the item.
+func (item *RawItem) MarshalJSON() ([]byte, error) {
+	return item.JSON, nil
+}
+
+// fields returns the decoded JSON of the item, to be marshaled without going
+// through SDK structs.
+func (item *RawItem) fields() (map[string]interface{}, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(item.JSON, &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON for item %q: %s", item.Key, err)
+	}
+	return fields, nil
+}
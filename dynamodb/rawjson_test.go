@@ -0,0 +1,76 @@
+package dynamodb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestInitWithRawData(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	allData := rawData(t, `{
+		"flags": {
+			"flag": {"key": "flag", "version": 3, "on": true, "newField": {"a": 1}}
+		},
+		"segments": {
+			"segment": {"key": "segment", "version": 1, "included": ["user"]}
+		}
+	}`)
+	if err := store.Init(allData); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := store.AllRaw(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if av := raw["flag"]["newField"]; av == nil || av.M["a"] == nil || *av.M["a"].N != "1" {
+		t.Errorf("unknown field not stored: %v", raw["flag"])
+	}
+
+	flag, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := flag.(*ld.FeatureFlag); !ok || f.Version != 3 || !f.On {
+		t.Errorf("got flag %#v", flag)
+	}
+
+	segment, err := store.Get(ld.Segments, "segment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := segment.(*ld.Segment); !ok || len(s.Included) != 1 {
+		t.Errorf("got segment %#v", segment)
+	}
+}
+
+func TestRawItemRequiresKey(t *testing.T) {
+	if err := json.Unmarshal([]byte(`{"version": 1}`), &dynamodb.RawItem{}); err == nil {
+		t.Error("expected error for item without key")
+	}
+}
+
+// rawData decodes a polling response into raw items that can be passed to
+// Init.
+func rawData(t *testing.T, body string) map[ld.VersionedDataKind]map[string]ld.VersionedData {
+	var data struct {
+		Flags    map[string]*dynamodb.RawItem `json:"flags"`
+		Segments map[string]*dynamodb.RawItem `json:"segments"`
+	}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		t.Fatal(err)
+	}
+	allData := map[ld.VersionedDataKind]map[string]ld.VersionedData{ld.Features: {}, ld.Segments: {}}
+	for key, item := range data.Flags {
+		allData[ld.Features][key] = item
+	}
+	for key, item := range data.Segments {
+		allData[ld.Segments][key] = item
+	}
+	return allData
+}
@@ -0,0 +1,83 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// RecreateTable truncates the store's table by deleting and recreating it
+// with the same key schema, provisioned throughput, stream settings, and
+// tags, plus the configured Tags. For large tables, this is much faster and
+// cheaper than deleting items one batch at a time.
+//
+// The table is unavailable while it's being recreated, so readers will fail
+// until the next sync has written data to it again. Use this only for tables
+// whose lifecycle is managed by the store's owner, e.g. via the lddstore
+// command, and not for tables managed by CloudFormation.
+func (store *DynamoDBFeatureStore) RecreateTable(ctx context.Context) error {
+	table := aws.String(store.Table)
+
+	desc, err := store.Client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: table})
+	if err != nil {
+		return fmt.Errorf("failed to describe table: %s", err)
+	}
+
+	tags, err := store.tableTags(ctx, desc.Table.TableArn)
+	if err != nil {
+		return err
+	}
+	for key, value := range store.Tags {
+		tags[key] = value
+	}
+
+	store.Logger.Printf("INFO: Deleting table %q to recreate it", store.Table)
+	if _, err := store.Client.DeleteTableWithContext(ctx, &dynamodb.DeleteTableInput{TableName: table}); err != nil {
+		return fmt.Errorf("failed to delete table: %s", err)
+	}
+	if err := store.Client.WaitUntilTableNotExistsWithContext(ctx, &dynamodb.DescribeTableInput{TableName: table}); err != nil {
+		return fmt.Errorf("failed to wait for table deletion: %s", err)
+	}
+
+	input := &dynamodb.CreateTableInput{
+		TableName:            table,
+		KeySchema:            desc.Table.KeySchema,
+		AttributeDefinitions: desc.Table.AttributeDefinitions,
+	}
+	if pt := desc.Table.ProvisionedThroughput; pt != nil {
+		input.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{
+			ReadCapacityUnits:  pt.ReadCapacityUnits,
+			WriteCapacityUnits: pt.WriteCapacityUnits,
+		}
+	}
+	if ss := desc.Table.StreamSpecification; ss != nil && aws.BoolValue(ss.StreamEnabled) {
+		input.StreamSpecification = ss
+	}
+
+	store.Logger.Printf("INFO: Creating table %q", store.Table)
+	created, err := store.Client.CreateTableWithContext(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to create table: %s", err)
+	}
+	if err := store.Client.WaitUntilTableExistsWithContext(ctx, &dynamodb.DescribeTableInput{TableName: table}); err != nil {
+		return fmt.Errorf("failed to wait for table creation: %s", err)
+	}
+
+	if len(tags) > 0 {
+		if _, err := store.Client.TagResourceWithContext(ctx, &dynamodb.TagResourceInput{
+			ResourceArn: created.TableDescription.TableArn,
+			Tags:        tagList(tags),
+		}); err != nil {
+			return fmt.Errorf("failed to restore tags: %s", err)
+		}
+	}
+
+	store.mu.Lock()
+	store.initialized = false
+	store.mu.Unlock()
+	store.itemCache().invalidate()
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package dynamodb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestRecreateTable(t *testing.T) {
+	store, client := newTestStore(t)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	client.tags = map[string][]*ddb.Tag{
+		tableARN("test-table"): {{Key: aws.String("team"), Value: aws.String("platform")}},
+	}
+
+	if err := store.RecreateTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(client.sortedItems("test-table")); n != 0 {
+		t.Errorf("expected empty table, got %d item(s)", n)
+	}
+	if store.Initialized() {
+		t.Error("expected store not to be initialized after truncation")
+	}
+	if tags := client.tags[tableARN("test-table")]; len(tags) != 1 || aws.StringValue(tags[0].Key) != "team" {
+		t.Errorf("expected tags to be restored, got %v", tags)
+	}
+	if client.count("DeleteTable") != 1 || client.count("CreateTable") != 1 {
+		t.Error("expected table to be deleted and created once")
+	}
+}
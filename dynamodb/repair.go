@@ -0,0 +1,48 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Repair overwrites a single item, e.g. one that is corrupt or stale, with a
+// fresh copy from LaunchDarkly. Unlike Upsert, it also overwrites an item
+// with the same version, but never one with a higher version. It reports
+// whether the item was written.
+//
+// If force is set, the item is written regardless of the stored version,
+// which is needed if the stored version itself can't be read.
+func (store *DynamoDBFeatureStore) Repair(kind ld.VersionedDataKind, item ld.VersionedData, force bool) (bool, error) {
+	var written bool
+	var err error
+	if force {
+		written, err = store.put(kind, item)
+	} else {
+		written, err = store.putIfVersion(kind, item, ">=")
+	}
+	if err != nil || !written {
+		return false, err
+	}
+
+	store.itemCache().invalidate()
+
+	if err := store.touchLastSynced(); err != nil {
+		store.Logger.Printf("WARN: Failed to update sync metadata: %s", err)
+	}
+	return true, nil
+}
+
+// put writes an item unconditionally.
+func (store *DynamoDBFeatureStore) put(kind ld.VersionedDataKind, item ld.VersionedData) (bool, error) {
+	av, err := store.marshalItem(kind, item)
+	if err != nil {
+		return false, err
+	}
+	_, err = store.Client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item:      av,
+	})
+	store.observe(writeRequest, err)
+	return err == nil, err
+}
@@ -0,0 +1,34 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestRepair(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2, On: false}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same version is overwritten
+	if written, err := store.Repair(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2, On: true}, false); err != nil || !written {
+		t.Fatalf("expected repair with same version, got written=%v err=%v", written, err)
+	}
+	flag, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !flag.(*ld.FeatureFlag).On {
+		t.Error("expected repaired flag to be on")
+	}
+
+	// Older version isn't, unless forced
+	if written, err := store.Repair(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1}, false); err != nil || written {
+		t.Errorf("expected older version to be skipped, got written=%v err=%v", written, err)
+	}
+	if written, err := store.Repair(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1}, true); err != nil || !written {
+		t.Errorf("expected forced repair, got written=%v err=%v", written, err)
+	}
+}
@@ -0,0 +1,158 @@
+package dynamodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// RequestError is an error returned by DynamoDB, annotated with the table,
+// operation, and request ID, which AWS support asks for when investigating
+// throttling or internal errors. It wraps the original error, so checks
+// like err.(awserr.Error).Code() work as usual.
+type RequestError struct {
+	// The original error
+	Err awserr.Error
+
+	// Table(s) and operation of the failed request, e.g. "GetItem"
+	Table     string
+	Operation string
+
+	// Status code and request ID of DynamoDB's response, if any
+	HTTPStatusCode int
+	ID             string
+}
+
+// Verify that RequestError satisfies the RequestFailure interface
+var _ awserr.RequestFailure = (*RequestError)(nil)
+
+func (e *RequestError) Error() string {
+	id := e.ID
+	if id == "" {
+		id = "none"
+	}
+	return fmt.Sprintf("%s on table %s failed (request ID: %s): %s",
+		e.Operation, e.Table, id, e.Err.Error())
+}
+
+// Code returns the error code of the original error, e.g.
+// dynamodb.ErrCodeConditionalCheckFailedException.
+func (e *RequestError) Code() string { return e.Err.Code() }
+
+// Message returns the message of the original error.
+func (e *RequestError) Message() string { return e.Err.Message() }
+
+// OrigErr returns the error that caused the original error, if any.
+func (e *RequestError) OrigErr() error { return e.Err.OrigErr() }
+
+// StatusCode returns the HTTP status code of DynamoDB's response, or zero if
+// there was none.
+func (e *RequestError) StatusCode() int { return e.HTTPStatusCode }
+
+// RequestID returns the ID of the failed request, or an empty string if
+// DynamoDB didn't respond.
+func (e *RequestError) RequestID() string { return e.ID }
+
+// NewClient creates a DynamoDB client whose errors are *RequestErrors. The
+// constructors of this package use it; use it as well for stores composed
+// with their own client.
+func NewClient(p client.ConfigProvider, cfgs ...*aws.Config) *dynamodb.DynamoDB {
+	c := dynamodb.New(p, cfgs...)
+	// Send returns the error before the Complete handlers run, so errors are
+	// annotated once the request won't be retried anymore
+	c.Handlers.AfterRetry.PushBack(annotateError)
+	return c
+}
+
+// annotateError wraps the error of a failed request in a *RequestError.
+func annotateError(r *request.Request) {
+	aerr, ok := r.Error.(awserr.Error)
+	if !ok {
+		return
+	}
+	if _, ok := aerr.(*RequestError); ok {
+		return
+	}
+	e := &RequestError{
+		Err:       aerr,
+		Table:     requestTable(r.Params),
+		Operation: r.Operation.Name,
+		ID:        r.RequestID,
+	}
+	if r.HTTPResponse != nil {
+		e.HTTPStatusCode = r.HTTPResponse.StatusCode
+	}
+	r.Error = e
+}
+
+// requestTable returns the name of the table(s) accessed by a request.
+func requestTable(params interface{}) string {
+	switch in := params.(type) {
+	case *dynamodb.GetItemInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.PutItemInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.UpdateItemInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.DeleteItemInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.QueryInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.ScanInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.DescribeTableInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.CreateTableInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.DeleteTableInput:
+		return aws.StringValue(in.TableName)
+	case *dynamodb.BatchWriteItemInput:
+		var tables []string
+		for table := range in.RequestItems {
+			tables = append(tables, table)
+		}
+		return joinTables(tables)
+	case *dynamodb.TransactWriteItemsInput:
+		seen := make(map[string]bool)
+		var tables []string
+		for _, item := range in.TransactItems {
+			var table *string
+			switch {
+			case item.Put != nil:
+				table = item.Put.TableName
+			case item.Delete != nil:
+				table = item.Delete.TableName
+			case item.Update != nil:
+				table = item.Update.TableName
+			case item.ConditionCheck != nil:
+				table = item.ConditionCheck.TableName
+			}
+			if table != nil && !seen[*table] {
+				seen[*table] = true
+				tables = append(tables, *table)
+			}
+		}
+		return joinTables(tables)
+	case *dynamodb.BatchGetItemInput:
+		var tables []string
+		for table := range in.RequestItems {
+			tables = append(tables, table)
+		}
+		return joinTables(tables)
+	}
+	return "-"
+}
+
+func joinTables(tables []string) string {
+	if len(tables) == 0 {
+		return "-"
+	}
+	sort.Strings(tables)
+	return strings.Join(tables, ",")
+}
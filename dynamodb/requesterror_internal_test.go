@@ -0,0 +1,22 @@
+package dynamodb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestRequestTableOfTransactions(t *testing.T) {
+	in := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{Delete: &dynamodb.Delete{TableName: aws.String("flags")}},
+			{Update: &dynamodb.Update{TableName: aws.String("overrides")}},
+			{ConditionCheck: &dynamodb.ConditionCheck{TableName: aws.String("audit")}},
+			{Put: &dynamodb.Put{TableName: aws.String("flags")}},
+		},
+	}
+	if got, want := requestTable(in), "audit,flags,overrides"; got != want {
+		t.Errorf("got table %q, want %q", got, want)
+	}
+}
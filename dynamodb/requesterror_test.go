@@ -0,0 +1,76 @@
+package dynamodb_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// newFailingStore returns a store whose requests fail with the given error
+// code, using a client created with NewClient.
+func newFailingStore(t *testing.T, code string) (*dynamodb.DynamoDBFeatureStore, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Header().Set("X-Amzn-Requestid", "REQUEST123")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#` + code + `","message":"failed"}`))
+	}))
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(srv.URL),
+		Region:      aws.String("us-east-1"),
+		MaxRetries:  aws.Int(0),
+	}))
+	store := &dynamodb.DynamoDBFeatureStore{
+		Client: dynamodb.NewClient(sess),
+		Table:  "test-table",
+		Logger: discardLogger{},
+	}
+	return store, srv.Close
+}
+
+func TestRequestErrors(t *testing.T) {
+	store, stop := newFailingStore(t, "ValidationException")
+	defer stop()
+
+	_, err := store.Get(ld.Features, "flag")
+	rerr, ok := err.(*dynamodb.RequestError)
+	if !ok {
+		t.Fatalf("got error %T: %v", err, err)
+	}
+	if rerr.Operation != "GetItem" || rerr.Table != "test-table" || rerr.RequestID() != "REQUEST123" || rerr.StatusCode() != 400 {
+		t.Errorf("got error %+v", rerr)
+	}
+	for _, s := range []string{"GetItem", "test-table", "REQUEST123", "ValidationException"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("error %q doesn't contain %q", err, s)
+		}
+	}
+	if aerr, ok := err.(awserr.RequestFailure); !ok || aerr.Code() != "ValidationException" {
+		t.Errorf("got error %#v, want awserr.RequestFailure", err)
+	}
+}
+
+func TestRequestErrorsKeepCodes(t *testing.T) {
+	store, stop := newFailingStore(t, ddb.ErrCodeConditionalCheckFailedException)
+	defer stop()
+
+	// Stale writes are rejected with a conditional check failure, which
+	// isn't an error
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1}); err != nil {
+		t.Errorf("got error %v", err)
+	}
+	if stats := store.Stats(); stats.ConditionalFailures != 1 || stats.Errors != 0 {
+		t.Errorf("got stats %+v", stats)
+	}
+}
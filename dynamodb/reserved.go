@@ -0,0 +1,148 @@
+package dynamodb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// reservedAttributes are used by the store itself, so fields of an item's
+// JSON with these names are stored under escaped names. Without escaping, a
+// "namespace" field, which raw items may have, would be overwritten by the
+// partition key, and an "expiresAt" field would be subject to a TTL on the
+// table meant for the sync lock. The sort key and "version" aren't reserved,
+// as they hold the item's own key and version.
+var reservedAttributes = map[string]bool{
+	tablePartitionKey: true,
+	"expiresAt":       true,
+}
+
+// escapedAttribute marks items with escaped field names. Items without it
+// were either written before fields were escaped or didn't need escaping,
+// and their attributes are read as they are.
+const escapedAttribute = "$escaped"
+
+// escapeAttributes renames the fields of an encoded item that are reserved,
+// and those that start with "$" to keep escaping reversible, by prefixing
+// them with "$". It marks the item and reports whether anything was renamed.
+func escapeAttributes(av map[string]*dynamodb.AttributeValue) bool {
+	var names []string
+	for name := range av {
+		if reservedAttributes[name] || strings.HasPrefix(name, "$") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return false
+	}
+
+	escaped := make(map[string]*dynamodb.AttributeValue, len(names))
+	for _, name := range names {
+		escaped["$"+name] = av[name]
+		delete(av, name)
+	}
+	for name, v := range escaped {
+		av[name] = v
+	}
+	av[escapedAttribute] = &dynamodb.AttributeValue{BOOL: aws.Bool(true)}
+	return true
+}
+
+// unescapeAttributes returns the attributes of an item as they were before
+// escapeAttributes, except for the partition key and checksum. Unescaped items
+// are returned as they are.
+func unescapeAttributes(av map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if _, ok := av[escapedAttribute]; !ok {
+		return av
+	}
+	fields := make(map[string]*dynamodb.AttributeValue, len(av))
+	for name, v := range av {
+		switch {
+		case name == tablePartitionKey || name == escapedAttribute || name == checksumAttribute:
+		case strings.HasPrefix(name, "$"):
+			fields[name[1:]] = v
+		default:
+			fields[name] = v
+		}
+	}
+	return fields
+}
+
+// ItemFields returns the fields of an item's JSON representation from its
+// attributes as stored in the table, e.g. as returned by AllRaw, undoing the
+// escaping of field names that collide with attributes used by the store.
+func ItemFields(av map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if _, ok := av[escapedAttribute]; ok {
+		return unescapeAttributes(av)
+	}
+	fields := make(map[string]*dynamodb.AttributeValue, len(av))
+	for name, v := range av {
+		if name != tablePartitionKey && name != checksumAttribute {
+			fields[name] = v
+		}
+	}
+	return fields
+}
+
+// MigrateReservedAttributes escapes the field names of items written before
+// names were escaped, e.g. an "expiresAt" field that a TTL on the table would
+// act on, and returns the number of rewritten items. Items changed by a sync
+// in the meantime are skipped, as they are escaped already.
+//
+// Fields named like the partition key were overwritten when such items were
+// written and can't be restored from the table. A full sync (Init) restores
+// them, as it rewrites all items.
+func (store *DynamoDBFeatureStore) MigrateReservedAttributes(ctx context.Context) (int, error) {
+	migrated := 0
+	for _, kind := range ld.VersionedDataKinds[:] {
+		items, err := store.AllRaw(kind)
+		if err != nil {
+			return migrated, err
+		}
+		for key, av := range items {
+			if _, ok := av[escapedAttribute]; ok {
+				continue
+			}
+			fields := ItemFields(av)
+			version := fields["version"]
+			if !escapeAttributes(fields) {
+				continue
+			}
+			fields[tablePartitionKey] = av[tablePartitionKey]
+			setChecksum(fields)
+
+			input := &dynamodb.PutItemInput{
+				TableName:                aws.String(store.Table),
+				Item:                     fields,
+				ConditionExpression:      aws.String("attribute_not_exists(#escaped) and #version = :version"),
+				ExpressionAttributeNames: map[string]*string{"#escaped": aws.String(escapedAttribute), "#version": aws.String("version")},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":version": version,
+				},
+			}
+			if version == nil {
+				input.ConditionExpression = aws.String("attribute_not_exists(#escaped) and attribute_not_exists(#version)")
+				input.ExpressionAttributeValues = nil
+			}
+			_, err := store.Client.PutItemWithContext(ctx, input)
+			store.observe(writeRequest, err)
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+				store.Logger.Printf("DEBUG: Not migrating item changed in the meantime (key=%s)", key)
+				continue
+			}
+			if err != nil {
+				return migrated, err
+			}
+			store.Logger.Printf("INFO: Escaped reserved attributes of %q item (key=%s)", kind.GetNamespace(), key)
+			migrated++
+		}
+	}
+	if migrated > 0 {
+		store.itemCache().invalidate()
+	}
+	return migrated, nil
+}
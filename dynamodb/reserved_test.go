@@ -0,0 +1,128 @@
+package dynamodb_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestReservedAttributesAreEscaped(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	allData := rawData(t, `{
+		"flags": {
+			"flag": {"key": "flag", "version": 2, "on": true,
+				"namespace": "team-a", "expiresAt": 123, "$escaped": "x", "$$": 1}
+		}
+	}`)
+	if err := store.Init(allData); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := store.AllRaw(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := raw["flag"]
+	if ns := aws.StringValue(item["namespace"].S); ns != ld.Features.GetNamespace() {
+		t.Errorf("got partition key %q", ns)
+	}
+	if _, ok := item["expiresAt"]; ok {
+		t.Error("expiresAt field stored unescaped")
+	}
+
+	var fields map[string]interface{}
+	if err := dynamodbattribute.UnmarshalMap(dynamodb.ItemFields(item), &fields); err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]interface{}
+	json.Unmarshal(allData[ld.Features]["flag"].(*dynamodb.RawItem).JSON, &want)
+	got, _ := json.Marshal(fields)
+	wantJSON, _ := json.Marshal(want)
+	if string(got) != string(wantJSON) {
+		t.Errorf("got fields %s, want %s", got, wantJSON)
+	}
+
+	flag, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := flag.(*ld.FeatureFlag); !ok || f.Version != 2 || !f.On {
+		t.Errorf("got flag %#v", flag)
+	}
+}
+
+func TestItemsWithoutReservedFieldsAreUnchanged(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := store.AllRaw(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range raw["flag"] {
+		if name[0] == '$' && name != "$checksum" {
+			t.Errorf("unexpected attribute %q", name)
+		}
+	}
+}
+
+func TestMigrateReservedAttributes(t *testing.T) {
+	store, client := newTestStore(t)
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "plain", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// Written before field names were escaped
+	client.PutItem(&ddb.PutItemInput{
+		TableName: aws.String("test-table"),
+		Item: map[string]*ddb.AttributeValue{
+			"namespace": {S: aws.String(ld.Features.GetNamespace())},
+			"key":       {S: aws.String("old")},
+			"version":   {N: aws.String("3")},
+			"on":        {BOOL: aws.Bool(true)},
+			"expiresAt": {N: aws.String("123")},
+		},
+	})
+	puts := client.count("PutItem")
+
+	migrated, err := store.MigrateReservedAttributes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 1 {
+		t.Errorf("got %d migrated items, want 1", migrated)
+	}
+	if n := client.count("PutItem") - puts; n != 1 {
+		t.Errorf("got %d writes, want 1", n)
+	}
+
+	raw, err := store.AllRaw(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["old"]["expiresAt"]; ok {
+		t.Error("expiresAt field not escaped")
+	}
+	if av := dynamodb.ItemFields(raw["old"])["expiresAt"]; av == nil || aws.StringValue(av.N) != "123" {
+		t.Errorf("got fields %v", dynamodb.ItemFields(raw["old"]))
+	}
+	flag, err := store.Get(ld.Features, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := flag.(*ld.FeatureFlag); !ok || f.Version != 3 || !f.On {
+		t.Errorf("got flag %#v", flag)
+	}
+
+	if migrated, err := store.MigrateReservedAttributes(context.Background()); err != nil || migrated != 0 {
+		t.Errorf("got %d migrated items on second run, err %v", migrated, err)
+	}
+}
@@ -0,0 +1,244 @@
+package dynamodb
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// RetryMode selects how failed requests to DynamoDB are retried.
+type RetryMode string
+
+const (
+	// RetryModeStandard retries failed requests with exponential backoff,
+	// randomized and capped at MaxBackoff.
+	RetryModeStandard RetryMode = "standard"
+
+	// RetryModeAdaptive works like RetryModeStandard but also slows down
+	// all requests of the client while DynamoDB throttles them, like the
+	// adaptive mode of newer AWS SDKs. Retries then don't keep hitting a
+	// partition whose capacity hasn't been adjusted yet.
+	RetryModeAdaptive RetryMode = "adaptive"
+)
+
+// Defaults of RetryOptions
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryMaxBackoff  = 20 * time.Second
+)
+
+// retryBaseDelay is the backoff before the first retry, doubled for each
+// further one. It's the same as that of the AWS SDK's DynamoDB client.
+const retryBaseDelay = 50 * time.Millisecond
+
+// RetryOptions configure how failed requests to DynamoDB are retried. The
+// AWS SDK's default for DynamoDB is 10 retries with uncapped backoff, which
+// adds up to almost a minute and can outlast the timeout of a Lambda
+// function.
+type RetryOptions struct {
+	// How to retry (optional, default: RetryModeStandard)
+	Mode RetryMode
+
+	// Maximum number of attempts per request, including the first one
+	// (optional, default: DefaultRetryMaxAttempts)
+	MaxAttempts int
+
+	// Maximum delay before a retry (optional, default:
+	// DefaultRetryMaxBackoff)
+	MaxBackoff time.Duration
+}
+
+// ParseRetryMode returns the retry mode with the given name, "standard" or
+// "adaptive".
+func ParseRetryMode(name string) (RetryMode, error) {
+	switch mode := RetryMode(name); mode {
+	case RetryModeStandard, RetryModeAdaptive:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown retry mode %q", name)
+}
+
+// ConfigureRetries makes the store's client retry failed requests according
+// to the given options instead of the AWS SDK's defaults. It fails if the
+// client isn't a *dynamodb.DynamoDB, e.g. a mock.
+func (store *DynamoDBFeatureStore) ConfigureRetries(opts RetryOptions) error {
+	c, ok := store.Client.(*dynamodb.DynamoDB)
+	if !ok {
+		return fmt.Errorf("can't configure retries of %T", store.Client)
+	}
+	if opts.Mode == "" {
+		opts.Mode = RetryModeStandard
+	}
+	if _, err := ParseRetryMode(string(opts.Mode)); err != nil {
+		return err
+	}
+	configureRetries(c.Client, opts)
+	return nil
+}
+
+func configureRetries(c *client.Client, opts RetryOptions) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	c.Retryer = retryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: opts.MaxAttempts - 1},
+		maxBackoff:     opts.MaxBackoff,
+	}
+
+	removeAdaptiveLimiter(&c.Handlers)
+	if opts.Mode == RetryModeAdaptive {
+		(&adaptiveLimiter{}).install(&c.Handlers)
+	}
+}
+
+// retryer retries like the AWS SDK's DynamoDB client, but with jitter and
+// capped backoff.
+type retryer struct {
+	client.DefaultRetryer
+	maxBackoff time.Duration
+}
+
+func (r retryer) RetryRules(req *request.Request) time.Duration {
+	backoff := r.maxBackoff
+	if n := uint(req.RetryCount); n < 30 && retryBaseDelay<<n < backoff {
+		backoff = retryBaseDelay << n
+	}
+	// Half of the delay is random so that clients throttled at the same
+	// time don't retry at the same time
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// Parameters of adaptive retries: the rate is cut by throttleFactor on each
+// throttled request, but not below minAdaptiveRate, and grows again by
+// recoveryStep per successful request. The limit is lifted once no request
+// was throttled for recoveryPeriod.
+const (
+	throttleFactor  = 0.5
+	minAdaptiveRate = 1.0
+	recoveryStep    = 0.5
+	recoveryPeriod  = 10 * time.Second
+)
+
+// adaptiveLimiter limits the rate of requests while DynamoDB throttles them.
+type adaptiveLimiter struct {
+	mu sync.Mutex
+
+	// Requests per second, or zero if requests aren't limited
+	rate float64
+
+	// Time the next request may be sent
+	next time.Time
+
+	// Time of the last throttled request
+	throttled time.Time
+
+	// Requests sent in the current window, to measure the rate when
+	// throttling starts
+	windowStart time.Time
+	windowCount int
+}
+
+// Names of the adaptive limiter's request handlers, so that configuring
+// retries again replaces them instead of adding another limiter
+const (
+	adaptiveWaitHandler      = "lddstore.AdaptiveWaitHandler"
+	adaptiveFailedHandler    = "lddstore.AdaptiveFailedHandler"
+	adaptiveCompletedHandler = "lddstore.AdaptiveCompletedHandler"
+)
+
+// install adds the limiter to the given request handlers. Requests wait
+// after they're signed, which happens for each attempt. An error from the
+// last Sign handler keeps the request from being sent, unlike one from a
+// Send handler, which the SDK's HTTP handler overwrites, or one from an
+// earlier Sign handler, which the signer overwrites if it fails because the
+// context is done.
+func (l *adaptiveLimiter) install(h *request.Handlers) {
+	h.Sign.PushBackNamed(request.NamedHandler{Name: adaptiveWaitHandler, Fn: l.wait})
+	h.Retry.PushFrontNamed(request.NamedHandler{Name: adaptiveFailedHandler, Fn: l.failed})
+	h.Complete.PushBackNamed(request.NamedHandler{Name: adaptiveCompletedHandler, Fn: l.completed})
+}
+
+// removeAdaptiveLimiter removes the handlers of an installed limiter, if any.
+func removeAdaptiveLimiter(h *request.Handlers) {
+	h.Sign.RemoveByName(adaptiveWaitHandler)
+	h.Retry.RemoveByName(adaptiveFailedHandler)
+	h.Complete.RemoveByName(adaptiveCompletedHandler)
+}
+
+// wait delays a request while the rate is limited. It gives up if the
+// request's context is done.
+func (l *adaptiveLimiter) wait(r *request.Request) {
+	l.mu.Lock()
+	now := time.Now()
+	if now.Sub(l.windowStart) > time.Second {
+		l.windowStart, l.windowCount = now, 0
+	}
+	l.windowCount++
+
+	if l.rate > 0 && now.Sub(l.throttled) > recoveryPeriod {
+		l.rate = 0
+	}
+	if l.rate == 0 {
+		l.mu.Unlock()
+		return
+	}
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(time.Second) / l.rate))
+	l.mu.Unlock()
+
+	if delay > 0 {
+		if err := aws.SleepWithContext(r.Context(), delay); err != nil {
+			r.Error = awserr.New(request.CanceledErrorCode, "request context canceled while waiting for adaptive rate limit", err)
+		}
+	}
+}
+
+// failed lowers the rate if the request was throttled.
+func (l *adaptiveLimiter) failed(r *request.Request) {
+	if !r.IsErrorThrottle() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rate := l.rate
+	if rate == 0 {
+		rate = float64(l.windowCount) / time.Since(l.windowStart).Seconds()
+	}
+	if rate *= throttleFactor; rate < minAdaptiveRate {
+		rate = minAdaptiveRate
+	}
+	l.rate = rate
+	l.throttled = time.Now()
+}
+
+// completed raises the rate after successful requests.
+func (l *adaptiveLimiter) completed(r *request.Request) {
+	if r.Error != nil {
+		return
+	}
+	l.mu.Lock()
+	if l.rate > 0 {
+		l.rate += recoveryStep
+	}
+	l.mu.Unlock()
+}
+
+// limit returns the current rate limit, zero if requests aren't limited.
+func (l *adaptiveLimiter) limit() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
@@ -0,0 +1,133 @@
+package dynamodb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestRetryerCapsBackoff(t *testing.T) {
+	r := retryer{maxBackoff: time.Second}
+	for count, max := range map[int]time.Duration{0: 50 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second, 100: time.Second} {
+		delay := r.RetryRules(&request.Request{RetryCount: count})
+		if delay < max/2 || delay > max {
+			t.Errorf("retry %d: got delay %s, want between %s and %s", count, delay, max/2, max)
+		}
+	}
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	l := &adaptiveLimiter{}
+	req := func() *request.Request {
+		r := &request.Request{HTTPRequest: &http.Request{}}
+		r.SetContext(context.Background())
+		return r
+	}
+
+	// Requests aren't limited until one is throttled
+	for i := 0; i < 10; i++ {
+		l.wait(req())
+	}
+	if rate := l.limit(); rate != 0 {
+		t.Fatalf("got rate %f before throttling", rate)
+	}
+
+	throttled := req()
+	throttled.Error = awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "throttled", nil)
+	l.failed(throttled)
+	rate := l.limit()
+	if rate < minAdaptiveRate {
+		t.Fatalf("got rate %f after throttling", rate)
+	}
+
+	l.failed(throttled)
+	if r := l.limit(); r != rate*throttleFactor && r != minAdaptiveRate {
+		t.Errorf("got rate %f after second throttling, want %f", r, rate*throttleFactor)
+	}
+
+	l.completed(req())
+	if r := l.limit(); r <= minAdaptiveRate*throttleFactor {
+		t.Errorf("rate not raised after success: %f", r)
+	}
+
+	// Waiting is aborted when the request's context is done
+	l.mu.Lock()
+	l.rate = minAdaptiveRate
+	l.next = time.Now().Add(time.Hour)
+	l.mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := req()
+	canceled.SetContext(ctx)
+	l.wait(canceled)
+	if aerr, ok := canceled.Error.(awserr.Error); !ok || aerr.Code() != request.CanceledErrorCode {
+		t.Errorf("got error %v, want canceled", canceled.Error)
+	}
+}
+
+func TestAdaptiveLimiterCancelsRequests(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := dynamodb.New(session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(srv.URL),
+		Region:      aws.String("us-east-1"),
+	})))
+	l := &adaptiveLimiter{rate: minAdaptiveRate, throttled: time.Now(), next: time.Now().Add(time.Hour)}
+	l.install(&c.Handlers)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test-table"),
+		Key:       ItemKey(ld.Features, "flag"),
+	})
+	// The error must come from the limiter, not from sending the request
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != request.CanceledErrorCode || !strings.Contains(aerr.Message(), "adaptive rate limit") {
+		t.Errorf("got error %v, want canceled while waiting", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("got %d requests after waiting was canceled, want 0", n)
+	}
+}
+
+func TestConfigureRetriesTwice(t *testing.T) {
+	c := dynamodb.New(session.Must(session.NewSession(&aws.Config{Region: aws.String("us-east-1")})))
+	sign, retry, complete := c.Handlers.Sign.Len(), c.Handlers.Retry.Len(), c.Handlers.Complete.Len()
+
+	for i := 0; i < 2; i++ {
+		configureRetries(c.Client, RetryOptions{Mode: RetryModeAdaptive})
+	}
+	if n := c.Handlers.Sign.Len(); n != sign+1 {
+		t.Errorf("got %d Sign handlers after configuring twice, want %d", n, sign+1)
+	}
+	if n := c.Handlers.Retry.Len(); n != retry+1 {
+		t.Errorf("got %d Retry handlers after configuring twice, want %d", n, retry+1)
+	}
+	if n := c.Handlers.Complete.Len(); n != complete+1 {
+		t.Errorf("got %d Complete handlers after configuring twice, want %d", n, complete+1)
+	}
+
+	configureRetries(c.Client, RetryOptions{Mode: RetryModeStandard})
+	if n := c.Handlers.Sign.Len(); n != sign {
+		t.Errorf("got %d Sign handlers in standard mode, want %d", n, sign)
+	}
+}
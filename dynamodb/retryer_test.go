@@ -0,0 +1,85 @@
+package dynamodb_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// newThrottlingStore returns a store whose requests are throttled by a fake
+// DynamoDB endpoint, which counts them.
+func newThrottlingStore(t *testing.T, requests *int32) (*dynamodb.DynamoDBFeatureStore, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"throttled"}`))
+	}))
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(srv.URL),
+		Region:      aws.String("us-east-1"),
+	}))
+	store := &dynamodb.DynamoDBFeatureStore{
+		Client: ddb.New(sess),
+		Table:  "test-table",
+		Logger: discardLogger{},
+	}
+	return store, srv.Close
+}
+
+func TestConfigureRetries(t *testing.T) {
+	for _, mode := range []dynamodb.RetryMode{dynamodb.RetryModeStandard, dynamodb.RetryModeAdaptive} {
+		var requests int32
+		store, stop := newThrottlingStore(t, &requests)
+		defer stop()
+
+		err := store.ConfigureRetries(dynamodb.RetryOptions{
+			Mode:        mode,
+			MaxAttempts: 4,
+			MaxBackoff:  20 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		start := time.Now()
+		if _, err := store.Get(ld.Features, "flag"); err == nil {
+			t.Fatalf("%s: expected error", mode)
+		}
+		if requests != 4 {
+			t.Errorf("%s: got %d requests, want 4", mode, requests)
+		}
+		// Backoff is at most 50ms, then 20ms twice; adaptive mode adds up
+		// to a second per request at its minimum rate
+		if elapsed := time.Since(start); elapsed > 4*time.Second {
+			t.Errorf("%s: retries took %s", mode, elapsed)
+		}
+	}
+}
+
+func TestConfigureRetriesRequiresAWSClient(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.ConfigureRetries(dynamodb.RetryOptions{}); err == nil {
+		t.Error("expected error for fake client")
+	}
+}
+
+func TestParseRetryMode(t *testing.T) {
+	if mode, err := dynamodb.ParseRetryMode("adaptive"); err != nil || mode != dynamodb.RetryModeAdaptive {
+		t.Errorf("got %q, %v", mode, err)
+	}
+	if _, err := dynamodb.ParseRetryMode("legacy"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,72 @@
+package dynamodb
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/sts"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// RoleOptions describe the IAM role assumed for DynamoDB access and how its
+// sessions are identified in CloudTrail, e.g. in accounts whose tables are
+// shared by several consumers.
+type RoleOptions struct {
+	// ARN of the role to assume
+	RoleARN string
+
+	// Name of the role session, which CloudTrail records as part of the
+	// principal, e.g. the function name (optional, default: a timestamp)
+	SessionName string
+
+	// Identity of the caller, which CloudTrail records for every request
+	// made with the session and which can't be changed by chained roles
+	// (optional)
+	SourceIdentity string
+
+	// Session tags, e.g. {"function": "store", "environment": "production"},
+	// recorded by CloudTrail and usable in IAM conditions (optional)
+	Tags map[string]string
+
+	// External ID required by the role's trust policy (optional)
+	ExternalID string
+}
+
+// NewRoleCredentials returns credentials of the role, refreshed as needed.
+func NewRoleCredentials(c client.ConfigProvider, opts RoleOptions) *credentials.Credentials {
+	return stscreds.NewCredentialsWithClient(sts.New(c), opts.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+		p.RoleSessionName = opts.SessionName
+		if opts.ExternalID != "" {
+			p.ExternalID = aws.String(opts.ExternalID)
+		}
+		if opts.SourceIdentity != "" {
+			p.SourceIdentity = aws.String(opts.SourceIdentity)
+		}
+		keys := make([]string, 0, len(opts.Tags))
+		for k := range opts.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p.Tags = append(p.Tags, &sts.Tag{Key: aws.String(k), Value: aws.String(opts.Tags[k])})
+		}
+	})
+}
+
+// NewDynamoDBFeatureStoreWithRole works like NewDynamoDBFeatureStore but
+// accesses DynamoDB with the credentials of the given role.
+func NewDynamoDBFeatureStoreWithRole(table string, opts RoleOptions, logger ld.Logger) (*DynamoDBFeatureStore, error) {
+	store, err := NewDynamoDBFeatureStore(table, logger)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := newSession()
+	if err != nil {
+		return nil, err
+	}
+	store.Client = NewClient(sess, &aws.Config{Credentials: NewRoleCredentials(sess, opts)})
+	return store, nil
+}
@@ -0,0 +1,73 @@
+package dynamodb_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+const assumeRoleResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIAROLE</AccessKeyId>
+      <SecretAccessKey>rolesecret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`
+
+func TestRoleCredentials(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		fmt.Fprintf(w, assumeRoleResponse, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer srv.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(srv.URL),
+		Region:      aws.String("us-east-1"),
+	}))
+	creds := dynamodb.NewRoleCredentials(sess, dynamodb.RoleOptions{
+		RoleARN:        "arn:aws:iam::123456789012:role/launchdarkly",
+		SessionName:    "store",
+		SourceIdentity: "launchdarkly-dynamo-store-production-store",
+		Tags:           map[string]string{"function": "store", "environment": "production"},
+	})
+
+	v, err := creds.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.AccessKeyID != "ASIAROLE" {
+		t.Errorf("got access key %q", v.AccessKeyID)
+	}
+
+	want := map[string]string{
+		"Action":              "AssumeRole",
+		"RoleArn":             "arn:aws:iam::123456789012:role/launchdarkly",
+		"RoleSessionName":     "store",
+		"SourceIdentity":      "launchdarkly-dynamo-store-production-store",
+		"Tags.member.1.Key":   "environment",
+		"Tags.member.1.Value": "production",
+		"Tags.member.2.Key":   "function",
+		"Tags.member.2.Value": "store",
+	}
+	for k, v := range want {
+		if got := form.Get(k); got != v {
+			t.Errorf("%s: got %q, want %q", k, got, v)
+		}
+	}
+}
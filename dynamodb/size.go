@@ -0,0 +1,84 @@
+package dynamodb
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// MaxItemSize is the maximum size of a DynamoDB item, including attribute
+// names.
+const MaxItemSize = 400 * 1024
+
+// ItemSize returns the size of an item as DynamoDB counts it towards
+// MaxItemSize and capacity usage.
+//
+// See https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/CapacityUnitCalculations.html
+func ItemSize(item map[string]*dynamodb.AttributeValue) int {
+	size := 0
+	for name, av := range item {
+		size += len(name) + itemValueSize(av)
+	}
+	return size
+}
+
+func itemValueSize(av *dynamodb.AttributeValue) int {
+	if av == nil {
+		return 0
+	}
+
+	switch {
+	case av.S != nil:
+		return len(aws.StringValue(av.S))
+	case av.N != nil:
+		return numberSize(aws.StringValue(av.N))
+	case av.B != nil:
+		return len(av.B)
+	case av.BOOL != nil, av.NULL != nil:
+		return 1
+	case av.M != nil:
+		size := 3
+		for name, v := range av.M {
+			size += len(name) + itemValueSize(v) + 1
+		}
+		return size
+	case av.L != nil:
+		size := 3
+		for _, v := range av.L {
+			size += itemValueSize(v) + 1
+		}
+		return size
+	case av.SS != nil:
+		size := 0
+		for _, s := range av.SS {
+			size += len(aws.StringValue(s))
+		}
+		return size
+	case av.NS != nil:
+		size := 0
+		for _, n := range av.NS {
+			size += numberSize(aws.StringValue(n))
+		}
+		return size
+	case av.BS != nil:
+		size := 0
+		for _, b := range av.BS {
+			size += len(b)
+		}
+		return size
+	}
+	return 0
+}
+
+// numberSize approximates the size of a number as 1 byte per two significant
+// digits plus 1 byte.
+func numberSize(n string) int {
+	digits := strings.TrimLeft(strings.TrimLeft(n, "-"), "0.")
+	if i := strings.IndexAny(digits, "eE"); i >= 0 {
+		digits = digits[:i]
+	}
+	digits = strings.Replace(digits, ".", "", 1)
+	digits = strings.TrimRight(digits, "0")
+	return (len(digits)+1)/2 + 1
+}
@@ -0,0 +1,38 @@
+package dynamodb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestItemSize(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"key":     {S: aws.String("flag")},  // 3 + 4
+		"version": {N: aws.String("12345")}, // 7 + 4
+		"on":      {BOOL: aws.Bool(true)},   // 2 + 1
+		"variations": {L: []*dynamodb.AttributeValue{ // 10 + 3 + 2*(1+1)
+			{BOOL: aws.Bool(true)},
+			{BOOL: aws.Bool(false)},
+		}},
+	}
+	if got, want := ItemSize(item), 7+11+3+17; got != want {
+		t.Errorf("got size %d, want %d", got, want)
+	}
+}
+
+func TestNumberSize(t *testing.T) {
+	for n, want := range map[string]int{
+		"0":       1,
+		"7":       2,
+		"100000":  2,
+		"12345":   4,
+		"-0.0012": 2,
+		"1.5e10":  2,
+	} {
+		if got := numberSize(n); got != want {
+			t.Errorf("numberSize(%q) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package dynamodb
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// VersionSkewError is returned by Upsert and Delete if the version of the
+// written item is more than MaxVersionSkew lower than the stored version.
+// This usually means that another syncer is writing stale data, e.g. one
+// configured with the SDK key of a different environment.
+type VersionSkewError struct {
+	Namespace string
+	Key       string
+	Version   int
+	Stored    int
+}
+
+func (e *VersionSkewError) Error() string {
+	return fmt.Sprintf("rejected %q item (key=%s) with version %d, which is %d behind the stored version %d; "+
+		"check for syncers writing stale data to the same table",
+		e.Namespace, e.Key, e.Version, e.Stored-e.Version, e.Stored)
+}
+
+// checkVersionSkew returns a VersionSkewError if an item that wasn't written
+// due to its version is too far behind the stored item.
+func (store *DynamoDBFeatureStore) checkVersionSkew(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	if store.MaxVersionSkew <= 0 {
+		return nil
+	}
+
+	result, err := store.Client.GetItem(&dynamodb.GetItemInput{
+		TableName:            aws.String(store.Table),
+		ConsistentRead:       aws.Bool(true),
+		Key:                  ItemKey(kind, item.GetKey()),
+		ProjectionExpression: aws.String("#version"),
+		ExpressionAttributeNames: map[string]*string{
+			"#version": aws.String("version"),
+		},
+	})
+	store.observe(getRequest, err)
+	if err != nil {
+		store.Logger.Printf("WARN: Failed to check version skew (key=%s): %s", item.GetKey(), err)
+		return nil
+	}
+	av, ok := result.Item["version"]
+	if !ok || av.N == nil {
+		return nil
+	}
+	stored, err := strconv.Atoi(*av.N)
+	if err != nil || stored-item.GetVersion() <= store.MaxVersionSkew {
+		return nil
+	}
+
+	store.Logger.Printf("ERROR: Rejecting %q item with version skew (key=%s version=%d stored=%d)",
+		kind.GetNamespace(), item.GetKey(), item.GetVersion(), stored)
+	if store.OnVersionSkew != nil {
+		store.OnVersionSkew(kind, item.GetKey(), item.GetVersion(), stored)
+	}
+	return &VersionSkewError{
+		Namespace: kind.GetNamespace(),
+		Key:       item.GetKey(),
+		Version:   item.GetVersion(),
+		Stored:    stored,
+	}
+}
@@ -0,0 +1,42 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestMaxVersionSkew(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.MaxVersionSkew = 10
+	var rejected []int
+	store.OnVersionSkew = func(kind ld.VersionedDataKind, key string, version, stored int) {
+		rejected = append(rejected, version)
+	}
+
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 50}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 45}); err != nil {
+		t.Errorf("got error %v for small skew, want none", err)
+	}
+
+	err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 3})
+	serr, ok := err.(*dynamodb.VersionSkewError)
+	if !ok || serr.Version != 3 || serr.Stored != 50 {
+		t.Errorf("got error %v, want version skew error", err)
+	}
+	if err := store.Delete(ld.Features, "flag", 30); err == nil {
+		t.Error("expected version skew error for delete")
+	}
+	if len(rejected) != 2 {
+		t.Errorf("got %d rejections, want 2", len(rejected))
+	}
+
+	item, err := store.Get(ld.Features, "flag")
+	if err != nil || item.GetVersion() != 50 {
+		t.Errorf("got item %v and error %v, want version 50", item, err)
+	}
+}
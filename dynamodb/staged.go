@@ -0,0 +1,65 @@
+package dynamodb
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const stagedKey = "staged"
+
+// StagedChange records when a version of an item was first seen in a table
+// that stages changes before they are rolled out (see sync.Approval.ApplyDue).
+type StagedChange struct {
+	Version  int       `json:"version"`
+	StagedAt time.Time `json:"stagedAt"`
+}
+
+// StagedChanges returns the changes recorded with SetStagedChanges, keyed by
+// namespace and key, e.g. "features/my-flag".
+func (store *DynamoDBFeatureStore) StagedChanges(ctx context.Context) (map[string]StagedChange, error) {
+	result, err := store.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(store.Table),
+		ConsistentRead: aws.Bool(true),
+		Key:            rawKey(metadataNamespace, stagedKey),
+	})
+	store.observe(getRequest, err)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]StagedChange)
+	if av := result.Item["changes"]; av != nil {
+		for id, c := range av.M {
+			change := StagedChange{StagedAt: fromMillis(c.M["stagedAt"])}
+			if v := c.M["version"]; v != nil && v.N != nil {
+				change.Version, _ = strconv.Atoi(*v.N)
+			}
+			changes[id] = change
+		}
+	}
+	return changes, nil
+}
+
+// SetStagedChanges replaces the recorded changes.
+func (store *DynamoDBFeatureStore) SetStagedChanges(ctx context.Context, changes map[string]StagedChange) error {
+	m := make(map[string]*dynamodb.AttributeValue, len(changes))
+	for id, c := range changes {
+		m[id] = &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+			"version":  {N: aws.String(strconv.Itoa(c.Version))},
+			"stagedAt": {N: aws.String(strconv.FormatInt(unixMillis(c.StagedAt), 10))},
+		}}
+	}
+	item := rawKey(metadataNamespace, stagedKey)
+	item["changes"] = &dynamodb.AttributeValue{M: m}
+
+	_, err := store.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.Table),
+		Item:      item,
+	})
+	store.observe(writeRequest, err)
+	return err
+}
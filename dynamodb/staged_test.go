@@ -0,0 +1,33 @@
+package dynamodb_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestStagedChanges(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	changes, err := store.StagedChanges(ctx)
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("got %v, %v for empty table", changes, err)
+	}
+
+	want := map[string]dynamodb.StagedChange{
+		"features/flag": {Version: 2, StagedAt: time.Unix(1535450400, 0)},
+	}
+	if err := store.SetStagedChanges(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err = store.StagedChanges(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %+v, want %+v", changes, want)
+	}
+}
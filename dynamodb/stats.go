@@ -0,0 +1,91 @@
+package dynamodb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Stats holds counters of the requests the store sent to DynamoDB, so that
+// applications can show the store's health on their own dashboards.
+type Stats struct {
+	// Requests by type. Reads of all items of a kind are Queries; there
+	// are no table scans.
+	Gets    int64
+	Queries int64
+	Writes  int64
+
+	// Failed requests, including throttled ones
+	Errors int64
+
+	// Requests rejected due to throttling
+	Throttles int64
+
+	// Writes skipped because the stored item has the same or a higher
+	// version, which isn't an error
+	ConditionalFailures int64
+
+	// Last error and when it occurred
+	LastError     error
+	LastErrorTime time.Time
+}
+
+// Types of requests counted in Stats
+type requestType int
+
+const (
+	getRequest requestType = iota
+	queryRequest
+	writeRequest
+)
+
+// statsTracker counts the requests of a store.
+type statsTracker struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+func (t *statsTracker) record(typ requestType, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch typ {
+	case getRequest:
+		t.stats.Gets++
+	case queryRequest:
+		t.stats.Queries++
+	case writeRequest:
+		t.stats.Writes++
+	}
+
+	if err == nil {
+		return
+	}
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		t.stats.ConditionalFailures++
+		return
+	}
+	t.stats.Errors++
+	if isThrottlingError(err) {
+		t.stats.Throttles++
+	}
+	t.stats.LastError = err
+	t.stats.LastErrorTime = time.Now()
+}
+
+// Stats returns the counters of all requests sent to DynamoDB since the store
+// was created.
+func (store *DynamoDBFeatureStore) Stats() Stats {
+	t := &store.stats
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// observe records the result of a request in the store's stats and status.
+func (store *DynamoDBFeatureStore) observe(typ requestType, err error) {
+	store.stats.record(typ, err)
+	store.updateStatus(err)
+}
@@ -0,0 +1,43 @@
+package dynamodb_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestStats(t *testing.T) {
+	store, client := newTestStore(t)
+
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ld.Features, "flag"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.All(ld.Features); err != nil {
+		t.Fatal(err)
+	}
+
+	client.readErr = awserr.New(ddb.ErrCodeProvisionedThroughputExceededException, "throttled", nil)
+	if _, err := store.All(ld.Features); err == nil {
+		t.Fatal("expected error")
+	}
+
+	stats := store.Stats()
+	// Each written item also updates the lastSynced metadata item
+	if stats.Writes != 3 || stats.ConditionalFailures != 1 {
+		t.Errorf("got %d writes and %d conditional failures, want 3 and 1", stats.Writes, stats.ConditionalFailures)
+	}
+	if stats.Gets != 1 || stats.Queries != 2 {
+		t.Errorf("got %d gets and %d queries, want 1 and 2", stats.Gets, stats.Queries)
+	}
+	if stats.Errors != 1 || stats.Throttles != 1 || stats.LastError != client.readErr || stats.LastErrorTime.IsZero() {
+		t.Errorf("got stats %+v, want one throttling error", stats)
+	}
+}
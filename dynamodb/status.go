@@ -0,0 +1,123 @@
+package dynamodb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DataStoreStatus tells whether the store can currently access DynamoDB.
+//
+// This version of the LaunchDarkly SDK has no DataStoreStatusProvider, so the
+// store reports its status itself, modeled after the newer SDKs' API.
+type DataStoreStatus struct {
+	// False if the last request to DynamoDB failed, e.g. due to throttling
+	// or a network error
+	Available bool
+
+	// Time of the last status change
+	Since time.Time
+
+	// Error that made the store unavailable
+	Err error
+}
+
+// statusTracker derives the store's status from the results of requests to
+// DynamoDB and notifies listeners of changes.
+type statusTracker struct {
+	mu        sync.Mutex
+	status    *DataStoreStatus
+	listeners []chan DataStoreStatus
+}
+
+// Status returns the current status of the store. A store that hasn't sent
+// any request yet is considered available.
+func (store *DynamoDBFeatureStore) Status() DataStoreStatus {
+	t := &store.status
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status == nil {
+		return DataStoreStatus{Available: true}
+	}
+	return *t.status
+}
+
+// AddStatusListener returns a channel that receives the new status whenever
+// the store becomes unavailable or recovers. The channel holds only the latest
+// status if the receiver falls behind.
+func (store *DynamoDBFeatureStore) AddStatusListener() <-chan DataStoreStatus {
+	t := &store.status
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := make(chan DataStoreStatus, 1)
+	t.listeners = append(t.listeners, ch)
+	return ch
+}
+
+// RemoveStatusListener stops sending status changes to the given channel and
+// closes it.
+func (store *DynamoDBFeatureStore) RemoveStatusListener(ch <-chan DataStoreStatus) {
+	t := &store.status
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, l := range t.listeners {
+		if l == ch {
+			close(l)
+			t.listeners = append(t.listeners[:i], t.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// updateStatus records the result of a request to DynamoDB. Errors that don't
+// indicate an outage, e.g. failed conditions, count as successful requests.
+func (store *DynamoDBFeatureStore) updateStatus(err error) {
+	available := !isOutage(err)
+
+	t := &store.status
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.status == nil {
+		if available {
+			return
+		}
+		t.status = &DataStoreStatus{Available: true}
+	}
+	if t.status.Available == available {
+		return
+	}
+
+	t.status = &DataStoreStatus{Available: available, Since: time.Now()}
+	if available {
+		store.Logger.Printf("INFO: DynamoDB is available again")
+	} else {
+		t.status.Err = err
+		store.Logger.Printf("WARN: DynamoDB is unavailable: %s", err)
+	}
+
+	for _, ch := range t.listeners {
+		// Replace a status that hasn't been received yet
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- *t.status
+	}
+}
+
+// isOutage reports whether an error returned by the DynamoDB client means
+// that DynamoDB can't be accessed.
+func isOutage(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	switch aerr.Code() {
+	case dynamodb.ErrCodeConditionalCheckFailedException, "ValidationException":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package dynamodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+func TestStatusListener(t *testing.T) {
+	store, client := newTestStore(t)
+	ch := store.AddStatusListener()
+	defer store.RemoveStatusListener(ch)
+
+	if !store.Status().Available {
+		t.Fatal("expected new store to be available")
+	}
+
+	client.readErr = awserr.New(ddb.ErrCodeProvisionedThroughputExceededException, "throttled", nil)
+	if _, err := store.Get(ld.Features, "flag"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := store.All(ld.Features); err == nil {
+		t.Fatal("expected error")
+	}
+
+	select {
+	case status := <-ch:
+		if status.Available || status.Err == nil {
+			t.Errorf("unexpected status: %+v", status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no status change received")
+	}
+	if store.Status().Available {
+		t.Error("expected store to be unavailable")
+	}
+
+	client.readErr = nil
+	if _, err := store.Get(ld.Features, "flag"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case status := <-ch:
+		if !status.Available {
+			t.Errorf("unexpected status: %+v", status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no status change received")
+	}
+}
+
+func TestStatusIgnoresFailedConditions(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2})
+	store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1})
+
+	if !store.Status().Available {
+		t.Errorf("unexpected status: %+v", store.Status())
+	}
+}
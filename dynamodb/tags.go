@@ -0,0 +1,102 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TagTable applies the configured Tags to the store's table. Existing tags
+// with other keys are kept.
+func (store *DynamoDBFeatureStore) TagTable(ctx context.Context) error {
+	if len(store.Tags) == 0 {
+		return nil
+	}
+	arn, err := store.tableARN(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = store.Client.TagResourceWithContext(ctx, &dynamodb.TagResourceInput{
+		ResourceArn: arn,
+		Tags:        tagList(store.Tags),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag table: %s", err)
+	}
+	return nil
+}
+
+// ValidateTags checks that the store's table has all configured Tags with the
+// configured values, e.g. to enforce a tagging policy.
+func (store *DynamoDBFeatureStore) ValidateTags(ctx context.Context) error {
+	if len(store.Tags) == 0 {
+		return nil
+	}
+	arn, err := store.tableARN(ctx)
+	if err != nil {
+		return err
+	}
+	tags, err := store.tableTags(ctx, arn)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for key, want := range store.Tags {
+		got, ok := tags[key]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("missing tag %q", key))
+		case got != want:
+			problems = append(problems, fmt.Sprintf("tag %q is %q, want %q", key, got, want))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("table %q: %s", store.Table, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func (store *DynamoDBFeatureStore) tableARN(ctx context.Context) (*string, error) {
+	desc, err := store.Client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(store.Table),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table: %s", err)
+	}
+	return desc.Table.TableArn, nil
+}
+
+func (store *DynamoDBFeatureStore) tableTags(ctx context.Context, arn *string) (map[string]string, error) {
+	out, err := store.Client.ListTagsOfResourceWithContext(ctx, &dynamodb.ListTagsOfResourceInput{
+		ResourceArn: arn,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %s", err)
+	}
+	tags := make(map[string]string, len(out.Tags))
+	for _, tag := range out.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags, nil
+}
+
+// tagList converts tags to the API representation, sorted by key.
+func tagList(tags map[string]string) []*dynamodb.Tag {
+	var keys []string
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := make([]*dynamodb.Tag, len(keys))
+	for i, key := range keys {
+		list[i] = &dynamodb.Tag{Key: aws.String(key), Value: aws.String(tags[key])}
+	}
+	return list
+}
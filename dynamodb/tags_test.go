@@ -0,0 +1,38 @@
+package dynamodb_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestTagTable(t *testing.T) {
+	store, client := newTestStore(t)
+	client.tables["test-table"] = nil
+	client.tags = map[string][]*ddb.Tag{
+		tableARN("test-table"): {
+			{Key: aws.String("owner"), Value: aws.String("someone")},
+			{Key: aws.String("team"), Value: aws.String("platform")},
+		},
+	}
+	store.Tags = map[string]string{"owner": "flags-team", "cost-center": "42"}
+
+	err := store.ValidateTags(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `missing tag "cost-center"`) ||
+		!strings.Contains(err.Error(), `tag "owner" is "someone", want "flags-team"`) {
+		t.Errorf("got error %v", err)
+	}
+
+	if err := store.TagTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ValidateTags(context.Background()); err != nil {
+		t.Errorf("expected valid tags after tagging, got %v", err)
+	}
+	if n := len(client.tags[tableARN("test-table")]); n != 3 {
+		t.Errorf("got %d tags, want 3", n)
+	}
+}
@@ -0,0 +1,88 @@
+package dynamodb
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Defaults of TransportOptions
+const (
+	DefaultMaxIdleConns        = 64
+	DefaultIdleConnTimeout     = 60 * time.Second
+	DefaultTLSHandshakeTimeout = 5 * time.Second
+)
+
+// TransportOptions tune how connections to DynamoDB are reused. Go's default
+// transport keeps only two idle connections per host, so bursts of
+// concurrent requests, e.g. the batch writes of a sync or the reads of a busy
+// function, pay for new TLS handshakes every time.
+type TransportOptions struct {
+	// Maximum number of idle connections kept open (optional, default:
+	// DefaultMaxIdleConns)
+	MaxIdleConns int
+
+	// Time after which idle connections are closed (optional, default:
+	// DefaultIdleConnTimeout)
+	IdleConnTimeout time.Duration
+
+	// Maximum time of a TLS handshake (optional, default:
+	// DefaultTLSHandshakeTimeout)
+	TLSHandshakeTimeout time.Duration
+}
+
+// defaultHTTPClient is shared by the stores created with the constructors of
+// this package, so that stores of several tables share connections.
+var defaultHTTPClient = NewHTTPClient(TransportOptions{})
+
+// NewHTTPClient returns an HTTP client for DynamoDB with the given
+// connection reuse settings. Proxies are configured via the environment as
+// usual.
+func NewHTTPClient(opts TransportOptions) *http.Client {
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	if opts.TLSHandshakeTimeout <= 0 {
+		opts.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			// All requests go to the same host
+			MaxIdleConns:          opts.MaxIdleConns,
+			MaxIdleConnsPerHost:   opts.MaxIdleConns,
+			IdleConnTimeout:       opts.IdleConnTimeout,
+			TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
+
+// newSession returns an AWS session that uses the shared HTTP client.
+func newSession() (*session.Session, error) {
+	return session.NewSession(&aws.Config{HTTPClient: defaultHTTPClient})
+}
+
+// ConfigureTransport makes the store's client use its own connections with
+// the given settings instead of those shared by the stores of this package.
+// It fails if the client isn't a *dynamodb.DynamoDB, e.g. a mock.
+func (store *DynamoDBFeatureStore) ConfigureTransport(opts TransportOptions) error {
+	c, ok := store.Client.(*dynamodb.DynamoDB)
+	if !ok {
+		return fmt.Errorf("can't configure transport of %T", store.Client)
+	}
+	c.Config.HTTPClient = NewHTTPClient(opts)
+	return nil
+}
@@ -0,0 +1,75 @@
+package dynamodb_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	gosync "sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	ddb "github.com/aws/aws-sdk-go/service/dynamodb"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestConfigureTransportReusesConnections(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Write([]byte(`{}`))
+	}))
+	srv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(srv.URL),
+		Region:      aws.String("us-east-1"),
+	}))
+	store := &dynamodb.DynamoDBFeatureStore{
+		Client: ddb.New(sess),
+		Table:  "test-table",
+		Logger: discardLogger{},
+	}
+	if err := store.ConfigureTransport(dynamodb.TransportOptions{MaxIdleConns: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	burst := func() {
+		var wg gosync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := store.Get(ld.Features, "flag"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	burst()
+	opened := atomic.LoadInt32(&conns)
+	burst()
+	if n := atomic.LoadInt32(&conns) - opened; n != 0 {
+		t.Errorf("second burst opened %d new connection(s), want 0", n)
+	}
+}
+
+func TestConfigureTransportRequiresAWSClient(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.ConfigureTransport(dynamodb.TransportOptions{}); err == nil {
+		t.Error("expected error for fake client")
+	}
+}
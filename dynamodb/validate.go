@@ -0,0 +1,235 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// DefaultValidationUsers are the users that flags are evaluated for by
+// ValidateFlags unless ValidationUsers is set. Their keys spread them over
+// rollout buckets.
+var DefaultValidationUsers = validationUsers(20)
+
+func validationUsers(n int) []ld.User {
+	users := make([]ld.User, n)
+	for i := range users {
+		users[i] = ld.NewUser(fmt.Sprintf("validation-user-%d", i))
+	}
+	return users
+}
+
+// ValidationError describes a flag that failed validation (see
+// ValidateFlags). Upsert returns it for invalid flags that weren't
+// quarantined.
+type ValidationError struct {
+	Namespace string
+	Key       string
+	Version   int
+	Err       error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %q item (key=%s version=%d): %s", e.Namespace, e.Key, e.Version, e.Err)
+}
+
+// ValidateFlag checks that the LaunchDarkly SDK can evaluate a flag: all
+// variation indexes must be in range, clauses must use operators known to
+// the SDK and valid regular expressions, and evaluating the flag must succeed
+// for each of the given users. Prerequisites aren't evaluated, as the flags
+// they refer to may not be stored yet, and segments are treated as empty.
+func ValidateFlag(flag *ld.FeatureFlag, users []ld.User) error {
+	if flag.OffVariation != nil {
+		if err := checkVariation(flag, "off variation", *flag.OffVariation); err != nil {
+			return err
+		}
+	}
+	for i, target := range flag.Targets {
+		if err := checkVariation(flag, fmt.Sprintf("target %d", i), target.Variation); err != nil {
+			return err
+		}
+	}
+	for i, rule := range flag.Rules {
+		what := fmt.Sprintf("rule %d", i)
+		if err := checkVariationOrRollout(flag, what, rule.VariationOrRollout); err != nil {
+			return err
+		}
+		for j, clause := range rule.Clauses {
+			if err := checkClause(clause); err != nil {
+				return fmt.Errorf("%s, clause %d: %s", what, j, err)
+			}
+		}
+	}
+	if err := checkVariationOrRollout(flag, "fallthrough", flag.Fallthrough); err != nil {
+		return err
+	}
+	for i, prereq := range flag.Prerequisites {
+		if prereq.Key == "" {
+			return fmt.Errorf("prerequisite %d has no key", i)
+		}
+	}
+
+	f := *flag
+	f.Prerequisites = nil
+	segments := ld.NewInMemoryFeatureStore(nil)
+	for _, user := range users {
+		if err := evaluateFlag(&f, user, segments); err != nil {
+			return fmt.Errorf("failed to evaluate for user %q: %s", *user.Key, err)
+		}
+	}
+	return nil
+}
+
+func checkVariation(flag *ld.FeatureFlag, what string, index int) error {
+	if index < 0 || index >= len(flag.Variations) {
+		return fmt.Errorf("%s has variation index %d, but there are %d variation(s)", what, index, len(flag.Variations))
+	}
+	return nil
+}
+
+func checkVariationOrRollout(flag *ld.FeatureFlag, what string, vr ld.VariationOrRollout) error {
+	if vr.Variation != nil {
+		return checkVariation(flag, what, *vr.Variation)
+	}
+	if vr.Rollout != nil {
+		for _, wv := range vr.Rollout.Variations {
+			if err := checkVariation(flag, what+" rollout", wv.Variation); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkClause(clause ld.Clause) error {
+	if clause.Attribute == "" && clause.Op != ld.OperatorSegmentMatch {
+		return fmt.Errorf("no attribute")
+	}
+	known := false
+	for _, op := range ld.OpsList {
+		if clause.Op == op {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("unknown operator %q", clause.Op)
+	}
+	if clause.Op == ld.OperatorMatches {
+		for _, v := range clause.Values {
+			if s, ok := v.(string); ok {
+				if _, err := regexp.Compile(s); err != nil {
+					return fmt.Errorf("invalid regular expression %q: %s", s, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateFlag evaluates a flag like the SDK does, turning panics, e.g. due
+// to negative variation indexes, into errors.
+func evaluateFlag(flag *ld.FeatureFlag, user ld.User, store ld.FeatureStore) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	_, err = flag.EvaluateExplain(user, store)
+	return err
+}
+
+// validateItem returns a *ValidationError if the item is a *RejectedItem or,
+// if ValidateFlags is set, a flag that fails ValidateFlag. Other items and
+// deleted flags are always valid.
+func (store *DynamoDBFeatureStore) validateItem(kind ld.VersionedDataKind, item ld.VersionedData) *ValidationError {
+	if rejected, ok := item.(*RejectedItem); ok {
+		return &ValidationError{
+			Namespace: kind.GetNamespace(),
+			Key:       item.GetKey(),
+			Version:   item.GetVersion(),
+			Err:       rejected.Err,
+		}
+	}
+	if !store.ValidateFlags || kind.GetNamespace() != ld.Features.GetNamespace() || item.IsDeleted() {
+		return nil
+	}
+
+	var flag *ld.FeatureFlag
+	var err error
+	switch v := item.(type) {
+	case *ld.FeatureFlag:
+		flag = v
+	case *RawItem:
+		flag = &ld.FeatureFlag{}
+		if err = json.Unmarshal(v.JSON, flag); err != nil {
+			err = fmt.Errorf("failed to decode: %s", err)
+		}
+	default:
+		err = fmt.Errorf("unexpected type %T", item)
+	}
+	if err == nil {
+		users := store.ValidationUsers
+		if len(users) == 0 {
+			users = DefaultValidationUsers
+		}
+		err = ValidateFlag(flag, users)
+	}
+	if err != nil {
+		return &ValidationError{
+			Namespace: kind.GetNamespace(),
+			Key:       item.GetKey(),
+			Version:   item.GetVersion(),
+			Err:       err,
+		}
+	}
+	return nil
+}
+
+// rejectInvalid writes an invalid item to the quarantine table, if there is
+// one, and returns an error if it wasn't quarantined.
+func (store *DynamoDBFeatureStore) rejectInvalid(kind ld.VersionedDataKind, item ld.VersionedData, verr *ValidationError) error {
+	if store.QuarantineTable == "" {
+		store.Logger.Printf("ERROR: Rejecting %s", verr)
+		return verr
+	}
+	if err := store.quarantine(kind, item, verr); err != nil {
+		store.Logger.Printf("ERROR: Failed to quarantine %s: %s", verr, err)
+		return verr
+	}
+	store.Logger.Printf("WARN: Quarantined %s", verr)
+	return nil
+}
+
+// rejectInvalidItems returns allData without the items that fail validation
+// and records their keys in the report.
+func (store *DynamoDBFeatureStore) rejectInvalidItems(allData map[ld.VersionedDataKind]map[string]ld.VersionedData, report *InitReport) map[ld.VersionedDataKind]map[string]ld.VersionedData {
+	valid := make(map[ld.VersionedDataKind]map[string]ld.VersionedData, len(allData))
+	for kind, items := range allData {
+		var rejected []string
+		for key, item := range items {
+			if verr := store.validateItem(kind, item); verr != nil {
+				store.rejectInvalid(kind, item, verr)
+				rejected = append(rejected, key)
+			}
+		}
+		if len(rejected) == 0 {
+			valid[kind] = items
+			continue
+		}
+
+		sort.Strings(rejected)
+		report.Rejected[kind] = rejected
+		valid[kind] = make(map[string]ld.VersionedData, len(items)-len(rejected))
+		for key, item := range items {
+			valid[kind][key] = item
+		}
+		for _, key := range rejected {
+			delete(valid[kind], key)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,220 @@
+package dynamodb_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func validFlag(key string, version int) *ld.FeatureFlag {
+	return &ld.FeatureFlag{
+		Key:          key,
+		Version:      version,
+		On:           true,
+		Variations:   []interface{}{false, true},
+		OffVariation: aws.Int(0),
+		Fallthrough:  ld.VariationOrRollout{Variation: aws.Int(1)},
+	}
+}
+
+func TestValidateFlag(t *testing.T) {
+	for name, tt := range map[string]struct {
+		modify func(*ld.FeatureFlag)
+		err    string
+	}{
+		"valid": {func(*ld.FeatureFlag) {}, ""},
+		"off variation": {func(f *ld.FeatureFlag) {
+			f.OffVariation = aws.Int(2)
+		}, "off variation has variation index 2"},
+		"target": {func(f *ld.FeatureFlag) {
+			f.Targets = []ld.Target{{Values: []string{"someone"}, Variation: -1}}
+		}, "target 0 has variation index -1"},
+		"rollout": {func(f *ld.FeatureFlag) {
+			f.Fallthrough = ld.VariationOrRollout{Rollout: &ld.Rollout{Variations: []ld.WeightedVariation{
+				{Variation: 0, Weight: 50000}, {Variation: 5, Weight: 50000},
+			}}}
+		}, "fallthrough rollout has variation index 5"},
+		"operator": {func(f *ld.FeatureFlag) {
+			f.Rules = []ld.Rule{{
+				VariationOrRollout: ld.VariationOrRollout{Variation: aws.Int(1)},
+				Clauses:            []ld.Clause{{Attribute: "email", Op: "isOneOf"}},
+			}}
+		}, `rule 0, clause 0: unknown operator "isOneOf"`},
+		"regexp": {func(f *ld.FeatureFlag) {
+			f.Rules = []ld.Rule{{
+				VariationOrRollout: ld.VariationOrRollout{Variation: aws.Int(1)},
+				Clauses:            []ld.Clause{{Attribute: "email", Op: ld.OperatorMatches, Values: []interface{}{"("}}},
+			}}
+		}, "invalid regular expression"},
+	} {
+		flag := validFlag("flag", 1)
+		tt.modify(flag)
+		err := dynamodb.ValidateFlag(flag, dynamodb.DefaultValidationUsers)
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: got error %v, want %q", name, err, tt.err)
+		}
+	}
+}
+
+func invalidFlag(key string, version int) *ld.FeatureFlag {
+	flag := validFlag(key, version)
+	flag.Fallthrough.Variation = aws.Int(2)
+	return flag
+}
+
+func TestUpsertRejectsInvalidFlags(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.ValidateFlags = true
+	if err := store.Upsert(ld.Features, validFlag("flag", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := store.Upsert(ld.Features, invalidFlag("flag", 2))
+	if verr, ok := err.(*dynamodb.ValidationError); !ok || verr.Key != "flag" || verr.Version != 2 {
+		t.Fatalf("got error %#v, want validation error", err)
+	}
+	flag, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag.GetVersion() != 1 {
+		t.Errorf("got version %d, want the valid version 1", flag.GetVersion())
+	}
+
+	// Deleting invalid flags is fine
+	if err := store.Delete(ld.Features, "flag", 3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpsertQuarantinesInvalidFlags(t *testing.T) {
+	store, client := newTestStore(t)
+	store.ValidateFlags = true
+	store.QuarantineTable = "quarantine"
+
+	var raw dynamodb.RawItem
+	if err := json.Unmarshal([]byte(`{"key":"raw","version":1,"on":true,"variations":[true],"fallthrough":{"variation":1}}`), &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []ld.VersionedData{invalidFlag("flag", 1), &raw} {
+		if err := store.Upsert(ld.Features, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := len(client.table("test-table")); n != 0 {
+		t.Errorf("got %d item(s) in main table, want none", n)
+	}
+	quarantined := client.table("quarantine")
+	if len(quarantined) != 2 {
+		t.Fatalf("got quarantined items %v", quarantined)
+	}
+	item := quarantined["features\x00raw"]
+	if aws.StringValue(item["item"].S) != string(raw.JSON) || !strings.Contains(aws.StringValue(item["reason"].S), "variation index 1") {
+		t.Errorf("got quarantined item %v", item)
+	}
+}
+
+func TestInitRejectsInvalidFlags(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.ValidateFlags = true
+	if err := store.Upsert(ld.Features, validFlag("flag", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"flag":  invalidFlag("flag", 2),
+			"other": validFlag("other", 1),
+		},
+		ld.Segments: {},
+	})
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if rejected := report.Rejected[ld.Features]; len(rejected) != 1 || rejected[0] != "flag" {
+		t.Errorf("got rejected items %v, want [flag]", report.Rejected)
+	}
+
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 2 || flags["flag"].GetVersion() != 1 || flags["other"] == nil {
+		t.Errorf("got flags %v, want the valid version of flag and other", flags)
+	}
+}
+
+func TestQuarantineRejectedItems(t *testing.T) {
+	store, _ := newTestStore(t)
+	store.QuarantineTable = "quarantine"
+	if err := store.Upsert(ld.Features, validFlag("flag", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"key":"flag","version":2,"on":"yes"}`)
+	rejected := dynamodb.NewRejectedItem("flag", payload, errors.New("cannot unmarshal"))
+	report := store.InitWithReport(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": rejected, "other": validFlag("other", 1)},
+	})
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if r := report.Rejected[ld.Features]; len(r) != 1 || r[0] != "flag" {
+		t.Errorf("got rejected items %v, want [flag]", report.Rejected)
+	}
+	flag, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag.GetVersion() != 1 {
+		t.Errorf("got version %d, want the stored version 1", flag.GetVersion())
+	}
+
+	quarantined, err := store.Quarantined()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quarantined) != 1 {
+		t.Fatalf("got quarantined items %v", quarantined)
+	}
+	q := quarantined[0]
+	if q.Namespace != "features" || q.Key != "flag" || q.Version != 2 || q.Item != string(payload) ||
+		!strings.Contains(q.Reason, "cannot unmarshal") || q.QuarantinedAt.IsZero() {
+		t.Errorf("got quarantined item %+v", q)
+	}
+
+	// The summary doesn't read the payload
+	summary, err := store.QuarantineSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary) != 1 || summary[0].Key != "flag" || summary[0].Version != 2 || summary[0].Item != "" ||
+		summary[0].Reason != q.Reason || !summary[0].QuarantinedAt.Equal(q.QuarantinedAt) {
+		t.Errorf("got quarantine summary %+v", summary)
+	}
+
+	if err := store.RemoveQuarantined(ld.Features, "flag"); err != nil {
+		t.Fatal(err)
+	}
+	if quarantined, err := store.Quarantined(); err != nil || len(quarantined) != 0 {
+		t.Errorf("got quarantined items %v, error %v", quarantined, err)
+	}
+}
+
+func TestUpsertRejectsRejectedItemsWithoutQuarantine(t *testing.T) {
+	store, client := newTestStore(t)
+	err := store.Upsert(ld.Features, dynamodb.NewRejectedItem("flag", []byte("{"), errors.New("unexpected EOF")))
+	if _, ok := err.(*dynamodb.ValidationError); !ok {
+		t.Errorf("got error %#v, want validation error", err)
+	}
+	if n := len(client.table("test-table")); n != 0 {
+		t.Errorf("got %d item(s) in main table, want none", n)
+	}
+}
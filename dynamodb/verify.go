@@ -0,0 +1,69 @@
+package dynamodb
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/canonical"
+)
+
+// verifyKind re-reads all items of a kind after Init and records the keys of
+// items whose content differs from what was written, e.g. due to marshaling
+// asymmetries or lost writes. Frozen items are expected to differ.
+func (store *DynamoDBFeatureStore) verifyKind(kind ld.VersionedDataKind, items map[string]ld.VersionedData, report *InitReport) error {
+	stored, err := store.AllIncludingDeleted(kind)
+	if err != nil {
+		return err
+	}
+
+	report.mu.Lock()
+	frozen := make(map[string]bool, len(report.Frozen[kind]))
+	for _, key := range report.Frozen[kind] {
+		frozen[key] = true
+	}
+	report.mu.Unlock()
+
+	var divergent []string
+	for key, item := range items {
+		if frozen[key] {
+			continue
+		}
+		want, err := contentHash(item)
+		if err != nil {
+			return err
+		}
+		var got string
+		if s, ok := stored[key]; ok {
+			if got, err = contentHash(s); err != nil {
+				return err
+			}
+		}
+		if got != want {
+			divergent = append(divergent, key)
+		}
+	}
+
+	if len(divergent) > 0 {
+		sort.Strings(divergent)
+		store.Logger.Printf("ERROR: %d %q item(s) differ from the data passed to Init: %v",
+			len(divergent), kind.GetNamespace(), divergent)
+		report.mu.Lock()
+		report.Divergent[kind] = divergent
+		report.mu.Unlock()
+	}
+	return nil
+}
+
+// contentHash returns a hash of an item's canonical JSON representation, which
+// ignores null values and empty lists and objects, as DynamoDB can't
+// distinguish them.
+func contentHash(item ld.VersionedData) (string, error) {
+	data, err := canonical.JSON(item)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
@@ -0,0 +1,54 @@
+package dynamodb
+
+import (
+	"context"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Polling intervals of WaitForInitialization
+const (
+	minWaitInterval = 100 * time.Millisecond
+	maxWaitInterval = 2 * time.Second
+)
+
+// WaitForInitialization blocks until another process, usually the sync
+// Lambda, has written feature flags to the table, or until ctx is done. Once
+// data is available, the store is marked as initialized.
+//
+// Use this in processes that only read from DynamoDB (daemon mode, UseLdd)
+// instead of relying on the timeout passed to ld.MakeCustomClient, which
+// doesn't wait for the store in that mode.
+func (store *DynamoDBFeatureStore) WaitForInitialization(ctx context.Context) error {
+	interval := minWaitInterval
+	for {
+		ready, err := store.hasData()
+		if err != nil {
+			store.Logger.Printf("WARN: Failed to check for data while waiting for initialization: %s", err)
+		} else if ready {
+			store.setInitialized()
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > maxWaitInterval {
+			interval = maxWaitInterval
+		}
+	}
+}
+
+// hasData reports whether the table has been synced and contains flags, at
+// least MinFlags of them if set.
+func (store *DynamoDBFeatureStore) hasData() (bool, error) {
+	synced, err := store.LastSynced()
+	if err != nil || synced.IsZero() {
+		return false, err
+	}
+	keys, err := store.queryKeys(ld.Features.GetNamespace())
+	return len(keys) > 0 && len(keys) >= store.MinFlags, err
+}
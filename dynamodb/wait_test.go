@@ -0,0 +1,41 @@
+package dynamodb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+func TestWaitForInitialization(t *testing.T) {
+	writer, client := newTestStore(t)
+	reader := &dynamodb.DynamoDBFeatureStore{Client: client, Table: writer.Table, Logger: writer.Logger}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := reader.WaitForInitialization(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want DeadlineExceeded", err)
+	}
+	if reader.Initialized() {
+		t.Fatal("expected store not to be initialized")
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		writer.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+			ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+		})
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := reader.WaitForInitialization(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !reader.Initialized() {
+		t.Error("expected store to be initialized")
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Package evaluator evaluates feature flags directly from a feature store, e.g.
+a DynamoDBFeatureStore, using the evaluation rules of the LaunchDarkly SDK.
+
+Unlike an ld.LDClient, an Evaluator has no background goroutines, doesn't
+connect to LaunchDarkly, and doesn't send analytics events, which makes it
+cheap to create per Lambda invocation:
+
+	e := evaluator.New(store)
+	value, _, err := e.Evaluate("my-flag", ld.NewUser("user-key"), false)
+*/
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Reason explains why a flag evaluated to a value.
+type Reason string
+
+// Evaluation reasons
+const (
+	ReasonOff                Reason = "OFF"
+	ReasonTargetMatch        Reason = "TARGET_MATCH"
+	ReasonRuleMatch          Reason = "RULE_MATCH"
+	ReasonFallthrough        Reason = "FALLTHROUGH"
+	ReasonPrerequisiteFailed Reason = "PREREQUISITE_FAILED"
+)
+
+// Errors returned by EvaluateDetail
+var (
+	ErrFlagNotFound   = errors.New("flag not found")
+	ErrUserKeyMissing = errors.New("user key is required")
+)
+
+// Detail is the result of evaluating a flag.
+type Detail struct {
+	// Value of the selected variation
+	Value interface{}
+
+	// Index of the selected variation
+	Variation *int
+
+	// Why the variation was selected
+	Reason Reason
+
+	// Matched rule if Reason is ReasonRuleMatch, and whether the variation
+	// was selected by a percentage rollout
+	Rule    *ld.Rule
+	InSplit bool
+}
+
+// Evaluator evaluates flags from a store.
+type Evaluator struct {
+	// Store to read flags and segments from
+	Store ld.FeatureStore
+}
+
+// New creates an evaluator for the given store.
+func New(store ld.FeatureStore) *Evaluator {
+	return &Evaluator{Store: store}
+}
+
+// EvaluateDetail evaluates a flag for a user and explains the result.
+func (e *Evaluator) EvaluateDetail(key string, user ld.User) (Detail, error) {
+	if user.Key == nil || *user.Key == "" {
+		return Detail{}, ErrUserKeyMissing
+	}
+
+	item, err := e.Store.Get(ld.Features, key)
+	if err != nil {
+		return Detail{}, err
+	}
+	flag, ok := item.(*ld.FeatureFlag)
+	if !ok || flag == nil {
+		return Detail{}, ErrFlagNotFound
+	}
+	return e.evaluate(flag, user)
+}
+
+func (e *Evaluator) evaluate(flag *ld.FeatureFlag, user ld.User) (Detail, error) {
+	reason := ReasonOff
+	if flag.On {
+		result, err := flag.EvaluateExplain(user, e.Store)
+		if err != nil {
+			return Detail{}, err
+		}
+		if result.Value != nil {
+			d := Detail{Value: result.Value, Variation: result.Variation}
+			if x := result.Explanation; x != nil {
+				switch x.Kind {
+				case "target":
+					d.Reason = ReasonTargetMatch
+				case "rule":
+					d.Reason = ReasonRuleMatch
+					d.Rule = x.Rule
+					d.InSplit = x.Rule.Rollout != nil
+				default:
+					d.Reason = ReasonFallthrough
+					d.InSplit = x.VariationOrRollout != nil && x.VariationOrRollout.Rollout != nil
+				}
+			}
+			return d, nil
+		}
+		// A prerequisite failed, so the off variation is served
+		reason = ReasonPrerequisiteFailed
+	}
+
+	if flag.OffVariation == nil || *flag.OffVariation >= len(flag.Variations) {
+		return Detail{Reason: reason}, fmt.Errorf("flag %q has no off variation", flag.Key)
+	}
+	return Detail{
+		Value:     flag.Variations[*flag.OffVariation],
+		Variation: flag.OffVariation,
+		Reason:    reason,
+	}, nil
+}
+
+// Evaluate evaluates a flag for a user and returns the value and variation
+// index, or defaultVal if the flag can't be evaluated. It has the signature
+// of ld.LDClient.Evaluate so that it can be used in its place.
+func (e *Evaluator) Evaluate(key string, user ld.User, defaultVal interface{}) (interface{}, *int, error) {
+	d, err := e.EvaluateDetail(key, user)
+	if err != nil || d.Value == nil {
+		return defaultVal, nil, err
+	}
+	return d.Value, d.Variation, nil
+}
+
+// AllFlags returns the values of all flags for a user, with nil for flags
+// that can't be evaluated.
+func (e *Evaluator) AllFlags(user ld.User) map[string]interface{} {
+	items, err := e.Store.All(ld.Features)
+	if err != nil {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(items))
+	for key, item := range items {
+		flag, ok := item.(*ld.FeatureFlag)
+		if !ok || user.Key == nil {
+			values[key] = nil
+			continue
+		}
+		d, _ := e.evaluate(flag, user)
+		values[key] = d.Value
+	}
+	return values
+}
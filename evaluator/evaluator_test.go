@@ -0,0 +1,193 @@
+package evaluator_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/evaluator"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func newTestEvaluator(t *testing.T) *evaluator.Evaluator {
+	store := ld.NewInMemoryFeatureStore(nil)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"flag": &ld.FeatureFlag{
+				Key:          "flag",
+				Version:      1,
+				On:           true,
+				Variations:   []interface{}{"off", "fallthrough", "target", "rule"},
+				OffVariation: intPtr(0),
+				Fallthrough:  ld.VariationOrRollout{Variation: intPtr(1)},
+				Targets:      []ld.Target{{Values: []string{"targeted"}, Variation: 2}},
+				Rules: []ld.Rule{{
+					VariationOrRollout: ld.VariationOrRollout{Variation: intPtr(3)},
+					Clauses: []ld.Clause{{
+						Attribute: "segmentMatch",
+						Op:        ld.OperatorSegmentMatch,
+						Values:    []interface{}{"beta"},
+					}},
+				}},
+			},
+			"off-flag": &ld.FeatureFlag{
+				Key:          "off-flag",
+				Version:      1,
+				Variations:   []interface{}{true, false},
+				OffVariation: intPtr(1),
+			},
+			"prereq-flag": &ld.FeatureFlag{
+				Key:           "prereq-flag",
+				Version:       1,
+				On:            true,
+				Variations:    []interface{}{"off", "on"},
+				OffVariation:  intPtr(0),
+				Fallthrough:   ld.VariationOrRollout{Variation: intPtr(1)},
+				Prerequisites: []ld.Prerequisite{{Key: "off-flag", Variation: 0}},
+			},
+		},
+		ld.Segments: {
+			"beta": &ld.Segment{Key: "beta", Version: 1, Included: []string{"beta-user"}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return evaluator.New(store)
+}
+
+func TestEvaluateDetail(t *testing.T) {
+	e := newTestEvaluator(t)
+
+	for _, tt := range []struct {
+		flag, user string
+		value      interface{}
+		reason     evaluator.Reason
+	}{
+		{"flag", "someone", "fallthrough", evaluator.ReasonFallthrough},
+		{"flag", "targeted", "target", evaluator.ReasonTargetMatch},
+		{"flag", "beta-user", "rule", evaluator.ReasonRuleMatch},
+		{"off-flag", "someone", false, evaluator.ReasonOff},
+		{"prereq-flag", "someone", "off", evaluator.ReasonPrerequisiteFailed},
+	} {
+		d, err := e.EvaluateDetail(tt.flag, ld.NewUser(tt.user))
+		if err != nil {
+			t.Errorf("%s/%s: %s", tt.flag, tt.user, err)
+			continue
+		}
+		if d.Value != tt.value || d.Reason != tt.reason {
+			t.Errorf("%s/%s: got %v (%s), want %v (%s)", tt.flag, tt.user, d.Value, d.Reason, tt.value, tt.reason)
+		}
+	}
+
+	if _, err := e.EvaluateDetail("unknown", ld.NewUser("someone")); err != evaluator.ErrFlagNotFound {
+		t.Errorf("got error %v, want ErrFlagNotFound", err)
+	}
+	if _, err := e.EvaluateDetail("flag", ld.User{}); err != evaluator.ErrUserKeyMissing {
+		t.Errorf("got error %v, want ErrUserKeyMissing", err)
+	}
+}
+
+func TestEvaluateReturnsDefault(t *testing.T) {
+	e := newTestEvaluator(t)
+	value, variation, err := e.Evaluate("unknown", ld.NewUser("someone"), "default")
+	if value != "default" || variation != nil || err == nil {
+		t.Errorf("got %v, %v, %v", value, variation, err)
+	}
+}
+
+func TestAllFlags(t *testing.T) {
+	values := newTestEvaluator(t).AllFlags(ld.NewUser("targeted"))
+	if len(values) != 3 || values["flag"] != "target" || values["off-flag"] != false {
+		t.Errorf("got %v", values)
+	}
+}
+
+type clientSideStore struct {
+	ld.FeatureStore
+	keys map[string]bool
+}
+
+func (s clientSideStore) ClientSideFlags() (map[string]bool, error) {
+	return s.keys, nil
+}
+
+func TestAllFlagsState(t *testing.T) {
+	e := newTestEvaluator(t)
+
+	state := e.AllFlagsState(ld.NewUser("beta-user"), evaluator.WithReasons)
+	if !state.Valid || len(state.Values) != 3 {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	meta := state.Metadata["flag"]
+	if state.Values["flag"] != "rule" || meta.Version == nil || meta.Reason == nil || meta.Reason.Kind != evaluator.ReasonRuleMatch {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+
+	state = e.AllFlagsState(ld.NewUser("someone"), evaluator.WithReasons, evaluator.DetailsOnlyForTrackedFlags)
+	if meta := state.Metadata["flag"]; meta.Version != nil || meta.Reason != nil || meta.Variation == nil {
+		t.Errorf("expected details to be omitted for untracked flag: %+v", meta)
+	}
+
+	data, err := state.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["flag"] != "fallthrough" || decoded["$valid"] != true || decoded["$flagsState"] == nil {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestAllFlagsStateClientSideOnly(t *testing.T) {
+	e := newTestEvaluator(t)
+
+	if state := e.AllFlagsState(ld.NewUser("someone"), evaluator.ClientSideOnly); state.Valid {
+		t.Error("expected invalid state for store without client-side info")
+	}
+
+	e.Store = clientSideStore{e.Store, map[string]bool{"off-flag": true}}
+	state := e.AllFlagsState(ld.NewUser("someone"), evaluator.ClientSideOnly)
+	if !state.Valid || len(state.Values) != 1 || state.Values["off-flag"] != false {
+		t.Errorf("unexpected state: %+v", state)
+	}
+}
+
+// pagingStore returns the flags "flag" and "off-flag" as the first page.
+type pagingStore struct {
+	ld.FeatureStore
+}
+
+func (s pagingStore) Page(kind ld.VersionedDataKind, cursor string, limit int) ([]ld.VersionedData, string, error) {
+	var items []ld.VersionedData
+	for _, key := range []string{"flag", "off-flag"} {
+		item, err := s.Get(kind, key)
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
+	}
+	return items, "next", nil
+}
+
+func TestFlagsPage(t *testing.T) {
+	e := newTestEvaluator(t)
+	if _, _, err := e.FlagsPage(ld.NewUser("targeted"), "", 2); err != evaluator.ErrPagingUnsupported {
+		t.Errorf("got error %v, want ErrPagingUnsupported", err)
+	}
+
+	e.Store = pagingStore{e.Store}
+	values, next, err := e.FlagsPage(ld.NewUser("targeted"), "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values["flag"] != "target" || values["off-flag"] != false || next != "next" {
+		t.Errorf("got %v and next cursor %q", values, next)
+	}
+}
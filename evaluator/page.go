@@ -0,0 +1,43 @@
+package evaluator
+
+import (
+	"errors"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// ErrPagingUnsupported is returned by FlagsPage if the store can't read flags
+// page by page.
+var ErrPagingUnsupported = errors.New("store doesn't support paging")
+
+// Pager is implemented by stores that read items page by page, e.g.
+// *dynamodb.DynamoDBFeatureStore.
+type Pager interface {
+	Page(kind ld.VersionedDataKind, cursor string, limit int) ([]ld.VersionedData, string, error)
+}
+
+// FlagsPage works like AllFlags but evaluates only a page of up to limit
+// flags, starting at the cursor returned with the previous page. It returns
+// the cursor of the next page, which is empty after the last page.
+func (e *Evaluator) FlagsPage(user ld.User, cursor string, limit int) (map[string]interface{}, string, error) {
+	pager, ok := e.Store.(Pager)
+	if !ok {
+		return nil, "", ErrPagingUnsupported
+	}
+	items, next, err := pager.Page(ld.Features, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	values := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		flag, ok := item.(*ld.FeatureFlag)
+		if !ok || user.Key == nil {
+			values[item.GetKey()] = nil
+			continue
+		}
+		d, _ := e.evaluate(flag, user)
+		values[flag.Key] = d.Value
+	}
+	return values, next, nil
+}
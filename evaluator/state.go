@@ -0,0 +1,140 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// FlagsStateOption changes what AllFlagsState includes.
+type FlagsStateOption int
+
+// Options of AllFlagsState, mirroring those of newer LaunchDarkly SDKs
+const (
+	// Only include flags available to client-side SDKs
+	ClientSideOnly FlagsStateOption = iota
+
+	// Include the reason of each evaluation
+	WithReasons
+
+	// Omit versions and reasons of flags without event tracking, to reduce
+	// the size of the state
+	DetailsOnlyForTrackedFlags
+)
+
+// ClientSideLister is implemented by stores that know which flags are
+// available to client-side SDKs, e.g. *dynamodb.DynamoDBFeatureStore.
+type ClientSideLister interface {
+	ClientSideFlags() (map[string]bool, error)
+}
+
+// FlagsState is a snapshot of all flag values for a user, with metadata for
+// bootstrapping client-side SDKs. It marshals to the JSON format the SDKs
+// expect.
+type FlagsState struct {
+	Values   map[string]interface{}
+	Metadata map[string]FlagMetadata
+	Valid    bool
+}
+
+// FlagMetadata holds the evaluation details of a flag in a FlagsState.
+type FlagMetadata struct {
+	Variation            *int        `json:"variation,omitempty"`
+	Version              *int        `json:"version,omitempty"`
+	Reason               *ReasonJSON `json:"reason,omitempty"`
+	TrackEvents          bool        `json:"trackEvents,omitempty"`
+	DebugEventsUntilDate *uint64     `json:"debugEventsUntilDate,omitempty"`
+}
+
+// ReasonJSON is the JSON representation of an evaluation reason.
+type ReasonJSON struct {
+	Kind   Reason `json:"kind"`
+	RuleID string `json:"ruleId,omitempty"`
+}
+
+// MarshalJSON encodes the state like the SDKs' AllFlagsState does, with flag
+// values at the top level.
+func (s *FlagsState) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(s.Values)+2)
+	for key, value := range s.Values {
+		m[key] = value
+	}
+	m["$flagsState"] = s.Metadata
+	m["$valid"] = s.Valid
+	return json.Marshal(m)
+}
+
+// AllFlagsState evaluates all flags for a user. The state is invalid, and
+// empty, if the store can't be read or the options can't be satisfied, e.g.
+// ClientSideOnly with a store that isn't a ClientSideLister.
+func (e *Evaluator) AllFlagsState(user ld.User, options ...FlagsStateOption) *FlagsState {
+	state := &FlagsState{
+		Values:   map[string]interface{}{},
+		Metadata: map[string]FlagMetadata{},
+	}
+	var clientSideOnly, withReasons, detailsOnlyTracked bool
+	for _, o := range options {
+		switch o {
+		case ClientSideOnly:
+			clientSideOnly = true
+		case WithReasons:
+			withReasons = true
+		case DetailsOnlyForTrackedFlags:
+			detailsOnlyTracked = true
+		}
+	}
+
+	if user.Key == nil {
+		return state
+	}
+
+	var clientSide map[string]bool
+	if clientSideOnly {
+		lister, ok := e.Store.(ClientSideLister)
+		if !ok {
+			return state
+		}
+		var err error
+		if clientSide, err = lister.ClientSideFlags(); err != nil {
+			return state
+		}
+	}
+
+	items, err := e.Store.All(ld.Features)
+	if err != nil {
+		return state
+	}
+
+	now := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	for key, item := range items {
+		flag, ok := item.(*ld.FeatureFlag)
+		if !ok || clientSideOnly && !clientSide[key] {
+			continue
+		}
+
+		d, _ := e.evaluate(flag, user)
+		state.Values[key] = d.Value
+
+		meta := FlagMetadata{
+			Variation:            d.Variation,
+			TrackEvents:          flag.TrackEvents,
+			DebugEventsUntilDate: flag.DebugEventsUntilDate,
+		}
+		debugging := flag.DebugEventsUntilDate != nil && *flag.DebugEventsUntilDate > now
+		if !detailsOnlyTracked || flag.TrackEvents || debugging {
+			version := flag.Version
+			meta.Version = &version
+			if withReasons && d.Reason != "" {
+				meta.Reason = &ReasonJSON{Kind: d.Reason}
+				if d.Rule != nil {
+					meta.Reason.RuleID = d.Rule.Id
+				}
+			}
+		}
+		state.Metadata[key] = meta
+	}
+
+	state.Valid = true
+	return state
+}
@@ -0,0 +1,264 @@
+/*
+Package fallback provides a LaunchDarkly feature store that reads from an
+ordered chain of stores, e.g. an in-memory cache, a DynamoDB table, and a flag
+file snapshot, moving on to the next store whenever one is unavailable, fails,
+or is too slow.
+
+The order and timeouts can be configured declaratively with Parse, so each
+consumer can pick its own tradeoff between availability and freshness:
+
+	layers, err := fallback.Parse("dynamodb:200ms,file", map[string]ld.FeatureStore{
+		"dynamodb": dynamoStore,
+		"file":     fileStore,
+	})
+	store := fallback.New(nil, layers...)
+*/
+package fallback
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*Chain)(nil)
+
+// ErrTimeout is recorded for a layer that didn't answer within its timeout.
+var ErrTimeout = errors.New("timed out")
+
+// Layer is a store in a fallback chain.
+type Layer struct {
+	// Name used in logs and stats
+	Name string
+
+	// Store to read from
+	Store ld.FeatureStore
+
+	// Maximum time to wait for a read before moving on to the next layer,
+	// or zero to wait indefinitely
+	Timeout time.Duration
+}
+
+// LayerStats describes the health of a layer and how often it served reads.
+type LayerStats struct {
+	// Whether the last read from the layer succeeded
+	Healthy bool
+
+	// Number of reads served, failed, timed out, and skipped because the
+	// layer wasn't initialized
+	Hits     int64
+	Errors   int64
+	Timeouts int64
+	Skipped  int64
+
+	// Most recent error and when it happened
+	LastError   string
+	LastErrorAt time.Time
+}
+
+// Chain serves reads from the first layer that answers successfully. Writes
+// go to all layers so that caches and snapshots stay up to date.
+type Chain struct {
+	// Layers in order of read preference
+	Layers []Layer
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+
+	mu    sync.Mutex
+	stats map[string]*LayerStats
+}
+
+// New creates a store that reads from the given layers in order.
+func New(logger ld.Logger, layers ...Layer) *Chain {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly Fallback]", log.LstdFlags)
+	}
+	return &Chain{Layers: layers, Logger: logger}
+}
+
+// Parse builds layers from a comma-separated spec of store names with
+// optional timeouts, e.g. "cache,dynamodb:200ms,file", using the given stores
+// by name.
+func Parse(spec string, stores map[string]ld.FeatureStore) ([]Layer, error) {
+	var layers []Layer
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		layer := Layer{Name: part}
+		if i := strings.Index(part, ":"); i >= 0 {
+			timeout, err := time.ParseDuration(part[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for layer %q: %s", part[:i], err)
+			}
+			layer.Name, layer.Timeout = part[:i], timeout
+		}
+		store, ok := stores[layer.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown layer %q", layer.Name)
+		}
+		layer.Store = store
+		layers = append(layers, layer)
+	}
+	if len(layers) == 0 {
+		return nil, errors.New("no layers given")
+	}
+	return layers, nil
+}
+
+// Stats returns the stats of all layers, keyed by layer name.
+func (c *Chain) Stats() map[string]LayerStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make(map[string]LayerStats, len(c.stats))
+	for name, s := range c.stats {
+		stats[name] = *s
+	}
+	return stats
+}
+
+func (c *Chain) record(layer Layer, fn func(*LayerStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats == nil {
+		c.stats = make(map[string]*LayerStats)
+	}
+	s, ok := c.stats[layer.Name]
+	if !ok {
+		s = &LayerStats{}
+		c.stats[layer.Name] = s
+	}
+	fn(s)
+}
+
+// read calls fn on each layer in turn until one succeeds.
+func (c *Chain) read(what string, fn func(ld.FeatureStore) (interface{}, error)) (interface{}, error) {
+	var lastErr error
+	for _, layer := range c.Layers {
+		if !layer.Store.Initialized() {
+			c.record(layer, func(s *LayerStats) { s.Skipped++ })
+			continue
+		}
+
+		result, err := callWithTimeout(layer, fn)
+		if err == nil {
+			c.record(layer, func(s *LayerStats) {
+				s.Hits++
+				s.Healthy = true
+			})
+			return result, nil
+		}
+
+		c.record(layer, func(s *LayerStats) {
+			if err == ErrTimeout {
+				s.Timeouts++
+			} else {
+				s.Errors++
+			}
+			s.Healthy = false
+			s.LastError = err.Error()
+			s.LastErrorAt = time.Now()
+		})
+		c.Logger.Printf("WARN: Failed to get %s from layer %q, trying next one: %s", what, layer.Name, err)
+		lastErr = err
+	}
+	if lastErr == nil {
+		return nil, fmt.Errorf("none of %d layer(s) is initialized", len(c.Layers))
+	}
+	return nil, fmt.Errorf("all layers failed, last error: %s", lastErr)
+}
+
+func callWithTimeout(layer Layer, fn func(ld.FeatureStore) (interface{}, error)) (interface{}, error) {
+	if layer.Timeout <= 0 {
+		return fn(layer.Store)
+	}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn(layer.Store)
+		done <- result{value, err}
+	}()
+
+	timer := time.NewTimer(layer.Timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-timer.C:
+		return nil, ErrTimeout
+	}
+}
+
+// Get returns an item from the first layer that answers.
+func (c *Chain) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	item, err := c.read(fmt.Sprintf("item (key=%s)", key), func(store ld.FeatureStore) (interface{}, error) {
+		return store.Get(kind, key)
+	})
+	if err != nil || item == nil {
+		return nil, err
+	}
+	return item.(ld.VersionedData), nil
+}
+
+// All returns all items from the first layer that answers.
+func (c *Chain) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	items, err := c.read(fmt.Sprintf("all %q items", kind.GetNamespace()), func(store ld.FeatureStore) (interface{}, error) {
+		return store.All(kind)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items.(map[string]ld.VersionedData), nil
+}
+
+// Init initializes all layers.
+func (c *Chain) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	return c.write(func(store ld.FeatureStore) error { return store.Init(allData) })
+}
+
+// Upsert updates an item in all layers.
+func (c *Chain) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	return c.write(func(store ld.FeatureStore) error { return store.Upsert(kind, item) })
+}
+
+// Delete deletes an item from all layers.
+func (c *Chain) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	return c.write(func(store ld.FeatureStore) error { return store.Delete(kind, key, version) })
+}
+
+// write calls fn on every layer and returns the first error.
+func (c *Chain) write(fn func(ld.FeatureStore) error) error {
+	var firstErr error
+	for _, layer := range c.Layers {
+		if err := fn(layer.Store); err != nil {
+			c.Logger.Printf("ERROR: Failed to write to layer %q: %s", layer.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Initialized returns true if any layer is initialized.
+func (c *Chain) Initialized() bool {
+	for _, layer := range c.Layers {
+		if layer.Store.Initialized() {
+			return true
+		}
+	}
+	return false
+}
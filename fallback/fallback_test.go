@@ -0,0 +1,101 @@
+package fallback_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/fallback"
+)
+
+// slowStore delays reads of the wrapped store, or fails them if err is set.
+type slowStore struct {
+	ld.FeatureStore
+	delay time.Duration
+	err   error
+}
+
+func (s *slowStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	time.Sleep(s.delay)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.FeatureStore.Get(kind, key)
+}
+
+func (s *slowStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	time.Sleep(s.delay)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.FeatureStore.All(kind)
+}
+
+func newStore(t *testing.T, version int) ld.FeatureStore {
+	store := ld.NewInMemoryFeatureStore(nil)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: version}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestChainFallsBack(t *testing.T) {
+	stores := map[string]ld.FeatureStore{
+		"cache":    ld.NewInMemoryFeatureStore(nil), // not initialized
+		"dynamodb": &slowStore{FeatureStore: newStore(t, 2), delay: 50 * time.Millisecond},
+		"broken":   &slowStore{FeatureStore: newStore(t, 3), err: errors.New("broken")},
+		"file":     newStore(t, 1),
+	}
+	layers, err := fallback.Parse("cache, dynamodb:10ms, broken, file", stores)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := fallback.New(log.New(ioutil.Discard, "", 0), layers...)
+
+	item, err := chain.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.GetVersion() != 1 {
+		t.Errorf("got version %d, want item from file layer", item.GetVersion())
+	}
+
+	stats := chain.Stats()
+	if s := stats["cache"]; s.Skipped != 1 {
+		t.Errorf("cache: got %+v, want skipped", s)
+	}
+	if s := stats["dynamodb"]; s.Timeouts != 1 || s.Healthy {
+		t.Errorf("dynamodb: got %+v, want timeout", s)
+	}
+	if s := stats["broken"]; s.Errors != 1 || s.LastError != "broken" {
+		t.Errorf("broken: got %+v, want error", s)
+	}
+	if s := stats["file"]; s.Hits != 1 || !s.Healthy {
+		t.Errorf("file: got %+v, want hit", s)
+	}
+}
+
+func TestChainAllLayersFail(t *testing.T) {
+	chain := fallback.New(log.New(ioutil.Discard, "", 0), fallback.Layer{
+		Name:  "broken",
+		Store: &slowStore{FeatureStore: newStore(t, 1), err: errors.New("broken")},
+	})
+	if _, err := chain.All(ld.Features); err == nil {
+		t.Error("expected error when all layers fail")
+	}
+}
+
+func TestParse(t *testing.T) {
+	stores := map[string]ld.FeatureStore{"a": ld.NewInMemoryFeatureStore(nil)}
+	for _, spec := range []string{"", "b", "a:forever"} {
+		if _, err := fallback.Parse(spec, stores); err == nil {
+			t.Errorf("expected error for spec %q", spec)
+		}
+	}
+}
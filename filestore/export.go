@@ -0,0 +1,47 @@
+package filestore
+
+import (
+	"encoding/json"
+	"io"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Export reads all flags and segments from a store, e.g. a
+// DynamoDBFeatureStore, and returns them in the flag file format. This is
+// useful for snapshotting production flags for integration tests.
+func Export(store ld.FeatureStore) (*FileData, error) {
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := store.All(ld.Segments)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &FileData{
+		Flags:    make(map[string]*ld.FeatureFlag, len(flags)),
+		Segments: make(map[string]*ld.Segment, len(segments)),
+	}
+	for key, item := range flags {
+		if flag, ok := item.(*ld.FeatureFlag); ok {
+			data.Flags[key] = flag
+		}
+	}
+	for key, item := range segments {
+		if segment, ok := item.(*ld.Segment); ok {
+			data.Segments[key] = segment
+		}
+	}
+
+	return data, nil
+}
+
+// Write writes the data as indented JSON, which can be read by Load and
+// LaunchDarkly's file data source.
+func (d *FileData) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
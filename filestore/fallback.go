@@ -0,0 +1,90 @@
+package filestore
+
+import (
+	"log"
+	"os"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*FallbackStore)(nil)
+
+// FallbackStore reads from a primary store and falls back to a secondary
+// store if the primary one fails or isn't initialized. All writes go to the
+// primary store.
+type FallbackStore struct {
+	// Store to read from and write to
+	Primary ld.FeatureStore
+
+	// Store to read from if the primary store is unavailable
+	Fallback ld.FeatureStore
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// WithFallbackFile wraps a store, e.g. a DynamoDBFeatureStore, so that it
+// falls back to the data of the given flag file if it's unavailable.
+func WithFallbackFile(primary ld.FeatureStore, path string, logger ld.Logger) (*FallbackStore, error) {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly FallbackStore]", log.LstdFlags)
+	}
+
+	fallback, err := NewFileFeatureStore(logger, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FallbackStore{
+		Primary:  primary,
+		Fallback: fallback,
+		Logger:   logger,
+	}, nil
+}
+
+// Get returns an item from the primary store, or from the fallback store if
+// the primary store fails.
+func (s *FallbackStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	if s.Primary.Initialized() {
+		item, err := s.Primary.Get(kind, key)
+		if err == nil {
+			return item, nil
+		}
+		s.Logger.Printf("WARN: Falling back after failing to get item (key=%s): %s", key, err)
+	}
+	return s.Fallback.Get(kind, key)
+}
+
+// All returns all items from the primary store, or from the fallback store if
+// the primary store fails.
+func (s *FallbackStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	if s.Primary.Initialized() {
+		items, err := s.Primary.All(kind)
+		if err == nil {
+			return items, nil
+		}
+		s.Logger.Printf("WARN: Falling back after failing to get all %q items: %s", kind.GetNamespace(), err)
+	}
+	return s.Fallback.All(kind)
+}
+
+// Init initializes the primary store.
+func (s *FallbackStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	return s.Primary.Init(allData)
+}
+
+// Upsert updates an item in the primary store.
+func (s *FallbackStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	return s.Primary.Upsert(kind, item)
+}
+
+// Delete deletes an item from the primary store.
+func (s *FallbackStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	return s.Primary.Delete(kind, key, version)
+}
+
+// Initialized returns true if either store is initialized.
+func (s *FallbackStore) Initialized() bool {
+	return s.Primary.Initialized() || s.Fallback.Initialized()
+}
@@ -0,0 +1,136 @@
+/*
+Package filestore provides a feature store backed by JSON files in the format
+of LaunchDarkly's file data source:
+
+	{
+	  "flags": {
+	    "flag-key": { "key": "flag-key", "on": true, "variations": [true, false], ... }
+	  },
+	  "flagValues": {
+	    "simple-flag-key": "value"
+	  },
+	  "segments": {
+	    "segment-key": { "key": "segment-key", "included": ["user-key"], ... }
+	  }
+	}
+
+This allows local development and air-gapped tests to work without DynamoDB or
+a connection to LaunchDarkly. (Only JSON is supported, not YAML.)
+*/
+package filestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*FileFeatureStore)(nil)
+
+// FileData is the structure of a flag file.
+type FileData struct {
+	Flags      map[string]*ld.FeatureFlag `json:"flags,omitempty"`
+	FlagValues map[string]interface{}     `json:"flagValues,omitempty"`
+	Segments   map[string]*ld.Segment     `json:"segments,omitempty"`
+}
+
+// FileFeatureStore is an in-memory feature store initialized with data from
+// flag files.
+type FileFeatureStore struct {
+	*ld.InMemoryFeatureStore
+}
+
+// NewFileFeatureStore creates a feature store with the data of the given flag
+// files. It's an error if the same key is defined in more than one file.
+func NewFileFeatureStore(logger ld.Logger, paths ...string) (*FileFeatureStore, error) {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly FileFeatureStore]", log.LstdFlags)
+	}
+
+	allData, err := Load(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &FileFeatureStore{ld.NewInMemoryFeatureStore(logger)}
+	if err := store.Init(allData); err != nil {
+		return nil, err
+	}
+
+	logger.Printf("INFO: Loaded %d flag(s) and %d segment(s) from %d file(s)",
+		len(allData[ld.Features]), len(allData[ld.Segments]), len(paths))
+
+	return store, nil
+}
+
+// Load reads the given flag files and returns their data in the format
+// expected by FeatureStore.Init.
+func Load(paths ...string) (map[ld.VersionedDataKind]map[string]ld.VersionedData, error) {
+	allData := map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {},
+		ld.Segments: {},
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var fileData FileData
+		if err := json.Unmarshal(data, &fileData); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		if err := fileData.merge(allData); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	return allData, nil
+}
+
+func (d *FileData) merge(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	add := func(kind ld.VersionedDataKind, key string, item ld.VersionedData) error {
+		if _, exists := allData[kind][key]; exists {
+			return fmt.Errorf("%s %q is defined more than once", kind, key)
+		}
+		allData[kind][key] = item
+		return nil
+	}
+
+	for key, flag := range d.Flags {
+		flag.Key = key
+		if err := add(ld.Features, key, flag); err != nil {
+			return err
+		}
+	}
+	for key, value := range d.FlagValues {
+		if err := add(ld.Features, key, flagWithValue(key, value)); err != nil {
+			return err
+		}
+	}
+	for key, segment := range d.Segments {
+		segment.Key = key
+		if err := add(ld.Segments, key, segment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flagWithValue returns a flag that serves the same value to everyone.
+func flagWithValue(key string, value interface{}) *ld.FeatureFlag {
+	variation := 0
+	return &ld.FeatureFlag{
+		Key:         key,
+		Version:     1,
+		On:          true,
+		Variations:  []interface{}{value},
+		Fallthrough: ld.VariationOrRollout{Variation: &variation},
+	}
+}
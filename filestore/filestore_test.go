@@ -0,0 +1,130 @@
+package filestore_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/filestore"
+)
+
+var discard = log.New(ioutil.Discard, "", 0)
+
+func TestFileFeatureStore(t *testing.T) {
+	store, err := filestore.NewFileFeatureStore(discard, "testdata/flags.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !store.Initialized() {
+		t.Error("expected store to be initialized")
+	}
+
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 2 {
+		t.Fatalf("got %d flags, want 2", len(flags))
+	}
+
+	user := ld.NewUser("bob")
+	value, _, _ := flags["rollout"].(*ld.FeatureFlag).Evaluate(user, store)
+	if value != "b" {
+		t.Errorf("got value %v for rollout flag", value)
+	}
+	value, _, _ = flags["simple"].(*ld.FeatureFlag).Evaluate(user, store)
+	if value != float64(42) {
+		t.Errorf("got value %v for simple flag", value)
+	}
+
+	segment, err := store.Get(ld.Segments, "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if segment == nil || segment.GetVersion() != 2 {
+		t.Errorf("unexpected segment: %v", segment)
+	}
+}
+
+func TestLoadDuplicateKeys(t *testing.T) {
+	if _, err := filestore.Load("testdata/flags.json", "testdata/flags.json"); err == nil {
+		t.Error("expected error for duplicate keys")
+	}
+}
+
+type failingStore struct {
+	ld.FeatureStore
+}
+
+func (failingStore) Initialized() bool { return true }
+
+func (failingStore) All(ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	return nil, errors.New("table not found")
+}
+
+func TestWithFallbackFile(t *testing.T) {
+	store, err := filestore.WithFallbackFile(failingStore{}, "testdata/flags.json", discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 2 {
+		t.Errorf("got %d flags from fallback, want 2", len(flags))
+	}
+}
+
+func TestExport(t *testing.T) {
+	store, err := filestore.NewFileFeatureStore(discard, "testdata/flags.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := filestore.Export(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ioutil.TempFile("", "flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if err := data.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	allData, err := filestore.Load(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allData[ld.Features]) != 2 || len(allData[ld.Segments]) != 1 {
+		t.Errorf("unexpected data after round trip: %v", allData)
+	}
+	if v := allData[ld.Features]["rollout"].GetVersion(); v != 3 {
+		t.Errorf("got version %d, want 3", v)
+	}
+}
+
+func TestImport(t *testing.T) {
+	store := ld.NewInMemoryFeatureStore(discard)
+	if err := filestore.Import(store, "testdata/flags.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !store.Initialized() {
+		t.Error("expected store to be initialized")
+	}
+	if flag, _ := store.Get(ld.Features, "simple"); flag == nil {
+		t.Error("expected imported flag")
+	}
+}
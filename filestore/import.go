@@ -0,0 +1,16 @@
+package filestore
+
+import (
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Import reads the given flag files and initializes a store, e.g. a
+// DynamoDBFeatureStore, with their data. Existing data is replaced. This is
+// useful for bootstrapping new environments.
+func Import(store ld.FeatureStore, paths ...string) error {
+	allData, err := Load(paths...)
+	if err != nil {
+		return err
+	}
+	return store.Init(allData)
+}
@@ -0,0 +1,269 @@
+/*
+Package fixtures generates realistic flag and segment data of configurable
+size and complexity for benchmarks, fuzzing, and load tests of the store and
+the sync pipeline:
+
+	data := fixtures.Generate(fixtures.Options{Seed: 1, Flags: 1000})
+	store.Init(data.AllData())
+
+The same options always produce the same data, so results of different runs
+can be compared. Flags have targets, rules with various operators, percentage
+rollouts, prerequisites on other flags, and rules matching segments; some
+segments are big, i.e. list many users.
+*/
+package fixtures
+
+import (
+	"fmt"
+	"math/rand"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/filestore"
+)
+
+// Options configure the generated data. Zero values select the defaults.
+type Options struct {
+	// Seed of the random generator
+	Seed int64
+
+	// Number of flags and segments (default: 100 flags, 10 segments)
+	Flags    int
+	Segments int
+
+	// Maximum number of rules per flag and segment (default: 3)
+	MaxRules int
+
+	// Maximum number of users per flag target and per regular segment
+	// (default: 10)
+	MaxTargets int
+
+	// Maximum number of prerequisites per flag (default: 1). Prerequisites
+	// only refer to flags generated before, so there are no cycles.
+	MaxPrerequisites int
+
+	// Percentage of flags that serve a percentage rollout by default
+	// (default: 30)
+	RolloutPercent int
+
+	// Number of segments that list BigSegmentSize users each (default: 1
+	// of 10,000 users)
+	BigSegments    int
+	BigSegmentSize int
+}
+
+func (o *Options) setDefaults() {
+	if o.Flags <= 0 {
+		o.Flags = 100
+	}
+	if o.Segments <= 0 {
+		o.Segments = 10
+	}
+	if o.MaxRules <= 0 {
+		o.MaxRules = 3
+	}
+	if o.MaxTargets <= 0 {
+		o.MaxTargets = 10
+	}
+	if o.MaxPrerequisites <= 0 {
+		o.MaxPrerequisites = 1
+	}
+	if o.RolloutPercent <= 0 {
+		o.RolloutPercent = 30
+	}
+	if o.BigSegments <= 0 {
+		o.BigSegments = 1
+	}
+	if o.BigSegments > o.Segments {
+		o.BigSegments = o.Segments
+	}
+	if o.BigSegmentSize <= 0 {
+		o.BigSegmentSize = 10000
+	}
+}
+
+// Data is the generated data.
+type Data struct {
+	Flags    map[string]*ld.FeatureFlag
+	Segments map[string]*ld.Segment
+}
+
+// AllData returns the data in the format expected by FeatureStore.Init.
+func (d *Data) AllData() map[ld.VersionedDataKind]map[string]ld.VersionedData {
+	allData := map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: make(map[string]ld.VersionedData, len(d.Flags)),
+		ld.Segments: make(map[string]ld.VersionedData, len(d.Segments)),
+	}
+	for key, flag := range d.Flags {
+		allData[ld.Features][key] = flag
+	}
+	for key, segment := range d.Segments {
+		allData[ld.Segments][key] = segment
+	}
+	return allData
+}
+
+// FileData returns the data in the flag file format, e.g. to be written to
+// a file for "lddstore import".
+func (d *Data) FileData() *filestore.FileData {
+	return &filestore.FileData{Flags: d.Flags, Segments: d.Segments}
+}
+
+// Generate generates flags and segments.
+func Generate(opts Options) *Data {
+	opts.setDefaults()
+	g := &generator{opts: opts, rand: rand.New(rand.NewSource(opts.Seed))}
+
+	data := &Data{
+		Flags:    make(map[string]*ld.FeatureFlag, opts.Flags),
+		Segments: make(map[string]*ld.Segment, opts.Segments),
+	}
+	// Segments come first so that flags can refer to them
+	for i := 0; i < opts.Segments; i++ {
+		segment := g.segment(i)
+		data.Segments[segment.Key] = segment
+	}
+	for i := 0; i < opts.Flags; i++ {
+		flag := g.flag(i)
+		data.Flags[flag.Key] = flag
+	}
+	return data
+}
+
+type generator struct {
+	opts Options
+	rand *rand.Rand
+}
+
+var attributes = []string{"email", "country", "plan", "appVersion", "signupDate"}
+
+func (g *generator) flag(i int) *ld.FeatureFlag {
+	key := fmt.Sprintf("flag-%04d", i)
+	variations := g.variations()
+	off := 0
+	flag := &ld.FeatureFlag{
+		Key:          key,
+		Version:      1 + g.rand.Intn(50),
+		On:           g.rand.Intn(10) > 0,
+		TrackEvents:  g.rand.Intn(5) == 0,
+		Salt:         g.hex(),
+		Sel:          g.hex(),
+		Variations:   variations,
+		OffVariation: &off,
+		Fallthrough:  g.variationOrRollout(len(variations), g.rand.Intn(100) < g.opts.RolloutPercent),
+	}
+
+	if i > 0 {
+		for n := g.rand.Intn(g.opts.MaxPrerequisites + 1); n > 0; n-- {
+			flag.Prerequisites = append(flag.Prerequisites, ld.Prerequisite{
+				Key:       fmt.Sprintf("flag-%04d", g.rand.Intn(i)),
+				Variation: 0,
+			})
+		}
+	}
+
+	if n := g.rand.Intn(g.opts.MaxTargets + 1); n > 0 {
+		flag.Targets = []ld.Target{{Values: g.users(n), Variation: g.rand.Intn(len(variations))}}
+	}
+
+	for r := g.rand.Intn(g.opts.MaxRules + 1); r > 0; r-- {
+		rule := ld.Rule{
+			Id:                 g.hex(),
+			VariationOrRollout: g.variationOrRollout(len(variations), g.rand.Intn(4) == 0),
+			Clauses:            []ld.Clause{g.clause()},
+		}
+		if g.opts.Segments > 0 && g.rand.Intn(3) == 0 {
+			rule.Clauses = append(rule.Clauses, ld.Clause{
+				Op:     ld.OperatorSegmentMatch,
+				Values: []interface{}{fmt.Sprintf("segment-%03d", g.rand.Intn(g.opts.Segments))},
+			})
+		}
+		flag.Rules = append(flag.Rules, rule)
+	}
+	return flag
+}
+
+// variations returns the variations of a boolean, string, number, or JSON
+// flag.
+func (g *generator) variations() []interface{} {
+	switch g.rand.Intn(4) {
+	case 0:
+		return []interface{}{"control", "treatment-a", "treatment-b"}
+	case 1:
+		return []interface{}{float64(0), float64(10), float64(100)}
+	case 2:
+		return []interface{}{
+			map[string]interface{}{"enabled": false},
+			map[string]interface{}{"enabled": true, "limit": float64(g.rand.Intn(1000))},
+		}
+	}
+	return []interface{}{false, true}
+}
+
+func (g *generator) variationOrRollout(variations int, rollout bool) ld.VariationOrRollout {
+	if !rollout {
+		v := g.rand.Intn(variations)
+		return ld.VariationOrRollout{Variation: &v}
+	}
+	// Split 100% (100000) among the variations
+	weights := make([]ld.WeightedVariation, variations)
+	left := 100000
+	for v := range weights {
+		weight := left
+		if v < variations-1 {
+			weight = g.rand.Intn(left + 1)
+		}
+		weights[v] = ld.WeightedVariation{Variation: v, Weight: weight}
+		left -= weight
+	}
+	return ld.VariationOrRollout{Rollout: &ld.Rollout{Variations: weights}}
+}
+
+func (g *generator) clause() ld.Clause {
+	switch attr := attributes[g.rand.Intn(len(attributes))]; attr {
+	case "email":
+		return ld.Clause{Attribute: attr, Op: ld.OperatorEndsWith, Values: []interface{}{"@example.com"}}
+	case "country":
+		return ld.Clause{Attribute: attr, Op: ld.OperatorIn, Values: []interface{}{"de", "us", "gb"}}
+	case "plan":
+		return ld.Clause{Attribute: attr, Op: ld.OperatorIn, Values: []interface{}{"enterprise"}, Negate: g.rand.Intn(2) == 0}
+	case "appVersion":
+		return ld.Clause{Attribute: attr, Op: ld.OperatorSemVerGreaterThan, Values: []interface{}{"2.0.0"}}
+	default:
+		return ld.Clause{Attribute: attr, Op: ld.OperatorBefore, Values: []interface{}{"2018-01-01T00:00:00Z"}}
+	}
+}
+
+func (g *generator) segment(i int) *ld.Segment {
+	segment := &ld.Segment{
+		Key:     fmt.Sprintf("segment-%03d", i),
+		Version: 1 + g.rand.Intn(20),
+		Salt:    g.hex(),
+	}
+	if i < g.opts.BigSegments {
+		segment.Included = g.users(g.opts.BigSegmentSize)
+		return segment
+	}
+
+	segment.Included = g.users(g.rand.Intn(g.opts.MaxTargets + 1))
+	segment.Excluded = g.users(g.rand.Intn(3))
+	for r := g.rand.Intn(g.opts.MaxRules + 1); r > 0; r-- {
+		segment.Rules = append(segment.Rules, ld.SegmentRule{
+			Id:      g.hex(),
+			Clauses: []ld.Clause{g.clause()},
+		})
+	}
+	return segment
+}
+
+func (g *generator) users(n int) []string {
+	users := make([]string, n)
+	for i := range users {
+		users[i] = fmt.Sprintf("user-%08d", g.rand.Intn(100000000))
+	}
+	return users
+}
+
+func (g *generator) hex() string {
+	return fmt.Sprintf("%016x", g.rand.Int63())
+}
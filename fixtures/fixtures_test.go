@@ -0,0 +1,105 @@
+package fixtures_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/fixtures"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	opts := fixtures.Options{Seed: 42, Flags: 50, Segments: 5, BigSegmentSize: 100}
+	if a, b := fixtures.Generate(opts), fixtures.Generate(opts); !reflect.DeepEqual(a, b) {
+		t.Error("expected the same data for the same options")
+	}
+	other := opts
+	other.Seed = 43
+	if a, b := fixtures.Generate(opts), fixtures.Generate(other); reflect.DeepEqual(a, b) {
+		t.Error("expected different data for different seeds")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	data := fixtures.Generate(fixtures.Options{Seed: 1, Flags: 200, Segments: 10, BigSegments: 2, BigSegmentSize: 500})
+
+	if len(data.Flags) != 200 || len(data.Segments) != 10 {
+		t.Fatalf("got %d flags and %d segments", len(data.Flags), len(data.Segments))
+	}
+	if n := len(data.Segments["segment-001"].Included); n != 500 {
+		t.Errorf("got big segment with %d users, want 500", n)
+	}
+
+	var prerequisites, rollouts, segmentRules int
+	for key, flag := range data.Flags {
+		for _, p := range flag.Prerequisites {
+			if p.Key >= key || data.Flags[p.Key] == nil {
+				t.Errorf("%s: invalid prerequisite %q", key, p.Key)
+			}
+			prerequisites++
+		}
+		if r := flag.Fallthrough.Rollout; r != nil {
+			total := 0
+			for _, wv := range r.Variations {
+				total += wv.Weight
+			}
+			if total != 100000 {
+				t.Errorf("%s: rollout weights add up to %d", key, total)
+			}
+			rollouts++
+		}
+		for _, rule := range flag.Rules {
+			for _, c := range rule.Clauses {
+				if c.Op == ld.OperatorSegmentMatch {
+					if data.Segments[c.Values[0].(string)] == nil {
+						t.Errorf("%s: unknown segment %v", key, c.Values[0])
+					}
+					segmentRules++
+				}
+			}
+		}
+	}
+	if prerequisites == 0 || rollouts == 0 || segmentRules == 0 {
+		t.Errorf("got %d prerequisites, %d rollouts, %d segment rules, want some of each",
+			prerequisites, rollouts, segmentRules)
+	}
+}
+
+func TestGeneratedFlagsEvaluate(t *testing.T) {
+	data := fixtures.Generate(fixtures.Options{Seed: 7})
+
+	store := ld.NewInMemoryFeatureStore(nil)
+	if err := store.Init(data.AllData()); err != nil {
+		t.Fatal(err)
+	}
+	config := ld.DefaultConfig
+	config.FeatureStore = store
+	config.UseLdd = true
+	config.SendEvents = false
+	client, err := ld.MakeCustomClient("sdk-key", config, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	email := "user@example.com"
+	user := ld.NewUser("user-1")
+	user.Email = &email
+	for key := range data.Flags {
+		if _, err := client.JsonVariation(key, user, nil); err != nil {
+			t.Errorf("%s: %s", key, err)
+		}
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for _, flags := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("flags=%d", flags), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				fixtures.Generate(fixtures.Options{Seed: int64(i), Flags: flags})
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Package flagusage cross-references the flags in a feature store with the flag
+keys that services actually use, similar to LaunchDarkly's code references,
+to find flags that are ready for cleanup.
+*/
+package flagusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// References maps flag keys to the names of the services that use them.
+type References map[string][]string
+
+// LoadReferences reads flag references from JSON. Two formats are accepted: a
+// list of flag keys, or an object mapping service names to lists of flag keys:
+//
+//	["flag-a", "flag-b"]
+//	{"checkout": ["flag-a"], "search": ["flag-a", "flag-b"]}
+func LoadReferences(r io.Reader) (References, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	refs := make(References)
+
+	var keys []string
+	if err := json.Unmarshal(raw, &keys); err == nil {
+		for _, key := range keys {
+			refs[key] = nil
+		}
+		return refs, nil
+	}
+
+	var services map[string][]string
+	if err := json.Unmarshal(raw, &services); err != nil {
+		return nil, fmt.Errorf("expected list of flag keys or object of services: %s", err)
+	}
+	for service, keys := range services {
+		for _, key := range keys {
+			refs[key] = append(refs[key], service)
+		}
+	}
+	for _, svcs := range refs {
+		sort.Strings(svcs)
+	}
+	return refs, nil
+}
+
+// Orphan is a flag that is referenced but doesn't exist in the store.
+type Orphan struct {
+	Key      string   `json:"key"`
+	Services []string `json:"services,omitempty"`
+}
+
+// Report lists flags that are candidates for cleanup.
+type Report struct {
+	// Flags in the store that aren't referenced by any service
+	Stale []string `json:"stale"`
+
+	// Flags referenced by services that don't exist in the store, e.g.
+	// because they were deleted while still in use
+	Orphaned []Orphan `json:"orphaned"`
+}
+
+// Compare cross-references the flags of a store with the given references.
+func Compare(store ld.FeatureStore, refs References) (*Report, error) {
+	flags, err := store.All(ld.Features)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Stale: []string{}, Orphaned: []Orphan{}}
+	for key := range flags {
+		if _, ok := refs[key]; !ok {
+			report.Stale = append(report.Stale, key)
+		}
+	}
+	for key, services := range refs {
+		if _, ok := flags[key]; !ok {
+			report.Orphaned = append(report.Orphaned, Orphan{Key: key, Services: services})
+		}
+	}
+
+	sort.Strings(report.Stale)
+	sort.Slice(report.Orphaned, func(i, j int) bool { return report.Orphaned[i].Key < report.Orphaned[j].Key })
+
+	return report, nil
+}
@@ -0,0 +1,59 @@
+package flagusage_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/flagusage"
+)
+
+func TestLoadReferences(t *testing.T) {
+	refs, err := flagusage.LoadReferences(strings.NewReader(`["a", "b"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (flagusage.References{"a": nil, "b": nil}); !reflect.DeepEqual(refs, want) {
+		t.Errorf("got %v, want %v", refs, want)
+	}
+
+	refs, err = flagusage.LoadReferences(strings.NewReader(`{"search": ["a", "b"], "checkout": ["a"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (flagusage.References{"a": {"checkout", "search"}, "b": {"search"}}); !reflect.DeepEqual(refs, want) {
+		t.Errorf("got %v, want %v", refs, want)
+	}
+
+	if _, err := flagusage.LoadReferences(strings.NewReader(`"a"`)); err == nil {
+		t.Error("expected error for invalid input")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	store := ld.NewInMemoryFeatureStore(nil)
+	store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"used":   &ld.FeatureFlag{Key: "used", Version: 1},
+			"unused": &ld.FeatureFlag{Key: "unused", Version: 1},
+		},
+	})
+
+	report, err := flagusage.Compare(store, flagusage.References{
+		"used":    {"search"},
+		"missing": {"checkout"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &flagusage.Report{
+		Stale:    []string{"unused"},
+		Orphaned: []flagusage.Orphan{{Key: "missing", Services: []string{"checkout"}}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("got %+v, want %+v", report, want)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/webhook"
+)
+
+// This function applies flag changes staged in the pending table (see
+// LAUNCHDARKLY_DYNAMODB_PENDING_TABLE of the store function) to the live
+// table. It takes one of these inputs:
+//
+//	{"action": "review"}
+//	{"action": "apply", "report": <report of the review>}
+//	{"action": "rollout", "urgent": ["features/kill-switch"]}
+//
+// The rollout action applies the changes staged longer than
+// LAUNCHDARKLY_ROLLOUT_DELAY ago, and urgent ones right away. It runs every
+// minute if a delay is configured.
+//
+// Step Functions can invoke it directly, e.g. before and after a human
+// approval step. Requests through API Gateway must be signed like webhook
+// deliveries, using LAUNCHDARKLY_APPROVAL_SECRET.
+func main() {
+	lambda.Start(handler)
+}
+
+// Input is the input of an approval.
+type Input struct {
+	Action string      `json:"action"`
+	Report sync.Report `json:"report,omitempty"`
+	Urgent []string    `json:"urgent,omitempty"`
+}
+
+// validate checks the input before any table is read.
+func (input Input) validate() error {
+	switch input.Action {
+	case "review", "rollout":
+		return nil
+	case "apply":
+		if input.Report == nil {
+			return errors.New("report of the approved review is required")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", input.Action)
+}
+
+func handler(ctx context.Context, event json.RawMessage) (interface{}, error) {
+	var req events.APIGatewayProxyRequest
+	if err := json.Unmarshal(event, &req); err == nil && req.HTTPMethod != "" {
+		return handleRequest(ctx, &req), nil
+	}
+
+	var input Input
+	if err := json.Unmarshal(event, &input); err != nil {
+		return nil, err
+	}
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+	return run(ctx, input)
+}
+
+// handleRequest serves an approval sent through API Gateway.
+func handleRequest(ctx context.Context, req *events.APIGatewayProxyRequest) *events.APIGatewayProxyResponse {
+	secret := os.Getenv("LAUNCHDARKLY_APPROVAL_SECRET")
+	if secret == "" || !webhook.VerifySignature([]byte(req.Body), req.Headers[webhook.SignatureHeader], secret) {
+		log.Printf("ERROR: Rejected approval request from %s: invalid signature", req.RequestContext.Identity.SourceIP)
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusUnauthorized}
+	}
+
+	var input Input
+	if err := json.Unmarshal([]byte(req.Body), &input); err != nil {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest, Body: err.Error()}
+	}
+	if err := input.validate(); err != nil {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest, Body: err.Error()}
+	}
+	result, err := run(ctx, input)
+	switch {
+	case err == sync.ErrApprovalOutdated:
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusConflict, Body: err.Error()}
+	case err != nil:
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("ERROR: Failed to encode result: %s", err)
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}
+	}
+	return &events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
+
+// run reviews or applies the pending changes of a valid input.
+func run(ctx context.Context, input Input) (interface{}, error) {
+	// The rollout runs on a schedule even if changes aren't staged
+	delay := os.Getenv("LAUNCHDARKLY_ROLLOUT_DELAY")
+	if input.Action == "rollout" && delay == "" {
+		log.Print("INFO: No rollout delay configured, changes need approval")
+		return sync.Delta{}, nil
+	}
+
+	table := os.Getenv("LAUNCHDARKLY_DYNAMODB_PENDING_TABLE")
+	if table == "" {
+		return nil, errors.New("LAUNCHDARKLY_DYNAMODB_PENDING_TABLE is not set")
+	}
+	pending, err := dynamodb.NewDynamoDBFeatureStore(table, nil)
+	if err != nil {
+		return nil, err
+	}
+	live, err := dynamodb.NewDynamoDBFeatureStore(os.Getenv("LAUNCHDARKLY_DYNAMODB_TABLE"), nil)
+	if err != nil {
+		return nil, err
+	}
+	approval := &sync.Approval{Pending: pending, Live: live}
+
+	switch input.Action {
+	case "review":
+		return approval.Review()
+	case "rollout":
+		if approval.Delay, err = time.ParseDuration(delay); err != nil {
+			return nil, fmt.Errorf("invalid LAUNCHDARKLY_ROLLOUT_DELAY: %s", err)
+		}
+		return approval.ApplyDue(ctx, time.Now(), input.Urgent...)
+	}
+	report, err := approval.Apply(input.Report)
+	if err != nil {
+		log.Printf("ERROR: Failed to apply approved changes: %s", err)
+	}
+	return report, err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+)
+
+// autoConfig discovers environments and SDK keys if a Relay Proxy
+// auto-configuration key is set.
+var autoConfig *sync.AutoConfig
+
+// This function implements a Step Functions task that syncs several
+// LaunchDarkly environments, each to its own table. See sync.Task for the
+// input and output format.
+func main() {
+	task := &sync.Task{NewSyncer: newSyncer}
+	if key := os.Getenv("LAUNCHDARKLY_AUTO_CONFIG_KEY"); key != "" {
+		autoConfig = &sync.AutoConfig{Key: key}
+		task.Discover = autoConfig.Discover
+	}
+	lambda.Start(task.Handle)
+}
+
+// newSyncer reads the SDK key of an environment from LAUNCHDARKLY_SDK_KEY_<NAME>
+// so that keys don't show up in the execution history, unless it was
+// discovered or can be looked up with the auto-configuration key. The table
+// defaults to LAUNCHDARKLY_DYNAMODB_TABLE_PREFIX followed by the environment
+// name.
+func newSyncer(env sync.Environment) (*sync.Syncer, error) {
+	sdkKey, err := lookupSDKKey(env)
+	if err != nil {
+		return nil, err
+	}
+
+	table := env.Table
+	if table == "" {
+		table = os.Getenv("LAUNCHDARKLY_DYNAMODB_TABLE_PREFIX") + env.Name
+	}
+	store, err := dynamodb.NewDynamoDBFeatureStore(table, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sync.Syncer{
+		Store:  store,
+		SDKKey: sdkKey,
+		Raw:    os.Getenv("LAUNCHDARKLY_SYNC_RAW") == "true",
+	}, nil
+}
+
+func lookupSDKKey(env sync.Environment) (string, error) {
+	if env.SDKKey != "" {
+		return env.SDKKey, nil
+	}
+	name := "LAUNCHDARKLY_SDK_KEY_" + strings.ToUpper(strings.Replace(env.Name, "-", "_", -1))
+	if sdkKey := os.Getenv(name); sdkKey != "" {
+		return sdkKey, nil
+	}
+	if autoConfig == nil {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+
+	envs, err := autoConfig.Discover(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to look up SDK key: %s", err)
+	}
+	for _, e := range envs {
+		if e.Name == env.Name {
+			return e.SDKKey, nil
+		}
+	}
+	return "", fmt.Errorf("neither %s is set nor environment %q auto-configured", name, env.Name)
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/api/apigw"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/handler"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/notify"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/webhook"
+)
+
+func main() {
+	h, err := newHandler()
+	if err != nil {
+		log.Fatalf("ERROR: %s", err)
+	}
+	lambda.Start(failOnServerError(apigw.Handler(h)))
+}
+
+// newHandler configures the webhook handler from the environment.
+func newHandler() (*handler.Handler, error) {
+	// In regulated environments, stage all changes in a pending table until
+	// they are approved (see the approve function)
+	table := os.Getenv("LAUNCHDARKLY_DYNAMODB_TABLE")
+	if pending := os.Getenv("LAUNCHDARKLY_DYNAMODB_PENDING_TABLE"); pending != "" {
+		log.Printf("INFO: Staging changes in table %q until approved", pending)
+		table = pending
+	}
+	store, err := newStore(table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize DynamoDBFeatureStore: %s", err)
+	}
+
+	// Optionally limit the write rate to leave capacity to other consumers
+	if rate := os.Getenv("LAUNCHDARKLY_DYNAMODB_WRITE_RATE"); rate != "" {
+		if store.WriteRateLimit, err = strconv.ParseFloat(rate, 64); err != nil {
+			return nil, fmt.Errorf("invalid LAUNCHDARKLY_DYNAMODB_WRITE_RATE %q: %s", rate, err)
+		}
+	}
+
+	// Optionally retry DynamoDB requests less than the AWS SDK does by
+	// default, so that throttling doesn't use up the function's timeout
+	if err := configureRetries(store); err != nil {
+		return nil, err
+	}
+
+	// Optionally tune how connections to DynamoDB are reused
+	if err := configureTransport(store); err != nil {
+		return nil, err
+	}
+
+	// Store empty strings as they are instead of as NULL, which would turn
+	// empty string variations into null
+	if os.Getenv("LAUNCHDARKLY_DYNAMODB_KEEP_EMPTY_STRINGS") == "true" {
+		store.EncoderOptions = append(store.EncoderOptions, func(e *dynamodbattribute.Encoder) {
+			e.NullEmptyString = false
+		})
+	}
+
+	// Re-read all items after a full sync and log those that differ from the
+	// data received from LaunchDarkly
+	store.VerifyInit = os.Getenv("LAUNCHDARKLY_SYNC_VERIFY") == "true"
+
+	// Don't serve flags that the SDK can't evaluate, and optionally keep
+	// them, as well as items that fail to decode, in a quarantine table for
+	// inspection (which makes syncs poll instead of using the Go SDK)
+	store.ValidateFlags = os.Getenv("LAUNCHDARKLY_SYNC_VALIDATE") == "true"
+	store.QuarantineTable = os.Getenv("LAUNCHDARKLY_DYNAMODB_QUARANTINE_TABLE")
+
+	// Remember the items of kinds that failed to sync so that scheduled
+	// runs can retry just those
+	store.DeadLetters = os.Getenv("LAUNCHDARKLY_SYNC_DEAD_LETTERS") == "true"
+
+	// Leave frozen items as they are, e.g. to pin a flag during an incident
+	store.Frozen = splitList(os.Getenv("LAUNCHDARKLY_SYNC_FROZEN"))
+	store.CheckFrozen = true
+
+	// Optionally sync only some data kinds, e.g. "features" if segments
+	// aren't used
+	if kinds := splitList(os.Getenv("LAUNCHDARKLY_SYNC_KINDS")); len(kinds) > 0 {
+		if store.Kinds, err = dynamodb.ParseKinds(kinds...); err != nil {
+			return nil, fmt.Errorf("invalid LAUNCHDARKLY_SYNC_KINDS: %s", err)
+		}
+	}
+
+	syncer := &sync.Syncer{
+		Store:  store,
+		SDKKey: os.Getenv("LAUNCHDARKLY_SDK_KEY"),
+		// Store the JSON received from LaunchDarkly as is, without dropping
+		// fields unknown to the SDK version used here
+		Raw: os.Getenv("LAUNCHDARKLY_SYNC_RAW") == "true",
+		// Retry transient failures, like the SDK timing out on a cold start,
+		// within the time the function has left
+		Retries: envInt("LAUNCHDARKLY_SYNC_RETRIES", 3),
+		// Log where slow syncs spend their time
+		LogTimings: os.Getenv("LAUNCHDARKLY_SYNC_TIMINGS") == "true",
+	}
+	// Reach LaunchDarkly via a proxy or with a custom CA bundle from VPCs
+	// without direct internet access
+	if syncer.HTTPClient, err = newHTTPClient(); err != nil {
+		return nil, err
+	}
+	// Optionally publish what changed so that downstream systems don't need
+	// to scan the table
+	if dest := os.Getenv("LAUNCHDARKLY_SYNC_DELTA_DESTINATION"); dest != "" {
+		sess, err := session.NewSession()
+		if err == nil {
+			syncer.Deltas, err = notify.ParseDestination(sess, dest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid LAUNCHDARKLY_SYNC_DELTA_DESTINATION: %s", err)
+		}
+	}
+	// Optionally tell an ops channel who changed what
+	if url := os.Getenv("LAUNCHDARKLY_SLACK_WEBHOOK_URL"); url != "" {
+		syncer.Summaries = notify.NewSlack(url)
+	}
+
+	cfg := handler.Config{
+		Syncer: syncer,
+		// Acknowledge, but skip, deliveries for projects and environments
+		// whose data isn't stored in this table
+		Filter: webhook.Filter{
+			Projects:     splitList(os.Getenv("LAUNCHDARKLY_WEBHOOK_PROJECTS")),
+			Environments: splitList(os.Getenv("LAUNCHDARKLY_WEBHOOK_ENVIRONMENTS")),
+		},
+		// On scheduled runs, sync only the items that failed before instead
+		// of repeating a full sync that keeps failing
+		Recover:              store.DeadLetters,
+		Report:               os.Getenv("LAUNCHDARKLY_SYNC_REPORT") == "true",
+		Timings:              syncer.LogTimings,
+		Rejections:           signatureFailures,
+		OnRepeatedRejections: alert,
+	}
+
+	// If a webhook secret is provided, verify the signature of the webhook
+	// payload to ensure that requests are generated by LaunchDarkly.
+	if secret := os.Getenv("LAUNCHDARKLY_WEBHOOK_SECRET"); secret != "" {
+		cfg.Verifier = handler.Secret(secret)
+	}
+
+	return handler.New(cfg), nil
+}
+
+// failOnServerError turns server errors into failed invocations, so that
+// failed scheduled syncs show up in the function's error metrics.
+func failOnServerError(h apigw.HandlerFunc) apigw.HandlerFunc {
+	return func(ctx context.Context, req *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+		resp, err := h(ctx, req)
+		if err == nil && resp.StatusCode >= 500 {
+			err = fmt.Errorf("sync failed with status %d", resp.StatusCode)
+		}
+		return resp, err
+	}
+}
+
+// signatureFailures counts rejected deliveries across invocations of a warm
+// Lambda container.
+var signatureFailures = &webhook.FailureCounter{
+	Threshold: envInt("LAUNCHDARKLY_WEBHOOK_ALERT_THRESHOLD", 5),
+	Window:    5 * time.Minute,
+}
+
+// newStore creates the store, accessing the table with the role given by
+// LAUNCHDARKLY_DYNAMODB_ROLE_ARN, if set. Its sessions are tagged with the
+// function name and stage so that CloudTrail tells consumers of shared
+// accounts apart.
+func newStore(table string) (*dynamodb.DynamoDBFeatureStore, error) {
+	role := os.Getenv("LAUNCHDARKLY_DYNAMODB_ROLE_ARN")
+	if role == "" {
+		return dynamodb.NewDynamoDBFeatureStore(table, nil)
+	}
+	function := os.Getenv("AWS_LAMBDA_FUNCTION_NAME")
+	tags := map[string]string{}
+	if function != "" {
+		tags["function"] = function
+	}
+	if env := os.Getenv("LAUNCHDARKLY_ENVIRONMENT"); env != "" {
+		tags["environment"] = env
+	}
+	return dynamodb.NewDynamoDBFeatureStoreWithRole(table, dynamodb.RoleOptions{
+		RoleARN:        role,
+		SessionName:    function,
+		SourceIdentity: function,
+		Tags:           tags,
+		ExternalID:     os.Getenv("LAUNCHDARKLY_DYNAMODB_ROLE_EXTERNAL_ID"),
+	}, nil)
+}
+
+// configureRetries applies the retry settings from the environment, if any.
+func configureRetries(store *dynamodb.DynamoDBFeatureStore) error {
+	mode := os.Getenv("LAUNCHDARKLY_DYNAMODB_RETRY_MODE")
+	attempts := os.Getenv("LAUNCHDARKLY_DYNAMODB_MAX_ATTEMPTS")
+	backoff := os.Getenv("LAUNCHDARKLY_DYNAMODB_MAX_BACKOFF")
+	if mode == "" && attempts == "" && backoff == "" {
+		return nil
+	}
+
+	var opts dynamodb.RetryOptions
+	var err error
+	if mode != "" {
+		if opts.Mode, err = dynamodb.ParseRetryMode(mode); err != nil {
+			return fmt.Errorf("invalid LAUNCHDARKLY_DYNAMODB_RETRY_MODE: %s", err)
+		}
+	}
+	opts.MaxAttempts = envInt("LAUNCHDARKLY_DYNAMODB_MAX_ATTEMPTS", 0)
+	if backoff != "" {
+		if opts.MaxBackoff, err = time.ParseDuration(backoff); err != nil {
+			return fmt.Errorf("invalid LAUNCHDARKLY_DYNAMODB_MAX_BACKOFF %q: %s", backoff, err)
+		}
+	}
+	return store.ConfigureRetries(opts)
+}
+
+// configureTransport applies the connection settings from the environment,
+// if any.
+func configureTransport(store *dynamodb.DynamoDBFeatureStore) error {
+	idleTimeout := os.Getenv("LAUNCHDARKLY_DYNAMODB_IDLE_CONN_TIMEOUT")
+	tlsTimeout := os.Getenv("LAUNCHDARKLY_DYNAMODB_TLS_HANDSHAKE_TIMEOUT")
+	if os.Getenv("LAUNCHDARKLY_DYNAMODB_MAX_IDLE_CONNS") == "" && idleTimeout == "" && tlsTimeout == "" {
+		return nil
+	}
+
+	opts := dynamodb.TransportOptions{
+		MaxIdleConns: envInt("LAUNCHDARKLY_DYNAMODB_MAX_IDLE_CONNS", 0),
+	}
+	var err error
+	if idleTimeout != "" {
+		if opts.IdleConnTimeout, err = time.ParseDuration(idleTimeout); err != nil {
+			return fmt.Errorf("invalid LAUNCHDARKLY_DYNAMODB_IDLE_CONN_TIMEOUT %q: %s", idleTimeout, err)
+		}
+	}
+	if tlsTimeout != "" {
+		if opts.TLSHandshakeTimeout, err = time.ParseDuration(tlsTimeout); err != nil {
+			return fmt.Errorf("invalid LAUNCHDARKLY_DYNAMODB_TLS_HANDSHAKE_TIMEOUT %q: %s", tlsTimeout, err)
+		}
+	}
+	return store.ConfigureTransport(opts)
+}
+
+// newHTTPClient returns a client for requests to LaunchDarkly if a proxy, CA
+// bundle, or timeout is configured, or nil to use the Go SDK's default.
+func newHTTPClient() (*http.Client, error) {
+	proxy := os.Getenv("LAUNCHDARKLY_PROXY_URL")
+	caBundle := os.Getenv("LAUNCHDARKLY_CA_BUNDLE")
+	timeout := os.Getenv("LAUNCHDARKLY_HTTP_TIMEOUT")
+	if proxy == "" && caBundle == "" && timeout == "" {
+		return nil, nil
+	}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LAUNCHDARKLY_PROXY_URL %q: %s", proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if caBundle != "" {
+		pem, err := ioutil.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read LAUNCHDARKLY_CA_BUNDLE: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in LAUNCHDARKLY_CA_BUNDLE %q", caBundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	client := &http.Client{Transport: transport}
+	if timeout != "" {
+		var err error
+		if client.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return nil, fmt.Errorf("invalid LAUNCHDARKLY_HTTP_TIMEOUT %q: %s", timeout, err)
+		}
+	}
+	return client, nil
+}
+
+// alert notifies the SNS topic, if configured, about repeatedly rejected
+// deliveries.
+func alert(msg string) {
+	topic := os.Getenv("LAUNCHDARKLY_WEBHOOK_ALERT_TOPIC_ARN")
+	if topic == "" {
+		return
+	}
+	sess, err := session.NewSession()
+	if err != nil {
+		log.Printf("ERROR: Failed to create AWS session for alert: %s", err)
+		return
+	}
+	subject := "LaunchDarkly webhook signature verification failing"
+	body := fmt.Sprintf("%d webhook deliveries to %s were rejected within %s. Last rejection: %s",
+		signatureFailures.Threshold, os.Getenv("LAUNCHDARKLY_DYNAMODB_TABLE"), signatureFailures.Window, msg)
+	if err := notify.NewSNS(sess, topic).Publish(subject, body); err != nil {
+		log.Printf("ERROR: Failed to send alert: %s", err)
+	}
+}
+
+// envInt returns the integer value of an environment variable, or def if it
+// is unset or not an integer. Zero is a valid value, e.g. to disable retries.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		log.Printf("WARN: Ignoring invalid %s=%q: %s", name, v, err)
+		return def
+	}
+	return n
+}
+
+// splitList splits a comma-separated list, ignoring empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,267 @@
+/*
+Package handler processes LaunchDarkly webhook deliveries and other sync
+triggers, like the store function of the serverless service does. It is an
+http.Handler, so it can be composed with your own middleware and served by
+any HTTP server, or by Lambda through apigw.Handler:
+
+	h := handler.New(handler.Config{
+		Syncer:   &sync.Syncer{Store: store, SDKKey: sdkKey},
+		Verifier: handler.Secret(os.Getenv("LAUNCHDARKLY_WEBHOOK_SECRET")),
+	})
+	lambda.Start(apigw.Handler(h))
+
+POST requests are treated as webhook deliveries. Other requests, e.g.
+scheduled Lambda invocations, which apigw turns into GET requests, trigger a
+sync without a payload.
+*/
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/webhook"
+)
+
+// Verifier checks the signature of a webhook delivery.
+type Verifier interface {
+	Verify(body []byte, signature string) bool
+}
+
+// Secret verifies signatures made with LaunchDarkly's webhook secret. An
+// empty secret rejects all deliveries; leave Config.Verifier nil instead to
+// skip verification.
+type Secret string
+
+// Verify implements Verifier.
+func (s Secret) Verify(body []byte, signature string) bool {
+	return s != "" && webhook.VerifySignature(body, signature, string(s))
+}
+
+// Deleter marks single items as deleted without knowing their version, like
+// dynamodb.DynamoDBFeatureStore.DeleteLatest.
+type Deleter interface {
+	DeleteLatest(kind ld.VersionedDataKind, key string) (bool, error)
+}
+
+// Config configures a Handler.
+type Config struct {
+	// Syncer performing the syncs (required). Its Trigger is set to the
+	// description of each delivery.
+	Syncer *sync.Syncer
+
+	// Checks the signature of deliveries. If nil, signatures aren't checked
+	// and deletions aren't applied ahead of the sync, as anyone could send a
+	// payload deleting flags.
+	Verifier Verifier
+
+	// Deliveries for other projects or environments are acknowledged
+	// without a sync
+	Filter webhook.Filter
+
+	// Applies deletions of verified deliveries right away (optional,
+	// default: the Syncer's Store if it is a Deleter)
+	Deleter Deleter
+
+	// If set, triggers other than deliveries only recover the Syncer's dead
+	// letter if it isn't empty, instead of repeating a full sync that keeps
+	// failing (see sync.Syncer.Recover)
+	Recover bool
+
+	// If set, responses contain the sync report, which is otherwise only
+	// returned for requests with the query parameter report=true
+	Report bool
+
+	// If set, responses contain the sync report along with a breakdown of
+	// where the sync spent its time (see sync.Timings), which is otherwise
+	// only returned for requests with the query parameter timings=true
+	Timings bool
+
+	// Counts rejected deliveries (optional). Once its threshold is reached,
+	// OnRepeatedRejections is called with a description of the last one.
+	Rejections           *webhook.FailureCounter
+	OnRepeatedRejections func(msg string)
+
+	// Clock for counting rejections (optional, default: time.Now)
+	Now func() time.Time
+
+	// Logger for request processing (optional)
+	Logger ld.Logger
+}
+
+// Handler syncs a LaunchDarkly environment on webhook deliveries.
+type Handler struct {
+	cfg Config
+}
+
+// New creates a Handler.
+func New(cfg Config) *Handler {
+	if cfg.Deleter == nil {
+		cfg.Deleter, _ = cfg.Syncer.Store.(Deleter)
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(os.Stderr, "[LaunchDarkly Webhook]", log.LstdFlags)
+	}
+	return &Handler{cfg: cfg}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload *webhook.Payload
+	delivery := r.Method == http.MethodPost
+	verified := false
+
+	if delivery {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Log some interesting headers
+		for _, name := range []string{"User-Agent", "X-Forwarded-For", "X-Amzn-Trace-Id"} {
+			h.cfg.Logger.Printf("DEBUG: %s: %s", name, r.Header.Get(name))
+		}
+		signature := r.Header.Get(webhook.SignatureHeader)
+		h.cfg.Logger.Printf("DEBUG: %s: %s", webhook.SignatureHeader, webhook.SignaturePrefix(signature))
+
+		if h.cfg.Verifier != nil {
+			if !h.cfg.Verifier.Verify(body, signature) {
+				h.reject(r, body)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h.cfg.Logger.Printf("INFO: Successfully verified signature of webhook payload")
+			verified = true
+		} else {
+			h.cfg.Logger.Printf("INFO: Skipping signature check of webhook payload")
+		}
+
+		// Acknowledge, but skip, deliveries for projects and environments
+		// whose data isn't stored in this table.
+		// Payloads of unexpected shape, e.g. after changes to LaunchDarkly's
+		// format, still trigger a full sync instead of failing.
+		if err = webhook.Validate(body); err != nil {
+			h.cfg.Logger.Printf("WARN: %s, syncing anyway", err)
+		} else if payload, err = webhook.Parse(body); err != nil {
+			h.cfg.Logger.Printf("WARN: Failed to parse webhook payload, syncing anyway: %s", err)
+		} else if unknown := payload.UnknownKinds(); len(unknown) > 0 {
+			h.cfg.Logger.Printf("INFO: Webhook delivery %s has unknown resource kinds %v, syncing anyway", payload.ID, unknown)
+		} else if !h.cfg.Filter.Matches(payload) {
+			h.cfg.Logger.Printf("INFO: Skipping webhook delivery %s for unrelated resources %v", payload.ID, payload.Resources())
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	// Apply deletions and archivals right away. This is only done for
+	// signed deliveries, as anyone could send a payload deleting flags.
+	if payload != nil && verified && h.cfg.Deleter != nil {
+		if h.applyDeletions(payload.Deletions(h.cfg.Filter)) && payload.OnlyDeletions() {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	// The Syncer is shared by concurrent requests, so the trigger is set on
+	// a copy
+	syncer := *h.cfg.Syncer
+	if payload != nil {
+		syncer.Trigger = payload.Describe()
+	} else if !delivery {
+		syncer.Trigger = "Scheduled sync"
+	}
+
+	if h.cfg.Recover && !delivery {
+		n, err := syncer.Recover(r.Context())
+		if err != nil {
+			h.cfg.Logger.Printf("ERROR: Failed to recover dead letter: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if n > 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	report, timings, err := syncer.SyncWithTimings(r.Context())
+	if err != nil {
+		h.cfg.Logger.Printf("ERROR: Failed to sync: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Optionally return what was synced so that deployment pipelines can
+	// assert on the result, and how long it took. Timings wrap the report
+	// so that the report's format stays the same.
+	var body interface{}
+	if h.cfg.Timings || r.URL.Query().Get("timings") == "true" {
+		body = struct {
+			Report  sync.Report   `json:"report"`
+			Timings *sync.Timings `json:"timings"`
+		}{report, timings}
+	} else if h.cfg.Report || r.URL.Query().Get("report") == "true" {
+		body = report
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			h.cfg.Logger.Printf("ERROR: Failed to create sync report: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// applyDeletions marks the deleted resources as deleted and reports whether
+// all of them were applied. Failures are left to the sync.
+func (h *Handler) applyDeletions(deleted []webhook.Resource) bool {
+	ok := true
+	for _, r := range deleted {
+		var kind ld.VersionedDataKind = ld.Features
+		if r.Kind == "segment" {
+			kind = ld.Segments
+		}
+		deleted, err := h.cfg.Deleter.DeleteLatest(kind, r.Key)
+		if err != nil {
+			h.cfg.Logger.Printf("ERROR: Failed to delete %s %q: %s", r.Kind, r.Key, err)
+			ok = false
+			continue
+		}
+		if deleted {
+			h.cfg.Logger.Printf("INFO: Deleted %s %q", r.Kind, r.Key)
+		}
+	}
+	return ok
+}
+
+// reject logs a delivery with an invalid signature, without revealing the
+// expected signature, and reports repeated rejections.
+func (h *Handler) reject(r *http.Request, body []byte) {
+	deliveryID := "-"
+	if payload, err := webhook.Parse(body); err == nil && payload.ID != "" {
+		deliveryID = payload.ID
+	}
+	msg := fmt.Sprintf("reason=invalid_signature size=%d source_ip=%s delivery_id=%s signature_prefix=%q",
+		len(body), r.RemoteAddr, deliveryID, webhook.SignaturePrefix(r.Header.Get(webhook.SignatureHeader)))
+	h.cfg.Logger.Printf("ERROR: Rejected webhook delivery: %s", msg)
+
+	if h.cfg.Rejections != nil && h.cfg.Rejections.Add(h.cfg.Now()) && h.cfg.OnRepeatedRejections != nil {
+		h.cfg.OnRepeatedRejections(msg)
+	}
+}
@@ -0,0 +1,145 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/handler"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/webhook"
+)
+
+const secret = "secret"
+
+// newLaunchDarkly returns a server serving one flag, counting requests.
+func newLaunchDarkly(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		w.Write([]byte(`{"flags": {"flag": {"key": "flag", "version": 1}}, "segments": {}}`))
+	}))
+}
+
+type fakeDeleter struct {
+	deleted []string
+}
+
+func (d *fakeDeleter) DeleteLatest(kind ld.VersionedDataKind, key string) (bool, error) {
+	d.deleted = append(d.deleted, kind.GetNamespace()+"/"+key)
+	return true, nil
+}
+
+func newTestHandler(t *testing.T, server *httptest.Server, cfg handler.Config) *handler.Handler {
+	cfg.Syncer = &sync.Syncer{
+		Store:   ld.NewInMemoryFeatureStore(nil),
+		SDKKey:  "sdk-key",
+		Raw:     true,
+		BaseURI: server.URL,
+	}
+	cfg.Verifier = handler.Secret(secret)
+	return handler.New(cfg)
+}
+
+func deliver(h http.Handler, body, signature string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set(webhook.SignatureHeader, signature)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerSyncs(t *testing.T) {
+	var requests int
+	server := newLaunchDarkly(t, &requests)
+	defer server.Close()
+	h := newTestHandler(t, server, handler.Config{})
+
+	body := `{"kind": "flag", "accesses": [{"action": "updateOn", "resource": "proj/default:env/production:flag/flag"}]}`
+	if w := deliver(h, body, webhook.Sign([]byte(body), secret)); w.Code != http.StatusOK || requests != 1 {
+		t.Errorf("got status %d and %d request(s) to LaunchDarkly", w.Code, requests)
+	}
+
+	// Scheduled runs return the report on request
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?report=true", nil))
+	if w.Code != http.StatusOK || w.Body.String() != `{"features":{"flag":1},"segments":{}}` {
+		t.Errorf("got status %d and body %s", w.Code, w.Body)
+	}
+}
+
+func TestHandlerReturnsTimings(t *testing.T) {
+	var requests int
+	server := newLaunchDarkly(t, &requests)
+	defer server.Close()
+	h := newTestHandler(t, server, handler.Config{})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?timings=true", nil))
+	var body struct {
+		Report  sync.Report
+		Timings map[string]interface{}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Report["features"]["flag"] != 1 || body.Timings["attempts"] != float64(1) || body.Timings["total"] == nil {
+		t.Errorf("got body %s", w.Body)
+	}
+}
+
+func TestHandlerRejectsInvalidSignatures(t *testing.T) {
+	var requests int
+	server := newLaunchDarkly(t, &requests)
+	defer server.Close()
+
+	now := time.Unix(1535450400, 0)
+	var alerts []string
+	h := newTestHandler(t, server, handler.Config{
+		Rejections:           &webhook.FailureCounter{Threshold: 2, Window: time.Minute},
+		OnRepeatedRejections: func(msg string) { alerts = append(alerts, msg) },
+		Now:                  func() time.Time { return now },
+	})
+
+	for i := 0; i < 2; i++ {
+		if w := deliver(h, `{"_id": "delivery"}`, "invalid"); w.Code != http.StatusUnauthorized {
+			t.Errorf("got status %d, want 401", w.Code)
+		}
+	}
+	if requests != 0 || len(alerts) != 1 || !strings.Contains(alerts[0], "delivery_id=delivery") {
+		t.Errorf("got %d request(s) to LaunchDarkly and alerts %q", requests, alerts)
+	}
+}
+
+func TestHandlerAppliesDeletions(t *testing.T) {
+	var requests int
+	server := newLaunchDarkly(t, &requests)
+	defer server.Close()
+
+	deleter := &fakeDeleter{}
+	h := newTestHandler(t, server, handler.Config{
+		Deleter: deleter,
+		Filter:  webhook.Filter{Environments: []string{"production"}},
+	})
+
+	body := `{"kind": "flag", "accesses": [{"action": "deleteFlag", "resource": "proj/default:env/production:flag/old-flag"}]}`
+	if w := deliver(h, body, webhook.Sign([]byte(body), secret)); w.Code != http.StatusOK {
+		t.Errorf("got status %d", w.Code)
+	}
+	if requests != 0 || len(deleter.deleted) != 1 || deleter.deleted[0] != "features/old-flag" {
+		t.Errorf("got %d request(s) to LaunchDarkly and deletions %v", requests, deleter.deleted)
+	}
+
+	// Deliveries for other environments are skipped
+	body = strings.Replace(body, "env/production", "env/staging", 1)
+	if w := deliver(h, body, webhook.Sign([]byte(body), secret)); w.Code != http.StatusOK {
+		t.Errorf("got status %d", w.Code)
+	}
+	if requests != 0 || len(deleter.deleted) != 1 {
+		t.Errorf("got %d request(s) to LaunchDarkly and deletions %v", requests, deleter.deleted)
+	}
+}
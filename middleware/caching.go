@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*CachingStore)(nil)
+
+// CachingStore caches the results of Get and All for a fixed time. Writes
+// through the CachingStore invalidate the whole cache; writes by other
+// processes become visible once the cached results expire.
+type CachingStore struct {
+	// Store to wrap
+	Store ld.FeatureStore
+
+	// How long results are cached
+	TTL time.Duration
+
+	mu    sync.Mutex
+	all   map[string]cachedItems
+	items map[string]cachedItem
+}
+
+type cachedItems struct {
+	items   map[string]ld.VersionedData
+	expires time.Time
+}
+
+type cachedItem struct {
+	item    ld.VersionedData
+	expires time.Time
+}
+
+// WithCaching returns a decorator that caches reads for the given time.
+func WithCaching(ttl time.Duration) Decorator {
+	return func(store ld.FeatureStore) ld.FeatureStore {
+		return &CachingStore{Store: store, TTL: ttl}
+	}
+}
+
+// Get returns a cached item, or gets it from the wrapped store.
+func (s *CachingStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	id := kind.GetNamespace() + "/" + key
+	now := time.Now()
+
+	s.mu.Lock()
+	if c, ok := s.items[id]; ok && now.Before(c.expires) {
+		s.mu.Unlock()
+		return c.item, nil
+	}
+	s.mu.Unlock()
+
+	item, err := s.Store.Get(kind, key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.items == nil {
+		s.items = make(map[string]cachedItem)
+	}
+	s.items[id] = cachedItem{item: item, expires: now.Add(s.TTL)}
+	s.mu.Unlock()
+
+	return item, nil
+}
+
+// All returns cached items, or gets them from the wrapped store.
+func (s *CachingStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	ns := kind.GetNamespace()
+	now := time.Now()
+
+	s.mu.Lock()
+	if c, ok := s.all[ns]; ok && now.Before(c.expires) {
+		s.mu.Unlock()
+		return copyItems(c.items), nil
+	}
+	s.mu.Unlock()
+
+	items, err := s.Store.All(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.all == nil {
+		s.all = make(map[string]cachedItems)
+	}
+	s.all[ns] = cachedItems{items: copyItems(items), expires: now.Add(s.TTL)}
+	s.mu.Unlock()
+
+	return items, nil
+}
+
+// Init forwards the call to the wrapped store and invalidates the cache.
+func (s *CachingStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	defer s.invalidate()
+	return s.Store.Init(allData)
+}
+
+// Upsert forwards the call to the wrapped store and invalidates the cache.
+func (s *CachingStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	defer s.invalidate()
+	return s.Store.Upsert(kind, item)
+}
+
+// Delete forwards the call to the wrapped store and invalidates the cache.
+func (s *CachingStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	defer s.invalidate()
+	return s.Store.Delete(kind, key, version)
+}
+
+// Initialized forwards the call to the wrapped store.
+func (s *CachingStore) Initialized() bool {
+	return s.Store.Initialized()
+}
+
+func (s *CachingStore) invalidate() {
+	s.mu.Lock()
+	s.all = nil
+	s.items = nil
+	s.mu.Unlock()
+}
+
+// copyItems copies a map of items, as callers of All may modify it.
+func copyItems(items map[string]ld.VersionedData) map[string]ld.VersionedData {
+	copied := make(map[string]ld.VersionedData, len(items))
+	for k, v := range items {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"log"
+	"os"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*LoggingStore)(nil)
+
+// LoggingStore logs every operation of the wrapped store with its duration.
+// Failed operations are logged as errors, all others as debug messages.
+type LoggingStore struct {
+	// Store to wrap
+	Store ld.FeatureStore
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// WithLogging returns a decorator that adds logging. If logger is nil, log
+// messages are written to stderr.
+func WithLogging(logger ld.Logger) Decorator {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly LoggingStore]", log.LstdFlags)
+	}
+	return func(store ld.FeatureStore) ld.FeatureStore {
+		return &LoggingStore{Store: store, Logger: logger}
+	}
+}
+
+func (s *LoggingStore) log(op, subject string, start time.Time, err error) {
+	if err != nil {
+		s.Logger.Printf("ERROR: %s %s failed after %s: %s", op, subject, time.Since(start), err)
+		return
+	}
+	s.Logger.Printf("DEBUG: %s %s took %s", op, subject, time.Since(start))
+}
+
+// Get logs and forwards the call to the wrapped store.
+func (s *LoggingStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	start := time.Now()
+	item, err := s.Store.Get(kind, key)
+	s.log(OpGet, kind.GetNamespace()+"/"+key, start, err)
+	return item, err
+}
+
+// All logs and forwards the call to the wrapped store.
+func (s *LoggingStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	start := time.Now()
+	items, err := s.Store.All(kind)
+	s.log(OpAll, kind.GetNamespace(), start, err)
+	return items, err
+}
+
+// Init logs and forwards the call to the wrapped store.
+func (s *LoggingStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	start := time.Now()
+	err := s.Store.Init(allData)
+	s.log(OpInit, "store", start, err)
+	return err
+}
+
+// Upsert logs and forwards the call to the wrapped store.
+func (s *LoggingStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	start := time.Now()
+	err := s.Store.Upsert(kind, item)
+	s.log(OpUpsert, kind.GetNamespace()+"/"+item.GetKey(), start, err)
+	return err
+}
+
+// Delete logs and forwards the call to the wrapped store.
+func (s *LoggingStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	start := time.Now()
+	err := s.Store.Delete(kind, key, version)
+	s.log(OpDelete, kind.GetNamespace()+"/"+key, start, err)
+	return err
+}
+
+// Initialized forwards the call to the wrapped store without logging, as
+// it's called very frequently by the LaunchDarkly client.
+func (s *LoggingStore) Initialized() bool {
+	return s.Store.Initialized()
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*MetricsStore)(nil)
+
+// OpStats holds the metrics of a single store operation.
+type OpStats struct {
+	Calls         int64
+	Errors        int64
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// Metrics collects call counts, errors, and latencies per store operation.
+// It is safe for concurrent use and may be shared by multiple stores.
+type Metrics struct {
+	mu  sync.Mutex
+	ops map[string]OpStats
+}
+
+func (m *Metrics) observe(op string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ops == nil {
+		m.ops = make(map[string]OpStats)
+	}
+	stats := m.ops[op]
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.TotalDuration += d
+	if d > stats.MaxDuration {
+		stats.MaxDuration = d
+	}
+	m.ops[op] = stats
+}
+
+// Snapshot returns the metrics collected so far, keyed by operation name.
+func (m *Metrics) Snapshot() map[string]OpStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]OpStats, len(m.ops))
+	for op, stats := range m.ops {
+		snapshot[op] = stats
+	}
+	return snapshot
+}
+
+// MetricsStore records metrics for every operation of the wrapped store.
+type MetricsStore struct {
+	// Store to wrap
+	Store ld.FeatureStore
+
+	// Metrics to record to
+	Metrics *Metrics
+}
+
+// WithMetrics returns a decorator that records metrics.
+func WithMetrics(metrics *Metrics) Decorator {
+	return func(store ld.FeatureStore) ld.FeatureStore {
+		return &MetricsStore{Store: store, Metrics: metrics}
+	}
+}
+
+// Get records metrics and forwards the call to the wrapped store.
+func (s *MetricsStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	start := time.Now()
+	item, err := s.Store.Get(kind, key)
+	s.Metrics.observe(OpGet, time.Since(start), err)
+	return item, err
+}
+
+// All records metrics and forwards the call to the wrapped store.
+func (s *MetricsStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	start := time.Now()
+	items, err := s.Store.All(kind)
+	s.Metrics.observe(OpAll, time.Since(start), err)
+	return items, err
+}
+
+// Init records metrics and forwards the call to the wrapped store.
+func (s *MetricsStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	start := time.Now()
+	err := s.Store.Init(allData)
+	s.Metrics.observe(OpInit, time.Since(start), err)
+	return err
+}
+
+// Upsert records metrics and forwards the call to the wrapped store.
+func (s *MetricsStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	start := time.Now()
+	err := s.Store.Upsert(kind, item)
+	s.Metrics.observe(OpUpsert, time.Since(start), err)
+	return err
+}
+
+// Delete records metrics and forwards the call to the wrapped store.
+func (s *MetricsStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	start := time.Now()
+	err := s.Store.Delete(kind, key, version)
+	s.Metrics.observe(OpDelete, time.Since(start), err)
+	return err
+}
+
+// Initialized forwards the call to the wrapped store.
+func (s *MetricsStore) Initialized() bool {
+	return s.Store.Initialized()
+}
@@ -0,0 +1,40 @@
+/*
+Package middleware provides decorators for LaunchDarkly feature stores.
+
+Each decorator wraps another store and adds a single cross-cutting concern,
+e.g. logging or metrics, so that these concerns can be composed freely
+instead of being built into a store implementation:
+
+	store = middleware.Chain(store,
+		middleware.WithLogging(logger),
+		middleware.WithMetrics(metrics),
+		middleware.WithCaching(30*time.Second),
+	)
+*/
+package middleware
+
+import (
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Decorator wraps a feature store to extend its behavior.
+type Decorator func(ld.FeatureStore) ld.FeatureStore
+
+// Chain wraps a store with the given decorators. The first decorator is the
+// outermost one, i.e. it sees every call first.
+func Chain(store ld.FeatureStore, decorators ...Decorator) ld.FeatureStore {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		store = decorators[i](store)
+	}
+	return store
+}
+
+// Names of store operations as passed to loggers, metrics, and tracers
+const (
+	OpGet         = "Get"
+	OpAll         = "All"
+	OpInit        = "Init"
+	OpUpsert      = "Upsert"
+	OpDelete      = "Delete"
+	OpInitialized = "Initialized"
+)
@@ -0,0 +1,162 @@
+package middleware_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+	ldtest "gopkg.in/launchdarkly/go-client.v4/shared_test"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/middleware"
+)
+
+type fakeTracer struct {
+	spans []string
+}
+
+func (t *fakeTracer) StartSpan(op, namespace string) func(error) {
+	return func(err error) {
+		t.spans = append(t.spans, op+" "+namespace)
+	}
+}
+
+func TestChain(t *testing.T) {
+	ldtest.RunFeatureStoreTests(t, func() ld.FeatureStore {
+		return middleware.Chain(ld.NewInMemoryFeatureStore(nil),
+			middleware.WithLogging(log.New(ioutil.Discard, "", 0)),
+			middleware.WithMetrics(&middleware.Metrics{}),
+			middleware.WithTracing(&fakeTracer{}),
+			middleware.WithCaching(time.Minute),
+		)
+	})
+}
+
+func TestChainOrder(t *testing.T) {
+	store := middleware.Chain(ld.NewInMemoryFeatureStore(nil), middleware.ReadOnly(), middleware.WithCaching(time.Minute))
+	if _, ok := store.(*middleware.ReadOnlyStore); !ok {
+		t.Errorf("expected first decorator to be outermost, got %T", store)
+	}
+}
+
+func TestLoggingStore(t *testing.T) {
+	var buf bytes.Buffer
+	store := middleware.Chain(ld.NewInMemoryFeatureStore(nil), middleware.WithLogging(log.New(&buf, "", 0)))
+	store.Get(ld.Features, "flag")
+
+	if out := buf.String(); !strings.Contains(out, "DEBUG: Get features/flag took") {
+		t.Errorf("unexpected log output: %q", out)
+	}
+}
+
+func TestMetricsStore(t *testing.T) {
+	metrics := &middleware.Metrics{}
+	store := middleware.Chain(ld.NewInMemoryFeatureStore(nil), middleware.WithMetrics(metrics), middleware.ReadOnly())
+
+	store.Get(ld.Features, "flag")
+	store.Get(ld.Features, "flag")
+	store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1})
+
+	stats := metrics.Snapshot()
+	if s := stats[middleware.OpGet]; s.Calls != 2 || s.Errors != 0 {
+		t.Errorf("unexpected Get stats: %+v", s)
+	}
+	if s := stats[middleware.OpUpsert]; s.Calls != 1 || s.Errors != 1 {
+		t.Errorf("unexpected Upsert stats: %+v", s)
+	}
+}
+
+func TestTracingStore(t *testing.T) {
+	tracer := &fakeTracer{}
+	store := middleware.Chain(ld.NewInMemoryFeatureStore(nil), middleware.WithTracing(tracer))
+	store.All(ld.Segments)
+
+	if len(tracer.spans) != 1 || tracer.spans[0] != "All segments" {
+		t.Errorf("unexpected spans: %v", tracer.spans)
+	}
+}
+
+func TestCachingStore(t *testing.T) {
+	inner := ld.NewInMemoryFeatureStore(nil)
+	store := middleware.Chain(inner, middleware.WithCaching(time.Minute))
+
+	store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 1})
+	if item, _ := store.Get(ld.Features, "flag"); item.GetVersion() != 1 {
+		t.Fatalf("got version %d, want 1", item.GetVersion())
+	}
+
+	// Writes bypassing the cache aren't visible until the TTL expires
+	inner.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2})
+	if item, _ := store.Get(ld.Features, "flag"); item.GetVersion() != 1 {
+		t.Errorf("got version %d, want cached version 1", item.GetVersion())
+	}
+
+	// Writes through the cache invalidate it
+	store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 3})
+	if item, _ := store.Get(ld.Features, "flag"); item.GetVersion() != 3 {
+		t.Errorf("got version %d, want 3", item.GetVersion())
+	}
+}
+
+func TestReadOnlyStore(t *testing.T) {
+	store := middleware.Chain(ld.NewInMemoryFeatureStore(nil), middleware.ReadOnly())
+
+	if err := store.Init(nil); err != middleware.ErrReadOnly {
+		t.Errorf("Init: got %v, want ErrReadOnly", err)
+	}
+	if err := store.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag"}); err != middleware.ErrReadOnly {
+		t.Errorf("Upsert: got %v, want ErrReadOnly", err)
+	}
+	if err := store.Delete(ld.Features, "flag", 1); err != middleware.ErrReadOnly {
+		t.Errorf("Delete: got %v, want ErrReadOnly", err)
+	}
+	if _, err := store.All(ld.Features); err != nil {
+		t.Errorf("All: %s", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	inner := ld.NewInMemoryFeatureStore(nil)
+	flag := &ld.FeatureFlag{
+		Key:     "flag",
+		Version: 1,
+		Targets: []ld.Target{{Values: []string{"alice"}, Variation: 0}},
+		Rules: []ld.Rule{{Clauses: []ld.Clause{
+			{Attribute: "email", Op: ld.OperatorIn, Values: []interface{}{"bob@example.com"}},
+			{Attribute: "country", Op: ld.OperatorIn, Values: []interface{}{"de"}},
+			{Attribute: "segmentMatch", Op: ld.OperatorSegmentMatch, Values: []interface{}{"beta"}},
+		}}},
+	}
+	if err := inner.Upsert(ld.Features, flag); err != nil {
+		t.Fatal(err)
+	}
+
+	store := middleware.Chain(inner, middleware.Redacted(middleware.RedactOptions{
+		Targets:          true,
+		ClauseValues:     true,
+		ClauseAttributes: []string{"email", "segmentMatch"},
+	}))
+	item, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redacted := item.(*ld.FeatureFlag)
+	if len(redacted.Targets[0].Values) != 0 {
+		t.Errorf("targets not redacted: %v", redacted.Targets)
+	}
+	clauses := redacted.Rules[0].Clauses
+	if clauses[0].Values[0] != middleware.RedactedValue || clauses[1].Values[0] != "de" || clauses[2].Values[0] != "beta" {
+		t.Errorf("unexpected clauses: %+v", clauses)
+	}
+
+	// The wrapped store must be left intact for evaluation
+	if orig, _ := inner.Get(ld.Features, "flag"); orig.(*ld.FeatureFlag).Targets[0].Values[0] != "alice" {
+		t.Error("wrapped store was modified")
+	}
+	if err := store.Upsert(ld.Features, flag); err != middleware.ErrReadOnly {
+		t.Errorf("got %v, want ErrReadOnly", err)
+	}
+}
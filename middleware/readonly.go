@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"errors"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*ReadOnlyStore)(nil)
+
+// ErrReadOnly is returned by all write operations of a ReadOnlyStore.
+var ErrReadOnly = errors.New("store is read-only")
+
+// ReadOnlyStore rejects all writes to the wrapped store. Use it for
+// processes that must only read the data written by the sync process, e.g.
+// clients in daemon mode (UseLdd).
+type ReadOnlyStore struct {
+	// Store to wrap
+	Store ld.FeatureStore
+}
+
+// ReadOnly returns a decorator that rejects writes.
+func ReadOnly() Decorator {
+	return func(store ld.FeatureStore) ld.FeatureStore {
+		return &ReadOnlyStore{Store: store}
+	}
+}
+
+// Get forwards the call to the wrapped store.
+func (s *ReadOnlyStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	return s.Store.Get(kind, key)
+}
+
+// All forwards the call to the wrapped store.
+func (s *ReadOnlyStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	return s.Store.All(kind)
+}
+
+// Init returns ErrReadOnly.
+func (s *ReadOnlyStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	return ErrReadOnly
+}
+
+// Upsert returns ErrReadOnly.
+func (s *ReadOnlyStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	return ErrReadOnly
+}
+
+// Delete returns ErrReadOnly.
+func (s *ReadOnlyStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	return ErrReadOnly
+}
+
+// Initialized forwards the call to the wrapped store.
+func (s *ReadOnlyStore) Initialized() bool {
+	return s.Store.Initialized()
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"encoding/json"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*RedactedStore)(nil)
+
+// RedactedValue replaces redacted targeting values.
+const RedactedValue = "[redacted]"
+
+// RedactOptions selects the targeting data removed by a RedactedStore.
+type RedactOptions struct {
+	// Remove the user keys of individual targets
+	Targets bool
+
+	// Replace the values of rule clauses with RedactedValue
+	ClauseValues bool
+
+	// Only redact clauses on these attributes, e.g. "email" and "key". All
+	// clauses are redacted if empty.
+	ClauseAttributes []string
+
+	// Remove the user keys included in and excluded from segments
+	SegmentMembers bool
+}
+
+// RedactedStore returns copies of flags and segments without user-identifying
+// targeting data. Use it for endpoints that expose the flag configuration,
+// e.g. to client-side code, but never to evaluate flags, as the redacted data
+// no longer match the right users. Writes are rejected with ErrReadOnly.
+type RedactedStore struct {
+	// Store to wrap
+	Store ld.FeatureStore
+
+	// Data to redact
+	Options RedactOptions
+}
+
+// Redacted returns a decorator that redacts targeting data on read.
+func Redacted(opts RedactOptions) Decorator {
+	return func(store ld.FeatureStore) ld.FeatureStore {
+		return &RedactedStore{Store: store, Options: opts}
+	}
+}
+
+// Get returns a redacted copy of the item.
+func (s *RedactedStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	item, err := s.Store.Get(kind, key)
+	if err != nil || item == nil {
+		return item, err
+	}
+	return s.redact(kind, item)
+}
+
+// All returns redacted copies of all items.
+func (s *RedactedStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	items, err := s.Store.All(kind)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]ld.VersionedData, len(items))
+	for key, item := range items {
+		if results[key], err = s.redact(kind, item); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Init returns ErrReadOnly.
+func (s *RedactedStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	return ErrReadOnly
+}
+
+// Upsert returns ErrReadOnly.
+func (s *RedactedStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	return ErrReadOnly
+}
+
+// Delete returns ErrReadOnly.
+func (s *RedactedStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	return ErrReadOnly
+}
+
+// Initialized forwards the call to the wrapped store.
+func (s *RedactedStore) Initialized() bool {
+	return s.Store.Initialized()
+}
+
+// redact modifies a deep copy of the item so that the wrapped store, which
+// may share items with an in-memory cache, is left intact.
+func (s *RedactedStore) redact(kind ld.VersionedDataKind, item ld.VersionedData) (ld.VersionedData, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	clone := kind.GetDefaultItem().(ld.VersionedData)
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, err
+	}
+
+	switch v := clone.(type) {
+	case *ld.FeatureFlag:
+		if s.Options.Targets {
+			for i := range v.Targets {
+				v.Targets[i].Values = []string{}
+			}
+		}
+		if s.Options.ClauseValues {
+			for i := range v.Rules {
+				s.redactClauses(v.Rules[i].Clauses)
+			}
+		}
+	case *ld.Segment:
+		if s.Options.SegmentMembers {
+			v.Included = []string{}
+			v.Excluded = []string{}
+		}
+		if s.Options.ClauseValues {
+			for i := range v.Rules {
+				s.redactClauses(v.Rules[i].Clauses)
+			}
+		}
+	}
+	return clone, nil
+}
+
+func (s *RedactedStore) redactClauses(clauses []ld.Clause) {
+	for i, c := range clauses {
+		// Segment references are names, not user data, and are needed to
+		// understand the rule
+		if c.Op == ld.OperatorSegmentMatch || !s.attributeRedacted(c.Attribute) {
+			continue
+		}
+		values := make([]interface{}, len(c.Values))
+		for j := range values {
+			values[j] = RedactedValue
+		}
+		clauses[i].Values = values
+	}
+}
+
+func (s *RedactedStore) attributeRedacted(attr string) bool {
+	if len(s.Options.ClauseAttributes) == 0 {
+		return true
+	}
+	for _, a := range s.Options.ClauseAttributes {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*TracingStore)(nil)
+
+// Tracer creates spans for store operations. Implement it to connect a
+// tracing system like AWS X-Ray or OpenTracing.
+type Tracer interface {
+	// StartSpan starts a span for an operation on the given namespace and
+	// returns a function that finishes the span with the operation's error.
+	StartSpan(op, namespace string) (finish func(err error))
+}
+
+// TracingStore creates a span for every operation of the wrapped store.
+type TracingStore struct {
+	// Store to wrap
+	Store ld.FeatureStore
+
+	// Tracer to create spans with
+	Tracer Tracer
+}
+
+// WithTracing returns a decorator that adds tracing.
+func WithTracing(tracer Tracer) Decorator {
+	return func(store ld.FeatureStore) ld.FeatureStore {
+		return &TracingStore{Store: store, Tracer: tracer}
+	}
+}
+
+// Get traces and forwards the call to the wrapped store.
+func (s *TracingStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	finish := s.Tracer.StartSpan(OpGet, kind.GetNamespace())
+	item, err := s.Store.Get(kind, key)
+	finish(err)
+	return item, err
+}
+
+// All traces and forwards the call to the wrapped store.
+func (s *TracingStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	finish := s.Tracer.StartSpan(OpAll, kind.GetNamespace())
+	items, err := s.Store.All(kind)
+	finish(err)
+	return items, err
+}
+
+// Init traces and forwards the call to the wrapped store.
+func (s *TracingStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	finish := s.Tracer.StartSpan(OpInit, "")
+	err := s.Store.Init(allData)
+	finish(err)
+	return err
+}
+
+// Upsert traces and forwards the call to the wrapped store.
+func (s *TracingStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	finish := s.Tracer.StartSpan(OpUpsert, kind.GetNamespace())
+	err := s.Store.Upsert(kind, item)
+	finish(err)
+	return err
+}
+
+// Delete traces and forwards the call to the wrapped store.
+func (s *TracingStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	finish := s.Tracer.StartSpan(OpDelete, kind.GetNamespace())
+	err := s.Store.Delete(kind, key, version)
+	finish(err)
+	return err
+}
+
+// Initialized forwards the call to the wrapped store.
+func (s *TracingStore) Initialized() bool {
+	return s.Store.Initialized()
+}
@@ -0,0 +1,289 @@
+/*
+Package mockld provides a test server that emulates LaunchDarkly's polling and
+streaming endpoints, so that syncs, webhook handlers, and consumers can be
+tested end to end without a LaunchDarkly account:
+
+	server := mockld.NewServer("sdk-key")
+	defer server.Close()
+	server.Put(fixtures.Generate(fixtures.Options{}).AllData())
+
+	syncer := &sync.Syncer{Store: store, SDKKey: "sdk-key", BaseURI: server.URL, StreamURI: server.URL}
+
+Changes made with Upsert and Delete are served by the polling endpoints right
+away and sent to open streams as "patch" and "delete" events. Disconnect and
+SendRaw help testing reconnects and malformed events.
+*/
+package mockld
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// StreamPath is the path of the streaming endpoint.
+const StreamPath = "/all"
+
+// Server is a fake LaunchDarkly service for one environment.
+type Server struct {
+	*httptest.Server
+
+	// Delay before clients reconnect after a disconnect, sent with the
+	// first event of each stream (optional, default: the client's, which
+	// is 3 seconds for the Go SDK)
+	RetryDelay time.Duration
+
+	sdkKey string
+
+	mu       sync.Mutex
+	data     map[ld.VersionedDataKind]map[string]ld.VersionedData
+	streams  map[chan event]struct{}
+	requests map[string]int
+	closed   bool
+}
+
+type event struct {
+	name string
+	data string
+}
+
+// NewServer starts a server that accepts the given SDK key. It serves no
+// flags or segments until Put is called.
+func NewServer(sdkKey string) *Server {
+	s := &Server{
+		sdkKey:   sdkKey,
+		data:     emptyData(),
+		streams:  map[chan event]struct{}{},
+		requests: map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func emptyData() map[ld.VersionedDataKind]map[string]ld.VersionedData {
+	return map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {},
+		ld.Segments: {},
+	}
+}
+
+// Close closes open streams and shuts the server down.
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.Disconnect()
+	s.Server.Close()
+}
+
+// Put replaces all flags and segments and sends them to open streams.
+func (s *Server) Put(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = emptyData()
+	for kind, items := range allData {
+		for key, item := range items {
+			s.data[kind][key] = item
+		}
+	}
+	s.broadcast(event{"put", s.putData()})
+}
+
+// Upsert adds or replaces an item and sends a "patch" event.
+func (s *Server) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[kind][item.GetKey()] = item
+	data, _ := json.Marshal(map[string]interface{}{"path": streamPath(kind, item.GetKey()), "data": item})
+	s.broadcast(event{"patch", string(data)})
+}
+
+// Delete removes an item and sends a "delete" event.
+func (s *Server) Delete(kind ld.VersionedDataKind, key string, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data[kind], key)
+	data, _ := json.Marshal(map[string]interface{}{"path": streamPath(kind, key), "version": version})
+	s.broadcast(event{"delete", string(data)})
+}
+
+// SendRaw sends an arbitrary event to open streams, e.g. one with malformed
+// data.
+func (s *Server) SendRaw(name, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broadcast(event{name, data})
+}
+
+// Disconnect closes all open streams, as happens when LaunchDarkly restarts
+// or a proxy drops idle connections. Clients are free to reconnect.
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.streams {
+		close(ch)
+		delete(s.streams, ch)
+	}
+}
+
+// Streams returns the number of open streams.
+func (s *Server) Streams() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+// Requests returns the number of requests to the given path, e.g.
+// ld.LatestAllPath or StreamPath.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	s.mu.Unlock()
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("Authorization") != s.sdkKey {
+		http.Error(w, "invalid SDK key", http.StatusUnauthorized)
+		return
+	}
+
+	switch path := r.URL.Path; {
+	case path == StreamPath:
+		s.serveStream(w, r)
+	case path == ld.LatestAllPath:
+		s.serveJSON(w, func() interface{} { return json.RawMessage(s.allJSON()) })
+	case path == ld.LatestFlagsPath:
+		s.serveJSON(w, func() interface{} { return s.data[ld.Features] })
+	case path == ld.LatestSegmentsPath:
+		s.serveJSON(w, func() interface{} { return s.data[ld.Segments] })
+	case strings.HasPrefix(path, ld.LatestFlagsPath+"/"):
+		s.serveItem(w, ld.Features, strings.TrimPrefix(path, ld.LatestFlagsPath+"/"))
+	case strings.HasPrefix(path, ld.LatestSegmentsPath+"/"):
+		s.serveItem(w, ld.Segments, strings.TrimPrefix(path, ld.LatestSegmentsPath+"/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveJSON(w http.ResponseWriter, value func() interface{}) {
+	s.mu.Lock()
+	body, err := json.Marshal(value())
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (s *Server) serveItem(w http.ResponseWriter, kind ld.VersionedDataKind, key string) {
+	s.mu.Lock()
+	item, ok := s.data[kind][key]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	s.serveJSON(w, func() interface{} { return item })
+}
+
+// serveStream sends the current data as "put" event, followed by all changes
+// until the client goes away or Disconnect is called.
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// Events are buffered so that slow clients don't block changes
+	ch := make(chan event, 100)
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	ch <- event{"put", s.putData()}
+	s.streams[ch] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, ch)
+		s.mu.Unlock()
+	}()
+
+	if s.RetryDelay > 0 {
+		fmt.Fprintf(w, "retry: %d\n", s.RetryDelay/time.Millisecond)
+	}
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\n", e.name)
+			for _, line := range strings.Split(e.data, "\n") {
+				fmt.Fprintf(w, "data: %s\n", line)
+			}
+			fmt.Fprint(w, "\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// broadcast sends an event to all open streams. The caller must hold s.mu.
+// Streams whose buffer is full are disconnected, like LaunchDarkly drops
+// clients that don't keep up.
+func (s *Server) broadcast(e event) {
+	for ch := range s.streams {
+		select {
+		case ch <- e:
+		default:
+			close(ch)
+			delete(s.streams, ch)
+		}
+	}
+}
+
+// allJSON returns all data in the format of the polling endpoint. The caller
+// must hold s.mu.
+func (s *Server) allJSON() string {
+	body, _ := json.Marshal(map[string]interface{}{
+		"flags":    s.data[ld.Features],
+		"segments": s.data[ld.Segments],
+	})
+	return string(body)
+}
+
+// putData returns the data of a "put" event. The caller must hold s.mu.
+func (s *Server) putData() string {
+	return fmt.Sprintf(`{"path":"/","data":%s}`, s.allJSON())
+}
+
+func streamPath(kind ld.VersionedDataKind, key string) string {
+	if kind == ld.Segments {
+		return "/segments/" + key
+	}
+	return "/flags/" + key
+}
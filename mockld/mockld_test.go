@@ -0,0 +1,138 @@
+package mockld_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/fixtures"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/mockld"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+)
+
+func TestPolling(t *testing.T) {
+	server := mockld.NewServer("sdk-key")
+	defer server.Close()
+	server.Put(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	})
+
+	for path, want := range map[string]int{
+		ld.LatestAllPath:                http.StatusOK,
+		ld.LatestFlagsPath + "/flag":    http.StatusOK,
+		ld.LatestFlagsPath + "/unknown": http.StatusNotFound,
+		ld.LatestSegmentsPath + "/flag": http.StatusNotFound,
+		ld.LatestSegmentsPath:           http.StatusOK,
+	} {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		req.Header.Set("Authorization", "sdk-key")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: got status %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+
+	resp, err := http.Get(server.URL + ld.LatestAllPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got status %d without SDK key", resp.StatusCode)
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	server := mockld.NewServer("sdk-key")
+	defer server.Close()
+	data := fixtures.Generate(fixtures.Options{Seed: 1, Flags: 20, Segments: 2, BigSegmentSize: 10})
+	server.Put(data.AllData())
+
+	for _, raw := range []bool{false, true} {
+		store := ld.NewInMemoryFeatureStore(log.New(ioutil.Discard, "", 0))
+		syncer := &sync.Syncer{
+			Store:     store,
+			SDKKey:    "sdk-key",
+			Raw:       raw,
+			BaseURI:   server.URL,
+			StreamURI: server.URL,
+			Logger:    log.New(ioutil.Discard, "", 0),
+		}
+		report, err := syncer.Sync(context.Background())
+		if err != nil {
+			t.Fatalf("raw=%v: %s", raw, err)
+		}
+		if len(report["features"]) != 20 || len(report["segments"]) != 2 {
+			t.Errorf("raw=%v: got report %v", raw, report)
+		}
+	}
+	if server.Requests(mockld.StreamPath) != 1 || server.Requests(ld.LatestAllPath) != 1 {
+		t.Errorf("got %d stream and %d polling requests, want 1 each",
+			server.Requests(mockld.StreamPath), server.Requests(ld.LatestAllPath))
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	server := mockld.NewServer("sdk-key")
+	server.RetryDelay = 10 * time.Millisecond
+	defer server.Close()
+	server.Put(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	})
+
+	store := ld.NewInMemoryFeatureStore(log.New(ioutil.Discard, "", 0))
+	config := ld.DefaultConfig
+	config.BaseUri = server.URL
+	config.StreamUri = server.URL
+	config.FeatureStore = store
+	config.SendEvents = false
+	config.Logger = log.New(ioutil.Discard, "", 0)
+	client, err := ld.MakeCustomClient("sdk-key", config, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	version := func(key string) int {
+		item, _ := store.Get(ld.Features, key)
+		if item == nil {
+			return 0
+		}
+		return item.GetVersion()
+	}
+	eventually := func(msg string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if cond() {
+				return
+			}
+		}
+		t.Fatal(msg)
+	}
+
+	server.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 2})
+	eventually("patch not applied", func() bool { return version("flag") == 2 })
+
+	server.Delete(ld.Features, "flag", 3)
+	eventually("delete not applied", func() bool { return version("flag") == 0 })
+
+	// Malformed events are skipped
+	server.SendRaw("patch", `{"path":"/flags/broken","data":`)
+	server.Upsert(ld.Features, &ld.FeatureFlag{Key: "other", Version: 1})
+	eventually("patch after malformed event not applied", func() bool { return version("other") == 1 })
+
+	// Clients reconnect and receive the current data
+	server.Disconnect()
+	server.Upsert(ld.Features, &ld.FeatureFlag{Key: "missed", Version: 1})
+	eventually("no reconnect", func() bool { return server.Requests(mockld.StreamPath) == 2 })
+	eventually("change made while disconnected not applied", func() bool { return version("missed") == 1 })
+}
@@ -0,0 +1,141 @@
+/*
+Package multistore provides a LaunchDarkly feature store that writes to
+multiple stores, e.g. DynamoDB tables in different regions, and reads from the
+first one that is available.
+
+This makes it possible to run active/active setups without DynamoDB Global
+Tables: a single sync process keeps all regional tables up-to-date, while
+readers in each region list their local table first.
+*/
+package multistore
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// Verify that the store satisfies the FeatureStore interface
+var _ ld.FeatureStore = (*MultiStore)(nil)
+
+// MultiStore fans out writes to all of its stores and serves reads from the
+// first store that is initialized and doesn't fail.
+type MultiStore struct {
+	// Stores in order of read preference
+	Stores []ld.FeatureStore
+
+	// Logger to write all log messages to
+	Logger ld.Logger
+}
+
+// New creates a store that combines the given stores.
+func New(logger ld.Logger, stores ...ld.FeatureStore) *MultiStore {
+	if logger == nil {
+		logger = log.New(os.Stderr, "[LaunchDarkly MultiStore]", log.LstdFlags)
+	}
+	return &MultiStore{Stores: stores, Logger: logger}
+}
+
+// Get returns an item from the first healthy store.
+func (s *MultiStore) Get(kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	var lastErr error
+	for i, store := range s.Stores {
+		if !store.Initialized() {
+			continue
+		}
+		item, err := store.Get(kind, key)
+		if err == nil {
+			return item, nil
+		}
+		s.Logger.Printf("WARN: Failed to get item from store %d, trying next one (key=%s): %s", i, key, err)
+		lastErr = err
+	}
+	return nil, s.readError(lastErr)
+}
+
+// All returns all items from the first healthy store.
+func (s *MultiStore) All(kind ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	var lastErr error
+	for i, store := range s.Stores {
+		if !store.Initialized() {
+			continue
+		}
+		items, err := store.All(kind)
+		if err == nil {
+			return items, nil
+		}
+		s.Logger.Printf("WARN: Failed to get all %q items from store %d, trying next one: %s", kind.GetNamespace(), i, err)
+		lastErr = err
+	}
+	return nil, s.readError(lastErr)
+}
+
+func (s *MultiStore) readError(lastErr error) error {
+	if lastErr == nil {
+		return fmt.Errorf("none of %d store(s) is initialized", len(s.Stores))
+	}
+	return fmt.Errorf("all stores failed, last error: %s", lastErr)
+}
+
+// Init initializes all stores concurrently.
+func (s *MultiStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	return s.fanOut("initialize", func(store ld.FeatureStore) error {
+		return store.Init(allData)
+	})
+}
+
+// Upsert updates an item in all stores concurrently.
+func (s *MultiStore) Upsert(kind ld.VersionedDataKind, item ld.VersionedData) error {
+	return s.fanOut("update item (key="+item.GetKey()+")", func(store ld.FeatureStore) error {
+		return store.Upsert(kind, item)
+	})
+}
+
+// Delete deletes an item from all stores concurrently.
+func (s *MultiStore) Delete(kind ld.VersionedDataKind, key string, version int) error {
+	return s.fanOut("delete item (key="+key+")", func(store ld.FeatureStore) error {
+		return store.Delete(kind, key, version)
+	})
+}
+
+// Initialized returns true if any store is initialized.
+func (s *MultiStore) Initialized() bool {
+	for _, store := range s.Stores {
+		if store.Initialized() {
+			return true
+		}
+	}
+	return false
+}
+
+// fanOut applies a write to all stores. A failing store doesn't keep the
+// write from reaching the others; the returned error lists all failures.
+func (s *MultiStore) fanOut(op string, write func(ld.FeatureStore) error) error {
+	errs := make([]error, len(s.Stores))
+
+	var wg sync.WaitGroup
+	for i, store := range s.Stores {
+		wg.Add(1)
+		go func(i int, store ld.FeatureStore) {
+			defer wg.Done()
+			errs[i] = write(store)
+		}(i, store)
+	}
+	wg.Wait()
+
+	var msgs []string
+	for i, err := range errs {
+		if err != nil {
+			s.Logger.Printf("ERROR: Failed to %s in store %d: %s", op, i, err)
+			msgs = append(msgs, fmt.Sprintf("store %d: %s", i, err))
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("failed to %s in %d of %d store(s): %s", op, len(msgs), len(s.Stores), strings.Join(msgs, "; "))
+	}
+	return nil
+}
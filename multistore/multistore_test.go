@@ -0,0 +1,98 @@
+package multistore_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+	ldtest "gopkg.in/launchdarkly/go-client.v4/shared_test"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/multistore"
+)
+
+// brokenStore fails all operations.
+type brokenStore struct{}
+
+var errBroken = errors.New("broken")
+
+func (brokenStore) Get(ld.VersionedDataKind, string) (ld.VersionedData, error) {
+	return nil, errBroken
+}
+
+func (brokenStore) All(ld.VersionedDataKind) (map[string]ld.VersionedData, error) {
+	return nil, errBroken
+}
+
+func (brokenStore) Init(map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	return errBroken
+}
+
+func (brokenStore) Upsert(ld.VersionedDataKind, ld.VersionedData) error {
+	return errBroken
+}
+
+func (brokenStore) Delete(ld.VersionedDataKind, string, int) error {
+	return errBroken
+}
+
+func (brokenStore) Initialized() bool {
+	return true
+}
+
+var discard = log.New(ioutil.Discard, "", 0)
+
+func TestMultiStore(t *testing.T) {
+	ldtest.RunFeatureStoreTests(t, func() ld.FeatureStore {
+		return multistore.New(discard, ld.NewInMemoryFeatureStore(nil), ld.NewInMemoryFeatureStore(nil))
+	})
+}
+
+func TestWritesFanOut(t *testing.T) {
+	a, b := ld.NewInMemoryFeatureStore(nil), ld.NewInMemoryFeatureStore(nil)
+	s := multistore.New(discard, a, brokenStore{}, b)
+
+	if err := s.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	}); err == nil {
+		t.Error("expected error from broken store")
+	}
+	if err := s.Upsert(ld.Features, &ld.FeatureFlag{Key: "other", Version: 1}); err == nil {
+		t.Error("expected error from broken store")
+	}
+
+	for _, store := range []ld.FeatureStore{a, b} {
+		items, err := store.All(ld.Features)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 {
+			t.Errorf("expected writes to reach healthy stores, got %v", items)
+		}
+	}
+}
+
+func TestReadsFallThrough(t *testing.T) {
+	healthy := ld.NewInMemoryFeatureStore(nil)
+	healthy.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+	})
+	uninitialized := ld.NewInMemoryFeatureStore(nil)
+
+	s := multistore.New(discard, uninitialized, brokenStore{}, healthy)
+
+	item, err := s.Get(ld.Features, "flag")
+	if err != nil || item == nil {
+		t.Fatalf("got %v, %v", item, err)
+	}
+	items, err := s.All(ld.Features)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("got %v, %v", items, err)
+	}
+
+	s = multistore.New(discard, brokenStore{})
+	if _, err := s.All(ld.Features); err == nil {
+		t.Error("expected error when all stores fail")
+	}
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// Publisher sends a message with a subject to a destination. It is
+// implemented by SNS, SQS, EventBridge, and Webhook.
+type Publisher interface {
+	Publish(subject, message string) error
+}
+
+// sendSigned posts a request to an AWS API, signed with Signature Version 4.
+// The clients of these services aren't part of the vendored AWS SDK.
+func sendSigned(client *http.Client, creds *credentials.Credentials, service, region, endpoint, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if _, err := v4.NewSigner(creds).Sign(req, bytes.NewReader(body), service, region, time.Now()); err != nil {
+		return err
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// arnRegion extracts the region from an ARN of the given service, e.g.
+// "arn:aws:sns:us-east-1:123456789012:alerts".
+func arnRegion(arn, service string) (string, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != service || parts[3] == "" {
+		return "", fmt.Errorf("invalid %s ARN: %q", strings.ToUpper(service), arn)
+	}
+	return parts[3], nil
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// EventSource is the source of events put on EventBridge by ParseDestination.
+const EventSource = "launchdarkly.dynamo-store"
+
+// ParseDestination returns a publisher for a destination given as:
+//
+//	arn:aws:sns:REGION:ACCOUNT:TOPIC           SNS topic
+//	arn:aws:events:REGION:ACCOUNT:event-bus/BUS  EventBridge event bus
+//	https://sqs.REGION.amazonaws.com/ACCOUNT/QUEUE  SQS queue
+//	http(s)://...                               webhook
+func ParseDestination(sess *session.Session, dest string) (Publisher, error) {
+	switch {
+	case strings.HasPrefix(dest, "arn:aws:sns:"):
+		return NewSNS(sess, dest), nil
+	case strings.HasPrefix(dest, "arn:aws:events:"):
+		region, err := arnRegion(dest, "events")
+		if err != nil {
+			return nil, err
+		}
+		e := NewEventBridge(sess, dest, EventSource)
+		e.Region = region
+		return e, nil
+	}
+
+	u, err := url.Parse(dest)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid destination %q", dest)
+	}
+	if queueRegion(u.Host) != "" && strings.HasSuffix(u.Host, ".amazonaws.com") {
+		return NewSQS(sess, dest), nil
+	}
+	return NewWebhook(dest), nil
+}
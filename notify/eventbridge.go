@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// EventBridge puts events on an Amazon EventBridge event bus.
+type EventBridge struct {
+	// Name or ARN of the event bus, e.g. "default"
+	EventBus string
+
+	// Source of the events, e.g. "launchdarkly.dynamo-store"
+	Source string
+
+	// Region of the event bus
+	Region string
+
+	// Credentials used to sign requests
+	Credentials *credentials.Credentials
+
+	// Endpoint of the EventBridge API; derived from Region if empty
+	Endpoint string
+
+	// HTTP client used to send requests
+	Client *http.Client
+}
+
+// NewEventBridge creates an EventBridge sender using the credentials and
+// region of an AWS session.
+func NewEventBridge(sess *session.Session, eventBus, source string) *EventBridge {
+	region := ""
+	if sess.Config.Region != nil {
+		region = *sess.Config.Region
+	}
+	return &EventBridge{
+		EventBus:    eventBus,
+		Source:      source,
+		Region:      region,
+		Credentials: sess.Config.Credentials,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish puts an event with the subject as detail type. The message must
+// be a JSON object, as EventBridge requires the event detail to be one.
+func (e *EventBridge) Publish(subject, message string) error {
+	endpoint := e.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://events.%s.amazonaws.com/", e.Region)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"Entries": []map[string]string{{
+			"EventBusName": e.EventBus,
+			"Source":       e.Source,
+			"DetailType":   subject,
+			"Detail":       message,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = sendSigned(e.Client, e.Credentials, "events", e.Region, endpoint,
+		"application/x-amz-json-1.1", map[string]string{"X-Amz-Target": "AWSEvents.PutEvents"}, body)
+	if err != nil {
+		return fmt.Errorf("failed to put event on %s: %s", e.EventBus, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Slack posts messages to a Slack channel through an incoming webhook.
+type Slack struct {
+	// Incoming webhook URL, e.g. https://hooks.slack.com/services/...
+	URL string
+
+	// HTTP client used to send requests
+	Client *http.Client
+}
+
+// NewSlack creates a Slack publisher for the given incoming webhook URL.
+func NewSlack(url string) *Slack {
+	return &Slack{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Publish posts the message as text, with the subject in bold as the first
+// line.
+func (s *Slack) Publish(subject, message string) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", subject, message),
+	})
+	if err != nil {
+		return err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		// Don't include the URL, which contains a secret token
+		return fmt.Errorf("failed to post to Slack: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
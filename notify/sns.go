@@ -0,0 +1,68 @@
+/*
+Package notify sends operational alerts, e.g. when webhook deliveries are
+rejected repeatedly, and publishes change notifications to downstream systems.
+*/
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// SNS publishes messages to an Amazon SNS topic.
+//
+// The SNS client isn't part of the vendored AWS SDK, so requests to the Query
+// API are signed and sent directly.
+type SNS struct {
+	// ARN of the topic to publish to
+	TopicARN string
+
+	// Credentials used to sign requests
+	Credentials *credentials.Credentials
+
+	// Endpoint of the SNS API; derived from the topic's region if empty
+	Endpoint string
+
+	// HTTP client used to send requests
+	Client *http.Client
+}
+
+// NewSNS creates an SNS publisher using the credentials of an AWS session.
+func NewSNS(sess *session.Session, topicARN string) *SNS {
+	return &SNS{
+		TopicARN:    topicARN,
+		Credentials: sess.Config.Credentials,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish sends a message with the given subject to the topic.
+func (s *SNS) Publish(subject, message string) error {
+	region, err := arnRegion(s.TopicARN, "sns")
+	if err != nil {
+		return err
+	}
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sns.%s.amazonaws.com/", region)
+	}
+
+	form := url.Values{}
+	form.Set("Action", "Publish")
+	form.Set("Version", "2010-03-31")
+	form.Set("TopicArn", s.TopicARN)
+	form.Set("Subject", subject)
+	form.Set("Message", message)
+
+	err = sendSigned(s.Client, s.Credentials, "sns", region, endpoint,
+		"application/x-www-form-urlencoded; charset=utf-8", nil, []byte(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %s", s.TopicARN, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package notify_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/notify"
+)
+
+func TestSNSPublish(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got = r
+	}))
+	defer srv.Close()
+
+	sns := &notify.SNS{
+		TopicARN:    "arn:aws:sns:eu-west-1:123456789012:alerts",
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    srv.URL,
+	}
+	if err := sns.Publish("subject", "message"); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth := got.Header.Get("Authorization"); !strings.Contains(auth, "/eu-west-1/sns/aws4_request") {
+		t.Errorf("unexpected Authorization header: %q", auth)
+	}
+	for k, want := range map[string]string{
+		"Action":   "Publish",
+		"TopicArn": sns.TopicARN,
+		"Subject":  "subject",
+		"Message":  "message",
+	} {
+		if v := got.PostForm.Get(k); v != want {
+			t.Errorf("%s = %q, want %q", k, v, want)
+		}
+	}
+}
+
+func TestSNSPublishError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AuthorizationError", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	sns := &notify.SNS{
+		TopicARN:    "arn:aws:sns:eu-west-1:123456789012:alerts",
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    srv.URL,
+	}
+	if err := sns.Publish("subject", "message"); err == nil {
+		t.Error("expected error")
+	}
+
+	sns.TopicARN = "alerts"
+	if err := sns.Publish("subject", "message"); err == nil {
+		t.Error("expected error for invalid ARN")
+	}
+}
+
+func TestPublishers(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+	creds := credentials.NewStaticCredentials("AKID", "SECRET", "")
+
+	bus := &notify.EventBridge{EventBus: "default", Source: "test", Region: "eu-west-1", Credentials: creds, Endpoint: srv.URL}
+	if err := bus.Publish("changes", `{"a":1}`); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("X-Amz-Target") != "AWSEvents.PutEvents" || !strings.Contains(string(body), `"DetailType":"changes"`) {
+		t.Errorf("unexpected EventBridge request: %s %s", got.Header, body)
+	}
+
+	hook := &notify.Webhook{URL: srv.URL}
+	if err := hook.Publish("changes", `{"a":1}`); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("X-Subject") != "changes" || string(body) != `{"a":1}` {
+		t.Errorf("unexpected webhook request: %s %s", got.Header, body)
+	}
+
+	slack := &notify.Slack{URL: srv.URL}
+	if err := slack.Publish("changes", "features/flag added (v1)"); err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"text":"*changes*\nfeatures/flag added (v1)"}` {
+		t.Errorf("unexpected Slack request: %s", body)
+	}
+}
+
+func TestParseDestination(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{Region: aws.String("eu-west-1")}))
+	for dest, want := range map[string]string{
+		"arn:aws:sns:eu-west-1:123456789012:changes":              "*notify.SNS",
+		"arn:aws:events:eu-west-1:123456789012:event-bus/default": "*notify.EventBridge",
+		"https://sqs.eu-west-1.amazonaws.com/123456789012/queue":  "*notify.SQS",
+		"https://example.com/hook":                                "*notify.Webhook",
+	} {
+		p, err := notify.ParseDestination(sess, dest)
+		if err != nil {
+			t.Errorf("%s: %s", dest, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", p); got != want {
+			t.Errorf("%s: got %s, want %s", dest, got, want)
+		}
+	}
+	if _, err := notify.ParseDestination(sess, "queue"); err == nil {
+		t.Error("expected error for invalid destination")
+	}
+}
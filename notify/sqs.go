@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// SQS sends messages to an Amazon SQS queue via the Query API.
+type SQS struct {
+	// URL of the queue, e.g.
+	// "https://sqs.eu-west-1.amazonaws.com/123456789012/flag-changes"
+	QueueURL string
+
+	// Credentials used to sign requests
+	Credentials *credentials.Credentials
+
+	// HTTP client used to send requests
+	Client *http.Client
+}
+
+// NewSQS creates an SQS sender using the credentials of an AWS session.
+func NewSQS(sess *session.Session, queueURL string) *SQS {
+	return &SQS{
+		QueueURL:    queueURL,
+		Credentials: sess.Config.Credentials,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish sends the message to the queue. Queues have no subjects, so it's
+// sent as message attribute "subject".
+func (s *SQS) Publish(subject, message string) error {
+	u, err := url.Parse(s.QueueURL)
+	if err != nil {
+		return err
+	}
+	region := queueRegion(u.Host)
+	if region == "" {
+		return fmt.Errorf("invalid SQS queue URL: %q", s.QueueURL)
+	}
+
+	form := url.Values{}
+	form.Set("Action", "SendMessage")
+	form.Set("Version", "2012-11-05")
+	form.Set("MessageBody", message)
+	form.Set("MessageAttribute.1.Name", "subject")
+	form.Set("MessageAttribute.1.Value.DataType", "String")
+	form.Set("MessageAttribute.1.Value.StringValue", subject)
+
+	err = sendSigned(s.Client, s.Credentials, "sqs", region, s.QueueURL,
+		"application/x-www-form-urlencoded; charset=utf-8", nil, []byte(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %s", s.QueueURL, err)
+	}
+	return nil
+}
+
+// queueRegion extracts the region from a host like
+// "sqs.eu-west-1.amazonaws.com".
+func queueRegion(host string) string {
+	parts := strings.Split(host, ".")
+	if len(parts) < 3 || parts[0] != "sqs" {
+		return ""
+	}
+	return parts[1]
+}
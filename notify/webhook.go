@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook posts messages to an HTTP endpoint.
+type Webhook struct {
+	// URL to post to
+	URL string
+
+	// HTTP client used to send requests
+	Client *http.Client
+}
+
+// NewWebhook creates a Webhook for the given URL.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Publish posts the message as JSON body, with the subject in the
+// X-Subject header.
+func (w *Webhook) Publish(subject, message string) error {
+	req, err := http.NewRequest("POST", w.URL, strings.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Subject", subject)
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("failed to post to %s: %s: %s", w.URL, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+/*
+Package openfeature provides an OpenFeature provider that evaluates flags
+locally from a feature store, e.g. a DynamoDBFeatureStore, for teams
+standardizing on the OpenFeature API.
+
+The OpenFeature Go SDK isn't vendored here, so the types below mirror those of
+the SDK's FeatureProvider interface (github.com/open-feature/go-sdk). A thin
+adapter that converts them, and adds an empty Hooks method, is all that's
+needed to register the provider with the SDK.
+*/
+package openfeature
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/evaluator"
+)
+
+// TargetingKey is the evaluation context attribute used as the user key.
+const TargetingKey = "targetingKey"
+
+// Reason explains why a value was resolved.
+type Reason string
+
+// Resolution reasons as defined by the OpenFeature specification
+const (
+	TargetingMatchReason Reason = "TARGETING_MATCH"
+	SplitReason          Reason = "SPLIT"
+	DisabledReason       Reason = "DISABLED"
+	DefaultReason        Reason = "DEFAULT"
+	ErrorReason          Reason = "ERROR"
+)
+
+// ErrorCode classifies resolution errors.
+type ErrorCode string
+
+// Error codes as defined by the OpenFeature specification
+const (
+	FlagNotFoundCode        ErrorCode = "FLAG_NOT_FOUND"
+	TypeMismatchCode        ErrorCode = "TYPE_MISMATCH"
+	TargetingKeyMissingCode ErrorCode = "TARGETING_KEY_MISSING"
+	GeneralCode             ErrorCode = "GENERAL"
+)
+
+// ResolutionError describes why the default value was returned.
+type ResolutionError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *ResolutionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Metadata describes the provider.
+type Metadata struct {
+	Name string
+}
+
+// FlattenedContext is the evaluation context, with the user key stored as
+// TargetingKey.
+type FlattenedContext map[string]interface{}
+
+// ProviderResolutionDetail holds the details of a resolution.
+type ProviderResolutionDetail struct {
+	ResolutionError *ResolutionError
+	Reason          Reason
+	Variant         string
+}
+
+// BoolResolutionDetail is the result of a boolean evaluation.
+type BoolResolutionDetail struct {
+	Value bool
+	ProviderResolutionDetail
+}
+
+// StringResolutionDetail is the result of a string evaluation.
+type StringResolutionDetail struct {
+	Value string
+	ProviderResolutionDetail
+}
+
+// FloatResolutionDetail is the result of a float evaluation.
+type FloatResolutionDetail struct {
+	Value float64
+	ProviderResolutionDetail
+}
+
+// IntResolutionDetail is the result of an integer evaluation.
+type IntResolutionDetail struct {
+	Value int64
+	ProviderResolutionDetail
+}
+
+// InterfaceResolutionDetail is the result of an object evaluation.
+type InterfaceResolutionDetail struct {
+	Value interface{}
+	ProviderResolutionDetail
+}
+
+// Provider resolves flags from a feature store.
+type Provider struct {
+	// Store to read flags and segments from
+	Store ld.FeatureStore
+}
+
+// NewProvider creates a provider for the given store.
+func NewProvider(store ld.FeatureStore) *Provider {
+	return &Provider{Store: store}
+}
+
+// Metadata returns the provider's name.
+func (p *Provider) Metadata() Metadata {
+	return Metadata{Name: "launchdarkly-dynamo-store"}
+}
+
+// BooleanEvaluation resolves a boolean flag.
+func (p *Provider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx FlattenedContext) BoolResolutionDetail {
+	value, detail := p.resolve(flag, evalCtx)
+	if detail.ResolutionError == nil {
+		if v, ok := value.(bool); ok {
+			return BoolResolutionDetail{v, detail}
+		}
+		detail = typeMismatch(value, "bool")
+	}
+	return BoolResolutionDetail{defaultValue, detail}
+}
+
+// StringEvaluation resolves a string flag.
+func (p *Provider) StringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx FlattenedContext) StringResolutionDetail {
+	value, detail := p.resolve(flag, evalCtx)
+	if detail.ResolutionError == nil {
+		if v, ok := value.(string); ok {
+			return StringResolutionDetail{v, detail}
+		}
+		detail = typeMismatch(value, "string")
+	}
+	return StringResolutionDetail{defaultValue, detail}
+}
+
+// FloatEvaluation resolves a numeric flag.
+func (p *Provider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx FlattenedContext) FloatResolutionDetail {
+	value, detail := p.resolve(flag, evalCtx)
+	if detail.ResolutionError == nil {
+		if v, ok := value.(float64); ok {
+			return FloatResolutionDetail{v, detail}
+		}
+		detail = typeMismatch(value, "float")
+	}
+	return FloatResolutionDetail{defaultValue, detail}
+}
+
+// IntEvaluation resolves a numeric flag whose value is a whole number.
+func (p *Provider) IntEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx FlattenedContext) IntResolutionDetail {
+	value, detail := p.resolve(flag, evalCtx)
+	if detail.ResolutionError == nil {
+		// JSON numbers are decoded as float64
+		if v, ok := value.(float64); ok && v == math.Trunc(v) {
+			return IntResolutionDetail{int64(v), detail}
+		}
+		detail = typeMismatch(value, "int")
+	}
+	return IntResolutionDetail{defaultValue, detail}
+}
+
+// ObjectEvaluation resolves a flag of any type, e.g. a JSON object.
+func (p *Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx FlattenedContext) InterfaceResolutionDetail {
+	value, detail := p.resolve(flag, evalCtx)
+	if detail.ResolutionError != nil {
+		return InterfaceResolutionDetail{defaultValue, detail}
+	}
+	return InterfaceResolutionDetail{value, detail}
+}
+
+// resolve evaluates a flag with the SDK's evaluation rules.
+func (p *Provider) resolve(key string, evalCtx FlattenedContext) (interface{}, ProviderResolutionDetail) {
+	user, err := toUser(evalCtx)
+	if err != nil {
+		return nil, failure(TargetingKeyMissingCode, err.Error())
+	}
+
+	d, err := evaluator.New(p.Store).EvaluateDetail(key, user)
+	switch {
+	case err == evaluator.ErrFlagNotFound:
+		return nil, failure(FlagNotFoundCode, fmt.Sprintf("flag %q not found", key))
+	case err != nil:
+		return nil, failure(GeneralCode, err.Error())
+	}
+
+	return d.Value, ProviderResolutionDetail{
+		Reason:  reason(d),
+		Variant: variant(d.Variation),
+	}
+}
+
+func reason(d evaluator.Detail) Reason {
+	switch {
+	case d.Reason == evaluator.ReasonOff:
+		return DisabledReason
+	case d.InSplit:
+		return SplitReason
+	case d.Reason == evaluator.ReasonTargetMatch, d.Reason == evaluator.ReasonRuleMatch:
+		return TargetingMatchReason
+	}
+	return DefaultReason
+}
+
+func variant(index *int) string {
+	if index == nil {
+		return ""
+	}
+	return strconv.Itoa(*index)
+}
+
+func failure(code ErrorCode, msg string) ProviderResolutionDetail {
+	return ProviderResolutionDetail{
+		ResolutionError: &ResolutionError{Code: code, Message: msg},
+		Reason:          ErrorReason,
+	}
+}
+
+func typeMismatch(value interface{}, want string) ProviderResolutionDetail {
+	return failure(TypeMismatchCode, fmt.Sprintf("flag value %v is a %T, not a %s", value, value, want))
+}
+
+// toUser converts an evaluation context to a user. Attributes named like
+// built-in user attributes, e.g. "email", are set as such; all others
+// become custom attributes.
+func toUser(evalCtx FlattenedContext) (ld.User, error) {
+	key, ok := evalCtx[TargetingKey].(string)
+	if !ok || key == "" {
+		return ld.User{}, fmt.Errorf("evaluation context has no %s", TargetingKey)
+	}
+
+	user := ld.NewUser(key)
+	builtin := map[string]**string{
+		"secondary": &user.Secondary,
+		"ip":        &user.Ip,
+		"country":   &user.Country,
+		"email":     &user.Email,
+		"firstName": &user.FirstName,
+		"lastName":  &user.LastName,
+		"avatar":    &user.Avatar,
+		"name":      &user.Name,
+	}
+	custom := make(map[string]interface{})
+	for name, value := range evalCtx {
+		if name == TargetingKey {
+			continue
+		}
+		if name == "anonymous" {
+			if b, ok := value.(bool); ok {
+				user.Anonymous = &b
+				continue
+			}
+		}
+		if field, ok := builtin[name]; ok {
+			if s, ok := value.(string); ok {
+				*field = &s
+				continue
+			}
+		}
+		custom[name] = value
+	}
+	if len(custom) > 0 {
+		user.Custom = &custom
+	}
+	return user, nil
+}
@@ -0,0 +1,105 @@
+package openfeature_test
+
+import (
+	"context"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/openfeature"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func newTestProvider(t *testing.T) *openfeature.Provider {
+	store := ld.NewInMemoryFeatureStore(nil)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"bool-flag": &ld.FeatureFlag{
+				Key:          "bool-flag",
+				Version:      1,
+				On:           true,
+				Variations:   []interface{}{false, true},
+				OffVariation: intPtr(0),
+				Fallthrough:  ld.VariationOrRollout{Variation: intPtr(0)},
+				Rules: []ld.Rule{{
+					VariationOrRollout: ld.VariationOrRollout{Variation: intPtr(1)},
+					Clauses: []ld.Clause{{
+						Attribute: "email",
+						Op:        ld.OperatorEndsWith,
+						Values:    []interface{}{"@example.com"},
+					}},
+				}},
+			},
+			"off-flag": &ld.FeatureFlag{
+				Key:          "off-flag",
+				Version:      1,
+				Variations:   []interface{}{"a", "b"},
+				OffVariation: intPtr(1),
+			},
+			"int-flag": &ld.FeatureFlag{
+				Key:         "int-flag",
+				Version:     1,
+				On:          true,
+				Variations:  []interface{}{float64(42)},
+				Fallthrough: ld.VariationOrRollout{Variation: intPtr(0)},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return openfeature.NewProvider(store)
+}
+
+func TestBooleanEvaluation(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+
+	d := p.BooleanEvaluation(ctx, "bool-flag", false, openfeature.FlattenedContext{
+		openfeature.TargetingKey: "user",
+		"email":                  "user@example.com",
+	})
+	if !d.Value || d.Reason != openfeature.TargetingMatchReason || d.Variant != "1" || d.ResolutionError != nil {
+		t.Errorf("got %+v, want rule match", d)
+	}
+
+	d = p.BooleanEvaluation(ctx, "bool-flag", true, openfeature.FlattenedContext{openfeature.TargetingKey: "user"})
+	if d.Value || d.Reason != openfeature.DefaultReason {
+		t.Errorf("got %+v, want fallthrough", d)
+	}
+
+	d = p.BooleanEvaluation(ctx, "bool-flag", true, openfeature.FlattenedContext{})
+	if !d.Value || d.ResolutionError == nil || d.ResolutionError.Code != openfeature.TargetingKeyMissingCode {
+		t.Errorf("got %+v, want missing targeting key", d)
+	}
+}
+
+func TestStringEvaluation(t *testing.T) {
+	p := newTestProvider(t)
+	evalCtx := openfeature.FlattenedContext{openfeature.TargetingKey: "user"}
+
+	d := p.StringEvaluation(context.Background(), "off-flag", "default", evalCtx)
+	if d.Value != "b" || d.Reason != openfeature.DisabledReason {
+		t.Errorf("got %+v, want off variation", d)
+	}
+
+	d = p.StringEvaluation(context.Background(), "unknown", "default", evalCtx)
+	if d.Value != "default" || d.ResolutionError == nil || d.ResolutionError.Code != openfeature.FlagNotFoundCode {
+		t.Errorf("got %+v, want flag not found", d)
+	}
+
+	d = p.StringEvaluation(context.Background(), "bool-flag", "default", evalCtx)
+	if d.Value != "default" || d.ResolutionError == nil || d.ResolutionError.Code != openfeature.TypeMismatchCode {
+		t.Errorf("got %+v, want type mismatch", d)
+	}
+}
+
+func TestIntEvaluation(t *testing.T) {
+	p := newTestProvider(t)
+	d := p.IntEvaluation(context.Background(), "int-flag", 0, openfeature.FlattenedContext{openfeature.TargetingKey: "user"})
+	if d.Value != 42 || d.ResolutionError != nil {
+		t.Errorf("got %+v, want 42", d)
+	}
+}
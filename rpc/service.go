@@ -0,0 +1,191 @@
+/*
+Package rpc implements the flag evaluation API defined in flags.proto on top
+of a feature store, e.g. a DynamoDBFeatureStore, so that services written in
+other languages can consume flags without embedding a LaunchDarkly SDK.
+
+The gRPC and protobuf libraries aren't vendored here, so the message types
+below are hand-written equivalents of the generated ones, and Service has the
+method set of the generated FlagsServer interface. To serve it over gRPC,
+generate the code with protoc-gen-go in your own build and register a Service:
+
+	pb.RegisterFlagsServer(grpcServer, &rpc.Service{Client: ldClient, Store: store})
+*/
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// User is a user to evaluate flags for.
+type User struct {
+	Key        string
+	Anonymous  bool
+	Attributes map[string]string
+}
+
+// EvaluateFlagRequest asks for the value of a single flag.
+type EvaluateFlagRequest struct {
+	FlagKey          string
+	User             *User
+	DefaultValueJson string
+}
+
+// EvaluateFlagResponse is the value of a single flag.
+type EvaluateFlagResponse struct {
+	ValueJson      string
+	VariationIndex int32
+	Error          string
+}
+
+// AllFlagsRequest asks for the values of all flags.
+type AllFlagsRequest struct {
+	User *User
+}
+
+// AllFlagsResponse holds the values of all flags.
+type AllFlagsResponse struct {
+	ValuesJson map[string]string
+}
+
+// GetSegmentRequest asks for a user segment.
+type GetSegmentRequest struct {
+	SegmentKey string
+}
+
+// GetSegmentResponse holds a user segment.
+type GetSegmentResponse struct {
+	Found       bool
+	SegmentJson string
+}
+
+// Client evaluates flags. It is satisfied by *evaluator.Evaluator and by
+// *ld.LDClient, which should be configured with UseLdd to read from the store
+// only.
+type Client interface {
+	Evaluate(key string, user ld.User, defaultVal interface{}) (interface{}, *int, error)
+	AllFlags(user ld.User) map[string]interface{}
+}
+
+// Service implements the Flags service.
+type Service struct {
+	// Client to evaluate flags with
+	Client Client
+
+	// Store to read segments from. Wrap it with middleware.Redacted to hide
+	// segment members from callers.
+	Store ld.FeatureStore
+}
+
+// EvaluateFlag evaluates a single flag for a user. Evaluation errors, e.g. an
+// unknown flag, are reported in the response along with the default value.
+func (s *Service) EvaluateFlag(ctx context.Context, req *EvaluateFlagRequest) (*EvaluateFlagResponse, error) {
+	if req.FlagKey == "" {
+		return nil, errors.New("flag key is required")
+	}
+	user, err := toUser(req.User)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultVal interface{}
+	if req.DefaultValueJson != "" {
+		if err := json.Unmarshal([]byte(req.DefaultValueJson), &defaultVal); err != nil {
+			return nil, errors.New("default value is not valid JSON")
+		}
+	}
+
+	value, variation, evalErr := s.Client.Evaluate(req.FlagKey, user, defaultVal)
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &EvaluateFlagResponse{ValueJson: string(valueJSON), VariationIndex: -1}
+	if variation != nil {
+		resp.VariationIndex = int32(*variation)
+	}
+	if evalErr != nil {
+		resp.Error = evalErr.Error()
+	}
+	return resp, nil
+}
+
+// AllFlags evaluates all flags for a user.
+func (s *Service) AllFlags(ctx context.Context, req *AllFlagsRequest) (*AllFlagsResponse, error) {
+	user, err := toUser(req.User)
+	if err != nil {
+		return nil, err
+	}
+
+	values := s.Client.AllFlags(user)
+	resp := &AllFlagsResponse{ValuesJson: make(map[string]string, len(values))}
+	for key, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		resp.ValuesJson[key] = string(data)
+	}
+	return resp, nil
+}
+
+// GetSegment returns a user segment from the store.
+func (s *Service) GetSegment(ctx context.Context, req *GetSegmentRequest) (*GetSegmentResponse, error) {
+	if req.SegmentKey == "" {
+		return nil, errors.New("segment key is required")
+	}
+	item, err := s.Store.Get(ld.Segments, req.SegmentKey)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return &GetSegmentResponse{}, nil
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	return &GetSegmentResponse{Found: true, SegmentJson: string(data)}, nil
+}
+
+// toUser converts a request user to an SDK user. Attributes with the name of
+// a built-in attribute, e.g. "email", are set as such; all others become
+// custom attributes.
+func toUser(u *User) (ld.User, error) {
+	if u == nil || u.Key == "" {
+		return ld.User{}, errors.New("user key is required")
+	}
+
+	user := ld.NewUser(u.Key)
+	if u.Anonymous {
+		user.Anonymous = &u.Anonymous
+	}
+
+	builtin := map[string]**string{
+		"secondary": &user.Secondary,
+		"ip":        &user.Ip,
+		"country":   &user.Country,
+		"email":     &user.Email,
+		"firstName": &user.FirstName,
+		"lastName":  &user.LastName,
+		"avatar":    &user.Avatar,
+		"name":      &user.Name,
+	}
+	custom := make(map[string]interface{})
+	for name, value := range u.Attributes {
+		value := value
+		if field, ok := builtin[name]; ok {
+			*field = &value
+		} else {
+			custom[name] = value
+		}
+	}
+	if len(custom) > 0 {
+		user.Custom = &custom
+	}
+	return user, nil
+}
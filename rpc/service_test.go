@@ -0,0 +1,101 @@
+package rpc_test
+
+import (
+	"context"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/rpc"
+)
+
+func newTestService(t *testing.T) *rpc.Service {
+	store := ld.NewInMemoryFeatureStore(nil)
+	if err := store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"flag": &ld.FeatureFlag{
+				Key:         "flag",
+				Version:     1,
+				On:          true,
+				Variations:  []interface{}{"a", "b"},
+				Fallthrough: ld.VariationOrRollout{Variation: intPtr(1)},
+			},
+		},
+		ld.Segments: {
+			"beta": &ld.Segment{Key: "beta", Version: 1, Included: []string{"user"}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	config := ld.DefaultConfig
+	config.FeatureStore = store
+	config.UseLdd = true
+	config.SendEvents = false
+	client, err := ld.MakeCustomClient("sdk-key", config, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &rpc.Service{Client: client, Store: store}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestEvaluateFlag(t *testing.T) {
+	s := newTestService(t)
+	user := &rpc.User{Key: "user", Attributes: map[string]string{"email": "user@example.com", "plan": "pro"}}
+
+	resp, err := s.EvaluateFlag(context.Background(), &rpc.EvaluateFlagRequest{FlagKey: "flag", User: user})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ValueJson != `"b"` || resp.VariationIndex != 1 || resp.Error != "" {
+		t.Errorf("got %+v", resp)
+	}
+
+	resp, err = s.EvaluateFlag(context.Background(), &rpc.EvaluateFlagRequest{
+		FlagKey: "unknown", User: user, DefaultValueJson: `"default"`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ValueJson != `"default"` || resp.VariationIndex != -1 || resp.Error == "" {
+		t.Errorf("got %+v", resp)
+	}
+
+	if _, err := s.EvaluateFlag(context.Background(), &rpc.EvaluateFlagRequest{FlagKey: "flag"}); err == nil {
+		t.Error("expected error without user")
+	}
+}
+
+func TestAllFlags(t *testing.T) {
+	s := newTestService(t)
+	resp, err := s.AllFlags(context.Background(), &rpc.AllFlagsRequest{User: &rpc.User{Key: "user"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ValuesJson) != 1 || resp.ValuesJson["flag"] != `"b"` {
+		t.Errorf("got %v", resp.ValuesJson)
+	}
+}
+
+func TestGetSegment(t *testing.T) {
+	s := newTestService(t)
+	resp, err := s.GetSegment(context.Background(), &rpc.GetSegmentRequest{SegmentKey: "beta"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Found || resp.SegmentJson == "" {
+		t.Errorf("got %+v", resp)
+	}
+
+	resp, err = s.GetSegment(context.Background(), &rpc.GetSegmentRequest{SegmentKey: "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Found {
+		t.Errorf("got %+v, want not found", resp)
+	}
+}
@@ -0,0 +1,187 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// ErrApprovalOutdated is returned by Apply if the pending changes differ from
+// the ones that were approved, e.g. because another sync staged more changes
+// during the review.
+var ErrApprovalOutdated = errors.New("pending changes differ from the approved ones")
+
+// Approval applies changes that a Syncer staged in a pending store to the
+// live store once they are approved. This supports change-control processes
+// where flag changes must be reviewed before they reach production: point the
+// Syncer's Store at the pending table, review the result of Review, and pass
+// its Report to Apply.
+//
+// Alternatively, ApplyDue rolls out changes once they have been pending for
+// a while, without review.
+type Approval struct {
+	// Store with the staged changes
+	Pending ld.FeatureStore
+
+	// Store read by clients
+	Live ld.FeatureStore
+
+	// How long changes stay pending before ApplyDue rolls them out, e.g. to
+	// let production lag behind staging
+	Delay time.Duration
+
+	// Records when changes were staged for ApplyDue (optional, default:
+	// Pending if it is a *dynamodb.DynamoDBFeatureStore)
+	StagingLog StagingLog
+
+	// Logger for approval progress (optional)
+	Logger ld.Logger
+}
+
+// StagingLog records when changes were first staged, so that delays span
+// several runs of ApplyDue.
+type StagingLog interface {
+	StagedChanges(ctx context.Context) (map[string]dynamodb.StagedChange, error)
+	SetStagedChanges(ctx context.Context, changes map[string]dynamodb.StagedChange) error
+}
+
+// Review is what an approver needs to decide on the pending changes.
+type Review struct {
+	// Changes the approval would apply to the live store
+	Delta Delta `json:"delta"`
+
+	// Contents of the pending store, to be passed to Apply
+	Report Report `json:"report"`
+}
+
+// Review compares the pending store with the live one.
+func (a *Approval) Review() (*Review, error) {
+	pending, err := storeReport(a.Pending)
+	if err != nil {
+		return nil, err
+	}
+	live, err := storeReport(a.Live)
+	if err != nil {
+		return nil, err
+	}
+	return &Review{Delta: ComputeDelta(live, pending), Report: pending}, nil
+}
+
+// Apply replaces the data of the live store with that of the pending store
+// and returns what the live store contains afterwards. It fails with
+// ErrApprovalOutdated unless the pending store still matches the approved
+// report, so that changes staged after the review are never applied
+// unnoticed.
+func (a *Approval) Apply(approved Report) (Report, error) {
+	if a.Logger == nil {
+		a.Logger = log.New(os.Stderr, "[LaunchDarkly Approval]", log.LstdFlags)
+	}
+
+	pending, err := storeReport(a.Pending)
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(pending, approved) {
+		return nil, ErrApprovalOutdated
+	}
+
+	allData := make(map[ld.VersionedDataKind]map[string]ld.VersionedData)
+	for _, kind := range storeKinds(a.Pending) {
+		if allData[kind], err = a.Pending.All(kind); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.Live.Init(allData); err != nil {
+		return nil, err
+	}
+	a.Logger.Printf("INFO: Applied approved changes to the live store")
+
+	return storeReport(a.Live)
+}
+
+// ApplyDue rolls out the pending changes that were staged at least Delay ago,
+// and records when newer ones were staged so that a later run applies them.
+// Changes of items listed in urgent, e.g. "features/kill-switch", are applied
+// right away, as are all changes if Delay is zero. It returns the changes
+// applied.
+//
+// Unlike Apply, changes are applied one by one without an approved report.
+// Run it periodically, e.g. every minute.
+func (a *Approval) ApplyDue(ctx context.Context, now time.Time, urgent ...string) (Delta, error) {
+	if a.Logger == nil {
+		a.Logger = log.New(os.Stderr, "[LaunchDarkly Approval]", log.LstdFlags)
+	}
+	stagingLog := a.StagingLog
+	if stagingLog == nil {
+		store, ok := a.Pending.(*dynamodb.DynamoDBFeatureStore)
+		if !ok {
+			return Delta{}, errors.New("delayed rollout requires a staging log")
+		}
+		stagingLog = store
+	}
+
+	review, err := a.Review()
+	if err != nil {
+		return Delta{}, err
+	}
+	staged, err := stagingLog.StagedChanges(ctx)
+	if err != nil {
+		return Delta{}, err
+	}
+
+	isUrgent := make(map[string]bool, len(urgent))
+	for _, id := range urgent {
+		isUrgent[id] = true
+	}
+
+	var applied Delta
+	pending := make(map[string]dynamodb.StagedChange)
+	for _, c := range review.Delta.Changes {
+		id := c.Kind + "/" + c.Key
+		sc, ok := staged[id]
+		if !ok || sc.Version != c.NewVersion {
+			sc = dynamodb.StagedChange{Version: c.NewVersion, StagedAt: now}
+		}
+		if !isUrgent[id] && now.Sub(sc.StagedAt) < a.Delay {
+			pending[id] = sc
+			continue
+		}
+		if err := a.applyChange(c); err != nil {
+			a.Logger.Printf("ERROR: Failed to roll out %s: %s", id, err)
+			pending[id] = sc
+			continue
+		}
+		applied.Changes = append(applied.Changes, c)
+	}
+
+	if len(applied.Changes) > 0 {
+		a.Logger.Printf("INFO: Rolled out %d change(s), %d still pending", len(applied.Changes), len(pending))
+	}
+	return applied, stagingLog.SetStagedChanges(ctx, pending)
+}
+
+// applyChange copies a single change from the pending to the live store.
+func (a *Approval) applyChange(c Change) error {
+	kinds, err := dynamodb.ParseKinds(c.Kind)
+	if err != nil {
+		return err
+	}
+	if c.Type == Removed {
+		return a.Live.Delete(kinds[0], c.Key, c.OldVersion+1)
+	}
+	item, err := a.Pending.Get(kinds[0], c.Key)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return errors.New("item vanished from the pending store")
+	}
+	return a.Live.Upsert(kinds[0], item)
+}
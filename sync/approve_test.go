@@ -0,0 +1,125 @@
+package sync_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+)
+
+func TestApproval(t *testing.T) {
+	pending := ld.NewInMemoryFeatureStore(nil)
+	pending.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 2}},
+		ld.Segments: {},
+	})
+	live := ld.NewInMemoryFeatureStore(nil)
+	live.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"flag":     &ld.FeatureFlag{Key: "flag", Version: 1},
+			"old-flag": &ld.FeatureFlag{Key: "old-flag", Version: 1},
+		},
+		ld.Segments: {},
+	})
+	approval := &sync.Approval{Pending: pending, Live: live}
+
+	review, err := approval.Review()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := review.Delta.Summary(); got != "features/flag changed (v1 -> v2)\nfeatures/old-flag removed (was v1)" {
+		t.Errorf("unexpected review: %s", got)
+	}
+
+	// Changes staged after the review must not be applied
+	pending.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 3})
+	if _, err := approval.Apply(review.Report); err != sync.ErrApprovalOutdated {
+		t.Fatalf("got error %v, want ErrApprovalOutdated", err)
+	}
+	if flag, _ := live.Get(ld.Features, "flag"); flag.GetVersion() != 1 {
+		t.Errorf("live store changed without approval: %+v", flag)
+	}
+
+	if review, err = approval.Review(); err != nil {
+		t.Fatal(err)
+	}
+	report, err := approval.Apply(review.Report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report["features"]) != 1 || report["features"]["flag"] != 3 {
+		t.Errorf("unexpected live store after approval: %v", report)
+	}
+}
+
+type fakeStagingLog struct {
+	changes map[string]dynamodb.StagedChange
+}
+
+func (l *fakeStagingLog) StagedChanges(ctx context.Context) (map[string]dynamodb.StagedChange, error) {
+	return l.changes, nil
+}
+
+func (l *fakeStagingLog) SetStagedChanges(ctx context.Context, changes map[string]dynamodb.StagedChange) error {
+	l.changes = changes
+	return nil
+}
+
+func TestApplyDue(t *testing.T) {
+	pending := ld.NewInMemoryFeatureStore(nil)
+	pending.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"flag":        &ld.FeatureFlag{Key: "flag", Version: 2},
+			"kill-switch": &ld.FeatureFlag{Key: "kill-switch", Version: 1},
+		},
+		ld.Segments: {},
+	})
+	live := ld.NewInMemoryFeatureStore(nil)
+	live.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {
+			"flag":     &ld.FeatureFlag{Key: "flag", Version: 1},
+			"old-flag": &ld.FeatureFlag{Key: "old-flag", Version: 1},
+		},
+		ld.Segments: {},
+	})
+	approval := &sync.Approval{
+		Pending:    pending,
+		Live:       live,
+		Delay:      10 * time.Minute,
+		StagingLog: &fakeStagingLog{},
+	}
+	ctx := context.Background()
+	start := time.Now()
+
+	applied, err := approval.ApplyDue(ctx, start, "features/kill-switch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := applied.Summary(); got != "features/kill-switch added (v1)" {
+		t.Errorf("got %q applied right away", got)
+	}
+
+	// A newer version restarts the delay of its item only
+	approval.ApplyDue(ctx, start.Add(5*time.Minute))
+	pending.Upsert(ld.Features, &ld.FeatureFlag{Key: "flag", Version: 3})
+	if applied, err = approval.ApplyDue(ctx, start.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if got := applied.Summary(); got != "features/old-flag removed (was v1)" {
+		t.Errorf("got %q applied after delay", got)
+	}
+	if flag, _ := live.Get(ld.Features, "flag"); flag.GetVersion() != 1 {
+		t.Errorf("flag rolled out too early: %+v", flag)
+	}
+
+	if applied, err = approval.ApplyDue(ctx, start.Add(20*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if flag, _ := live.Get(ld.Features, "flag"); len(applied.Changes) != 1 || flag.GetVersion() != 3 {
+		t.Errorf("got %q applied, live flag %+v", applied.Summary(), flag)
+	}
+}
@@ -0,0 +1,175 @@
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultAutoConfigURI is the base URI of LaunchDarkly's stream that serves
+// Relay Proxy auto-configuration.
+const DefaultAutoConfigURI = "https://stream.launchdarkly.com"
+
+// AutoConfigPath is the path of the auto-configuration stream.
+const AutoConfigPath = "/relay_auto_config"
+
+// AutoConfig discovers LaunchDarkly environments and their SDK keys with a
+// Relay Proxy auto-configuration key, so that environments created later are
+// synced without configuring their SDK keys one by one.
+type AutoConfig struct {
+	// Relay Proxy auto-configuration key ("rel-...")
+	Key string
+
+	// Base URI of the stream (optional, default: DefaultAutoConfigURI)
+	BaseURI string
+
+	// HTTP client for the stream (optional, default: http.DefaultClient)
+	HTTPClient *http.Client
+}
+
+// AutoConfigEnvironment is an environment of the auto-configuration.
+type AutoConfigEnvironment struct {
+	ID          string
+	Key         string
+	Name        string
+	ProjectKey  string
+	ProjectName string
+	SDKKey      string
+	Version     int
+}
+
+// TaskName returns the name the environment has in a Task, which is its key
+// prefixed with the project key, e.g. "default-production", as environment
+// keys are only unique within a project.
+func (e AutoConfigEnvironment) TaskName() string {
+	return e.ProjectKey + "-" + e.Key
+}
+
+// Environments connects to the stream, reads the initial configuration, and
+// returns its environments sorted by project and key.
+func (a *AutoConfig) Environments(ctx context.Context) ([]AutoConfigEnvironment, error) {
+	baseURI := a.BaseURI
+	if baseURI == "" {
+		baseURI = DefaultAutoConfigURI
+	}
+	req, err := http.NewRequest("GET", baseURI+AutoConfigPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", a.Key)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("User-Agent", "launchdarkly-dynamo-store")
+
+	// The stream stays open, so don't wait longer than a regular request
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	// Read server-sent events until the first "put", which holds the whole
+	// configuration. Later "patch" and "delete" events are only relevant to
+	// long-running processes.
+	var event string
+	var data bytes.Buffer
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event == "put" {
+				return ParseAutoConfig(data.Bytes())
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("auto-configuration stream ended without configuration")
+}
+
+// ParseAutoConfig parses the data of a "put" event of the auto-configuration
+// stream.
+func ParseAutoConfig(data []byte) ([]AutoConfigEnvironment, error) {
+	var put struct {
+		Data struct {
+			Environments map[string]struct {
+				EnvID    string `json:"envId"`
+				EnvKey   string `json:"envKey"`
+				EnvName  string `json:"envName"`
+				ProjKey  string `json:"projKey"`
+				ProjName string `json:"projName"`
+				SDKKey   struct {
+					Value string `json:"value"`
+				} `json:"sdkKey"`
+				Version int `json:"version"`
+			} `json:"environments"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &put); err != nil {
+		return nil, fmt.Errorf("invalid auto-configuration: %s", err)
+	}
+
+	envs := make([]AutoConfigEnvironment, 0, len(put.Data.Environments))
+	for id, e := range put.Data.Environments {
+		if e.EnvID == "" {
+			e.EnvID = id
+		}
+		envs = append(envs, AutoConfigEnvironment{
+			ID:          e.EnvID,
+			Key:         e.EnvKey,
+			Name:        e.EnvName,
+			ProjectKey:  e.ProjKey,
+			ProjectName: e.ProjName,
+			SDKKey:      e.SDKKey.Value,
+			Version:     e.Version,
+		})
+	}
+	sort.Slice(envs, func(i, j int) bool {
+		return envs[i].TaskName() < envs[j].TaskName()
+	})
+	return envs, nil
+}
+
+// Discover returns the environments of the auto-configuration for a Task,
+// named by TaskName.
+func (a *AutoConfig) Discover(ctx context.Context) ([]Environment, error) {
+	autoEnvs, err := a.Environments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	envs := make([]Environment, 0, len(autoEnvs))
+	for _, e := range autoEnvs {
+		envs = append(envs, Environment{Name: e.TaskName(), SDKKey: e.SDKKey})
+	}
+	return envs, nil
+}
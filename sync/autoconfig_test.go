@@ -0,0 +1,81 @@
+package sync_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+)
+
+const autoConfigPut = `{"path":"/","data":{"environments":{
+	"env1":{"envId":"env1","envKey":"production","envName":"Production","projKey":"default","projName":"Default","sdkKey":{"value":"sdk-prod"},"version":2},
+	"env2":{"envId":"env2","envKey":"staging","envName":"Staging","projKey":"default","projName":"Default","sdkKey":{"value":"sdk-staging","expiring":{"value":"sdk-old","timestamp":1}},"version":1}
+}}}`
+
+func TestAutoConfigDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != sync.AutoConfigPath || r.Header.Get("Authorization") != "rel-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, ":comment\n\nevent: put\ndata: %s\n\n", compactJSON(autoConfigPut))
+		w.(http.Flusher).Flush()
+		// Keep the stream open like LaunchDarkly does
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ac := &sync.AutoConfig{Key: "rel-key", BaseURI: server.URL}
+	envs, err := ac.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sync.Environment{
+		{Name: "default-production", SDKKey: "sdk-prod"},
+		{Name: "default-staging", SDKKey: "sdk-staging"},
+	}
+	if !reflect.DeepEqual(envs, want) {
+		t.Errorf("got %+v, want %+v", envs, want)
+	}
+
+	ac.Key = "wrong-key"
+	if _, err := ac.Discover(context.Background()); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestTaskDiscoversEnvironments(t *testing.T) {
+	var synced []string
+	task := &sync.Task{
+		NewSyncer: func(env sync.Environment) (*sync.Syncer, error) {
+			synced = append(synced, env.Name+"="+env.SDKKey)
+			return nil, fmt.Errorf("permanent failure")
+		},
+		Discover: func(ctx context.Context) ([]sync.Environment, error) {
+			return []sync.Environment{{Name: "default-production", SDKKey: "sdk-prod"}}, nil
+		},
+	}
+	out, err := task.Handle(context.Background(), sync.TaskInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(synced, []string{"default-production=sdk-prod"}) {
+		t.Errorf("synced %v", synced)
+	}
+	if !reflect.DeepEqual(out.Failed, []string{"default-production"}) {
+		t.Errorf("got failed %v", out.Failed)
+	}
+}
+
+func compactJSON(s string) string {
+	var buf bytes.Buffer
+	json.Compact(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,114 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/notify"
+)
+
+// ChangeType tells how an item changed in a sync.
+type ChangeType string
+
+// Types of changes
+const (
+	Added   ChangeType = "added"
+	Changed ChangeType = "changed"
+	Removed ChangeType = "removed"
+)
+
+// Change describes an item that was added, changed, or removed by a sync.
+type Change struct {
+	Kind       string     `json:"kind"`
+	Key        string     `json:"key"`
+	Type       ChangeType `json:"type"`
+	OldVersion int        `json:"oldVersion,omitempty"`
+	NewVersion int        `json:"newVersion,omitempty"`
+}
+
+// Delta lists the changes of a sync, sorted by kind and key.
+type Delta struct {
+	Changes []Change `json:"changes"`
+}
+
+// DeltaSubject is the subject of published deltas.
+const DeltaSubject = "LaunchDarkly flag changes"
+
+// ComputeDelta compares the reports before and after a sync.
+func ComputeDelta(before, after Report) Delta {
+	var changes []Change
+	for ns, items := range after {
+		for key, version := range items {
+			old, ok := before[ns][key]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Kind: ns, Key: key, Type: Added, NewVersion: version})
+			case old != version:
+				changes = append(changes, Change{Kind: ns, Key: key, Type: Changed, OldVersion: old, NewVersion: version})
+			}
+		}
+	}
+	for ns, items := range before {
+		for key, version := range items {
+			if _, ok := after[ns][key]; !ok {
+				changes = append(changes, Change{Kind: ns, Key: key, Type: Removed, OldVersion: version})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	return Delta{Changes: changes}
+}
+
+// Summary lists the changes in a human-readable form, one per line, e.g.
+// "features/my-flag changed (v3 -> v4)".
+func (d Delta) Summary() string {
+	lines := make([]string, len(d.Changes))
+	for i, c := range d.Changes {
+		var versions string
+		switch c.Type {
+		case Added:
+			versions = fmt.Sprintf("v%d", c.NewVersion)
+		case Changed:
+			versions = fmt.Sprintf("v%d -> v%d", c.OldVersion, c.NewVersion)
+		case Removed:
+			versions = fmt.Sprintf("was v%d", c.OldVersion)
+		}
+		lines[i] = fmt.Sprintf("%s/%s %s (%s)", c.Kind, c.Key, c.Type, versions)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// publishDelta sends a non-empty delta to the publisher. Failures are only
+// logged, as the sync itself succeeded.
+func (s *Syncer) publishDelta(p notify.Publisher, delta Delta) {
+	msg, err := json.Marshal(delta)
+	if err != nil {
+		s.Logger.Printf("ERROR: Failed to encode delta: %s", err)
+		return
+	}
+	if err := p.Publish(DeltaSubject, string(msg)); err != nil {
+		s.Logger.Printf("ERROR: Failed to publish delta: %s", err)
+		return
+	}
+	s.Logger.Printf("INFO: Published %d change(s)", len(delta.Changes))
+}
+
+// publishSummary sends a readable summary of a non-empty delta to the
+// publisher, prefixed with what triggered the sync. Failures are only logged.
+func (s *Syncer) publishSummary(p notify.Publisher, delta Delta) {
+	msg := delta.Summary()
+	if s.Trigger != "" {
+		msg = s.Trigger + "\n" + msg
+	}
+	if err := p.Publish(DeltaSubject, msg); err != nil {
+		s.Logger.Printf("ERROR: Failed to publish change summary: %s", err)
+	}
+}
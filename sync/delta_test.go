@@ -0,0 +1,92 @@
+package sync_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+)
+
+type fakePublisher struct {
+	messages []string
+}
+
+func (p *fakePublisher) Publish(subject, message string) error {
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func TestComputeDelta(t *testing.T) {
+	before := sync.Report{"features": {"same": 1, "changed": 1, "removed": 3}}
+	after := sync.Report{"features": {"same": 1, "changed": 2, "added": 1}, "segments": {}}
+
+	want := []sync.Change{
+		{Kind: "features", Key: "added", Type: sync.Added, NewVersion: 1},
+		{Kind: "features", Key: "changed", Type: sync.Changed, OldVersion: 1, NewVersion: 2},
+		{Kind: "features", Key: "removed", Type: sync.Removed, OldVersion: 3},
+	}
+	if got := sync.ComputeDelta(before, after).Changes; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDeltaSummary(t *testing.T) {
+	delta := sync.ComputeDelta(
+		sync.Report{"features": {"changed": 1, "removed": 3}},
+		sync.Report{"features": {"changed": 2, "added": 1}},
+	)
+	want := "features/added added (v1)\nfeatures/changed changed (v1 -> v2)\nfeatures/removed removed (was v3)"
+	if got := delta.Summary(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSyncPublishesDelta(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	store := ld.NewInMemoryFeatureStore(nil)
+	store.Init(map[ld.VersionedDataKind]map[string]ld.VersionedData{
+		ld.Features: {"flag": &ld.FeatureFlag{Key: "flag", Version: 1}},
+		ld.Segments: {"segment": &ld.Segment{Key: "segment", Version: 1}},
+	})
+	publisher, summaries := &fakePublisher{}, &fakePublisher{}
+	syncer := &sync.Syncer{
+		Store:     store,
+		SDKKey:    "sdk-key",
+		Raw:       true,
+		BaseURI:   server.URL,
+		Deltas:    publisher,
+		Summaries: summaries,
+		Trigger:   "Scheduled sync",
+	}
+
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(publisher.messages))
+	}
+	var delta sync.Delta
+	if err := json.Unmarshal([]byte(publisher.messages[0]), &delta); err != nil {
+		t.Fatal(err)
+	}
+	if len(delta.Changes) != 1 || delta.Changes[0].Key != "flag" || delta.Changes[0].NewVersion != 2 {
+		t.Errorf("unexpected delta: %+v", delta)
+	}
+	if len(summaries.messages) != 1 || summaries.messages[0] != "Scheduled sync\nfeatures/flag changed (v1 -> v2)" {
+		t.Errorf("unexpected summaries: %q", summaries.messages)
+	}
+
+	// Nothing changed, so nothing is published
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher.messages) != 1 {
+		t.Errorf("got %d messages after unchanged sync, want 1", len(publisher.messages))
+	}
+}
@@ -0,0 +1,76 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// Recover syncs only the items listed in the store's dead letter (see
+// dynamodb.DynamoDBFeatureStore.DeadLetters), fetching each of them from
+// LaunchDarkly. Recovered items are removed from the dead letter; items that
+// fail again stay in it for the next run. It returns the number of items
+// recovered, which is zero if the dead letter is empty.
+func (s *Syncer) Recover(ctx context.Context) (int, error) {
+	if s.Logger == nil {
+		s.Logger = log.New(os.Stderr, "[LaunchDarkly Sync]", log.LstdFlags)
+	}
+	store, ok := s.Store.(*dynamodb.DynamoDBFeatureStore)
+	if !ok {
+		return 0, errors.New("recovery requires a DynamoDB store")
+	}
+
+	dl, err := store.DeadLetter(ctx)
+	if err != nil || dl.Empty() {
+		return 0, err
+	}
+
+	recovered := make(map[string][]string)
+	failed := make(map[string][]string)
+	n, nFailed := 0, 0
+	var lastErr error
+	for namespace, keys := range dl.Keys {
+		kinds, err := dynamodb.ParseKinds(namespace)
+		if err != nil {
+			s.Logger.Printf("WARN: Dropping dead letter of unknown kind: %s", err)
+			recovered[namespace] = keys
+			continue
+		}
+		kind := kinds[0]
+
+		for _, key := range keys {
+			item, err := s.FetchItem(ctx, kind, key)
+			if serr, ok := err.(*StatusError); ok && serr.StatusCode == http.StatusNotFound {
+				_, err = store.DeleteLatest(kind, key)
+			} else if err == nil {
+				_, err = store.Repair(kind, item, false)
+			}
+			if err != nil {
+				s.Logger.Printf("ERROR: Failed to recover %q item (key=%s): %s", namespace, key, err)
+				failed[namespace] = append(failed[namespace], key)
+				nFailed++
+				lastErr = err
+				continue
+			}
+			recovered[namespace] = append(recovered[namespace], key)
+			n++
+		}
+	}
+
+	if err := store.RemoveFromDeadLetter(ctx, recovered); err != nil {
+		return n, err
+	}
+	if len(failed) > 0 {
+		if err := store.AddToDeadLetter(ctx, failed, lastErr); err != nil {
+			s.Logger.Printf("WARN: Failed to update dead letter: %s", err)
+		}
+		return n, fmt.Errorf("failed to recover %d item(s): %s", nFailed, lastErr)
+	}
+	s.Logger.Printf("INFO: Recovered %d item(s) from dead letter", n)
+	return n, nil
+}
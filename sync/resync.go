@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/notify"
+)
+
+// ResyncSubject is the subject of resync requests.
+const ResyncSubject = "LaunchDarkly resync requested"
+
+// ResyncRequest asks the sync to repopulate a table that a consumer found
+// unexpectedly empty.
+type ResyncRequest struct {
+	// Table that lacks data
+	Table string `json:"table"`
+
+	// Number of flags found, and the number expected at least
+	Flags    int `json:"flags"`
+	MinFlags int `json:"minFlags"`
+
+	// When the consumer found the table lacking
+	RequestedAt time.Time `json:"requestedAt"`
+}
+
+// RequestResync publishes a resync request, e.g. to the SNS topic that
+// triggers the store function. Use it as the store's OnTooFewFlags hook:
+//
+//	store.OnTooFewFlags = func(count int) {
+//		err := sync.RequestResync(topic, sync.ResyncRequest{Table: store.Table, Flags: count, MinFlags: store.MinFlags})
+//		...
+//	}
+func RequestResync(p notify.Publisher, req ResyncRequest) error {
+	if req.RequestedAt.IsZero() {
+		req.RequestedAt = time.Now()
+	}
+	msg, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return p.Publish(ResyncSubject, string(msg))
+}
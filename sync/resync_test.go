@@ -0,0 +1,28 @@
+package sync_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+)
+
+func TestRequestResync(t *testing.T) {
+	publisher := &fakePublisher{}
+	req := sync.ResyncRequest{Table: "launchdarkly-production", Flags: 0, MinFlags: 10}
+	if err := sync.RequestResync(publisher, req); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(publisher.messages))
+	}
+
+	var got sync.ResyncRequest
+	if err := json.Unmarshal([]byte(publisher.messages[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Table != req.Table || got.MinFlags != 10 || time.Since(got.RequestedAt) > time.Minute {
+		t.Errorf("unexpected request: %+v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// DefaultRetryBackoff is the delay before the first retry of a failed sync.
+const DefaultRetryBackoff = 500 * time.Millisecond
+
+// minAttemptTime is the least time an attempt needs to be worth starting.
+// Retries stop once less than this is left of the context's deadline.
+const minAttemptTime = time.Second
+
+// syncWithRetries syncs the store, retrying transient failures with
+// exponential backoff as long as the context's deadline leaves time for
+// another attempt. Attempts and backoff are recorded in timings.
+func (s *Syncer) syncWithRetries(ctx context.Context, store ld.FeatureStore, timings *Timings) error {
+	backoff := s.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		timings.Attempts++
+		err := s.syncAttempt(ctx, store, attempt < s.Retries)
+		if err == nil || attempt >= s.Retries || !IsRetryable(err) {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff+minAttemptTime {
+			s.Logger.Printf("WARN: Sync attempt %d failed, no time left to retry: %s", attempt+1, err)
+			return err
+		}
+		s.Logger.Printf("WARN: Sync attempt %d failed, retrying in %s: %s", attempt+1, backoff, err)
+
+		wait := time.Now()
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		timings.Backoff += Duration(time.Since(wait))
+		backoff *= 2
+	}
+}
+
+// syncAttempt syncs the store once. If more attempts follow, it is limited to
+// AttemptTimeout so that a hanging connection doesn't use up the time left
+// for retries.
+func (s *Syncer) syncAttempt(ctx context.Context, store ld.FeatureStore, more bool) error {
+	if more {
+		timeout := s.AttemptTimeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	switch {
+	case s.Raw:
+		return s.syncRaw(ctx, store)
+	case s.HTTPClient != nil, s.quarantines():
+		return s.syncHTTP(ctx, store)
+	}
+	return s.syncClient(ctx, store)
+}
@@ -0,0 +1,372 @@
+// Package sync copies flag data from LaunchDarkly to a DynamoDB table. It
+// contains the logic of the sync Lambda function so that it can be embedded in
+// other services, cron jobs, or Step Functions.
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/notify"
+)
+
+// DefaultTimeout is the time a sync may take if the context has no deadline.
+const DefaultTimeout = 10 * time.Second
+
+// Syncer syncs the data of one LaunchDarkly environment to a feature store.
+type Syncer struct {
+	// Store to write to, usually a *dynamodb.DynamoDBFeatureStore
+	Store ld.FeatureStore
+
+	// SDK key of the LaunchDarkly environment
+	SDKKey string
+
+	// If set, store the JSON received from LaunchDarkly as is instead of
+	// decoding it with the Go SDK (see dynamodb.RawItem)
+	Raw bool
+
+	// Base URI of LaunchDarkly's API (optional)
+	BaseURI string
+
+	// Base URI of LaunchDarkly's streaming API, which the Go SDK receives
+	// the data from unless Raw or HTTPClient is set (optional)
+	StreamURI string
+
+	// HTTP client for requests to LaunchDarkly, e.g. with a proxy, a custom
+	// CA bundle, or shorter timeouts for Lambdas in locked-down VPCs
+	// (optional, default: http.DefaultClient). The Go SDK can't be given a
+	// client, so syncs fetch the data themselves if it is set. They also do
+	// so if the store has a QuarantineTable.
+	HTTPClient *http.Client
+
+	// If set, the changes of each sync are published here as a Delta, so
+	// that downstream systems don't need to scan the table (optional)
+	Deltas notify.Publisher
+
+	// If set, a readable summary of the changes of each sync is published
+	// here, e.g. to a Slack channel with notify.NewSlack (optional)
+	Summaries notify.Publisher
+
+	// What caused the sync, e.g. a webhook payload's Describe, which is
+	// included in summaries (optional)
+	Trigger string
+
+	// Number of times a sync is retried after a transient failure, e.g. when
+	// the SDK times out connecting to LaunchDarkly on a cold start. Retries
+	// stop early when the context's deadline is near.
+	Retries int
+
+	// Delay before the first retry, doubled for each further retry
+	// (optional, default: DefaultRetryBackoff)
+	RetryBackoff time.Duration
+
+	// Maximum time of an attempt that may still be retried (optional,
+	// default: DefaultTimeout)
+	AttemptTimeout time.Duration
+
+	// If set, a breakdown of where each sync spent its time is logged (see
+	// Timings)
+	LogTimings bool
+
+	// Called with the timings of each successful sync, e.g. to emit metrics
+	// (optional)
+	OnTimings func(*Timings)
+
+	// Logger for sync progress (optional)
+	Logger ld.Logger
+}
+
+// Report lists the keys and versions of all items in the store after a sync,
+// grouped by namespace, e.g. {"features":{"my-flag":3},"segments":{}}.
+type Report map[string]map[string]int
+
+// Sync writes all flags and segments of the environment to the store and
+// returns what the store contains afterwards.
+func (s *Syncer) Sync(ctx context.Context) (Report, error) {
+	report, _, err := s.SyncWithTimings(ctx)
+	return report, err
+}
+
+// SyncWithTimings works like Sync but also returns where the sync spent its
+// time.
+func (s *Syncer) SyncWithTimings(ctx context.Context) (Report, *Timings, error) {
+	if s.Logger == nil {
+		s.Logger = log.New(os.Stderr, "[LaunchDarkly Sync]", log.LstdFlags)
+	}
+	start := time.Now()
+	timings := &Timings{}
+
+	var before Report
+	if s.Deltas != nil || s.Summaries != nil {
+		var err error
+		reportStart := time.Now()
+		if before, err = s.report(); err != nil {
+			s.Logger.Printf("WARN: Failed to read store before sync, not publishing delta: %s", err)
+		}
+		timings.Report += Duration(time.Since(reportStart))
+	}
+
+	store := &timedStore{FeatureStore: s.Store}
+	syncStart := time.Now()
+	err := s.syncWithRetries(ctx, store, timings)
+	init, kinds := store.initTime()
+	timings.Init = Duration(init)
+	timings.Fetch = Duration(time.Since(syncStart)-init) - timings.Backoff
+	timings.Kinds = kinds
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Logger.Printf("INFO: Successfully updated the feature store!")
+
+	reportStart := time.Now()
+	after, err := s.report()
+	if err != nil {
+		return nil, nil, err
+	}
+	timings.Report += Duration(time.Since(reportStart))
+
+	if before != nil {
+		if delta := ComputeDelta(before, after); len(delta.Changes) > 0 {
+			if s.Deltas != nil {
+				s.publishDelta(s.Deltas, delta)
+			}
+			if s.Summaries != nil {
+				s.publishSummary(s.Summaries, delta)
+			}
+		}
+	}
+
+	timings.Total = Duration(time.Since(start))
+	if s.LogTimings {
+		s.Logger.Printf("INFO: Sync timings: %s", timings)
+	}
+	if s.OnTimings != nil {
+		s.OnTimings(timings)
+	}
+	return after, timings, nil
+}
+
+func (s *Syncer) baseURI() string {
+	if s.BaseURI != "" {
+		return s.BaseURI
+	}
+	return ld.DefaultConfig.BaseUri
+}
+
+// syncClient lets the Go SDK initialize the store.
+func (s *Syncer) syncClient(ctx context.Context, store ld.FeatureStore) error {
+	timeout := DefaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	config := ld.DefaultConfig
+	config.BaseUri = s.baseURI()
+	if s.StreamURI != "" {
+		config.StreamUri = s.StreamURI
+	}
+	config.FeatureStore = store
+
+	type result struct {
+		client *ld.LDClient
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		client, err := ld.MakeCustomClient(s.SDKKey, config, timeout)
+		done <- result{client, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.client != nil {
+			r.client.Close()
+		}
+		return r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.client != nil {
+				r.client.Close()
+			}
+		}()
+		return ctx.Err()
+	}
+}
+
+// syncRaw fetches all flags and segments from LaunchDarkly's polling endpoint
+// and writes them to the store as raw JSON.
+func (s *Syncer) syncRaw(ctx context.Context, store ld.FeatureStore) error {
+	body, err := s.get(ctx, ld.LatestAllPath)
+	if err != nil {
+		return err
+	}
+
+	allData, err := s.decodeAllData(body, func(kind ld.VersionedDataKind, data []byte) (ld.VersionedData, error) {
+		item := &dynamodb.RawItem{}
+		err := json.Unmarshal(data, item)
+		return item, err
+	})
+	if err != nil {
+		return err
+	}
+	return store.Init(allData)
+}
+
+// syncHTTP fetches all flags and segments with HTTPClient and decodes them
+// with the Go SDK's structs, like the SDK does.
+func (s *Syncer) syncHTTP(ctx context.Context, store ld.FeatureStore) error {
+	body, err := s.get(ctx, ld.LatestAllPath)
+	if err != nil {
+		return err
+	}
+
+	allData, err := s.decodeAllData(body, func(kind ld.VersionedDataKind, data []byte) (ld.VersionedData, error) {
+		item := kind.GetDefaultItem()
+		err := json.Unmarshal(data, item)
+		return item.(ld.VersionedData), err
+	})
+	if err != nil {
+		return err
+	}
+	return store.Init(allData)
+}
+
+// decodeAllData decodes the flags and segments of a polling response one by
+// one. An item that fails to decode fails the sync, unless the store has a
+// quarantine table, in which case it's passed to Init as a
+// *dynamodb.RejectedItem so that the other items are still synced.
+func (s *Syncer) decodeAllData(body []byte, decode func(kind ld.VersionedDataKind, data []byte) (ld.VersionedData, error)) (map[ld.VersionedDataKind]map[string]ld.VersionedData, error) {
+	var data struct {
+		Flags    map[string]json.RawMessage `json:"flags"`
+		Segments map[string]json.RawMessage `json:"segments"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	quarantine := s.quarantines()
+
+	allData := make(map[ld.VersionedDataKind]map[string]ld.VersionedData, 2)
+	for kind, items := range map[ld.VersionedDataKind]map[string]json.RawMessage{
+		ld.Features: data.Flags,
+		ld.Segments: data.Segments,
+	} {
+		allData[kind] = make(map[string]ld.VersionedData, len(items))
+		for key, raw := range items {
+			item, err := decode(kind, raw)
+			if err != nil {
+				if !quarantine {
+					return nil, fmt.Errorf("failed to decode %q item (key=%s): %s", kind.GetNamespace(), key, err)
+				}
+				item = dynamodb.NewRejectedItem(key, raw, err)
+			}
+			allData[kind][key] = item
+		}
+	}
+	return allData, nil
+}
+
+// quarantines reports whether the store quarantines items that fail to decode.
+// As the Go SDK rejects all data if a single item fails to decode, syncs then
+// fetch and decode the data themselves, like with HTTPClient.
+func (s *Syncer) quarantines() bool {
+	store, ok := s.Store.(*dynamodb.DynamoDBFeatureStore)
+	return ok && store.QuarantineTable != ""
+}
+
+// FetchItem fetches a single flag or segment from LaunchDarkly, e.g. to
+// repair it with dynamodb.DynamoDBFeatureStore.Repair. It returns a
+// *StatusError with status 404 if the item doesn't exist.
+func (s *Syncer) FetchItem(ctx context.Context, kind ld.VersionedDataKind, key string) (ld.VersionedData, error) {
+	path := ld.LatestFlagsPath
+	if kind == ld.Segments {
+		path = ld.LatestSegmentsPath
+	}
+	body, err := s.get(ctx, path+"/"+url.PathEscape(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Raw {
+		item := &dynamodb.RawItem{}
+		if err := json.Unmarshal(body, item); err != nil {
+			return nil, err
+		}
+		return item, nil
+	}
+	item := kind.GetDefaultItem()
+	if err := json.Unmarshal(body, item); err != nil {
+		return nil, err
+	}
+	return item.(ld.VersionedData), nil
+}
+
+// get requests a resource from LaunchDarkly's SDK API.
+func (s *Syncer) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequest("GET", s.baseURI()+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", s.SDKKey)
+	req.Header.Set("User-Agent", "launchdarkly-dynamo-store")
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s *Syncer) report() (Report, error) {
+	return storeReport(s.Store)
+}
+
+// storeReport lists the items of all kinds kept in the store.
+func storeReport(store ld.FeatureStore) (Report, error) {
+	kinds := storeKinds(store)
+	report := make(Report, len(kinds))
+	for _, kind := range kinds {
+		items, err := store.All(kind)
+		if err != nil {
+			return nil, err
+		}
+		versions := make(map[string]int, len(items))
+		for key, item := range items {
+			versions[key] = item.GetVersion()
+		}
+		report[kind.GetNamespace()] = versions
+	}
+	return report, nil
+}
+
+// storeKinds returns the data kinds kept in the store.
+func storeKinds(store ld.FeatureStore) []ld.VersionedDataKind {
+	if store, ok := store.(*dynamodb.DynamoDBFeatureStore); ok && len(store.Kinds) > 0 {
+		return store.Kinds
+	}
+	return ld.VersionedDataKinds[:]
+}
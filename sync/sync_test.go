@@ -0,0 +1,374 @@
+package sync_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+	"github.com/mlafeldt/launchdarkly-dynamo-store/sync"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != ld.LatestAllPath || r.Header.Get("Authorization") != "sdk-key" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{
+			"flags": {"flag": {"key": "flag", "version": 2, "on": true}},
+			"segments": {"segment": {"key": "segment", "version": 1}}
+		}`))
+	}))
+}
+
+func TestSyncRaw(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	syncer := &sync.Syncer{
+		Store:   ld.NewInMemoryFeatureStore(nil),
+		SDKKey:  "sdk-key",
+		Raw:     true,
+		BaseURI: server.URL,
+	}
+	report, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report["features"]["flag"] != 2 || report["segments"]["segment"] != 1 {
+		t.Errorf("got report %v", report)
+	}
+}
+
+func TestSyncRawFailure(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	syncer := &sync.Syncer{
+		Store:   ld.NewInMemoryFeatureStore(nil),
+		SDKKey:  "wrong-key",
+		Raw:     true,
+		BaseURI: server.URL,
+	}
+	if _, err := syncer.Sync(context.Background()); err == nil {
+		t.Error("expected error for invalid SDK key")
+	}
+}
+
+func TestTaskClassifiesFailures(t *testing.T) {
+	var status int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	task := &sync.Task{NewSyncer: func(env sync.Environment) (*sync.Syncer, error) {
+		if env.Name == "unknown" {
+			return nil, errors.New("no SDK key")
+		}
+		return &sync.Syncer{
+			Store:   ld.NewInMemoryFeatureStore(nil),
+			SDKKey:  env.Name,
+			Raw:     true,
+			BaseURI: server.URL,
+		}, nil
+	}}
+	input := sync.TaskInput{Environments: []sync.Environment{{Name: "staging"}, {Name: "unknown"}}}
+
+	status = http.StatusServiceUnavailable
+	output, err := task.Handle(context.Background(), input)
+	if rerr, ok := err.(*sync.RetryableError); !ok || len(rerr.Environments) != 1 || rerr.Environments[0] != "staging" {
+		t.Errorf("got error %v, want retryable error for staging", err)
+	}
+	if len(output.Failed) != 1 || output.Failed[0] != "unknown" {
+		t.Errorf("got failed environments %v, want [unknown]", output.Failed)
+	}
+
+	status = http.StatusUnauthorized
+	output, err = task.Handle(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Failed) != 2 || output.Environments["staging"].Retryable {
+		t.Errorf("got output %+v, want both environments failed permanently", output)
+	}
+}
+
+func TestTaskRetriesThrottledInit(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	// DynamoDB throttles queries, so that Init fails inside each kind after
+	// claiming the generation
+	var generation string
+	ddb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		switch target := r.Header.Get("X-Amz-Target"); {
+		case strings.HasSuffix(target, ".Query"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"throttled"}`))
+		case strings.HasSuffix(target, ".PutItem"):
+			var in struct {
+				Item map[string]struct{ N string }
+			}
+			json.NewDecoder(r.Body).Decode(&in)
+			if n := in.Item["generation"].N; n != "" {
+				generation = n
+			}
+			w.Write([]byte(`{}`))
+		case strings.HasSuffix(target, ".GetItem") && generation != "":
+			w.Write([]byte(`{"Item":{"generation":{"N":"` + generation + `"}}}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer ddb.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(ddb.URL),
+		Region:      aws.String("us-east-1"),
+		MaxRetries:  aws.Int(0),
+	}))
+
+	task := &sync.Task{NewSyncer: func(env sync.Environment) (*sync.Syncer, error) {
+		return &sync.Syncer{
+			Store: &dynamodb.DynamoDBFeatureStore{
+				Client: dynamodb.NewClient(sess),
+				Table:  "test-table",
+				Logger: log.New(ioutil.Discard, "", 0),
+			},
+			SDKKey:  "sdk-key",
+			Raw:     true,
+			BaseURI: server.URL,
+			Logger:  log.New(ioutil.Discard, "", 0),
+		}, nil
+	}}
+	input := sync.TaskInput{Environments: []sync.Environment{{Name: "staging"}}}
+
+	output, err := task.Handle(context.Background(), input)
+	if _, ok := err.(*sync.RetryableError); !ok {
+		t.Errorf("got error %v, want retryable error", err)
+	}
+	if result := output.Environments["staging"]; result == nil || !result.Retryable ||
+		!strings.Contains(result.Error, "ProvisionedThroughputExceededException") {
+		t.Errorf("got result %+v, want retryable throttling error", result)
+	}
+}
+
+func TestFetchItem(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != ld.LatestFlagsPath+"/my-flag" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"key": "my-flag", "version": 7, "on": true}`))
+	}))
+	defer server.Close()
+
+	syncer := &sync.Syncer{SDKKey: "sdk-key", BaseURI: server.URL}
+	item, err := syncer.FetchItem(context.Background(), ld.Features, "my-flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag, ok := item.(*ld.FeatureFlag); !ok || flag.Version != 7 || !flag.On {
+		t.Errorf("got item %#v", item)
+	}
+
+	_, err = syncer.FetchItem(context.Background(), ld.Features, "other-flag")
+	if serr, ok := err.(*sync.StatusError); !ok || serr.StatusCode != http.StatusNotFound {
+		t.Errorf("got error %v, want 404", err)
+	}
+}
+
+func TestSyncRetriesTransientFailures(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	failures := 2
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	syncer := &sync.Syncer{
+		Store:        ld.NewInMemoryFeatureStore(nil),
+		SDKKey:       "sdk-key",
+		Raw:          true,
+		BaseURI:      flaky.URL,
+		Retries:      2,
+		RetryBackoff: time.Millisecond,
+	}
+	var reported *sync.Timings
+	syncer.OnTimings = func(t *sync.Timings) { reported = t }
+	_, timings, err := syncer.SyncWithTimings(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures != 0 {
+		t.Errorf("%d failures left, want 0", failures)
+	}
+	if timings.Attempts != 3 || timings.Backoff <= 0 || timings.Total < timings.Fetch+timings.Init+timings.Backoff {
+		t.Errorf("got timings %s", timings)
+	}
+	if reported != timings {
+		t.Error("OnTimings not called with the timings")
+	}
+}
+
+func TestSyncStopsRetryingWithoutTimeLeft(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	syncer := &sync.Syncer{
+		Store:        ld.NewInMemoryFeatureStore(nil),
+		SDKKey:       "sdk-key",
+		Raw:          true,
+		BaseURI:      server.URL,
+		Retries:      5,
+		RetryBackoff: time.Millisecond,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, err := syncer.Sync(ctx)
+	if serr, ok := err.(*sync.StatusError); !ok || serr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got error %v, want 503", err)
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests, want 1", requests)
+	}
+}
+
+func TestSyncMalformedItem(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"flags": {"flag": {"key": "flag", "version": "2"}}, "segments": {}}`))
+	}))
+	defer server.Close()
+
+	// Without a quarantine table, malformed items fail the sync
+	for _, raw := range []bool{false, true} {
+		syncer := &sync.Syncer{
+			Store:      ld.NewInMemoryFeatureStore(nil),
+			SDKKey:     "sdk-key",
+			Raw:        raw,
+			BaseURI:    server.URL,
+			HTTPClient: http.DefaultClient,
+		}
+		_, err := syncer.Sync(context.Background())
+		if err == nil || !strings.Contains(err.Error(), `"features" item (key=flag)`) {
+			t.Errorf("raw=%t: got error %v", raw, err)
+		}
+	}
+}
+
+func TestSyncQuarantinePolls(t *testing.T) {
+	var polled bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polled = r.URL.Path == ld.LatestAllPath
+		w.Write([]byte(`{"flags": {"flag": {"key": "flag", "version": "2"}}, "segments": {}}`))
+	}))
+	defer server.Close()
+
+	// The DynamoDB API rejects all requests, which is enough to tell that
+	// the sync got past decoding
+	ddb := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ValidationException","message":"failed"}`))
+	}))
+	defer ddb.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String(ddb.URL),
+		Region:      aws.String("us-east-1"),
+		MaxRetries:  aws.Int(0),
+	}))
+
+	// Without HTTPClient, a store with a quarantine table still makes the
+	// sync poll, as the Go SDK would reject the malformed item
+	syncer := &sync.Syncer{
+		Store: &dynamodb.DynamoDBFeatureStore{
+			Client:          dynamodb.NewClient(sess),
+			Table:           "test-table",
+			QuarantineTable: "test-quarantine",
+			Logger:          log.New(ioutil.Discard, "", 0),
+		},
+		SDKKey:  "sdk-key",
+		BaseURI: server.URL,
+		Logger:  log.New(ioutil.Discard, "", 0),
+	}
+	_, err := syncer.Sync(context.Background())
+	if !polled {
+		t.Error("sync didn't use the polling endpoint")
+	}
+	if err == nil || strings.Contains(err.Error(), "failed to decode") {
+		t.Errorf("got error %v, want DynamoDB error", err)
+	}
+}
+
+func TestSyncHTTPClient(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	// Route requests for an unreachable host to the test server, like a
+	// proxy would
+	var proxied int
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		proxied++
+		r.URL.Host = server.Listener.Addr().String()
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	store := ld.NewInMemoryFeatureStore(nil)
+	syncer := &sync.Syncer{
+		Store:      store,
+		SDKKey:     "sdk-key",
+		BaseURI:    "http://launchdarkly.invalid",
+		HTTPClient: client,
+	}
+	report, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxied != 1 {
+		t.Errorf("got %d requests via client, want 1", proxied)
+	}
+	if report["features"]["flag"] != 2 || report["segments"]["segment"] != 1 {
+		t.Errorf("got report %v", report)
+	}
+	item, err := store.Get(ld.Features, "flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flag, ok := item.(*ld.FeatureFlag); !ok || !flag.On {
+		t.Errorf("got %#v, want decoded flag", item)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,172 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	ld "gopkg.in/launchdarkly/go-client.v4"
+)
+
+// TaskInput is the input of a Step Functions task that syncs several
+// LaunchDarkly environments, e.g. {"environments":[{"name":"staging"}]}.
+type TaskInput struct {
+	Environments []Environment `json:"environments"`
+}
+
+// Environment identifies a LaunchDarkly environment and the table it is
+// synced to.
+type Environment struct {
+	Name  string `json:"name"`
+	Table string `json:"table,omitempty"`
+
+	// SDK key of discovered environments, which is kept out of the task's
+	// output and execution history
+	SDKKey string `json:"-"`
+}
+
+// TaskOutput is the output of a Step Functions sync task.
+type TaskOutput struct {
+	// Results per environment name
+	Environments map[string]*EnvironmentResult `json:"environments"`
+
+	// Names of environments that failed permanently, e.g. due to an invalid
+	// SDK key, and need attention
+	Failed []string `json:"failed"`
+}
+
+// EnvironmentResult is the outcome of syncing one environment.
+type EnvironmentResult struct {
+	Report    Report `json:"report,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"`
+}
+
+// RetryableError is returned by a task if at least one environment failed
+// with a transient error. Match its name in a Retry rule of the task state:
+//
+//	"Retry": [{"ErrorEquals": ["RetryableError"], "MaxAttempts": 3}]
+type RetryableError struct {
+	Environments []string
+	Output       *TaskOutput
+}
+
+func (e *RetryableError) Error() string {
+	var msgs []string
+	for _, name := range e.Environments {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", name, e.Output.Environments[name].Error))
+	}
+	return fmt.Sprintf("transient failure syncing %d environment(s): %s",
+		len(e.Environments), strings.Join(msgs, "; "))
+}
+
+// StatusError is returned if LaunchDarkly responds with an unexpected HTTP
+// status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response from LaunchDarkly: %s", e.Status)
+}
+
+// Task implements a Step Functions task that syncs several environments.
+type Task struct {
+	// NewSyncer returns a Syncer for the given environment, e.g. with the
+	// environment's SDK key and a store for its table
+	NewSyncer func(env Environment) (*Syncer, error)
+
+	// Discover lists the environments to sync if the input has none, e.g.
+	// with AutoConfig (optional)
+	Discover func(ctx context.Context) ([]Environment, error)
+}
+
+// Handle syncs all environments of the input, or the discovered ones if the
+// input has none, and reports the outcome per environment.
+//
+// If any environment failed with a transient error, Handle returns a
+// *RetryableError so that Step Functions can retry the task; syncs are
+// idempotent, so retrying environments that succeeded is harmless. Permanent
+// failures are listed in TaskOutput.Failed instead, so that the state machine
+// can alert on them with a Choice state.
+func (t *Task) Handle(ctx context.Context, input TaskInput) (*TaskOutput, error) {
+	if len(input.Environments) == 0 && t.Discover != nil {
+		envs, err := t.Discover(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover environments: %s", err)
+		}
+		input.Environments = envs
+	}
+	if len(input.Environments) == 0 {
+		return nil, fmt.Errorf("no environments given")
+	}
+
+	output := &TaskOutput{Environments: make(map[string]*EnvironmentResult, len(input.Environments))}
+	var retryable []string
+
+	for _, env := range input.Environments {
+		result := &EnvironmentResult{}
+		output.Environments[env.Name] = result
+
+		syncer, err := t.NewSyncer(env)
+		if err == nil {
+			result.Report, err = syncer.Sync(ctx)
+		}
+		if err == nil {
+			continue
+		}
+
+		result.Error = err.Error()
+		result.Retryable = IsRetryable(err)
+		if result.Retryable {
+			retryable = append(retryable, env.Name)
+		} else {
+			output.Failed = append(output.Failed, env.Name)
+		}
+	}
+
+	sort.Strings(output.Failed)
+	if len(retryable) > 0 {
+		sort.Strings(retryable)
+		return output, &RetryableError{Environments: retryable, Output: output}
+	}
+	return output, nil
+}
+
+// IsRetryable reports whether a sync error is likely transient, e.g. a
+// timeout, throttling, or a server error.
+func IsRetryable(err error) bool {
+	switch err := err.(type) {
+	case *StatusError:
+		return err.StatusCode == 429 || err.StatusCode >= 500
+	case net.Error:
+		return err.Timeout() || err.Temporary()
+	}
+	if err == context.DeadlineExceeded || err == ld.ErrInitializationTimeout {
+		return true
+	}
+	// The AWS SDK considers all errors retryable that it doesn't know
+	if aerr, ok := err.(awserr.Error); ok {
+		return request.IsErrorRetryable(aerr) || request.IsErrorThrottle(aerr)
+	}
+	// Errors wrapping several others, like the *dynamodb.InitError listing
+	// each failed kind, are only transient if all of them are
+	switch err := err.(type) {
+	case interface{ Unwrap() []error }:
+		errs := err.Unwrap()
+		for _, e := range errs {
+			if !IsRetryable(e) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	case interface{ Unwrap() error }:
+		return IsRetryable(err.Unwrap())
+	}
+	return false
+}
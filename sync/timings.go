@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	gosync "sync"
+	"time"
+
+	ld "gopkg.in/launchdarkly/go-client.v4"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/dynamodb"
+)
+
+// Timings breaks down where a sync spent its time, to find out why a sync
+// is slow.
+type Timings struct {
+	// Number of attempts, including retries
+	Attempts int `json:"attempts"`
+
+	// Receiving the data from LaunchDarkly, including the initialization of
+	// the Go SDK's client
+	Fetch Duration `json:"fetch"`
+
+	// Writing the data to the store
+	Init Duration `json:"init"`
+
+	// Reading the store for the report and delta
+	Report Duration `json:"report"`
+
+	// Waiting between retries
+	Backoff Duration `json:"backoff,omitempty"`
+
+	// The whole sync, including backoff between retries
+	Total Duration `json:"total"`
+
+	// Breakdown of Init per namespace, only available for DynamoDB stores
+	Kinds map[string]KindTimings `json:"kinds,omitempty"`
+}
+
+// KindTimings breaks down the time Init spent on a data kind (see
+// dynamodb.InitDurations).
+type KindTimings struct {
+	Scan    Duration `json:"scan"`
+	Marshal Duration `json:"marshal"`
+	Write   Duration `json:"write"`
+	Delete  Duration `json:"delete"`
+	Verify  Duration `json:"verify,omitempty"`
+	Total   Duration `json:"total"`
+}
+
+// Duration is a time.Duration that is marshaled to JSON in readable form,
+// e.g. "1.5s".
+type Duration time.Duration
+
+// MarshalJSON returns the duration as string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).Round(time.Millisecond).String()
+}
+
+// String summarizes the timings in one line, e.g. for logs.
+func (t *Timings) String() string {
+	s := fmt.Sprintf("total %s (attempts %d, fetch %s, init %s, report %s, backoff %s)",
+		t.Total, t.Attempts, t.Fetch, t.Init, t.Report, t.Backoff)
+	namespaces := make([]string, 0, len(t.Kinds))
+	for ns := range t.Kinds {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	var kinds []string
+	for _, ns := range namespaces {
+		k := t.Kinds[ns]
+		kinds = append(kinds, fmt.Sprintf("%s %s (scan %s, marshal %s, write %s, delete %s, verify %s)",
+			ns, k.Total, k.Scan, k.Marshal, k.Write, k.Delete, k.Verify))
+	}
+	if len(kinds) > 0 {
+		s += "; " + strings.Join(kinds, "; ")
+	}
+	return s
+}
+
+// timedStore measures the time spent in Init, which for the Go SDK happens
+// while its client is initialized. The SDK may still call Init after the
+// sync gave up waiting for it, hence the lock.
+type timedStore struct {
+	ld.FeatureStore
+
+	mu    gosync.Mutex
+	init  time.Duration
+	kinds map[string]KindTimings
+}
+
+func (s *timedStore) Init(allData map[ld.VersionedDataKind]map[string]ld.VersionedData) error {
+	start := time.Now()
+	var err error
+	var kinds map[string]KindTimings
+	if store, ok := s.FeatureStore.(*dynamodb.DynamoDBFeatureStore); ok {
+		report := store.InitWithReport(allData)
+		kinds = make(map[string]KindTimings, len(report.Durations))
+		for kind, d := range report.Durations {
+			kinds[kind.GetNamespace()] = KindTimings{
+				Scan:    Duration(d.Scan),
+				Marshal: Duration(d.Marshal),
+				Write:   Duration(d.Write),
+				Delete:  Duration(d.Delete),
+				Verify:  Duration(d.Verify),
+				Total:   Duration(d.Total),
+			}
+		}
+		err = report.Err()
+	} else {
+		err = s.FeatureStore.Init(allData)
+	}
+
+	s.mu.Lock()
+	s.init += time.Since(start)
+	if kinds != nil {
+		s.kinds = kinds
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// initTime returns the time spent in Init so far and the per-kind breakdown
+// of the last call.
+func (s *timedStore) initTime() (time.Duration, map[string]KindTimings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.init, s.kinds
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaError describes a part of a webhook payload that doesn't have the
+// expected shape.
+type SchemaError struct {
+	// Path of the offending field, e.g. "accesses[0].resource"
+	Path string
+
+	// What's wrong with it
+	Problem string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid webhook payload: %s: %s", e.Path, e.Problem)
+}
+
+// Resource kinds whose changes are reflected in the data synced to DynamoDB
+var knownKinds = map[string]bool{
+	"flag":    true,
+	"segment": true,
+}
+
+// Validate checks the parts of a payload this package relies on. It is
+// tolerant of fields it doesn't know, so that additions to LaunchDarkly's
+// audit log format don't break the service, but returns a SchemaError if a
+// known field has a different type or a resource can't be parsed.
+func Validate(body []byte) error {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return &SchemaError{Path: "$", Problem: "not a JSON object"}
+	}
+
+	for _, field := range []string{"_id", "kind", "name"} {
+		if v, ok := doc[field]; ok && v != nil {
+			if _, ok := v.(string); !ok {
+				return &SchemaError{Path: field, Problem: "must be a string"}
+			}
+		}
+	}
+	if v, ok := doc["date"]; ok && v != nil {
+		if _, ok := v.(float64); !ok {
+			return &SchemaError{Path: "date", Problem: "must be a number"}
+		}
+	}
+
+	v, ok := doc["accesses"]
+	if !ok || v == nil {
+		return nil
+	}
+	accesses, ok := v.([]interface{})
+	if !ok {
+		return &SchemaError{Path: "accesses", Problem: "must be an array"}
+	}
+	for i, a := range accesses {
+		path := fmt.Sprintf("accesses[%d]", i)
+		access, ok := a.(map[string]interface{})
+		if !ok {
+			return &SchemaError{Path: path, Problem: "must be an object"}
+		}
+		for _, field := range []string{"action", "resource"} {
+			if s, ok := access[field].(string); !ok || s == "" {
+				return &SchemaError{Path: path + "." + field, Problem: "must be a non-empty string"}
+			}
+		}
+		if r := ParseResource(access["resource"].(string)); r.Kind == "" || r.Key == "" {
+			return &SchemaError{Path: path + ".resource", Problem: fmt.Sprintf("%q has no resource kind and key", access["resource"])}
+		}
+	}
+	return nil
+}
+
+// UnknownKinds returns the resources of kinds other than flags and segments,
+// e.g. of resource types added to LaunchDarkly later. Callers should pass
+// such deliveries through to a full sync rather than rejecting them.
+func (p *Payload) UnknownKinds() []Resource {
+	var unknown []Resource
+	for _, r := range p.Resources() {
+		if !knownKinds[r.Kind] {
+			unknown = append(unknown, r)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,51 @@
+package webhook_test
+
+import (
+	"testing"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/webhook"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		body string
+		path string
+	}{
+		{payload, ""},
+		{`{"accesses": [], "newField": {"nested": true}}`, ""},
+		{`{}`, ""},
+		{`[]`, "$"},
+		{`{"_id": 42}`, "_id"},
+		{`{"date": "yesterday"}`, "date"},
+		{`{"accesses": {}}`, "accesses"},
+		{`{"accesses": ["flag"]}`, "accesses[0]"},
+		{`{"accesses": [{"resource": "proj/default:env/production:flag/x"}]}`, "accesses[0].action"},
+		{`{"accesses": [{"action": "updateOn", "resource": "proj/default:env/production"}]}`, "accesses[0].resource"},
+	} {
+		err := webhook.Validate([]byte(tt.body))
+		if tt.path == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tt.body, err)
+			}
+			continue
+		}
+		serr, ok := err.(*webhook.SchemaError)
+		if !ok || serr.Path != tt.path {
+			t.Errorf("%s: got %v, want error at %s", tt.body, err, tt.path)
+		}
+	}
+}
+
+func TestUnknownKinds(t *testing.T) {
+	p, err := webhook.Parse([]byte(`{"accesses": [
+    {"action": "updateOn", "resource": "proj/default:env/production:flag/x"},
+    {"action": "createMetric", "resource": "proj/default:metric/clicks"}
+  ]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := p.UnknownKinds()
+	if len(unknown) != 1 || unknown[0].Kind != "metric" {
+		t.Errorf("unexpected unknown kinds: %+v", unknown)
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Package webhook parses and verifies LaunchDarkly webhook deliveries, which
+notify the serverless service about changes to feature flags and segments.
+*/
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SignatureHeader is the header LaunchDarkly uses to sign webhook payloads.
+const SignatureHeader = "X-Ld-Signature"
+
+// Payload is a webhook payload, which is an entry of LaunchDarkly's audit
+// log. Only the fields used by this package are parsed.
+type Payload struct {
+	ID        string   `json:"_id"`
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Date      int64    `json:"date"`
+	TitleVerb string   `json:"titleVerb"`
+	Member    *Member  `json:"member"`
+	Accesses  []Access `json:"accesses"`
+}
+
+// Member is the LaunchDarkly account member who made a change.
+type Member struct {
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
+// Describe summarizes who changed what and when, e.g. "Jane Doe
+// (jane@example.com) turned on the flag Dark Mode at 2018-08-28 10:00:00
+// UTC", for notifications.
+func (p *Payload) Describe() string {
+	who := "Someone"
+	if m := p.Member; m != nil {
+		name := strings.TrimSpace(m.FirstName + " " + m.LastName)
+		switch {
+		case name != "" && m.Email != "":
+			who = fmt.Sprintf("%s (%s)", name, m.Email)
+		case m.Email != "":
+			who = m.Email
+		case name != "":
+			who = name
+		}
+	}
+	what := p.TitleVerb
+	if what == "" {
+		what = "changed the " + p.Kind
+	}
+	s := fmt.Sprintf("%s %s %s", who, what, p.Name)
+	if p.Date > 0 {
+		date := time.Unix(0, p.Date*int64(time.Millisecond)).UTC()
+		s += " at " + date.Format("2006-01-02 15:04:05 MST")
+	}
+	return s
+}
+
+// Access describes an action performed on a resource.
+type Access struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+// Resource is a parsed resource specifier like
+// "proj/default:env/production:flag/my-flag".
+type Resource struct {
+	Project     string
+	Environment string
+	Kind        string
+	Key         string
+}
+
+// Parse parses a webhook payload.
+func Parse(body []byte) (*Payload, error) {
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ParseResource parses a resource specifier. Tags (e.g. "env/production;tag")
+// are ignored.
+func ParseResource(s string) Resource {
+	var r Resource
+	for _, part := range strings.Split(s, ":") {
+		i := strings.Index(part, "/")
+		if i < 0 {
+			continue
+		}
+		typ, name := part[:i], part[i+1:]
+		if j := strings.Index(name, ";"); j >= 0 {
+			name = name[:j]
+		}
+		switch typ {
+		case "proj":
+			r.Project = name
+		case "env":
+			r.Environment = name
+		default:
+			r.Kind, r.Key = typ, name
+		}
+	}
+	return r
+}
+
+// Resources returns the parsed resources of all accesses.
+func (p *Payload) Resources() []Resource {
+	resources := make([]Resource, 0, len(p.Accesses))
+	for _, a := range p.Accesses {
+		resources = append(resources, ParseResource(a.Resource))
+	}
+	return resources
+}
+
+// Actions that remove a flag or segment from the data served to SDKs.
+// Archived flags are no longer served either, so they count as deleted.
+var deleteActions = map[string]bool{
+	"deleteFlag":    true,
+	"archiveFlag":   true,
+	"deleteSegment": true,
+}
+
+// Deletions returns the resources deleted or archived by the payload that
+// match the filter.
+func (p *Payload) Deletions(f Filter) []Resource {
+	var deleted []Resource
+	for _, a := range p.Accesses {
+		r := ParseResource(a.Resource)
+		if deleteActions[a.Action] && r.Key != "" && f.MatchesResource(r) {
+			deleted = append(deleted, r)
+		}
+	}
+	return deleted
+}
+
+// OnlyDeletions reports whether all accesses of the payload are deletions,
+// in which case applying them is all a sync would do.
+func (p *Payload) OnlyDeletions() bool {
+	if len(p.Accesses) == 0 {
+		return false
+	}
+	for _, a := range p.Accesses {
+		if !deleteActions[a.Action] {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter selects webhook deliveries by project and environment keys. An empty
+// list matches everything.
+type Filter struct {
+	Projects     []string
+	Environments []string
+}
+
+// Matches reports whether any resource of the payload belongs to one of the
+// filter's projects and environments. Payloads without resources always
+// match, so that unknown deliveries still trigger a sync.
+func (f Filter) Matches(p *Payload) bool {
+	resources := p.Resources()
+	if len(resources) == 0 {
+		return true
+	}
+	for _, r := range resources {
+		if f.MatchesResource(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesResource reports whether a resource belongs to one of the filter's
+// projects and environments.
+func (f Filter) MatchesResource(r Resource) bool {
+	return contains(f.Projects, r.Project) && contains(f.Environments, r.Environment)
+}
+
+func contains(list []string, s string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifySignature reports whether signature is the HMAC-SHA256 of the
+// payload, keyed with the webhook secret.
+func VerifySignature(payload []byte, signature, secret string) bool {
+	expected := Sign(payload, secret)
+	return subtle.ConstantTimeCompare([]byte(signature), []byte(expected)) == 1
+}
+
+// Sign returns the signature of a webhook payload.
+func Sign(payload []byte, secret string) string {
+	sig := hmac.New(sha256.New, []byte(secret))
+	sig.Write(payload)
+	return hex.EncodeToString(sig.Sum(nil))
+}
+
+// FailureCounter counts failures, e.g. of signature verification, within a
+// sliding time window. It is safe for concurrent use.
+type FailureCounter struct {
+	// Number of failures within Window that trigger an alert
+	Threshold int
+
+	// Length of the sliding window
+	Window time.Duration
+
+	mu       sync.Mutex
+	failures []time.Time
+}
+
+// Add records a failure at the given time and reports whether the threshold
+// has been reached. The counter is reset afterwards so that a burst of
+// failures triggers a single alert.
+func (c *FailureCounter) Add(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recent := c.failures[:0]
+	for _, t := range c.failures {
+		if now.Sub(t) < c.Window {
+			recent = append(recent, t)
+		}
+	}
+	c.failures = append(recent, now)
+
+	if len(c.failures) >= c.Threshold {
+		c.failures = nil
+		return true
+	}
+	return false
+}
+
+// SignaturePrefix truncates a signature so that it can be logged without
+// revealing it in full.
+func SignaturePrefix(signature string) string {
+	const n = 8
+	if len(signature) <= n {
+		return signature
+	}
+	return signature[:n] + "..."
+}
@@ -0,0 +1,146 @@
+package webhook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mlafeldt/launchdarkly-dynamo-store/webhook"
+)
+
+const payload = `{
+  "_id": "5b2a8d2d1f3c1e0001c5d6e7",
+  "kind": "flag",
+  "name": "My Flag",
+  "accesses": [
+    {"action": "updateOn", "resource": "proj/default:env/production;critical:flag/my-flag"}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	p, err := webhook.Parse([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "5b2a8d2d1f3c1e0001c5d6e7" || p.Kind != "flag" {
+		t.Errorf("unexpected payload: %+v", p)
+	}
+
+	resources := p.Resources()
+	want := webhook.Resource{Project: "default", Environment: "production", Kind: "flag", Key: "my-flag"}
+	if len(resources) != 1 || resources[0] != want {
+		t.Errorf("got resources %+v, want %+v", resources, want)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	p, err := webhook.Parse([]byte(`{
+  "kind": "flag",
+  "name": "My Flag",
+  "date": 1535450400000,
+  "titleVerb": "turned on the flag",
+  "member": {"email": "jane@example.com", "firstName": "Jane", "lastName": "Doe"}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Jane Doe (jane@example.com) turned on the flag My Flag at 2018-08-28 10:00:00 UTC"
+	if got := p.Describe(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	p, _ = webhook.Parse([]byte(payload))
+	if got := p.Describe(); got != "Someone changed the flag My Flag" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	p, err := webhook.Parse([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		filter webhook.Filter
+		want   bool
+	}{
+		{webhook.Filter{}, true},
+		{webhook.Filter{Projects: []string{"default"}}, true},
+		{webhook.Filter{Projects: []string{"other"}}, false},
+		{webhook.Filter{Environments: []string{"staging", "production"}}, true},
+		{webhook.Filter{Projects: []string{"default"}, Environments: []string{"staging"}}, false},
+	} {
+		if got := tt.filter.Matches(p); got != tt.want {
+			t.Errorf("%+v: got %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+
+	if !(webhook.Filter{Projects: []string{"other"}}).Matches(&webhook.Payload{}) {
+		t.Error("expected payload without resources to match")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	sig := webhook.Sign([]byte(payload), "secret")
+	if !webhook.VerifySignature([]byte(payload), sig, "secret") {
+		t.Error("expected valid signature")
+	}
+	if webhook.VerifySignature([]byte(payload), sig, "other") {
+		t.Error("expected invalid signature for wrong secret")
+	}
+}
+
+func TestFailureCounter(t *testing.T) {
+	c := &webhook.FailureCounter{Threshold: 3, Window: time.Minute}
+	now := time.Now()
+
+	if c.Add(now) || c.Add(now.Add(10*time.Second)) {
+		t.Fatal("alerted too early")
+	}
+	if !c.Add(now.Add(20 * time.Second)) {
+		t.Fatal("expected alert after 3 failures")
+	}
+	if c.Add(now.Add(30 * time.Second)) {
+		t.Fatal("expected counter to be reset after alert")
+	}
+
+	// Failures outside the window don't count
+	if c.Add(now.Add(2*time.Minute)) || c.Add(now.Add(3*time.Minute)) {
+		t.Fatal("counted failures outside the window")
+	}
+}
+
+func TestSignaturePrefix(t *testing.T) {
+	if got := webhook.SignaturePrefix("0123456789abcdef"); got != "01234567..." {
+		t.Errorf("got %q", got)
+	}
+	if got := webhook.SignaturePrefix("abc"); got != "abc" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDeletions(t *testing.T) {
+	p, err := webhook.Parse([]byte(`{
+  "accesses": [
+    {"action": "deleteFlag", "resource": "proj/default:env/production:flag/old-flag"},
+    {"action": "archiveFlag", "resource": "proj/default:env/staging:flag/archived-flag"},
+    {"action": "deleteSegment", "resource": "proj/default:env/production:segment/beta"}
+  ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.OnlyDeletions() {
+		t.Error("expected payload with only deletions")
+	}
+
+	deleted := p.Deletions(webhook.Filter{Environments: []string{"production"}})
+	if len(deleted) != 2 || deleted[0].Key != "old-flag" || deleted[1].Kind != "segment" {
+		t.Errorf("unexpected deletions: %+v", deleted)
+	}
+
+	p, _ = webhook.Parse([]byte(payload))
+	if p.OnlyDeletions() || len(p.Deletions(webhook.Filter{})) != 0 {
+		t.Error("expected no deletions for updateOn")
+	}
+}